@@ -0,0 +1,102 @@
+package aggregates
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// currencySymbols are the currency symbols accepted in place of their ISO
+// 4217 code.
+var currencySymbols = map[string]string{
+	"€": "EUR",
+	"$": "USD",
+	"£": "GBP",
+}
+
+var (
+	// currencyCodeRegexp matches an ISO 4217 code, in any case.
+	currencyCodeRegexp = regexp.MustCompile(`^[A-Za-z]{3}$`)
+
+	// amountNumberRegexp matches a plain decimal number, signs, exponents and
+	// thousands separators aren't accepted.
+	amountNumberRegexp = regexp.MustCompile(`^[0-9]+(\.[0-9]+)?$`)
+)
+
+// ParseAmount parses a fiat amount to send, such as "EUR 5.05", which must be
+// in one of the supported currencies, positive, and not more precise than the
+// currency minor unit.
+//
+// The currency can go either before or after the number, as an ISO 4217 code
+// or a currency symbol, so "5.05 EUR", "€5.05" and "5.05€" are accepted too.
+//
+// The errors wrap ErrInvalidAmount, or ErrCurrencyNotSupported when the
+// currency is valid but not supported.
+func ParseAmount(input string, currencies []string) (Money, error) {
+	currency, number, err := splitAmount(strings.TrimSpace(input))
+	if err != nil {
+		return Money{}, err
+	}
+
+	if !supportedCurrency(currency, currencies) {
+		return Money{}, fmt.Errorf("%w: %s", ErrCurrencyNotSupported, currency)
+	}
+
+	if strings.HasPrefix(number, "-") {
+		return Money{}, fmt.Errorf("%w: %s must be positive", ErrInvalidAmount, number)
+	}
+
+	if !amountNumberRegexp.MatchString(number) {
+		return Money{}, fmt.Errorf("%w: %s is not a decimal number", ErrInvalidAmount, number)
+	}
+
+	money, err := ParseMoney(currency, number)
+	if err != nil {
+		return Money{}, err
+	}
+
+	if money.Amount == 0 {
+		return Money{}, fmt.Errorf("%w: %s must be positive", ErrInvalidAmount, number)
+	}
+
+	return money, nil
+}
+
+// splitAmount splits an amount into its upper cased currency code and its
+// number.
+func splitAmount(input string) (string, string, error) {
+	for symbol, currency := range currencySymbols {
+		if number, ok := strings.CutPrefix(input, symbol); ok {
+			return currency, strings.TrimSpace(number), nil
+		}
+
+		if number, ok := strings.CutSuffix(input, symbol); ok {
+			return currency, strings.TrimSpace(number), nil
+		}
+	}
+
+	fields := strings.Fields(input)
+	if len(fields) != 2 {
+		return "", "", fmt.Errorf("%w: %q must be a currency and a number", ErrInvalidAmount, input)
+	}
+
+	switch {
+	case currencyCodeRegexp.MatchString(fields[0]):
+		return strings.ToUpper(fields[0]), fields[1], nil
+	case currencyCodeRegexp.MatchString(fields[1]):
+		return strings.ToUpper(fields[1]), fields[0], nil
+	default:
+		return "", "", fmt.Errorf("%w: %q has no ISO 4217 currency code", ErrInvalidAmount, input)
+	}
+}
+
+// supportedCurrency reports whether the currency is one of the currencies.
+func supportedCurrency(currency string, currencies []string) bool {
+	for _, supported := range currencies {
+		if supported == currency {
+			return true
+		}
+	}
+
+	return false
+}
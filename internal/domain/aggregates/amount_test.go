@@ -0,0 +1,58 @@
+package aggregates_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jcleira/coding-challenge/internal/domain/aggregates"
+)
+
+func TestParseAmount(t *testing.T) {
+	t.Parallel()
+
+	currencies := []string{"EUR", "USD", "JPY"}
+
+	tests := []struct {
+		name      string
+		input     string
+		want      aggregates.Money
+		wantError error
+	}{
+		{name: "code first", input: "EUR 5.05", want: aggregates.Money{Amount: 505, Currency: "EUR"}},
+		{name: "code last", input: "5.05 EUR", want: aggregates.Money{Amount: 505, Currency: "EUR"}},
+		{name: "lower case code", input: "usd 5", want: aggregates.Money{Amount: 500, Currency: "USD"}},
+		{name: "symbol first", input: "€5.05", want: aggregates.Money{Amount: 505, Currency: "EUR"}},
+		{name: "symbol last", input: "5.05 €", want: aggregates.Money{Amount: 505, Currency: "EUR"}},
+		{name: "no minor unit", input: "JPY 1500", want: aggregates.Money{Amount: 1500, Currency: "JPY"}},
+		{name: "unsupported currency", input: "GBP 5", wantError: aggregates.ErrCurrencyNotSupported},
+		{name: "unsupported symbol", input: "£5", wantError: aggregates.ErrCurrencyNotSupported},
+		{name: "negative", input: "EUR -3", wantError: aggregates.ErrInvalidAmount},
+		{name: "zero", input: "EUR 0.00", wantError: aggregates.ErrInvalidAmount},
+		{name: "exponent", input: "EUR 1e9", wantError: aggregates.ErrInvalidAmount},
+		{name: "over precision", input: "EUR 5.055", wantError: aggregates.ErrInvalidAmount},
+		{name: "out of range", input: "EUR 99999999999999999999", wantError: aggregates.ErrInvalidAmount},
+		{name: "missing currency", input: "5.05", wantError: aggregates.ErrInvalidAmount},
+		{name: "invalid code", input: "EURO 5", wantError: aggregates.ErrInvalidAmount},
+		{name: "extra tokens", input: "EUR 5 EUR", wantError: aggregates.ErrInvalidAmount},
+		{name: "empty", input: "", wantError: aggregates.ErrInvalidAmount},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			money, err := aggregates.ParseAmount(tt.input, currencies)
+			if tt.wantError != nil {
+				assert.True(t, errors.Is(err, tt.wantError), "got %v", err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, money)
+		})
+	}
+}
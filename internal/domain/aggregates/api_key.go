@@ -0,0 +1,202 @@
+package aggregates
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Scope is a permission granted to an API key.
+type Scope string
+
+const (
+	// ScopeReadBalance allows reading the balance of the key wallets.
+	ScopeReadBalance Scope = "read-balance"
+
+	// ScopeReadTransactions allows listing the transactions of the key
+	// wallets.
+	ScopeReadTransactions Scope = "read-transactions"
+
+	// ScopeSend allows sending transactions from the key wallets.
+	ScopeSend Scope = "send"
+
+	// ScopeInit allows creating wallets.
+	ScopeInit Scope = "init"
+
+	// ScopeAdmin allows the administrative operations, such as rotating
+	// wallets, overriding rates, querying the audit log or managing API keys,
+	// on any wallet.
+	ScopeAdmin Scope = "admin"
+)
+
+// apiKeyPrefix is the prefix of the API key tokens, it makes them easy to
+// spot in logs and by secret scanners.
+const apiKeyPrefix = "sk"
+
+// Scopes are the scopes that can be granted to an API key.
+var Scopes = []Scope{
+	ScopeReadBalance,
+	ScopeReadTransactions,
+	ScopeSend,
+	ScopeInit,
+	ScopeAdmin,
+}
+
+// WalletScoped reports whether the scope grants operations on the wallets of
+// the API key, instead of operations not bound to a wallet.
+func (s Scope) WalletScoped() bool {
+	switch s {
+	case ScopeReadBalance, ScopeReadTransactions, ScopeSend:
+		return true
+	default:
+		return false
+	}
+}
+
+// ParseScopes parses a comma separated list of scopes, such as
+// "read-balance,send".
+func ParseScopes(input string) ([]Scope, error) {
+	var scopes []Scope
+
+	for _, name := range strings.Split(input, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+
+		scope, err := ParseScope(name)
+		if err != nil {
+			return nil, err
+		}
+
+		scopes = append(scopes, scope)
+	}
+
+	if len(scopes) == 0 {
+		return nil, fmt.Errorf("%w: no scopes", ErrInvalidScope)
+	}
+
+	return scopes, nil
+}
+
+// ParseScope parses the name of a scope.
+func ParseScope(name string) (Scope, error) {
+	for _, scope := range Scopes {
+		if string(scope) == name {
+			return scope, nil
+		}
+	}
+
+	return "", fmt.Errorf("%w: %s", ErrInvalidScope, name)
+}
+
+// APIKey is the domain representation of an API key, only the hash of its
+// secret is kept, the plain token is shown once, when the key is issued.
+type APIKey struct {
+	ID         string
+	Tenant     string
+	Name       string
+	Hash       string
+	Scopes     []Scope
+	Wallets    []string
+	CreatedAt  time.Time
+	LastUsedAt time.Time
+	RevokedAt  time.Time
+}
+
+// NewAPIKey generates a new API key with the scopes on the wallets, returning
+// the key and its plain token.
+func NewAPIKey(name string, scopes []Scope, wallets []string) (APIKey, string, error) {
+	id, err := randomHex(8)
+	if err != nil {
+		return APIKey{}, "", fmt.Errorf("error generating API key id: %w", err)
+	}
+
+	secret, err := randomHex(32)
+	if err != nil {
+		return APIKey{}, "", fmt.Errorf("error generating API key secret: %w", err)
+	}
+
+	key := APIKey{
+		ID:        id,
+		Name:      name,
+		Hash:      hashAPIKeySecret(secret),
+		Scopes:    scopes,
+		Wallets:   wallets,
+		CreatedAt: time.Now().UTC(),
+	}
+
+	return key, apiKeyPrefix + "_" + id + "_" + secret, nil
+}
+
+// ParseAPIKeyToken splits an API key token into its key ID and its secret.
+func ParseAPIKeyToken(token string) (string, string, error) {
+	parts := strings.Split(token, "_")
+	if len(parts) != 3 || parts[0] != apiKeyPrefix || parts[1] == "" || parts[2] == "" {
+		return "", "", fmt.Errorf("%w: malformed API key", ErrUnauthenticated)
+	}
+
+	return parts[1], parts[2], nil
+}
+
+// Verify reports whether the secret is the secret of the key, in constant
+// time.
+func (k APIKey) Verify(secret string) bool {
+	return subtle.ConstantTimeCompare(
+		[]byte(k.Hash), []byte(hashAPIKeySecret(secret))) == 1
+}
+
+// Revoked reports whether the key has been revoked.
+func (k APIKey) Revoked() bool {
+	return !k.RevokedAt.IsZero()
+}
+
+// HasScope reports whether the key has been granted the scope, the admin
+// scope grants every other scope.
+func (k APIKey) HasScope(scope Scope) bool {
+	return k.Principal().HasScope(scope)
+}
+
+// AllowsWallet reports whether the key can operate on the wallet, admin keys
+// can operate on any wallet.
+func (k APIKey) AllowsWallet(publicKey string) bool {
+	return k.Principal().AllowsWallet(publicKey)
+}
+
+// Principal returns the principal of the requests authenticated with the
+// key.
+func (k APIKey) Principal() Principal {
+	return Principal{
+		Actor:   k.Actor(),
+		Tenant:  k.Tenant,
+		Scopes:  k.Scopes,
+		Wallets: k.Wallets,
+	}
+}
+
+// Actor returns the actor recorded in the audit log for the requests
+// authenticated with the key.
+func (k APIKey) Actor() string {
+	return "api-key:" + k.ID
+}
+
+// hashAPIKeySecret hashes an API key secret, the secrets are random and long
+// enough for a plain SHA-256 to be safe at rest.
+func hashAPIKeySecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// randomHex returns n random bytes, hex encoded.
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
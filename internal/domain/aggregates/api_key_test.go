@@ -0,0 +1,125 @@
+package aggregates_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jcleira/coding-challenge/internal/domain/aggregates"
+)
+
+func TestParseScopes(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		input     string
+		want      []aggregates.Scope
+		wantError error
+	}{
+		{name: "single scope", input: "send", want: []aggregates.Scope{aggregates.ScopeSend}},
+		{
+			name:  "multiple scopes",
+			input: "read-balance, read-transactions",
+			want:  []aggregates.Scope{aggregates.ScopeReadBalance, aggregates.ScopeReadTransactions},
+		},
+		{name: "unknown scope", input: "send,write", wantError: aggregates.ErrInvalidScope},
+		{name: "empty", input: " , ", wantError: aggregates.ErrInvalidScope},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			scopes, err := aggregates.ParseScopes(tt.input)
+			if tt.wantError != nil {
+				assert.True(t, errors.Is(err, tt.wantError), "got %v", err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, scopes)
+		})
+	}
+}
+
+func TestNewAPIKey(t *testing.T) {
+	t.Parallel()
+
+	key, token, err := aggregates.NewAPIKey("shop",
+		[]aggregates.Scope{aggregates.ScopeSend}, []string{"wallet"})
+	require.NoError(t, err)
+
+	id, secret, err := aggregates.ParseAPIKeyToken(token)
+	require.NoError(t, err)
+
+	assert.Equal(t, key.ID, id)
+	assert.NotContains(t, key.Hash, secret)
+	assert.True(t, key.Verify(secret))
+	assert.False(t, key.Verify(secret+"0"))
+	assert.False(t, key.Revoked())
+
+	for _, token := range []string{"", "sk_" + id, "pk_" + id + "_" + secret, "sk__" + secret} {
+		_, _, err := aggregates.ParseAPIKeyToken(token)
+		assert.True(t, errors.Is(err, aggregates.ErrUnauthenticated), "token %q", token)
+	}
+}
+
+func TestAPIKey_Permissions(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		key        aggregates.APIKey
+		scope      aggregates.Scope
+		wallet     string
+		wantScope  bool
+		wantWallet bool
+	}{
+		{
+			name:       "granted scope and wallet",
+			key:        aggregates.APIKey{Scopes: []aggregates.Scope{aggregates.ScopeSend}, Wallets: []string{"a"}},
+			scope:      aggregates.ScopeSend,
+			wallet:     "a",
+			wantScope:  true,
+			wantWallet: true,
+		},
+		{
+			name:       "other wallet",
+			key:        aggregates.APIKey{Scopes: []aggregates.Scope{aggregates.ScopeSend}, Wallets: []string{"a"}},
+			scope:      aggregates.ScopeSend,
+			wallet:     "b",
+			wantScope:  true,
+			wantWallet: false,
+		},
+		{
+			name:       "other scope",
+			key:        aggregates.APIKey{Scopes: []aggregates.Scope{aggregates.ScopeReadBalance}, Wallets: []string{"a"}},
+			scope:      aggregates.ScopeSend,
+			wallet:     "a",
+			wantScope:  false,
+			wantWallet: true,
+		},
+		{
+			name:       "admin",
+			key:        aggregates.APIKey{Scopes: []aggregates.Scope{aggregates.ScopeAdmin}},
+			scope:      aggregates.ScopeSend,
+			wallet:     "b",
+			wantScope:  true,
+			wantWallet: true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.wantScope, tt.key.HasScope(tt.scope))
+			assert.Equal(t, tt.wantWallet, tt.key.AllowsWallet(tt.wallet))
+		})
+	}
+}
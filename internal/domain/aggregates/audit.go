@@ -0,0 +1,117 @@
+package aggregates
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	// AuditOutcomeSuccess is the outcome recorded for operations that
+	// completed.
+	AuditOutcomeSuccess = "success"
+
+	// AuditOutcomeFailure is the outcome recorded for operations that failed.
+	AuditOutcomeFailure = "failure"
+)
+
+const (
+	// AuditActionWalletCreate is recorded when a wallet is created.
+	AuditActionWalletCreate = "wallet.create"
+
+	// AuditActionWalletAccess is recorded when a wallet private key is read
+	// from the vault.
+	AuditActionWalletAccess = "wallet.access"
+
+	// AuditActionWalletRotate is recorded when a wallet key is rotated.
+	AuditActionWalletRotate = "wallet.rotate"
+
+	// AuditActionTransactionSend is recorded when a transaction is sent.
+	AuditActionTransactionSend = "transaction.send"
+
+	// AuditActionAuditQuery is recorded when the audit log is queried.
+	AuditActionAuditQuery = "audit.query"
+
+	// AuditActionRateOverrideSet is recorded when an exchange rate is
+	// overridden by hand.
+	AuditActionRateOverrideSet = "rate.override.set"
+
+	// AuditActionRateOverrideClear is recorded when an exchange rate override
+	// is removed.
+	AuditActionRateOverrideClear = "rate.override.clear"
+
+	// AuditActionAPIKeyIssue is recorded when an API key is issued.
+	AuditActionAPIKeyIssue = "api_key.issue"
+
+	// AuditActionAPIKeyRevoke is recorded when an API key is revoked.
+	AuditActionAPIKeyRevoke = "api_key.revoke"
+)
+
+// AuditEvent is the domain representation of an operation worth keeping a
+// trace of, such as key management or money movements.
+type AuditEvent struct {
+	Time      time.Time
+	Tenant    string
+	Actor     string
+	RequestID string
+	Action    string
+	Wallet    string
+	Params    map[string]string
+	Outcome   string
+	Error     string
+}
+
+// NewAuditEvent creates an audit event for the action on wallet, taking the
+// tenant, the actor and the request ID from ctx.
+func NewAuditEvent(ctx context.Context, action, wallet string) AuditEvent {
+	return AuditEvent{
+		Time:      time.Now().UTC(),
+		Tenant:    TenantFromContext(ctx),
+		Actor:     ActorFromContext(ctx),
+		RequestID: RequestIDFromContext(ctx),
+		Action:    action,
+		Wallet:    wallet,
+		Params:    map[string]string{},
+	}
+}
+
+// SetOutcome sets the outcome of the event from the error returned by the
+// audited operation.
+func (e *AuditEvent) SetOutcome(err error) {
+	if err != nil {
+		e.Outcome = AuditOutcomeFailure
+		e.Error = err.Error()
+		return
+	}
+
+	e.Outcome = AuditOutcomeSuccess
+}
+
+// AuditFilter defines the criteria to query the audit log, zero values match
+// every event.
+type AuditFilter struct {
+	Tenant string
+	Wallet string
+	From   time.Time
+	To     time.Time
+}
+
+// Matches reports whether the event matches the filter.
+func (f AuditFilter) Matches(event AuditEvent) bool {
+	if f.Tenant != "" && f.Tenant != event.Tenant {
+		return false
+	}
+
+	if f.Wallet != "" && f.Wallet != event.Wallet {
+		return false
+	}
+
+	if !f.From.IsZero() && event.Time.Before(f.From) {
+		return false
+	}
+
+	if !f.To.IsZero() && event.Time.After(f.To) {
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,74 @@
+package aggregates
+
+import "context"
+
+// contextKey is the type of the keys used to store request scoped values in a
+// context, it's unexported to prevent collisions with other packages.
+type contextKey int
+
+const (
+	requestIDContextKey contextKey = iota
+	actorContextKey
+	principalContextKey
+	tenantContextKey
+)
+
+// AnonymousActor is the actor used when the request carries no identity.
+const AnonymousActor = "anonymous"
+
+// ContextWithRequestID returns a copy of ctx carrying the request ID.
+func ContextWithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDContextKey, requestID)
+}
+
+// RequestIDFromContext returns the request ID carried by ctx, if any.
+func RequestIDFromContext(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDContextKey).(string)
+	return requestID
+}
+
+// ContextWithActor returns a copy of ctx carrying the actor performing the
+// request.
+func ContextWithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorContextKey, actor)
+}
+
+// ActorFromContext returns the actor carried by ctx, or AnonymousActor if
+// there is none.
+func ActorFromContext(ctx context.Context) string {
+	actor, ok := ctx.Value(actorContextKey).(string)
+	if !ok || actor == "" {
+		return AnonymousActor
+	}
+
+	return actor
+}
+
+// ContextWithPrincipal returns a copy of ctx carrying the principal
+// authenticated for the request.
+func ContextWithPrincipal(ctx context.Context, principal Principal) context.Context {
+	return context.WithValue(ctx, principalContextKey, principal)
+}
+
+// PrincipalFromContext returns the principal carried by ctx, if any.
+func PrincipalFromContext(ctx context.Context) (Principal, bool) {
+	principal, ok := ctx.Value(principalContextKey).(Principal)
+	return principal, ok
+}
+
+// ContextWithTenant returns a copy of ctx carrying the tenant the request
+// operates on.
+func ContextWithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantContextKey, tenant)
+}
+
+// TenantFromContext returns the tenant carried by ctx, or DefaultTenant if
+// there is none.
+func TenantFromContext(ctx context.Context) string {
+	tenant, ok := ctx.Value(tenantContextKey).(string)
+	if !ok || tenant == "" {
+		return DefaultTenant
+	}
+
+	return tenant
+}
@@ -0,0 +1,5 @@
+package aggregates
+
+// DefaultCurrency is the fiat currency used when a request doesn't specify
+// one, it keeps the endpoints compatible with the original EUR only API.
+const DefaultCurrency = "EUR"
@@ -6,13 +6,26 @@ var (
 	// ErrCurrencyNotSupported is returned when the currency is not supported.
 	ErrCurrencyNotSupported = errors.New("currency not supported")
 
-	// ErrRateExpired is returned when the rate is expired.
+	// ErrRateExpired is returned when the rate is expired, or when it was
+	// never fetched while the exchange runs degraded.
 	ErrRateExpired = errors.New("rate expired")
 
+	// ErrInvalidRateOverride is returned when a rate override has a non
+	// positive value or TTL.
+	ErrInvalidRateOverride = errors.New("invalid rate override")
+
+	// ErrInvalidAmount is returned when an amount can't be represented
+	// exactly, such as a fiat amount with more decimals than its currency.
+	ErrInvalidAmount = errors.New("invalid amount")
+
 	// ErrTransactionConfirmationTimeout is returned when the transaction
 	// confirmation times out.
 	ErrTransactionConfirmationTimeout = errors.New("transaction confirmation timeout")
 
+	// ErrSolanaUnavailable is returned when none of the Solana RPC endpoints
+	// could serve a call, because they are down or throttling the service.
+	ErrSolanaUnavailable = errors.New("solana unavailable")
+
 	// ErrNoRecentBlockHashValue is returned when the get recent block hash returns
 	// an empty value.
 	ErrNoRecentBlockHashValue = errors.New("not recent block hash value")
@@ -28,4 +41,61 @@ var (
 
 	// ErrInvalidInstruction is returned when the instruction is invalid
 	ErrInvalidInstruction = errors.New("invalid instruction")
+
+	// ErrWalletNotFound is returned when there is no wallet for a public key.
+	ErrWalletNotFound = errors.New("wallet not found")
+
+	// ErrInvalidPublicKey is returned when a public key isn't a valid base58
+	// encoded Solana public key.
+	ErrInvalidPublicKey = errors.New("invalid public key")
+
+	// ErrInvalidSignature is returned when a transaction signature isn't a
+	// valid base58 encoded Solana signature.
+	ErrInvalidSignature = errors.New("invalid signature")
+
+	// ErrWalletRetired is returned when an operation requires an active wallet
+	// but the wallet has been retired by a key rotation.
+	ErrWalletRetired = errors.New("wallet retired")
+
+	// ErrNoFeeValue is returned when the get fee for message returns an empty
+	// value.
+	ErrNoFeeValue = errors.New("no fee value")
+
+	// ErrAuditLogTampered is returned when the audit log hash chain doesn't
+	// verify.
+	ErrAuditLogTampered = errors.New("audit log tampered")
+
+	// ErrValuationNotSupported is returned when the transactions valuation
+	// mode is not supported.
+	ErrValuationNotSupported = errors.New("valuation not supported")
+
+	// ErrHistoricalRateNotFound is returned when there is no known exchange
+	// rate close enough to the requested time.
+	ErrHistoricalRateNotFound = errors.New("historical rate not found")
+
+	// ErrUnauthenticated is returned when a request carries no credentials, or
+	// carries invalid, unknown or revoked ones.
+	ErrUnauthenticated = errors.New("unauthenticated")
+
+	// ErrForbidden is returned when the credentials of a request don't grant
+	// the operation, or don't grant it on the wallet.
+	ErrForbidden = errors.New("forbidden")
+
+	// ErrInvalidScope is returned when a scope name isn't one of the known
+	// scopes.
+	ErrInvalidScope = errors.New("invalid scope")
+
+	// ErrAPIKeyNotFound is returned when there is no API key with an ID.
+	ErrAPIKeyNotFound = errors.New("API key not found")
+
+	// ErrInvalidTenant is returned when a tenant name isn't a valid one.
+	ErrInvalidTenant = errors.New("invalid tenant")
+
+	// ErrRateLimited is returned when a client makes requests faster than its
+	// rate limit allows.
+	ErrRateLimited = errors.New("rate limited")
+
+	// ErrQuotaExceeded is returned when a client has used up its daily quota
+	// of requests.
+	ErrQuotaExceeded = errors.New("quota exceeded")
 )
@@ -0,0 +1,202 @@
+package aggregates
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// Lamports is an amount of lamports, the smallest unit of SOL.
+type Lamports uint64
+
+// LamportsPerSOL is the number of lamports in a SOL.
+const LamportsPerSOL Lamports = 1000000000
+
+// Money is a fiat amount, stored as an integer number of the currency minor
+// units, such as cents, so it's exact and never drifts when added up.
+type Money struct {
+	Amount   int64
+	Currency string
+}
+
+// RoundingMode defines how amounts that don't fit in the target unit are
+// rounded.
+type RoundingMode string
+
+const (
+	// RoundHalfEven rounds to the nearest unit, and ties to the even one,
+	// also known as banker's rounding. It doesn't bias sums of many amounts.
+	RoundHalfEven RoundingMode = "half-even"
+
+	// RoundHalfUp rounds to the nearest unit, and ties away from zero.
+	RoundHalfUp RoundingMode = "half-up"
+
+	// RoundFloor rounds down, towards negative infinity.
+	RoundFloor RoundingMode = "floor"
+)
+
+// DefaultRoundingMode is the rounding mode used for the conversions between
+// lamports and fiat amounts.
+const DefaultRoundingMode = RoundHalfEven
+
+// currencyExponents are the number of decimals of the currencies minor units,
+// as defined by ISO 4217, currencies not listed use two decimals.
+var currencyExponents = map[string]int{
+	"JPY": 0,
+	"KRW": 0,
+	"CLP": 0,
+}
+
+// CurrencyExponent returns the number of decimals of the currency minor unit,
+// such as 2 for EUR cents.
+func CurrencyExponent(currency string) int {
+	if exponent, ok := currencyExponents[currency]; ok {
+		return exponent
+	}
+
+	return 2
+}
+
+// ParseRoundingMode parses a rounding mode, defaulting to
+// DefaultRoundingMode when empty.
+func ParseRoundingMode(mode string) (RoundingMode, error) {
+	switch RoundingMode(mode) {
+	case "":
+		return DefaultRoundingMode, nil
+	case RoundHalfEven, RoundHalfUp, RoundFloor:
+		return RoundingMode(mode), nil
+	default:
+		return "", fmt.Errorf("unknown rounding mode: %s", mode)
+	}
+}
+
+// ParseMoney parses a decimal fiat amount, such as "5.05", in the currency.
+// Amounts with more decimals than the currency minor unit are rejected, as
+// rounding them would silently change what was asked for.
+func ParseMoney(currency, amount string) (Money, error) {
+	value, ok := new(big.Rat).SetString(amount)
+	if !ok {
+		return Money{}, fmt.Errorf("%w: %s", ErrInvalidAmount, amount)
+	}
+
+	minor := new(big.Rat).Mul(value, minorUnits(currency))
+	if !minor.IsInt() {
+		return Money{}, fmt.Errorf("%w: %s has more than %d decimals",
+			ErrInvalidAmount, amount, CurrencyExponent(currency))
+	}
+
+	if !minor.Num().IsInt64() {
+		return Money{}, fmt.Errorf("%w: %s is out of range", ErrInvalidAmount, amount)
+	}
+
+	return Money{Amount: minor.Num().Int64(), Currency: currency}, nil
+}
+
+// Decimal returns the amount as a decimal string in major units, such as
+// "5.05".
+func (m Money) Decimal() string {
+	return m.Rat().FloatString(CurrencyExponent(m.Currency))
+}
+
+// String returns the amount with its currency, such as "EUR 5.05".
+func (m Money) String() string {
+	return fmt.Sprintf("%s %s", m.Currency, m.Decimal())
+}
+
+// Rat returns the amount in major units as a big.Rat.
+func (m Money) Rat() *big.Rat {
+	return new(big.Rat).SetFrac(big.NewInt(m.Amount), minorUnits(m.Currency).Num())
+}
+
+// SOL returns the amount of lamports in SOL as a decimal string, such as
+// "1.5".
+func (l Lamports) SOL() string {
+	sol := new(big.Rat).SetFrac(
+		new(big.Int).SetUint64(uint64(l)),
+		new(big.Int).SetUint64(uint64(LamportsPerSOL)),
+	).FloatString(9)
+
+	return strings.TrimRight(strings.TrimRight(sol, "0"), ".")
+}
+
+// LamportsToMoney converts an amount of lamports to the rate fiat currency,
+// rounding to the currency minor unit with mode.
+func LamportsToMoney(lamports Lamports, rate Rate, mode RoundingMode) (Money, error) {
+	value := new(big.Rat).SetFrac(
+		new(big.Int).SetUint64(uint64(lamports)),
+		new(big.Int).SetUint64(uint64(LamportsPerSOL)),
+	)
+	value.Mul(value, rate.Value)
+	value.Mul(value, minorUnits(rate.Currency))
+
+	minor := roundRat(value, mode)
+	if !minor.IsInt64() {
+		return Money{}, fmt.Errorf("%w: %s lamports is out of range",
+			ErrInvalidAmount, lamports.SOL())
+	}
+
+	return Money{Amount: minor.Int64(), Currency: rate.Currency}, nil
+}
+
+// MoneyToLamports converts a fiat amount to lamports with the rate, which
+// must be for the amount currency, rounding to a lamport with mode.
+func MoneyToLamports(money Money, rate Rate, mode RoundingMode) (Lamports, error) {
+	if money.Currency != rate.Currency {
+		return 0, fmt.Errorf("rate currency %s doesn't match amount currency %s",
+			rate.Currency, money.Currency)
+	}
+
+	if money.Amount < 0 {
+		return 0, fmt.Errorf("%w: %s is negative", ErrInvalidAmount, money)
+	}
+
+	value := money.Rat()
+	value.Quo(value, rate.Value)
+	value.Mul(value, new(big.Rat).SetUint64(uint64(LamportsPerSOL)))
+
+	lamports := roundRat(value, mode)
+	if !lamports.IsUint64() {
+		return 0, fmt.Errorf("%w: %s is out of range", ErrInvalidAmount, money)
+	}
+
+	return Lamports(lamports.Uint64()), nil
+}
+
+// minorUnits returns the number of minor units in a major unit of the
+// currency, such as 100 for EUR.
+func minorUnits(currency string) *big.Rat {
+	exponent := big.NewInt(int64(CurrencyExponent(currency)))
+
+	return new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), exponent, nil))
+}
+
+// roundRat rounds the value to an integer with mode.
+func roundRat(value *big.Rat, mode RoundingMode) *big.Int {
+	// The denominator of a big.Rat is always positive, so the euclidean
+	// division gives the floor quotient and a non negative remainder.
+	quotient, remainder := new(big.Int).DivMod(value.Num(), value.Denom(), new(big.Int))
+
+	if mode == RoundFloor || remainder.Sign() == 0 {
+		return quotient
+	}
+
+	// Compare the remainder with half the denominator.
+	half := new(big.Int).Lsh(remainder, 1).Cmp(value.Denom())
+
+	roundUp := false
+	switch {
+	case half > 0:
+		roundUp = true
+	case half == 0 && mode == RoundHalfUp:
+		// Away from zero, the floor quotient of a negative tie already is.
+		roundUp = value.Sign() > 0
+	case half == 0:
+		roundUp = quotient.Bit(0) == 1
+	}
+
+	if roundUp {
+		quotient.Add(quotient, big.NewInt(1))
+	}
+
+	return quotient
+}
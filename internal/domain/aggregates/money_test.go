@@ -0,0 +1,125 @@
+package aggregates_test
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jcleira/coding-challenge/internal/domain/aggregates"
+)
+
+func TestParseMoney(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		currency string
+		amount   string
+		want     aggregates.Money
+		wantErr  bool
+	}{
+		{name: "cents", currency: "EUR", amount: "5.05", want: aggregates.Money{Amount: 505, Currency: "EUR"}},
+		{name: "integer", currency: "EUR", amount: "100", want: aggregates.Money{Amount: 10000, Currency: "EUR"}},
+		{name: "no minor unit", currency: "JPY", amount: "1500", want: aggregates.Money{Amount: 1500, Currency: "JPY"}},
+		{name: "too many decimals", currency: "EUR", amount: "5.055", wantErr: true},
+		{name: "decimals without minor unit", currency: "JPY", amount: "1500.5", wantErr: true},
+		{name: "not a number", currency: "EUR", amount: "five", wantErr: true},
+		{name: "out of range", currency: "EUR", amount: "1e30", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			money, err := aggregates.ParseMoney(tt.currency, tt.amount)
+			if tt.wantErr {
+				assert.True(t, errors.Is(err, aggregates.ErrInvalidAmount))
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, money)
+		})
+	}
+}
+
+func TestMoney_String(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "EUR 5.05", aggregates.Money{Amount: 505, Currency: "EUR"}.String())
+	assert.Equal(t, "EUR -0.05", aggregates.Money{Amount: -5, Currency: "EUR"}.String())
+	assert.Equal(t, "JPY 1500", aggregates.Money{Amount: 1500, Currency: "JPY"}.String())
+}
+
+func TestLamportsToMoney(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		lamports aggregates.Lamports
+		rate     *big.Rat
+		mode     aggregates.RoundingMode
+		want     int64
+	}{
+		// 1.5 SOL at 0.01 is 1.5 cents, a tie between 1 and 2 cents.
+		{name: "half even tie to even", lamports: 1500000000, rate: big.NewRat(1, 100), mode: aggregates.RoundHalfEven, want: 2},
+		// 2.5 SOL at 0.01 is 2.5 cents, a tie between 2 and 3 cents.
+		{name: "half even tie to even down", lamports: 2500000000, rate: big.NewRat(1, 100), mode: aggregates.RoundHalfEven, want: 2},
+		{name: "half up tie", lamports: 2500000000, rate: big.NewRat(1, 100), mode: aggregates.RoundHalfUp, want: 3},
+		{name: "floor", lamports: 2900000000, rate: big.NewRat(1, 100), mode: aggregates.RoundFloor, want: 2},
+		{name: "nearest", lamports: 2600000000, rate: big.NewRat(1, 100), mode: aggregates.RoundHalfEven, want: 3},
+		{name: "one lamport", lamports: 1, rate: big.NewRat(85, 1), mode: aggregates.RoundHalfUp, want: 0},
+		{name: "exact", lamports: 5000000000, rate: big.NewRat(8587262, 100000), mode: aggregates.RoundFloor, want: 42936},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			money, err := aggregates.LamportsToMoney(tt.lamports,
+				aggregates.Rate{Currency: "EUR", Value: tt.rate}, tt.mode)
+			require.NoError(t, err)
+
+			assert.Equal(t, aggregates.Money{Amount: tt.want, Currency: "EUR"}, money)
+		})
+	}
+}
+
+func TestMoneyToLamports(t *testing.T) {
+	t.Parallel()
+
+	rate := aggregates.Rate{Currency: "EUR", Value: big.NewRat(3, 1)}
+
+	// 1 EUR at 3 EUR per SOL is 333333333.33 lamports.
+	tests := []struct {
+		mode aggregates.RoundingMode
+		want aggregates.Lamports
+	}{
+		{mode: aggregates.RoundHalfEven, want: 333333333},
+		{mode: aggregates.RoundHalfUp, want: 333333333},
+		{mode: aggregates.RoundFloor, want: 333333333},
+	}
+
+	for _, tt := range tests {
+		lamports, err := aggregates.MoneyToLamports(
+			aggregates.Money{Amount: 100, Currency: "EUR"}, rate, tt.mode)
+		require.NoError(t, err)
+		assert.Equal(t, tt.want, lamports)
+	}
+
+	// 2 EUR at 3 EUR per SOL is 666666666.67 lamports.
+	lamports, err := aggregates.MoneyToLamports(
+		aggregates.Money{Amount: 200, Currency: "EUR"}, rate, aggregates.RoundHalfEven)
+	require.NoError(t, err)
+	assert.Equal(t, aggregates.Lamports(666666667), lamports)
+
+	lamports, err = aggregates.MoneyToLamports(
+		aggregates.Money{Amount: 200, Currency: "EUR"}, rate, aggregates.RoundFloor)
+	require.NoError(t, err)
+	assert.Equal(t, aggregates.Lamports(666666666), lamports)
+}
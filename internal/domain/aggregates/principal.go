@@ -0,0 +1,40 @@
+package aggregates
+
+// Principal is the authenticated identity of a request, with the tenant it
+// belongs to, the scopes it's been granted and the wallets it can operate on,
+// whether it comes from an API key or from a bearer token.
+type Principal struct {
+	Actor   string
+	Tenant  string
+	Scopes  []Scope
+	Wallets []string
+}
+
+// HasScope reports whether the principal has been granted the scope, the
+// admin scope grants every other scope.
+func (p Principal) HasScope(scope Scope) bool {
+	for _, granted := range p.Scopes {
+		if granted == scope || granted == ScopeAdmin {
+			return true
+		}
+	}
+
+	return false
+}
+
+// AllowsWallet reports whether the principal can operate on the wallet,
+// admins can operate on any wallet of their tenant. Whether the wallet belongs
+// to the tenant is up to the caller to check.
+func (p Principal) AllowsWallet(publicKey string) bool {
+	if p.HasScope(ScopeAdmin) {
+		return true
+	}
+
+	for _, wallet := range p.Wallets {
+		if wallet == publicKey {
+			return true
+		}
+	}
+
+	return false
+}
@@ -11,8 +11,15 @@ import (
 // float64, still my preference here would be to have a int64 with the value
 // including the decimal part and keep track of the decimals, as it would be
 // more precise and easier to work with.
+//
+// Sources lists the providers whose quotes contributed to the rate, and Time
+// is when the rate was observed. Overridden rates have been set by hand,
+// replacing the provider rates until they expire.
 type Rate struct {
-	Currency  string
-	Value     *big.Rat
-	ExpiredAt time.Time
+	Currency   string
+	Value      *big.Rat
+	Sources    []string
+	Time       time.Time
+	ExpiredAt  time.Time
+	Overridden bool
 }
@@ -0,0 +1,34 @@
+package aggregates
+
+import "time"
+
+// RateHealthState defines how up to date the exchange rates are.
+type RateHealthState string
+
+const (
+	// RateHealthFresh means every rate has been refreshed recently.
+	RateHealthFresh RateHealthState = "fresh"
+
+	// RateHealthStale means some rate missed its last refreshes, but it can
+	// still be used as it hasn't expired.
+	RateHealthStale RateHealthState = "stale"
+
+	// RateHealthExpired means some rate is expired or was never fetched, so
+	// operations needing it will fail.
+	RateHealthExpired RateHealthState = "expired"
+)
+
+// RateHealth is the health of the exchange rates, it's meant to be used by
+// readiness probes and operators.
+//
+// State is the worst state among the supported currencies. OpenCircuits lists
+// the rate providers that are currently skipped for a currency after failing
+// repeatedly, as provider/currency, such as binance/JPY.
+type RateHealth struct {
+	State               RateHealthState
+	LastSuccess         time.Time
+	LastError           string
+	LastErrorAt         time.Time
+	ConsecutiveFailures int
+	OpenCircuits        []string
+}
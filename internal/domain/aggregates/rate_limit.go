@@ -0,0 +1,101 @@
+package aggregates
+
+import (
+	"math"
+	"time"
+)
+
+// RateClass is a class of endpoints sharing a rate limit, the sends are
+// limited apart from the reads, as each of them costs several RPC calls.
+type RateClass string
+
+const (
+	// RateClassAuth is the class of every authenticated endpoint, limited per
+	// address before the credentials are checked, so they can't be guessed.
+	RateClassAuth RateClass = "auth"
+
+	// RateClassRead is the class of every endpoint but the sends.
+	RateClassRead RateClass = "read"
+
+	// RateClassSend is the class of the endpoints sending transactions.
+	RateClassSend RateClass = "send"
+)
+
+// RateLimit is the limit of the requests of a client to a class of
+// endpoints, a token bucket refilled at Rate tokens per second holding up to
+// Burst tokens, and an optional daily quota, zero meaning no quota.
+type RateLimit struct {
+	Rate       float64
+	Burst      int
+	DailyQuota int
+}
+
+// RateLimitDecision is the outcome of checking a request against its rate
+// limit, with the state of the bucket to report to the client.
+type RateLimitDecision struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	Reset      time.Duration
+	RetryAfter time.Duration
+}
+
+// TokenBucket is a token bucket, it starts full and is refilled continuously,
+// the zero value must be initialized with NewTokenBucket.
+type TokenBucket struct {
+	limit     RateLimit
+	tokens    float64
+	updatedAt time.Time
+}
+
+// NewTokenBucket creates a full token bucket for the limit.
+func NewTokenBucket(limit RateLimit, now time.Time) *TokenBucket {
+	return &TokenBucket{
+		limit:     limit,
+		tokens:    float64(limit.Burst),
+		updatedAt: now,
+	}
+}
+
+// Take takes a token from the bucket if there is any left.
+func (b *TokenBucket) Take(now time.Time) RateLimitDecision {
+	b.refill(now)
+
+	decision := RateLimitDecision{Limit: b.limit.Burst}
+
+	if b.tokens >= 1 {
+		b.tokens--
+		decision.Allowed = true
+	} else {
+		decision.RetryAfter = b.timeFor(1 - b.tokens)
+	}
+
+	decision.Remaining = int(math.Floor(b.tokens))
+	decision.Reset = b.timeFor(float64(b.limit.Burst) - b.tokens)
+
+	return decision
+}
+
+// Full reports whether the bucket is full at now, a full bucket is the same
+// as a new one, so it can be dropped.
+func (b *TokenBucket) Full(now time.Time) bool {
+	b.refill(now)
+	return b.tokens >= float64(b.limit.Burst)
+}
+
+// refill adds the tokens accrued since the last update.
+func (b *TokenBucket) refill(now time.Time) {
+	if elapsed := now.Sub(b.updatedAt).Seconds(); elapsed > 0 {
+		b.tokens = math.Min(float64(b.limit.Burst), b.tokens+elapsed*b.limit.Rate)
+		b.updatedAt = now
+	}
+}
+
+// timeFor returns the time it takes to refill the tokens.
+func (b *TokenBucket) timeFor(tokens float64) time.Duration {
+	if tokens <= 0 || b.limit.Rate <= 0 {
+		return 0
+	}
+
+	return time.Duration(math.Ceil(tokens / b.limit.Rate * float64(time.Second)))
+}
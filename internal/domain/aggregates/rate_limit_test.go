@@ -0,0 +1,46 @@
+package aggregates_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/jcleira/coding-challenge/internal/domain/aggregates"
+)
+
+func TestTokenBucket_Take(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	bucket := aggregates.NewTokenBucket(aggregates.RateLimit{Rate: 2, Burst: 3}, now)
+
+	// The bucket starts full.
+	for remaining := 2; remaining >= 0; remaining-- {
+		decision := bucket.Take(now)
+		assert.True(t, decision.Allowed)
+		assert.Equal(t, 3, decision.Limit)
+		assert.Equal(t, remaining, decision.Remaining)
+	}
+
+	decision := bucket.Take(now)
+	assert.False(t, decision.Allowed)
+	assert.Equal(t, 0, decision.Remaining)
+	assert.Equal(t, 500*time.Millisecond, decision.RetryAfter)
+	assert.Equal(t, 1500*time.Millisecond, decision.Reset)
+	assert.False(t, bucket.Full(now))
+
+	// Two tokens are refilled every second.
+	now = now.Add(500 * time.Millisecond)
+
+	decision = bucket.Take(now)
+	assert.True(t, decision.Allowed)
+	assert.Equal(t, 0, decision.Remaining)
+
+	// Up to the burst.
+	now = now.Add(time.Hour)
+
+	assert.True(t, bucket.Full(now))
+	assert.Equal(t, 2, bucket.Take(now).Remaining)
+}
@@ -0,0 +1,32 @@
+package aggregates
+
+// The dependencies checked for the readiness of the service.
+const (
+	ReadinessCheckSolana   = "solana_rpc"
+	ReadinessCheckExchange = "exchange_rates"
+	ReadinessCheckVault    = "vault"
+)
+
+// ReadinessCheck is the outcome of checking a dependency the service needs to
+// serve requests, Err is nil when it's available.
+type ReadinessCheck struct {
+	Name string
+	Err  error
+}
+
+// Readiness is the readiness of the service to serve requests, it's meant to
+// be used by readiness probes, such as the Kubernetes ones.
+type Readiness struct {
+	Checks []ReadinessCheck
+}
+
+// Ready returns whether every dependency is available.
+func (r Readiness) Ready() bool {
+	for _, check := range r.Checks {
+		if check.Err != nil {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,25 @@
+package aggregates
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// DefaultTenant is the tenant of the requests without a tenant, such as the
+// ones of a single tenant deployment, and of the data stored before tenants
+// existed.
+const DefaultTenant = "default"
+
+// tenantPattern is the pattern of the tenant names, they are used as
+// directory names, so they're restricted to lowercase letters, digits and
+// dashes.
+var tenantPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,62}$`)
+
+// ValidateTenant checks the tenant name is a valid one.
+func ValidateTenant(tenant string) error {
+	if !tenantPattern.MatchString(tenant) {
+		return fmt.Errorf("%w: %q", ErrInvalidTenant, tenant)
+	}
+
+	return nil
+}
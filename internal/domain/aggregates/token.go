@@ -0,0 +1,38 @@
+package aggregates
+
+import "strings"
+
+// TokenClaims are the claims of a verified bearer token, such as an OIDC ID
+// or access token, keyed by name.
+type TokenClaims map[string]interface{}
+
+// Subject returns the sub claim, the identity of the token owner.
+func (c TokenClaims) Subject() string {
+	subject, _ := c["sub"].(string)
+	return subject
+}
+
+// Strings returns the values of a claim holding a list, either as an array of
+// strings or as a space separated string, such as the OAuth scope claim.
+func (c TokenClaims) Strings(name string) []string {
+	switch value := c[name].(type) {
+	case string:
+		return strings.Fields(value)
+
+	case []interface{}:
+		values := make([]string, 0, len(value))
+		for _, item := range value {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+
+		return values
+
+	case []string:
+		return value
+
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,26 @@
+package aggregates_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/jcleira/coding-challenge/internal/domain/aggregates"
+)
+
+func TestTokenClaims_Strings(t *testing.T) {
+	t.Parallel()
+
+	claims := aggregates.TokenClaims{
+		"sub":     "user",
+		"scope":   "openid  send",
+		"wallets": []interface{}{"a", 1, "b"},
+		"exp":     1700000000,
+	}
+
+	assert.Equal(t, "user", claims.Subject())
+	assert.Equal(t, []string{"openid", "send"}, claims.Strings("scope"))
+	assert.Equal(t, []string{"a", "b"}, claims.Strings("wallets"))
+	assert.Nil(t, claims.Strings("exp"))
+	assert.Nil(t, claims.Strings("missing"))
+}
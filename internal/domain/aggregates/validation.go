@@ -0,0 +1,28 @@
+package aggregates
+
+import "fmt"
+
+// ValidationError is returned when a request field isn't valid, it names the
+// field so clients can tell which one to fix.
+type ValidationError struct {
+	Field string
+	Err   error
+}
+
+// NewValidationError creates a new ValidationError for the field.
+func NewValidationError(field string, err error) *ValidationError {
+	return &ValidationError{
+		Field: field,
+		Err:   err,
+	}
+}
+
+// Error returns the error message, prefixed by the field name.
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Field, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
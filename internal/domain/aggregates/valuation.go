@@ -0,0 +1,53 @@
+package aggregates
+
+import "fmt"
+
+// Valuation defines which exchange rate is used to value transactions.
+type Valuation string
+
+const (
+	// ValuationCurrent values transactions at the current exchange rate.
+	ValuationCurrent Valuation = "current"
+
+	// ValuationHistorical values transactions at the exchange rate at the
+	// time of their block.
+	ValuationHistorical Valuation = "historical"
+)
+
+// ParseValuation parses a valuation, defaulting to ValuationCurrent when
+// empty.
+func ParseValuation(valuation string) (Valuation, error) {
+	switch Valuation(valuation) {
+	case "", ValuationCurrent:
+		return ValuationCurrent, nil
+	case ValuationHistorical:
+		return ValuationHistorical, nil
+	default:
+		return "", fmt.Errorf("%w: %s", ErrValuationNotSupported, valuation)
+	}
+}
+
+// MaxTransactionsLimit is the maximum number of transactions listed in a
+// single page.
+const MaxTransactionsLimit = 1000
+
+// TransactionsPage defines which page of the transactions of a wallet is
+// listed, newest first.
+type TransactionsPage struct {
+	// Limit is the maximum number of transactions listed, zero lists all of
+	// them.
+	Limit int
+
+	// Before lists only the transactions older than the one with this
+	// signature, empty lists from the newest one.
+	Before string
+}
+
+// TransactionsQuery defines the criteria for listing the transactions of a
+// wallet.
+type TransactionsQuery struct {
+	PublicKey string
+	Currency  string
+	Valuation Valuation
+	Page      TransactionsPage
+}
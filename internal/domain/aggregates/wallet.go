@@ -1,7 +1,15 @@
 package aggregates
 
+import "log/slog"
+
 // Wallet is a structure to store the private and public keys of a wallet.
 type Wallet struct {
 	PrivateKey []byte
 	PublicKey  string
 }
+
+// LogValue implements slog.LogValuer, logging only the public key, so the
+// private key never reaches the logs.
+func (w Wallet) LogValue() slog.Value {
+	return slog.GroupValue(slog.String("public_key", w.PublicKey))
+}
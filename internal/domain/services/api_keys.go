@@ -0,0 +1,155 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/jcleira/coding-challenge/internal/domain/aggregates"
+)
+
+// apiKeyTouchInterval is how often the last use of an API key is persisted,
+// so authenticating a burst of requests doesn't write to the store each time.
+const apiKeyTouchInterval = time.Minute
+
+// APIKeyManager define the dependencies to issue, list, revoke and
+// authenticate API keys, issuing and revoking keys is recorded in the audit
+// log.
+type APIKeyManager struct {
+	store  APIKeyStore
+	audit  AuditRecorder
+	logger *slog.Logger
+}
+
+// NewAPIKeyManager creates a new APIKeyManager.
+func NewAPIKeyManager(store APIKeyStore, audit AuditRecorder, logger *slog.Logger) *APIKeyManager {
+	return &APIKeyManager{
+		store:  store,
+		audit:  audit,
+		logger: logger,
+	}
+}
+
+// Issue issues a new API key with the scopes on the wallets, returning the
+// key and its plain token, which can't be recovered later. The key belongs to
+// the tenant carried by ctx.
+func (m *APIKeyManager) Issue(ctx context.Context,
+	name string, scopes []aggregates.Scope, wallets []string) (aggregates.APIKey, string, error) {
+	tenant := aggregates.TenantFromContext(ctx)
+	if err := aggregates.ValidateTenant(tenant); err != nil {
+		return aggregates.APIKey{}, "", err
+	}
+
+	key, token, err := aggregates.NewAPIKey(name, scopes, wallets)
+	if err != nil {
+		return aggregates.APIKey{}, "", err
+	}
+
+	key.Tenant = tenant
+
+	event := aggregates.NewAuditEvent(ctx, aggregates.AuditActionAPIKeyIssue, "")
+	event.Params["id"] = key.ID
+	event.Params["name"] = name
+	event.Params["scopes"] = fmt.Sprint(scopes)
+	event.Params["wallets"] = fmt.Sprint(wallets)
+
+	if err := m.store.Create(ctx, key); err != nil {
+		err = fmt.Errorf("error creating API key: %w", err)
+		return aggregates.APIKey{}, "", recordAudit(ctx, m.audit, event, err)
+	}
+
+	if err := recordAudit(ctx, m.audit, event, nil); err != nil {
+		return aggregates.APIKey{}, "", err
+	}
+
+	return key, token, nil
+}
+
+// List lists the API keys of the tenant carried by ctx, including the revoked
+// ones.
+func (m *APIKeyManager) List(ctx context.Context) ([]aggregates.APIKey, error) {
+	keys, err := m.store.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error listing API keys: %w", err)
+	}
+
+	tenant := aggregates.TenantFromContext(ctx)
+
+	tenantKeys := make([]aggregates.APIKey, 0, len(keys))
+	for _, key := range keys {
+		if key.Tenant == tenant {
+			tenantKeys = append(tenantKeys, key)
+		}
+	}
+
+	return tenantKeys, nil
+}
+
+// Revoke revokes the API key with the id, its token is rejected from then on.
+// The keys of other tenants than the one carried by ctx aren't found.
+func (m *APIKeyManager) Revoke(ctx context.Context, id string) error {
+	event := aggregates.NewAuditEvent(ctx, aggregates.AuditActionAPIKeyRevoke, "")
+	event.Params["id"] = id
+
+	key, err := m.store.Get(ctx, id)
+	if err == nil && key.Tenant != aggregates.TenantFromContext(ctx) {
+		err = fmt.Errorf("%w: %s", aggregates.ErrAPIKeyNotFound, id)
+	}
+	if err != nil {
+		err = fmt.Errorf("error getting API key: %w", err)
+		return recordAudit(ctx, m.audit, event, err)
+	}
+
+	if !key.Revoked() {
+		key.RevokedAt = time.Now().UTC()
+
+		if err := m.store.Update(ctx, key); err != nil {
+			err = fmt.Errorf("error revoking API key: %w", err)
+			return recordAudit(ctx, m.audit, event, err)
+		}
+	}
+
+	return recordAudit(ctx, m.audit, event, nil)
+}
+
+// Authenticate returns the API key of the token, unknown, revoked or
+// mismatching tokens return ErrUnauthenticated. The last use of the key is
+// tracked, at most once every apiKeyTouchInterval.
+func (m *APIKeyManager) Authenticate(ctx context.Context, token string) (aggregates.APIKey, error) {
+	id, secret, err := aggregates.ParseAPIKeyToken(token)
+	if err != nil {
+		return aggregates.APIKey{}, err
+	}
+
+	key, err := m.store.Get(ctx, id)
+	if errors.Is(err, aggregates.ErrAPIKeyNotFound) {
+		return aggregates.APIKey{}, fmt.Errorf("%w: unknown API key", aggregates.ErrUnauthenticated)
+	}
+	if err != nil {
+		return aggregates.APIKey{}, fmt.Errorf("error getting API key: %w", err)
+	}
+
+	if !key.Verify(secret) {
+		return aggregates.APIKey{}, fmt.Errorf("%w: invalid API key", aggregates.ErrUnauthenticated)
+	}
+
+	if key.Revoked() {
+		return aggregates.APIKey{}, fmt.Errorf("%w: revoked API key", aggregates.ErrUnauthenticated)
+	}
+
+	// Only the last use is written back, so a revocation since the key was
+	// read isn't undone.
+	now := time.Now().UTC()
+	if now.Sub(key.LastUsedAt) >= apiKeyTouchInterval {
+		key.LastUsedAt = now
+
+		// A failure tracking the last use shouldn't reject a valid key.
+		if err := m.store.Touch(ctx, key.ID, now); err != nil {
+			m.logger.ErrorContext(ctx, "error tracking API key use", "error", err, "id", key.ID)
+		}
+	}
+
+	return key, nil
+}
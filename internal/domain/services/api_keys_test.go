@@ -0,0 +1,289 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jcleira/coding-challenge/internal/domain/aggregates"
+	"github.com/jcleira/coding-challenge/internal/domain/services"
+	"github.com/jcleira/coding-challenge/mocks"
+)
+
+func TestAPIKeyManager_Issue(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	auditOutcome := func(outcome string) interface{} {
+		return mock.MatchedBy(func(event aggregates.AuditEvent) bool {
+			return event.Action == aggregates.AuditActionAPIKeyIssue &&
+				event.Params["name"] == "shop" &&
+				event.Params["scopes"] == "[send]" &&
+				event.Outcome == outcome
+		})
+	}
+
+	tests := []struct {
+		name       string
+		beforeFunc func(*mocks.APIKeyStore, *mocks.AuditRecorder)
+		wantError  error
+	}{
+		{
+			name: "successful issue",
+			beforeFunc: func(store *mocks.APIKeyStore, audit *mocks.AuditRecorder) {
+				store.On("Create", ctx, mock.Anything).Return(nil)
+				audit.On("Record", ctx, auditOutcome(aggregates.AuditOutcomeSuccess)).Return(nil)
+			},
+		},
+		{
+			name: "error creating key",
+			beforeFunc: func(store *mocks.APIKeyStore, audit *mocks.AuditRecorder) {
+				store.On("Create", ctx, mock.Anything).Return(errors.New("store error"))
+				audit.On("Record", ctx, auditOutcome(aggregates.AuditOutcomeFailure)).Return(nil)
+			},
+			wantError: errors.New("error creating API key: store error"),
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var (
+				store = mocks.NewAPIKeyStore(t)
+				audit = mocks.NewAuditRecorder(t)
+			)
+
+			tt.beforeFunc(store, audit)
+
+			manager := services.NewAPIKeyManager(store, audit, slog.Default())
+
+			key, token, err := manager.Issue(ctx,
+				"shop", []aggregates.Scope{aggregates.ScopeSend}, []string{"wallet"})
+
+			if tt.wantError != nil {
+				assert.Error(t, err)
+				assert.Equal(t, tt.wantError.Error(), err.Error())
+				return
+			}
+
+			require.NoError(t, err)
+
+			id, secret, err := aggregates.ParseAPIKeyToken(token)
+			require.NoError(t, err)
+			assert.Equal(t, key.ID, id)
+			assert.True(t, key.Verify(secret))
+			assert.Equal(t, []string{"wallet"}, key.Wallets)
+			assert.Equal(t, aggregates.DefaultTenant, key.Tenant)
+		})
+	}
+}
+
+func TestAPIKeyManager_List(t *testing.T) {
+	t.Parallel()
+
+	ctx := aggregates.ContextWithTenant(context.Background(), "acme")
+
+	store := mocks.NewAPIKeyStore(t)
+	store.On("List", ctx).Return([]aggregates.APIKey{
+		{ID: "default", Tenant: aggregates.DefaultTenant},
+		{ID: "acme", Tenant: "acme"},
+		{ID: "globex", Tenant: "globex"},
+	}, nil)
+
+	keys, err := services.NewAPIKeyManager(store, mocks.NewAuditRecorder(t), slog.Default()).List(ctx)
+	require.NoError(t, err)
+
+	assert.Equal(t, []aggregates.APIKey{{ID: "acme", Tenant: "acme"}}, keys)
+}
+
+func TestAPIKeyManager_Revoke(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	auditOutcome := func(outcome string) interface{} {
+		return mock.MatchedBy(func(event aggregates.AuditEvent) bool {
+			return event.Action == aggregates.AuditActionAPIKeyRevoke &&
+				event.Params["id"] == "id" &&
+				event.Outcome == outcome
+		})
+	}
+
+	tests := []struct {
+		name       string
+		beforeFunc func(*mocks.APIKeyStore, *mocks.AuditRecorder)
+		wantError  error
+	}{
+		{
+			name: "successful revoke",
+			beforeFunc: func(store *mocks.APIKeyStore, audit *mocks.AuditRecorder) {
+				store.On("Get", ctx, "id").
+					Return(aggregates.APIKey{ID: "id", Tenant: aggregates.DefaultTenant}, nil)
+				store.On("Update", ctx, mock.MatchedBy(func(key aggregates.APIKey) bool {
+					return key.ID == "id" && key.Revoked()
+				})).Return(nil)
+				audit.On("Record", ctx, auditOutcome(aggregates.AuditOutcomeSuccess)).Return(nil)
+			},
+		},
+		{
+			name: "already revoked",
+			beforeFunc: func(store *mocks.APIKeyStore, audit *mocks.AuditRecorder) {
+				store.On("Get", ctx, "id").
+					Return(aggregates.APIKey{
+						ID:        "id",
+						Tenant:    aggregates.DefaultTenant,
+						RevokedAt: time.Now(),
+					}, nil)
+				audit.On("Record", ctx, auditOutcome(aggregates.AuditOutcomeSuccess)).Return(nil)
+			},
+		},
+		{
+			name: "unknown key",
+			beforeFunc: func(store *mocks.APIKeyStore, audit *mocks.AuditRecorder) {
+				store.On("Get", ctx, "id").Return(aggregates.APIKey{}, aggregates.ErrAPIKeyNotFound)
+				audit.On("Record", ctx, auditOutcome(aggregates.AuditOutcomeFailure)).Return(nil)
+			},
+			wantError: aggregates.ErrAPIKeyNotFound,
+		},
+		{
+			name: "key of another tenant",
+			beforeFunc: func(store *mocks.APIKeyStore, audit *mocks.AuditRecorder) {
+				store.On("Get", ctx, "id").Return(aggregates.APIKey{ID: "id", Tenant: "acme"}, nil)
+				audit.On("Record", ctx, auditOutcome(aggregates.AuditOutcomeFailure)).Return(nil)
+			},
+			wantError: aggregates.ErrAPIKeyNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var (
+				store = mocks.NewAPIKeyStore(t)
+				audit = mocks.NewAuditRecorder(t)
+			)
+
+			tt.beforeFunc(store, audit)
+
+			err := services.NewAPIKeyManager(store, audit, slog.Default()).Revoke(ctx, "id")
+
+			if tt.wantError != nil {
+				assert.True(t, errors.Is(err, tt.wantError), "got %v", err)
+				return
+			}
+
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestAPIKeyManager_Authenticate(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	key, token, err := aggregates.NewAPIKey("shop", []aggregates.Scope{aggregates.ScopeSend}, nil)
+	require.NoError(t, err)
+
+	recentlyUsed := key
+	recentlyUsed.LastUsedAt = time.Now().UTC()
+
+	revoked := key
+	revoked.RevokedAt = time.Now().UTC()
+
+	tests := []struct {
+		name       string
+		token      string
+		beforeFunc func(*mocks.APIKeyStore)
+		wantError  error
+	}{
+		{
+			name:  "first use is tracked",
+			token: token,
+			beforeFunc: func(store *mocks.APIKeyStore) {
+				store.On("Get", ctx, key.ID).Return(key, nil)
+				store.On("Touch", ctx, key.ID, mock.MatchedBy(func(at time.Time) bool {
+					return !at.IsZero()
+				})).Return(nil)
+			},
+		},
+		{
+			name:  "recent use isn't tracked again",
+			token: token,
+			beforeFunc: func(store *mocks.APIKeyStore) {
+				store.On("Get", ctx, key.ID).Return(recentlyUsed, nil)
+			},
+		},
+		{
+			name:  "tracking errors don't reject the key",
+			token: token,
+			beforeFunc: func(store *mocks.APIKeyStore) {
+				store.On("Get", ctx, key.ID).Return(key, nil)
+				store.On("Touch", ctx, key.ID, mock.Anything).Return(errors.New("store error"))
+			},
+		},
+		{
+			name:      "malformed token",
+			token:     "token",
+			wantError: aggregates.ErrUnauthenticated,
+		},
+		{
+			name:  "unknown key",
+			token: token,
+			beforeFunc: func(store *mocks.APIKeyStore) {
+				store.On("Get", ctx, key.ID).Return(aggregates.APIKey{}, aggregates.ErrAPIKeyNotFound)
+			},
+			wantError: aggregates.ErrUnauthenticated,
+		},
+		{
+			name:  "wrong secret",
+			token: "sk_" + key.ID + "_secret",
+			beforeFunc: func(store *mocks.APIKeyStore) {
+				store.On("Get", ctx, key.ID).Return(key, nil)
+			},
+			wantError: aggregates.ErrUnauthenticated,
+		},
+		{
+			name:  "revoked key",
+			token: token,
+			beforeFunc: func(store *mocks.APIKeyStore) {
+				store.On("Get", ctx, key.ID).Return(revoked, nil)
+			},
+			wantError: aggregates.ErrUnauthenticated,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			store := mocks.NewAPIKeyStore(t)
+			if tt.beforeFunc != nil {
+				tt.beforeFunc(store)
+			}
+
+			authenticated, err := services.NewAPIKeyManager(store, mocks.NewAuditRecorder(t), slog.Default()).
+				Authenticate(ctx, tt.token)
+
+			if tt.wantError != nil {
+				assert.True(t, errors.Is(err, tt.wantError), "got %v", err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, key.ID, authenticated.ID)
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/jcleira/coding-challenge/internal/domain/aggregates"
+)
+
+// recordAudit records the event with the outcome of err, the audited operation
+// error. An audit failure is only surfaced when the operation succeeded, as
+// otherwise the operation error is more relevant to the caller.
+func recordAudit(
+	ctx context.Context, audit AuditRecorder, event aggregates.AuditEvent, err error) error {
+	event.SetOutcome(err)
+
+	if auditErr := audit.Record(ctx, event); auditErr != nil && err == nil {
+		return fmt.Errorf("error recording audit event: %w", auditErr)
+	}
+
+	return err
+}
+
+// recordCommittedAudit records the event of an operation that took effect and
+// can't be undone, such as a transaction already broadcast, with the outcome
+// of err. An audit failure is logged instead of returned, failing a completed
+// operation would have its caller retry it.
+func recordCommittedAudit(ctx context.Context, audit AuditRecorder,
+	logger *slog.Logger, event aggregates.AuditEvent, err error) error {
+	event.SetOutcome(err)
+
+	if auditErr := audit.Record(ctx, event); auditErr != nil {
+		logger.ErrorContext(ctx, "error recording audit event of a completed operation",
+			"error", auditErr, "action", event.Action, "wallet", event.Wallet, "params", event.Params)
+	}
+
+	return err
+}
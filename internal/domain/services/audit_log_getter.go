@@ -0,0 +1,48 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jcleira/coding-challenge/internal/domain/aggregates"
+)
+
+// AuditLogGetter defines the dependencies for querying the audit log.
+type AuditLogGetter struct {
+	audit AuditLog
+}
+
+// NewAuditLogGetter creates a new AuditLogGetter.
+func NewAuditLogGetter(audit AuditLog) *AuditLogGetter {
+	return &AuditLogGetter{
+		audit: audit,
+	}
+}
+
+// GetEvents gets the audit events matching the filter, oldest first, only the
+// events of the tenant carried by ctx are returned. Reading the audit log is
+// an admin action, so it's audited as well.
+func (alg *AuditLogGetter) GetEvents(
+	ctx context.Context, filter aggregates.AuditFilter) ([]aggregates.AuditEvent, error) {
+	filter.Tenant = aggregates.TenantFromContext(ctx)
+
+	event := aggregates.NewAuditEvent(ctx, aggregates.AuditActionAuditQuery, filter.Wallet)
+	if !filter.From.IsZero() {
+		event.Params["from"] = filter.From.Format(time.RFC3339)
+	}
+	if !filter.To.IsZero() {
+		event.Params["to"] = filter.To.Format(time.RFC3339)
+	}
+
+	events, err := alg.audit.Query(ctx, filter)
+	if err != nil {
+		err = fmt.Errorf("error querying audit log: %w", err)
+	}
+
+	if err := recordAudit(ctx, alg.audit, event, err); err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
@@ -0,0 +1,98 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/jcleira/coding-challenge/internal/domain/aggregates"
+	"github.com/jcleira/coding-challenge/internal/domain/services"
+	"github.com/jcleira/coding-challenge/mocks"
+)
+
+func TestAuditLogGetter_GetEvents(t *testing.T) {
+	t.Parallel()
+
+	ctx := aggregates.ContextWithTenant(context.Background(), "acme")
+
+	filter := aggregates.AuditFilter{
+		Tenant: "acme",
+		Wallet: "testPublicKey",
+		From:   time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+
+	// The tenant of the request is enforced over the one of the filter.
+	requested := filter
+	requested.Tenant = "globex"
+
+	events := []aggregates.AuditEvent{
+		{
+			Action:  aggregates.AuditActionWalletCreate,
+			Wallet:  "testPublicKey",
+			Outcome: aggregates.AuditOutcomeSuccess,
+		},
+	}
+
+	auditOutcome := func(outcome string) interface{} {
+		return mock.MatchedBy(func(event aggregates.AuditEvent) bool {
+			return event.Action == aggregates.AuditActionAuditQuery &&
+				event.Wallet == filter.Wallet &&
+				event.Params["from"] == "2024-01-01T00:00:00Z" &&
+				event.Outcome == outcome
+		})
+	}
+
+	tests := []struct {
+		name       string
+		beforeFunc func(*mocks.AuditLog)
+		want       []aggregates.AuditEvent
+		wantError  error
+	}{
+		{
+			name: "successful audit log query",
+			beforeFunc: func(audit *mocks.AuditLog) {
+				audit.On("Query", ctx, filter).Return(events, nil)
+				audit.On("Record", ctx, auditOutcome(aggregates.AuditOutcomeSuccess)).Return(nil)
+			},
+			want: events,
+		},
+		{
+			name: "error querying audit log",
+			beforeFunc: func(audit *mocks.AuditLog) {
+				audit.On("Query", ctx, filter).Return(nil, errors.New("query error"))
+				audit.On("Record", ctx, auditOutcome(aggregates.AuditOutcomeFailure)).Return(nil)
+			},
+			wantError: errors.New("error querying audit log: query error"),
+		},
+	}
+
+	for _, test := range tests {
+		tt := test
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			audit := mocks.NewAuditLog(t)
+
+			tt.beforeFunc(audit)
+
+			service := services.NewAuditLogGetter(audit)
+
+			result, err := service.GetEvents(ctx, requested)
+
+			audit.AssertExpectations(t)
+
+			if tt.wantError != nil {
+				assert.Error(t, err)
+				assert.Equal(t, tt.wantError.Error(), err.Error())
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, result)
+		})
+	}
+}
@@ -2,6 +2,8 @@ package services
 
 import (
 	"context"
+	"math/big"
+	"time"
 
 	"github.com/jcleira/coding-challenge/internal/domain/aggregates"
 )
@@ -9,7 +11,8 @@ import (
 // SolanaGetter defines the methods for getting transactions from the Solana
 // blockchain.
 type SolanaGetter interface {
-	GetTransactions(ctx context.Context, publicKey string) ([]aggregates.Transaction, error)
+	GetTransactions(ctx context.Context,
+		publicKey string, page aggregates.TransactionsPage) ([]aggregates.Transaction, error)
 }
 
 // SolanaSender is an interface that defines the methods for sending
@@ -27,22 +30,114 @@ type SolanaBalanceGetter interface {
 	GetBalance(ctx context.Context, publicKey string) (uint64, error)
 }
 
-// ExchangeGetter defines the methods for getting exchange rates.
+// ExchangeGetter defines the methods for getting exchange rates for a fiat
+// currency.
 type ExchangeGetter interface {
-	GetRate() (aggregates.Rate, error)
+	GetRate(ctx context.Context, currency string) (aggregates.Rate, error)
 }
 
-// WalletGetter defines the methods for getting wallets.
+// HistoricalRateGetter defines the methods for getting the exchange rate for
+// a fiat currency at a point in time.
+type HistoricalRateGetter interface {
+	GetRateAt(ctx context.Context, currency string, at time.Time) (aggregates.Rate, error)
+}
+
+// WalletGetter defines the methods for getting the wallets of the tenant
+// carried by the context.
 type WalletGetter interface {
-	GetWallet(publicKey string) (aggregates.Wallet, error)
+	GetWallet(ctx context.Context, publicKey string) (aggregates.Wallet, error)
 }
 
-// WalletCreator defines the methods for creating wallets.
+// WalletCreator defines the methods for creating wallets for the tenant
+// carried by the context.
 type WalletCreator interface {
-	CreateWallet() (aggregates.Wallet, error)
+	CreateWallet(ctx context.Context) (aggregates.Wallet, error)
 }
 
-// RateGetter defines the methods for getting exchange rates.
+// RateGetter defines the methods for getting exchange rates for a fiat
+// currency.
 type RateGetter interface {
-	GetRate() (aggregates.Rate, error)
+	GetRate(ctx context.Context, currency string) (aggregates.Rate, error)
+}
+
+// RateHealthGetter defines the methods for getting the health of the
+// exchange rates.
+type RateHealthGetter interface {
+	Health() aggregates.RateHealth
+}
+
+// HealthChecker defines the methods for checking a dependency is reachable.
+type HealthChecker interface {
+	CheckHealth(ctx context.Context) error
+}
+
+// RateSubscriber defines the methods for subscribing to exchange rate
+// updates.
+type RateSubscriber interface {
+	Subscribe() (<-chan aggregates.Rate, func())
+}
+
+// RateOverrider defines the methods for overriding exchange rates by hand.
+type RateOverrider interface {
+	SetOverride(currency string, value *big.Rat, ttl time.Duration) (aggregates.Rate, error)
+	ClearOverride(currency string) error
+}
+
+// SolanaFeeGetter defines the methods for getting the fee the Solana
+// blockchain charges for a transfer.
+type SolanaFeeGetter interface {
+	GetTransferFee(ctx context.Context, from, to string) (uint64, error)
+}
+
+// SolanaSweeper defines the methods for moving the full balance of a wallet
+// to another address.
+type SolanaSweeper interface {
+	SolanaSender
+	SolanaBalanceGetter
+	SolanaFeeGetter
+}
+
+// WalletRotatorVault defines the methods the vault needs to provide for rotating
+// wallets.
+type WalletRotatorVault interface {
+	WalletGetter
+	WalletCreator
+	RetireWallet(ctx context.Context, oldPublicKey, newPublicKey string) error
+}
+
+// AuditRecorder defines the methods for recording audit events.
+type AuditRecorder interface {
+	Record(ctx context.Context, event aggregates.AuditEvent) error
+}
+
+// AuditQuerier defines the methods for querying audit events.
+type AuditQuerier interface {
+	Query(ctx context.Context, filter aggregates.AuditFilter) ([]aggregates.AuditEvent, error)
+}
+
+// AuditLog defines the methods for recording and querying audit events.
+type AuditLog interface {
+	AuditRecorder
+	AuditQuerier
+}
+
+// APIKeyStore defines the methods for persisting API keys.
+type APIKeyStore interface {
+	Create(ctx context.Context, key aggregates.APIKey) error
+	Get(ctx context.Context, id string) (aggregates.APIKey, error)
+	List(ctx context.Context) ([]aggregates.APIKey, error)
+	Update(ctx context.Context, key aggregates.APIKey) error
+	Touch(ctx context.Context, id string, at time.Time) error
+}
+
+// TokenVerifier defines the methods for verifying bearer tokens, returning
+// their claims.
+type TokenVerifier interface {
+	Verify(ctx context.Context, token string) (aggregates.TokenClaims, error)
+}
+
+// QuotaStore defines the methods for counting the requests of the clients
+// against their daily quotas, shared by every replica.
+type QuotaStore interface {
+	Increment(ctx context.Context, key string, day time.Time) (int, error)
 }
@@ -0,0 +1,76 @@
+package services
+
+import (
+	"fmt"
+
+	"github.com/jcleira/coding-challenge/internal/domain/aggregates"
+)
+
+// Converter converts amounts between lamports and fiat currencies, every
+// service converting amounts goes through it, so balances, transactions and
+// sends always agree on the conversion direction and rounding.
+//
+// Rates are the price of a SOL in the fiat currency, such as 85.87 for
+// SOLEUR, so lamports are multiplied by the rate and fiat amounts divided.
+type Converter struct {
+	mode aggregates.RoundingMode
+}
+
+// NewConverter creates a new Converter rounding with the given mode.
+func NewConverter(mode aggregates.RoundingMode) *Converter {
+	return &Converter{
+		mode: mode,
+	}
+}
+
+// ToFiat converts an amount of lamports to the rate fiat currency.
+func (c *Converter) ToFiat(
+	lamports aggregates.Lamports, rate aggregates.Rate) (aggregates.Money, error) {
+	money, err := aggregates.LamportsToMoney(lamports, rate, c.mode)
+	if err != nil {
+		return aggregates.Money{}, fmt.Errorf("error converting to %s: %w", rate.Currency, err)
+	}
+
+	return money, nil
+}
+
+// ToLamports converts a fiat amount to lamports, the rate must be for the
+// amount currency.
+func (c *Converter) ToLamports(
+	money aggregates.Money, rate aggregates.Rate) (aggregates.Lamports, error) {
+	lamports, err := aggregates.MoneyToLamports(money, rate, c.mode)
+	if err != nil {
+		return 0, fmt.Errorf("error converting to lamports: %w", err)
+	}
+
+	return lamports, nil
+}
+
+// SetFiatAmount sets the transaction fiat amount and exchange rate from its
+// amount in lamports.
+func (c *Converter) SetFiatAmount(
+	transaction *aggregates.Transaction, rate aggregates.Rate) error {
+	money, err := c.ToFiat(transaction.AmountLAM, rate)
+	if err != nil {
+		return err
+	}
+
+	transaction.Amount = money
+	transaction.ExchangeRate = rate.Value
+
+	return nil
+}
+
+// SetLamportsAmount sets the transaction amount in lamports from its fiat
+// amount.
+func (c *Converter) SetLamportsAmount(
+	transaction *aggregates.Transaction, rate aggregates.Rate) error {
+	lamports, err := c.ToLamports(transaction.Amount, rate)
+	if err != nil {
+		return err
+	}
+
+	transaction.AmountLAM = lamports
+
+	return nil
+}
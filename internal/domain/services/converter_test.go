@@ -0,0 +1,228 @@
+package services_test
+
+import (
+	"math"
+	"math/big"
+	"math/rand"
+	"reflect"
+	"testing"
+	"testing/quick"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jcleira/coding-challenge/internal/domain/aggregates"
+	"github.com/jcleira/coding-challenge/internal/domain/services"
+)
+
+func TestConverter_SetFiatAmount(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		amountLAM aggregates.Lamports
+		rate      *big.Rat
+		currency  string
+		wantFiat  string
+	}{
+		{
+			name:      "Set EUR amount correctly",
+			amountLAM: 2000000000,
+			rate:      big.NewRat(12345, 10000),
+			currency:  "EUR",
+			wantFiat:  "EUR 2.47",
+		},
+		{
+			name:      "Set USD amount correctly",
+			amountLAM: 2000000000,
+			rate:      big.NewRat(2, 1),
+			currency:  "USD",
+			wantFiat:  "USD 4.00",
+		},
+	}
+
+	converter := services.NewConverter(aggregates.RoundHalfEven)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transaction := aggregates.Transaction{
+				AmountLAM: tt.amountLAM,
+			}
+			rate := aggregates.Rate{
+				Currency: tt.currency,
+				Value:    tt.rate,
+			}
+
+			err := converter.SetFiatAmount(&transaction, rate)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantFiat, transaction.Amount.String())
+			assert.Equal(t, tt.rate, transaction.ExchangeRate)
+		})
+	}
+}
+
+func TestConverter_SetLamportsAmount(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		amount       aggregates.Money
+		rate         *big.Rat
+		rateCurrency string
+		wantLAM      aggregates.Lamports
+		wantErr      bool
+	}{
+		{
+			name:         "Set Lamports amount correctly",
+			amount:       aggregates.Money{Amount: 162, Currency: "EUR"},
+			rate:         big.NewRat(12345, 10000),
+			rateCurrency: "EUR",
+			wantLAM:      1312272175,
+			wantErr:      false,
+		},
+		{
+			name:         "Error with a negative amount",
+			amount:       aggregates.Money{Amount: -162, Currency: "EUR"},
+			rate:         big.NewRat(12345, 10000),
+			rateCurrency: "EUR",
+			wantLAM:      0,
+			wantErr:      true,
+		},
+		{
+			name:         "Error with a rate for another currency",
+			amount:       aggregates.Money{Amount: 162, Currency: "USD"},
+			rate:         big.NewRat(12345, 10000),
+			rateCurrency: "EUR",
+			wantLAM:      0,
+			wantErr:      true,
+		},
+	}
+
+	converter := services.NewConverter(aggregates.RoundHalfEven)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transaction := aggregates.Transaction{
+				Amount: tt.amount,
+			}
+			rate := aggregates.Rate{
+				Currency: tt.rateCurrency,
+				Value:    tt.rate,
+			}
+
+			err := converter.SetLamportsAmount(&transaction, rate)
+
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.wantLAM, transaction.AmountLAM)
+			}
+		})
+	}
+}
+
+// conversionInput is a random fiat amount and SOL rate for the conversion
+// properties.
+type conversionInput struct {
+	Cents int64
+	Rate  *big.Rat
+}
+
+// Generate generates amounts across the whole range that fits in lamports,
+// from a cent up to the lamports maximum, at rates from a cent to a million
+// EUR per SOL.
+func (conversionInput) Generate(r *rand.Rand, _ int) reflect.Value {
+	// Rates with up to 5 decimals, as the providers return them.
+	rate := big.NewRat(1000+r.Int63n(100000000000), 100000)
+
+	// The maximum amount of cents whose lamports still fit in an uint64.
+	maxCents := new(big.Rat).Mul(rate, big.NewRat(100, 1))
+	maxCents.Mul(maxCents, new(big.Rat).SetFrac(
+		new(big.Int).SetUint64(math.MaxUint64),
+		big.NewInt(int64(aggregates.LamportsPerSOL))))
+
+	limit := new(big.Int).Quo(maxCents.Num(), maxCents.Denom())
+	if !limit.IsInt64() {
+		limit.SetInt64(math.MaxInt64)
+	}
+
+	// Spread the amounts across every order of magnitude, uniform values
+	// would almost always be close to the maximum.
+	magnitude := big.NewInt(r.Int63n(int64(limit.BitLen())) + 1)
+	upper := new(big.Int).Lsh(big.NewInt(1), uint(magnitude.Int64()))
+	if upper.Cmp(limit) > 0 {
+		upper = limit
+	}
+
+	return reflect.ValueOf(conversionInput{
+		Cents: 1 + r.Int63n(upper.Int64()),
+		Rate:  rate,
+	})
+}
+
+func TestConverter_RoundTrip(t *testing.T) {
+	t.Parallel()
+
+	modes := []aggregates.RoundingMode{
+		aggregates.RoundHalfEven,
+		aggregates.RoundHalfUp,
+		aggregates.RoundFloor,
+	}
+
+	for _, mode := range modes {
+		mode := mode
+		t.Run(string(mode), func(t *testing.T) {
+			t.Parallel()
+
+			converter := services.NewConverter(mode)
+
+			// EUR to lamports and back to EUR is off by one cent at most.
+			roundTrip := func(input conversionInput) bool {
+				rate := aggregates.Rate{Currency: "EUR", Value: input.Rate}
+
+				lamports, err := converter.ToLamports(
+					aggregates.Money{Amount: input.Cents, Currency: "EUR"}, rate)
+				if err != nil {
+					t.Logf("error converting %d cents at %s: %v", input.Cents, input.Rate, err)
+					return false
+				}
+
+				money, err := converter.ToFiat(lamports, rate)
+				if err != nil {
+					t.Logf("error converting %d lamports at %s: %v", lamports, input.Rate, err)
+					return false
+				}
+
+				diff := money.Amount - input.Cents
+				return money.Currency == "EUR" && diff >= -1 && diff <= 1
+			}
+
+			require.NoError(t, quick.Check(roundTrip, &quick.Config{MaxCount: 10000}))
+
+			// Converting more lamports never gives a smaller fiat amount.
+			monotonic := func(a, b uint64, input conversionInput) bool {
+				if a > b {
+					a, b = b, a
+				}
+
+				rate := aggregates.Rate{Currency: "EUR", Value: input.Rate}
+
+				low, err := converter.ToFiat(aggregates.Lamports(a), rate)
+				if err != nil {
+					return true
+				}
+
+				high, err := converter.ToFiat(aggregates.Lamports(b), rate)
+				if err != nil {
+					return true
+				}
+
+				return low.Amount <= high.Amount
+			}
+
+			require.NoError(t, quick.Check(monotonic, &quick.Config{MaxCount: 10000}))
+		})
+	}
+}
@@ -0,0 +1,23 @@
+package services
+
+import (
+	"github.com/jcleira/coding-challenge/internal/domain/aggregates"
+)
+
+// ExchangeHealthGetter define the dependencies to get the exchange rates
+// health.
+type ExchangeHealthGetter struct {
+	exchange RateHealthGetter
+}
+
+// NewExchangeHealthGetter creates a new ExchangeHealthGetter.
+func NewExchangeHealthGetter(exchange RateHealthGetter) *ExchangeHealthGetter {
+	return &ExchangeHealthGetter{
+		exchange: exchange,
+	}
+}
+
+// GetHealth gets the health of the exchange rates.
+func (e *ExchangeHealthGetter) GetHealth() aggregates.RateHealth {
+	return e.exchange.Health()
+}
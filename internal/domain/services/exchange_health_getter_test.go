@@ -0,0 +1,32 @@
+package services_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/jcleira/coding-challenge/internal/domain/aggregates"
+	"github.com/jcleira/coding-challenge/internal/domain/services"
+	"github.com/jcleira/coding-challenge/mocks"
+)
+
+func TestExchangeHealthGetter_GetHealth(t *testing.T) {
+	t.Parallel()
+
+	health := aggregates.RateHealth{
+		State:               aggregates.RateHealthStale,
+		LastSuccess:         time.Now().Add(-time.Minute),
+		LastError:           "error fetching EUR rate",
+		LastErrorAt:         time.Now(),
+		ConsecutiveFailures: 2,
+		OpenCircuits:        []string{"kraken"},
+	}
+
+	rateHealthGetter := mocks.NewRateHealthGetter(t)
+	rateHealthGetter.On("Health").Return(health)
+
+	exchangeHealthGetter := services.NewExchangeHealthGetter(rateHealthGetter)
+
+	assert.Equal(t, health, exchangeHealthGetter.GetHealth())
+}
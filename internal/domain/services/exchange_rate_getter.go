@@ -1,7 +1,13 @@
 package services
 
 import (
+	"context"
 	"fmt"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/jcleira/coding-challenge/internal/domain/aggregates"
 )
 
 // ExchangeRateGetter define the dependencies to get exchange rates.
@@ -16,15 +22,17 @@ func NewExchangeRateGetter(exchange RateGetter) *ExchangeRateGetter {
 	}
 }
 
-// GetRate gets the exchange rate.
-func (e *ExchangeRateGetter) GetRate() (float64, error) {
-	rate, err := e.exchange.GetRate()
+// GetRate gets the SOL exchange rate for the given fiat currency.
+func (e *ExchangeRateGetter) GetRate(
+	ctx context.Context, currency string) (_ aggregates.Rate, err error) {
+	ctx, span := tracer.Start(ctx, "ExchangeRateGetter.GetRate",
+		trace.WithAttributes(attribute.String("currency", currency)))
+	defer func() { endSpan(span, err) }()
+
+	rate, err := e.exchange.GetRate(ctx, currency)
 	if err != nil {
-		return 0, fmt.Errorf("error getting exchange rate: %w", err)
+		return aggregates.Rate{}, fmt.Errorf("error getting exchange rate: %w", err)
 	}
 
-	// Ignoring exact checks here
-	value, _ := rate.Value.Float64()
-
-	return value, nil
+	return rate, nil
 }
@@ -1,12 +1,14 @@
 package services_test
 
 import (
+	"context"
 	"errors"
 	"math/big"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 
 	"github.com/jcleira/coding-challenge/internal/domain/aggregates"
 	"github.com/jcleira/coding-challenge/internal/domain/services"
@@ -17,7 +19,7 @@ func TestExchangeRateGetter_GetRate(t *testing.T) {
 	t.Parallel()
 
 	rate := aggregates.Rate{
-		Currency:  "USD",
+		Currency:  "EUR",
 		Value:     big.NewRat(12345, 10000),
 		ExpiredAt: time.Now().Add(1 * time.Hour),
 	}
@@ -25,20 +27,20 @@ func TestExchangeRateGetter_GetRate(t *testing.T) {
 	tests := []struct {
 		name       string
 		beforeFunc func(*mocks.RateGetter)
-		wantRate   float64
+		wantRate   aggregates.Rate
 		wantError  error
 	}{
 		{
 			name: "successful rate retrieval",
 			beforeFunc: func(erg *mocks.RateGetter) {
-				erg.On("GetRate").Return(rate, nil)
+				erg.On("GetRate", mock.Anything, "EUR").Return(rate, nil)
 			},
-			wantRate: 1.234500,
+			wantRate: rate,
 		},
 		{
 			name: "error in rate retrieval",
 			beforeFunc: func(erg *mocks.RateGetter) {
-				erg.On("GetRate").
+				erg.On("GetRate", mock.Anything, "EUR").
 					Return(aggregates.Rate{}, errors.New("error"))
 			},
 			wantError: errors.New("error getting exchange rate: error"),
@@ -55,7 +57,7 @@ func TestExchangeRateGetter_GetRate(t *testing.T) {
 
 			exchangeRateGetter := services.NewExchangeRateGetter(rateGetter)
 
-			rate, err := exchangeRateGetter.GetRate()
+			rate, err := exchangeRateGetter.GetRate(context.Background(), "EUR")
 
 			rateGetter.AssertExpectations(t)
 
@@ -0,0 +1,87 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/jcleira/coding-challenge/internal/domain/aggregates"
+)
+
+// ExchangeRateOverrider define the dependencies to override exchange rates by
+// hand, every override is recorded in the audit log.
+//
+// The exchange rates are shared by every tenant, so only the default tenant,
+// the one of the deployment operators, can override them.
+type ExchangeRateOverrider struct {
+	exchange RateOverrider
+	audit    AuditRecorder
+}
+
+// NewExchangeRateOverrider creates a new ExchangeRateOverrider.
+func NewExchangeRateOverrider(
+	exchange RateOverrider,
+	audit AuditRecorder,
+) *ExchangeRateOverrider {
+	return &ExchangeRateOverrider{
+		exchange: exchange,
+		audit:    audit,
+	}
+}
+
+// SetOverride overrides the SOL exchange rate for the given fiat currency
+// with value, for the ttl duration.
+func (e *ExchangeRateOverrider) SetOverride(ctx context.Context,
+	currency string, value *big.Rat, ttl time.Duration) (aggregates.Rate, error) {
+	event := aggregates.NewAuditEvent(ctx, aggregates.AuditActionRateOverrideSet, "")
+	event.Params["currency"] = currency
+	event.Params["ttl"] = ttl.String()
+	if value != nil {
+		event.Params["rate"] = value.FloatString(8)
+	}
+
+	if err := requireDefaultTenant(ctx); err != nil {
+		return aggregates.Rate{}, recordAudit(ctx, e.audit, event, err)
+	}
+
+	rate, err := e.exchange.SetOverride(currency, value, ttl)
+	if err != nil {
+		err = fmt.Errorf("error setting rate override: %w", err)
+	}
+
+	if err := recordAudit(ctx, e.audit, event, err); err != nil {
+		return aggregates.Rate{}, err
+	}
+
+	return rate, nil
+}
+
+// ClearOverride removes the override of the SOL exchange rate for the given
+// fiat currency, the fetched rates are used again from then on.
+func (e *ExchangeRateOverrider) ClearOverride(ctx context.Context, currency string) error {
+	event := aggregates.NewAuditEvent(ctx, aggregates.AuditActionRateOverrideClear, "")
+	event.Params["currency"] = currency
+
+	if err := requireDefaultTenant(ctx); err != nil {
+		return recordAudit(ctx, e.audit, event, err)
+	}
+
+	err := e.exchange.ClearOverride(currency)
+	if err != nil {
+		err = fmt.Errorf("error clearing rate override: %w", err)
+	}
+
+	return recordAudit(ctx, e.audit, event, err)
+}
+
+// requireDefaultTenant returns ErrForbidden unless ctx carries the default
+// tenant.
+func requireDefaultTenant(ctx context.Context) error {
+	if tenant := aggregates.TenantFromContext(ctx); tenant != aggregates.DefaultTenant {
+		return fmt.Errorf("%w: tenant %s can't override the shared exchange rates",
+			aggregates.ErrForbidden, tenant)
+	}
+
+	return nil
+}
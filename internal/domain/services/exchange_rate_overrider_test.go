@@ -0,0 +1,189 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/jcleira/coding-challenge/internal/domain/aggregates"
+	"github.com/jcleira/coding-challenge/internal/domain/services"
+	"github.com/jcleira/coding-challenge/mocks"
+)
+
+func TestExchangeRateOverrider_SetOverride(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	value := big.NewRat(8510, 100)
+
+	override := aggregates.Rate{
+		Currency:   "EUR",
+		Value:      value,
+		Sources:    []string{"override"},
+		ExpiredAt:  time.Now().Add(time.Hour),
+		Overridden: true,
+	}
+
+	auditOutcome := func(outcome string) interface{} {
+		return mock.MatchedBy(func(event aggregates.AuditEvent) bool {
+			return event.Action == aggregates.AuditActionRateOverrideSet &&
+				event.Params["currency"] == "EUR" &&
+				event.Params["rate"] == "85.10000000" &&
+				event.Params["ttl"] == "1h0m0s" &&
+				event.Outcome == outcome
+		})
+	}
+
+	tests := []struct {
+		name       string
+		beforeFunc func(*mocks.RateOverrider, *mocks.AuditRecorder)
+		want       aggregates.Rate
+		wantError  error
+	}{
+		{
+			name: "successful override",
+			beforeFunc: func(exchange *mocks.RateOverrider, audit *mocks.AuditRecorder) {
+				exchange.On("SetOverride", "EUR", value, time.Hour).Return(override, nil)
+				audit.On("Record", ctx, auditOutcome(aggregates.AuditOutcomeSuccess)).Return(nil)
+			},
+			want: override,
+		},
+		{
+			name: "error setting override",
+			beforeFunc: func(exchange *mocks.RateOverrider, audit *mocks.AuditRecorder) {
+				exchange.On("SetOverride", "EUR", value, time.Hour).
+					Return(aggregates.Rate{}, aggregates.ErrCurrencyNotSupported)
+				audit.On("Record", ctx, auditOutcome(aggregates.AuditOutcomeFailure)).Return(nil)
+			},
+			wantError: errors.New("error setting rate override: currency not supported"),
+		},
+		{
+			name: "error recording audit event",
+			beforeFunc: func(exchange *mocks.RateOverrider, audit *mocks.AuditRecorder) {
+				exchange.On("SetOverride", "EUR", value, time.Hour).Return(override, nil)
+				audit.On("Record", ctx, auditOutcome(aggregates.AuditOutcomeSuccess)).
+					Return(errors.New("audit error"))
+			},
+			wantError: errors.New("error recording audit event: audit error"),
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var (
+				exchange = mocks.NewRateOverrider(t)
+				audit    = mocks.NewAuditRecorder(t)
+			)
+
+			tt.beforeFunc(exchange, audit)
+
+			service := services.NewExchangeRateOverrider(exchange, audit)
+
+			result, err := service.SetOverride(ctx, "EUR", value, time.Hour)
+
+			exchange.AssertExpectations(t)
+			audit.AssertExpectations(t)
+
+			if tt.wantError != nil {
+				assert.Error(t, err)
+				assert.Equal(t, tt.wantError.Error(), err.Error())
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, result)
+		})
+	}
+}
+
+func TestExchangeRateOverrider_SetOverrideOtherTenant(t *testing.T) {
+	t.Parallel()
+
+	ctx := aggregates.ContextWithTenant(context.Background(), "acme")
+
+	audit := mocks.NewAuditRecorder(t)
+	audit.On("Record", ctx, mock.MatchedBy(func(event aggregates.AuditEvent) bool {
+		return event.Action == aggregates.AuditActionRateOverrideSet &&
+			event.Tenant == "acme" &&
+			event.Outcome == aggregates.AuditOutcomeFailure
+	})).Return(nil)
+
+	service := services.NewExchangeRateOverrider(mocks.NewRateOverrider(t), audit)
+
+	_, err := service.SetOverride(ctx, "EUR", big.NewRat(8510, 100), time.Hour)
+	assert.True(t, errors.Is(err, aggregates.ErrForbidden), "got %v", err)
+}
+
+func TestExchangeRateOverrider_ClearOverride(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	auditOutcome := func(outcome string) interface{} {
+		return mock.MatchedBy(func(event aggregates.AuditEvent) bool {
+			return event.Action == aggregates.AuditActionRateOverrideClear &&
+				event.Params["currency"] == "EUR" &&
+				event.Outcome == outcome
+		})
+	}
+
+	tests := []struct {
+		name       string
+		beforeFunc func(*mocks.RateOverrider, *mocks.AuditRecorder)
+		wantError  error
+	}{
+		{
+			name: "successful clear",
+			beforeFunc: func(exchange *mocks.RateOverrider, audit *mocks.AuditRecorder) {
+				exchange.On("ClearOverride", "EUR").Return(nil)
+				audit.On("Record", ctx, auditOutcome(aggregates.AuditOutcomeSuccess)).Return(nil)
+			},
+		},
+		{
+			name: "error clearing override",
+			beforeFunc: func(exchange *mocks.RateOverrider, audit *mocks.AuditRecorder) {
+				exchange.On("ClearOverride", "EUR").Return(aggregates.ErrCurrencyNotSupported)
+				audit.On("Record", ctx, auditOutcome(aggregates.AuditOutcomeFailure)).Return(nil)
+			},
+			wantError: errors.New("error clearing rate override: currency not supported"),
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var (
+				exchange = mocks.NewRateOverrider(t)
+				audit    = mocks.NewAuditRecorder(t)
+			)
+
+			tt.beforeFunc(exchange, audit)
+
+			service := services.NewExchangeRateOverrider(exchange, audit)
+
+			err := service.ClearOverride(ctx, "EUR")
+
+			exchange.AssertExpectations(t)
+			audit.AssertExpectations(t)
+
+			if tt.wantError != nil {
+				assert.Error(t, err)
+				assert.Equal(t, tt.wantError.Error(), err.Error())
+				return
+			}
+
+			assert.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,25 @@
+package services
+
+import (
+	"github.com/jcleira/coding-challenge/internal/domain/aggregates"
+)
+
+// ExchangeRateStreamer define the dependencies to stream exchange rate
+// updates.
+type ExchangeRateStreamer struct {
+	exchange RateSubscriber
+}
+
+// NewExchangeRateStreamer creates a new ExchangeRateStreamer.
+func NewExchangeRateStreamer(exchange RateSubscriber) *ExchangeRateStreamer {
+	return &ExchangeRateStreamer{
+		exchange: exchange,
+	}
+}
+
+// Subscribe subscribes to the exchange rate updates, the returned function
+// must be called to unsubscribe. The channel is closed when unsubscribing or
+// when the subscriber can't keep up with the updates.
+func (e *ExchangeRateStreamer) Subscribe() (<-chan aggregates.Rate, func()) {
+	return e.exchange.Subscribe()
+}
@@ -0,0 +1,35 @@
+package services_test
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/jcleira/coding-challenge/internal/domain/aggregates"
+	"github.com/jcleira/coding-challenge/internal/domain/services"
+	"github.com/jcleira/coding-challenge/mocks"
+)
+
+func TestExchangeRateStreamer_Subscribe(t *testing.T) {
+	t.Parallel()
+
+	rate := aggregates.Rate{Currency: "EUR", Value: big.NewRat(85, 1)}
+
+	updates := make(chan aggregates.Rate, 1)
+	updates <- rate
+
+	unsubscribed := false
+
+	rateSubscriber := mocks.NewRateSubscriber(t)
+	rateSubscriber.On("Subscribe").
+		Return((<-chan aggregates.Rate)(updates), func() { unsubscribed = true })
+
+	streamer := services.NewExchangeRateStreamer(rateSubscriber)
+
+	got, unsubscribe := streamer.Subscribe()
+	assert.Equal(t, rate, <-got)
+
+	unsubscribe()
+	assert.True(t, unsubscribed)
+}
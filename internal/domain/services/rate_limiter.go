@@ -0,0 +1,113 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/jcleira/coding-challenge/internal/domain/aggregates"
+)
+
+// rateLimiterSweepInterval is how often the buckets refilled up to their
+// burst are dropped, so idle clients don't pile up in memory.
+const rateLimiterSweepInterval = time.Minute
+
+// RateLimiter define the dependencies to limit the requests of each client
+// per class of endpoints.
+//
+// The token buckets are kept in memory, so each replica enforces them on its
+// own, while the daily quotas are counted in the quota store, shared by every
+// replica.
+type RateLimiter struct {
+	limits map[aggregates.RateClass]aggregates.RateLimit
+	quotas QuotaStore
+	logger *slog.Logger
+
+	mu      sync.Mutex
+	buckets map[string]*aggregates.TokenBucket
+	sweptAt time.Time
+}
+
+// NewRateLimiter creates a new RateLimiter with the limits of each class of
+// endpoints, the classes without a limit aren't limited. The quotas can be
+// nil when no limit has a daily quota.
+func NewRateLimiter(limits map[aggregates.RateClass]aggregates.RateLimit,
+	quotas QuotaStore, logger *slog.Logger) *RateLimiter {
+	return &RateLimiter{
+		limits:  limits,
+		quotas:  quotas,
+		logger:  logger,
+		buckets: make(map[string]*aggregates.TokenBucket),
+	}
+}
+
+// Allow takes a token from the bucket of the client for the class, and counts
+// the request against its daily quota. Denied requests return the decision
+// along with ErrRateLimited or ErrQuotaExceeded.
+func (l *RateLimiter) Allow(ctx context.Context,
+	client string, class aggregates.RateClass) (aggregates.RateLimitDecision, error) {
+	limit, ok := l.limits[class]
+	if !ok {
+		return aggregates.RateLimitDecision{Allowed: true}, nil
+	}
+
+	now := time.Now()
+
+	decision := l.take(client+"|"+string(class), limit, now)
+	if !decision.Allowed {
+		return decision, fmt.Errorf("%w: %s on %s endpoints", aggregates.ErrRateLimited, client, class)
+	}
+
+	if limit.DailyQuota == 0 || l.quotas == nil {
+		return decision, nil
+	}
+
+	day := now.UTC().Truncate(24 * time.Hour)
+
+	count, err := l.quotas.Increment(ctx, client+"|"+string(class), day)
+	if err != nil {
+		// The quota store being unavailable shouldn't take the API down with
+		// it, the token buckets still protect the RPC budget meanwhile.
+		l.logger.ErrorContext(ctx, "error counting request against the daily quota",
+			"error", err, "client", client)
+		return decision, nil
+	}
+
+	if count > limit.DailyQuota {
+		decision.Allowed = false
+		decision.RetryAfter = day.Add(24 * time.Hour).Sub(now)
+
+		return decision, fmt.Errorf("%w: %s used its %d daily %s requests",
+			aggregates.ErrQuotaExceeded, client, limit.DailyQuota, class)
+	}
+
+	return decision, nil
+}
+
+// take takes a token from the bucket with the key, creating it when it
+// doesn't exist yet.
+func (l *RateLimiter) take(
+	key string, limit aggregates.RateLimit, now time.Time) aggregates.RateLimitDecision {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.Sub(l.sweptAt) >= rateLimiterSweepInterval {
+		for bucketKey, bucket := range l.buckets {
+			if bucket.Full(now) {
+				delete(l.buckets, bucketKey)
+			}
+		}
+
+		l.sweptAt = now
+	}
+
+	bucket, ok := l.buckets[key]
+	if !ok {
+		bucket = aggregates.NewTokenBucket(limit, now)
+		l.buckets[key] = bucket
+	}
+
+	return bucket.Take(now)
+}
@@ -0,0 +1,117 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jcleira/coding-challenge/internal/domain/aggregates"
+	"github.com/jcleira/coding-challenge/internal/domain/services"
+	"github.com/jcleira/coding-challenge/mocks"
+)
+
+func TestRateLimiter_Allow(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	// The buckets barely refill during the test, so they're only drained.
+	limits := map[aggregates.RateClass]aggregates.RateLimit{
+		aggregates.RateClassRead: {Rate: 0.001, Burst: 2},
+		aggregates.RateClassSend: {Rate: 0.001, Burst: 2, DailyQuota: 1},
+	}
+
+	tests := []struct {
+		name       string
+		class      aggregates.RateClass
+		requests   int
+		beforeFunc func(*mocks.QuotaStore)
+		wantError  error
+	}{
+		{
+			name:     "within the burst",
+			class:    aggregates.RateClassRead,
+			requests: 2,
+		},
+		{
+			name:      "over the burst",
+			class:     aggregates.RateClassRead,
+			requests:  3,
+			wantError: aggregates.ErrRateLimited,
+		},
+		{
+			name:     "unlimited class",
+			class:    aggregates.RateClass("stream"),
+			requests: 10,
+		},
+		{
+			name:     "within the daily quota",
+			class:    aggregates.RateClassSend,
+			requests: 1,
+			beforeFunc: func(store *mocks.QuotaStore) {
+				store.On("Increment", ctx, "client|send", mock.Anything).Return(1, nil).Once()
+			},
+		},
+		{
+			name:     "over the daily quota",
+			class:    aggregates.RateClassSend,
+			requests: 2,
+			beforeFunc: func(store *mocks.QuotaStore) {
+				store.On("Increment", ctx, "client|send", mock.Anything).Return(1, nil).Once()
+				store.On("Increment", ctx, "client|send", mock.Anything).Return(2, nil).Once()
+			},
+			wantError: aggregates.ErrQuotaExceeded,
+		},
+		{
+			name:     "quota store errors don't reject the request",
+			class:    aggregates.RateClassSend,
+			requests: 2,
+			beforeFunc: func(store *mocks.QuotaStore) {
+				store.On("Increment", ctx, "client|send", mock.Anything).
+					Return(0, errors.New("store error"))
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			store := mocks.NewQuotaStore(t)
+			if tt.beforeFunc != nil {
+				tt.beforeFunc(store)
+			}
+
+			limiter := services.NewRateLimiter(limits, store, slog.Default())
+
+			var (
+				decision aggregates.RateLimitDecision
+				err      error
+			)
+			for i := 0; i < tt.requests; i++ {
+				decision, err = limiter.Allow(ctx, "client", tt.class)
+			}
+
+			if tt.wantError != nil {
+				assert.True(t, errors.Is(err, tt.wantError), "got %v", err)
+				assert.False(t, decision.Allowed)
+				assert.Positive(t, decision.RetryAfter)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.True(t, decision.Allowed)
+
+			// Other clients have their own buckets.
+			decision, err = limiter.Allow(ctx, "other", aggregates.RateClassRead)
+			require.NoError(t, err)
+			assert.True(t, decision.Allowed)
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package services
+
+import (
+	"context"
+
+	"github.com/jcleira/coding-challenge/internal/domain/aggregates"
+)
+
+// ReadinessChecker define the dependencies to check the readiness of the
+// service.
+type ReadinessChecker struct {
+	solana   HealthChecker
+	exchange RateHealthGetter
+	vault    HealthChecker
+}
+
+// NewReadinessChecker creates a new ReadinessChecker.
+func NewReadinessChecker(
+	solana HealthChecker, exchange RateHealthGetter, vault HealthChecker) *ReadinessChecker {
+	return &ReadinessChecker{
+		solana:   solana,
+		exchange: exchange,
+		vault:    vault,
+	}
+}
+
+// CheckReadiness checks the Solana RPC is reachable, the exchange rates
+// haven't expired and the vault is accessible. Stale rates are still usable,
+// so they don't make the service unready.
+func (r *ReadinessChecker) CheckReadiness(ctx context.Context) aggregates.Readiness {
+	var exchangeErr error
+	if r.exchange.Health().State == aggregates.RateHealthExpired {
+		exchangeErr = aggregates.ErrRateExpired
+	}
+
+	return aggregates.Readiness{
+		Checks: []aggregates.ReadinessCheck{
+			{Name: aggregates.ReadinessCheckSolana, Err: r.solana.CheckHealth(ctx)},
+			{Name: aggregates.ReadinessCheckExchange, Err: exchangeErr},
+			{Name: aggregates.ReadinessCheckVault, Err: r.vault.CheckHealth(ctx)},
+		},
+	}
+}
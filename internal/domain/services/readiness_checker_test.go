@@ -0,0 +1,83 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/jcleira/coding-challenge/internal/domain/aggregates"
+	"github.com/jcleira/coding-challenge/internal/domain/services"
+	"github.com/jcleira/coding-challenge/mocks"
+)
+
+func TestReadinessChecker_CheckReadiness(t *testing.T) {
+	t.Parallel()
+
+	errUnreachable := errors.New("connection refused")
+
+	tests := []struct {
+		name       string
+		solanaErr  error
+		rateState  aggregates.RateHealthState
+		vaultErr   error
+		wantChecks []aggregates.ReadinessCheck
+		wantReady  bool
+	}{
+		{
+			name:      "ready",
+			rateState: aggregates.RateHealthFresh,
+			wantChecks: []aggregates.ReadinessCheck{
+				{Name: aggregates.ReadinessCheckSolana},
+				{Name: aggregates.ReadinessCheckExchange},
+				{Name: aggregates.ReadinessCheckVault},
+			},
+			wantReady: true,
+		},
+		{
+			name:      "stale rates",
+			rateState: aggregates.RateHealthStale,
+			wantChecks: []aggregates.ReadinessCheck{
+				{Name: aggregates.ReadinessCheckSolana},
+				{Name: aggregates.ReadinessCheckExchange},
+				{Name: aggregates.ReadinessCheckVault},
+			},
+			wantReady: true,
+		},
+		{
+			name:      "unavailable dependencies",
+			solanaErr: errUnreachable,
+			rateState: aggregates.RateHealthExpired,
+			vaultErr:  errUnreachable,
+			wantChecks: []aggregates.ReadinessCheck{
+				{Name: aggregates.ReadinessCheckSolana, Err: errUnreachable},
+				{Name: aggregates.ReadinessCheckExchange, Err: aggregates.ErrRateExpired},
+				{Name: aggregates.ReadinessCheckVault, Err: errUnreachable},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			solana := mocks.NewHealthChecker(t)
+			solana.On("CheckHealth", mock.Anything).Return(tt.solanaErr)
+
+			exchange := mocks.NewRateHealthGetter(t)
+			exchange.On("Health").Return(aggregates.RateHealth{State: tt.rateState})
+
+			vault := mocks.NewHealthChecker(t)
+			vault.On("CheckHealth", mock.Anything).Return(tt.vaultErr)
+
+			readiness := services.NewReadinessChecker(solana, exchange, vault).
+				CheckReadiness(context.Background())
+
+			assert.Equal(t, tt.wantChecks, readiness.Checks)
+			assert.Equal(t, tt.wantReady, readiness.Ready())
+		})
+	}
+}
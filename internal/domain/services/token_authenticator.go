@@ -0,0 +1,83 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jcleira/coding-challenge/internal/domain/aggregates"
+)
+
+// scopeClaims are the claims carrying the scopes of a token, scope is the
+// OAuth 2 one and scp the one some identity providers use instead.
+var scopeClaims = []string{"scope", "scp"}
+
+// TokenAuthenticator define the dependencies to authenticate bearer tokens,
+// mapping their claims to the tenant, scopes and wallets of a principal.
+type TokenAuthenticator struct {
+	verifier      TokenVerifier
+	walletsClaim  string
+	tenantClaim   string
+	defaultTenant string
+}
+
+// NewTokenAuthenticator creates a new TokenAuthenticator taking the wallets
+// of the token owner from the walletsClaim claim, and its tenant from the
+// tenantClaim claim. Tokens without the tenant claim belong to defaultTenant,
+// or are rejected when it's empty.
+func NewTokenAuthenticator(verifier TokenVerifier,
+	walletsClaim, tenantClaim, defaultTenant string) *TokenAuthenticator {
+	return &TokenAuthenticator{
+		verifier:      verifier,
+		walletsClaim:  walletsClaim,
+		tenantClaim:   tenantClaim,
+		defaultTenant: defaultTenant,
+	}
+}
+
+// Authenticate verifies the token and returns its principal, the scopes of
+// the token that aren't scopes of the API, such as openid, are ignored.
+//
+// Tokens without a tenant are rejected, unless a default tenant is
+// configured, as falling back to a tenant, such as the default one the
+// first admin keys belong to, would grant them its wallets.
+func (a *TokenAuthenticator) Authenticate(
+	ctx context.Context, token string) (aggregates.Principal, error) {
+	claims, err := a.verifier.Verify(ctx, token)
+	if err != nil {
+		return aggregates.Principal{}, err
+	}
+
+	subject := claims.Subject()
+	if subject == "" {
+		return aggregates.Principal{}, fmt.Errorf("%w: token without subject", aggregates.ErrUnauthenticated)
+	}
+
+	tenant := a.defaultTenant
+	if value, ok := claims[a.tenantClaim]; ok {
+		tenant, _ = value.(string)
+
+		if err := aggregates.ValidateTenant(tenant); err != nil {
+			return aggregates.Principal{}, fmt.Errorf("%w: %w", aggregates.ErrUnauthenticated, err)
+		}
+	}
+
+	if tenant == "" {
+		return aggregates.Principal{}, fmt.Errorf("%w: token without tenant", aggregates.ErrUnauthenticated)
+	}
+
+	principal := aggregates.Principal{
+		Actor:   "oidc:" + subject,
+		Tenant:  tenant,
+		Wallets: claims.Strings(a.walletsClaim),
+	}
+
+	for _, name := range scopeClaims {
+		for _, value := range claims.Strings(name) {
+			if scope, err := aggregates.ParseScope(value); err == nil {
+				principal.Scopes = append(principal.Scopes, scope)
+			}
+		}
+	}
+
+	return principal, nil
+}
@@ -0,0 +1,159 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jcleira/coding-challenge/internal/domain/aggregates"
+	"github.com/jcleira/coding-challenge/internal/domain/services"
+	"github.com/jcleira/coding-challenge/mocks"
+)
+
+func TestTokenAuthenticator_Authenticate(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	tests := []struct {
+		name          string
+		defaultTenant string
+		beforeFunc    func(*mocks.TokenVerifier)
+		want          aggregates.Principal
+		wantError     error
+	}{
+		{
+			name: "scope claim",
+			beforeFunc: func(verifier *mocks.TokenVerifier) {
+				verifier.On("Verify", ctx, "token").Return(aggregates.TokenClaims{
+					"sub":          "user",
+					"org":          "acme",
+					"scope":        "openid read-balance send",
+					"payment_keys": []interface{}{"walletA", "walletB"},
+				}, nil)
+			},
+			want: aggregates.Principal{
+				Actor:   "oidc:user",
+				Tenant:  "acme",
+				Scopes:  []aggregates.Scope{aggregates.ScopeReadBalance, aggregates.ScopeSend},
+				Wallets: []string{"walletA", "walletB"},
+			},
+		},
+		{
+			name: "scp claim",
+			beforeFunc: func(verifier *mocks.TokenVerifier) {
+				verifier.On("Verify", ctx, "token").Return(aggregates.TokenClaims{
+					"sub": "user",
+					"org": "acme",
+					"scp": []interface{}{"admin", "profile"},
+				}, nil)
+			},
+			want: aggregates.Principal{
+				Actor:   "oidc:user",
+				Tenant:  "acme",
+				Scopes:  []aggregates.Scope{aggregates.ScopeAdmin},
+				Wallets: nil,
+			},
+		},
+		{
+			name: "tenant claim",
+			beforeFunc: func(verifier *mocks.TokenVerifier) {
+				verifier.On("Verify", ctx, "token").Return(aggregates.TokenClaims{
+					"sub":   "user",
+					"org":   "acme",
+					"scope": "send",
+				}, nil)
+			},
+			want: aggregates.Principal{
+				Actor:  "oidc:user",
+				Tenant: "acme",
+				Scopes: []aggregates.Scope{aggregates.ScopeSend},
+			},
+		},
+		{
+			name: "token without tenant claim",
+			beforeFunc: func(verifier *mocks.TokenVerifier) {
+				verifier.On("Verify", ctx, "token").Return(aggregates.TokenClaims{
+					"sub": "user",
+					"scp": []interface{}{"admin"},
+				}, nil)
+			},
+			wantError: aggregates.ErrUnauthenticated,
+		},
+		{
+			name:          "token without tenant claim with a default tenant",
+			defaultTenant: "platform",
+			beforeFunc: func(verifier *mocks.TokenVerifier) {
+				verifier.On("Verify", ctx, "token").Return(aggregates.TokenClaims{
+					"sub":   "user",
+					"scope": "send",
+				}, nil)
+			},
+			want: aggregates.Principal{
+				Actor:  "oidc:user",
+				Tenant: "platform",
+				Scopes: []aggregates.Scope{aggregates.ScopeSend},
+			},
+		},
+		{
+			name: "empty tenant claim",
+			beforeFunc: func(verifier *mocks.TokenVerifier) {
+				verifier.On("Verify", ctx, "token").Return(aggregates.TokenClaims{
+					"sub": "user",
+					"org": "",
+				}, nil)
+			},
+			wantError: aggregates.ErrUnauthenticated,
+		},
+		{
+			name: "invalid tenant claim",
+			beforeFunc: func(verifier *mocks.TokenVerifier) {
+				verifier.On("Verify", ctx, "token").Return(aggregates.TokenClaims{
+					"sub": "user",
+					"org": "../acme",
+				}, nil)
+			},
+			wantError: aggregates.ErrUnauthenticated,
+		},
+		{
+			name: "token without subject",
+			beforeFunc: func(verifier *mocks.TokenVerifier) {
+				verifier.On("Verify", ctx, "token").
+					Return(aggregates.TokenClaims{"scope": "send"}, nil)
+			},
+			wantError: aggregates.ErrUnauthenticated,
+		},
+		{
+			name: "invalid token",
+			beforeFunc: func(verifier *mocks.TokenVerifier) {
+				verifier.On("Verify", ctx, "token").
+					Return(nil, aggregates.ErrUnauthenticated)
+			},
+			wantError: aggregates.ErrUnauthenticated,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			verifier := mocks.NewTokenVerifier(t)
+			tt.beforeFunc(verifier)
+
+			principal, err := services.NewTokenAuthenticator(verifier, "payment_keys", "org", tt.defaultTenant).
+				Authenticate(ctx, "token")
+
+			if tt.wantError != nil {
+				assert.True(t, errors.Is(err, tt.wantError), "got %v", err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, principal)
+		})
+	}
+}
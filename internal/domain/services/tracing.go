@@ -0,0 +1,22 @@
+package services
+
+import (
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracer is the tracer of the services spans, they are children of the span
+// carried by the context of each call, usually the one of the HTTP request.
+var tracer = otel.Tracer("github.com/jcleira/coding-challenge/internal/domain/services")
+
+// endSpan ends the span, recording the error and flagging the span as failed
+// when there is one.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	span.End()
+}
@@ -2,42 +2,113 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
 	"github.com/jcleira/coding-challenge/internal/domain/aggregates"
 )
 
 // TransactionsGetter defines the dependencies for getting transactions from the Solana
 // blockchain.
 type TransactionsGetter struct {
-	solana   SolanaGetter
-	exchange ExchangeGetter
+	solana    SolanaGetter
+	exchange  ExchangeGetter
+	history   HistoricalRateGetter
+	converter *Converter
+	logger    *slog.Logger
 }
 
 // NewTransactionsGetter creates a new TransactionsGetter.
-func NewTransactionsGetter(solana SolanaGetter, exchange ExchangeGetter) *TransactionsGetter {
+func NewTransactionsGetter(
+	solana SolanaGetter,
+	exchange ExchangeGetter,
+	history HistoricalRateGetter,
+	converter *Converter,
+	logger *slog.Logger,
+) *TransactionsGetter {
 	return &TransactionsGetter{
-		solana:   solana,
-		exchange: exchange,
+		solana:    solana,
+		exchange:  exchange,
+		history:   history,
+		converter: converter,
+		logger:    logger,
 	}
 }
 
-func (t *TransactionsGetter) GetTransactions(ctx context.Context, publicKey string) ([]aggregates.Transaction, error) {
-	transactions, err := t.solana.GetTransactions(ctx, publicKey)
+// GetTransactions gets the transactions of a wallet, with their amounts
+// converted to the query fiat currency.
+//
+// With the historical valuation every transaction is converted at the rate at
+// its block time, otherwise all of them are converted at the current rate.
+// The transactions without a block time or a historical rate are returned
+// without their fiat amount and rate.
+func (t *TransactionsGetter) GetTransactions(
+	ctx context.Context, query aggregates.TransactionsQuery) (_ []aggregates.Transaction, err error) {
+	ctx, span := tracer.Start(ctx, "TransactionsGetter.GetTransactions", trace.WithAttributes(
+		attribute.String("currency", query.Currency),
+		attribute.String("valuation", string(query.Valuation)),
+	))
+	defer func() { endSpan(span, err) }()
+
+	transactions, err := t.solana.GetTransactions(ctx, query.PublicKey, query.Page)
 	if err != nil {
-		slog.Error("error getting transactions", err)
+		t.logger.ErrorContext(ctx, "error getting transactions",
+			"error", err, "wallet", query.PublicKey)
 		return nil, fmt.Errorf("error getting transactions: %w", err)
 	}
 
-	rate, err := t.exchange.GetRate()
+	span.SetAttributes(attribute.Int("transactions.count", len(transactions)))
+
+	if query.Valuation == aggregates.ValuationHistorical {
+		unvalued := 0
+		for i := range transactions {
+			// A transaction without a block time, or older than the known
+			// rates, is listed without its value instead of failing the list.
+			if transactions[i].BlockTime.IsZero() {
+				unvalued++
+				t.logger.WarnContext(ctx, "transaction without block time, skipping its valuation",
+					"wallet", query.PublicKey, "signature", transactions[i].Signature)
+				continue
+			}
+
+			rate, err := t.history.GetRateAt(ctx, query.Currency, transactions[i].BlockTime)
+			if errors.Is(err, aggregates.ErrHistoricalRateNotFound) {
+				unvalued++
+				t.logger.WarnContext(ctx, "historical rate not found, skipping the transaction valuation",
+					"error", err, "wallet", query.PublicKey, "signature", transactions[i].Signature)
+				continue
+			}
+			if err != nil {
+				t.logger.ErrorContext(ctx, "error getting historical rate",
+					"error", err, "wallet", query.PublicKey, "signature", transactions[i].Signature)
+				return nil, fmt.Errorf("error getting historical rate: %w", err)
+			}
+
+			if err := t.converter.SetFiatAmount(&transactions[i], rate); err != nil {
+				return nil, fmt.Errorf("error setting fiat amount: %w", err)
+			}
+		}
+
+		span.SetAttributes(attribute.Int("transactions.unvalued", unvalued))
+
+		return transactions, nil
+	}
+
+	rate, err := t.exchange.GetRate(ctx, query.Currency)
 	if err != nil {
-		slog.Error("error getting rate", err)
+		t.logger.ErrorContext(ctx, "error getting rate",
+			"error", err, "wallet", query.PublicKey, "currency", query.Currency)
 		return nil, fmt.Errorf("error getting rate: %w", err)
 	}
 
 	for i := range transactions {
-		transactions[i].SetEURAmount(rate)
+		if err := t.converter.SetFiatAmount(&transactions[i], rate); err != nil {
+			return nil, fmt.Errorf("error setting fiat amount: %w", err)
+		}
 	}
 
 	return transactions, nil
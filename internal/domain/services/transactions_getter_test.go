@@ -3,11 +3,13 @@ package services_test
 import (
 	"context"
 	"errors"
+	"log/slog"
 	"math/big"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 
 	"github.com/jcleira/coding-challenge/internal/domain/aggregates"
 	"github.com/jcleira/coding-challenge/internal/domain/services"
@@ -19,16 +21,20 @@ func TestTransactionsGetter_GetTransactions(t *testing.T) {
 
 	ctx := context.Background()
 	publicKey := "testPublicKey"
+	page := aggregates.TransactionsPage{Limit: 2, Before: "Signature0"}
+
+	blockTime := time.Date(2023, 9, 1, 16, 0, 5, 0, time.UTC)
+	oldBlockTime := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
 
 	rate := aggregates.Rate{
-		Currency:  "SOLEUR",
+		Currency:  "EUR",
 		Value:     big.NewRat(12345, 10000),
 		ExpiredAt: time.Now().Add(24 * time.Hour),
 	}
 
 	transactions := []aggregates.Transaction{
 		{
-			BlockTime:    time.Now(),
+			BlockTime:    blockTime,
 			Signer:       "Signer1",
 			CounterParty: "CounterParty1",
 			Accounts:     []string{"Account1", "Account2"},
@@ -36,7 +42,7 @@ func TestTransactionsGetter_GetTransactions(t *testing.T) {
 			Signature:    "Signature1",
 		},
 		{
-			BlockTime:    time.Now(),
+			BlockTime:    blockTime,
 			Signer:       "Signer2",
 			CounterParty: "CounterParty2",
 			Accounts:     []string{"Account3", "Account4"},
@@ -47,24 +53,88 @@ func TestTransactionsGetter_GetTransactions(t *testing.T) {
 
 	tests := []struct {
 		name       string
-		beforeFunc func(*mocks.SolanaGetter, *mocks.ExchangeGetter)
+		beforeFunc func(*mocks.SolanaGetter, *mocks.ExchangeGetter, *mocks.HistoricalRateGetter)
+		valuation  aggregates.Valuation
 		want       []aggregates.Transaction
+		wantAmount []string
 		wantError  error
 	}{
 		{
 			name: "successful transaction retrieval",
-			beforeFunc: func(solana *mocks.SolanaGetter, exchange *mocks.ExchangeGetter) {
-				solana.On("GetTransactions", ctx, publicKey).
+			beforeFunc: func(solana *mocks.SolanaGetter, exchange *mocks.ExchangeGetter, history *mocks.HistoricalRateGetter) {
+				solana.On("GetTransactions", mock.Anything, publicKey, page).
+					Return(transactions, nil)
+
+				exchange.On("GetRate", mock.Anything, "EUR").Return(rate, nil)
+			},
+			want: transactions,
+			// 6.1725 and 12.345 EUR, rounded half to even.
+			wantAmount: []string{"EUR 6.17", "EUR 12.34"},
+		},
+		{
+			name:      "successful transaction retrieval with historical valuation",
+			valuation: aggregates.ValuationHistorical,
+			beforeFunc: func(solana *mocks.SolanaGetter, exchange *mocks.ExchangeGetter, history *mocks.HistoricalRateGetter) {
+				solana.On("GetTransactions", mock.Anything, publicKey, page).
 					Return(transactions, nil)
 
-				exchange.On("GetRate").Return(rate, nil)
+				history.On("GetRateAt", mock.Anything, "EUR", blockTime).Return(rate, nil)
+
+				exchange.AssertNotCalled(t, "GetRate")
 			},
 			want: transactions,
 		},
+		{
+			name:      "historical rate not found skips the valuation",
+			valuation: aggregates.ValuationHistorical,
+			beforeFunc: func(solana *mocks.SolanaGetter, exchange *mocks.ExchangeGetter, history *mocks.HistoricalRateGetter) {
+				solana.On("GetTransactions", mock.Anything, publicKey, page).
+					Return([]aggregates.Transaction{
+						{BlockTime: oldBlockTime, AmountLAM: 5000000000, Signature: "Signature1"},
+						{BlockTime: blockTime, AmountLAM: 10000000000, Signature: "Signature2"},
+					}, nil)
+
+				history.On("GetRateAt", mock.Anything, "EUR", oldBlockTime).
+					Return(aggregates.Rate{}, aggregates.ErrHistoricalRateNotFound)
+				history.On("GetRateAt", mock.Anything, "EUR", blockTime).Return(rate, nil)
+			},
+			want: []aggregates.Transaction{
+				{BlockTime: oldBlockTime, AmountLAM: 5000000000, Signature: "Signature1"},
+				{BlockTime: blockTime, AmountLAM: 10000000000, Signature: "Signature2",
+					Amount: aggregates.Money{Amount: 1234, Currency: "EUR"}, ExchangeRate: rate.Value},
+			},
+		},
+		{
+			name:      "transaction without block time skips the valuation",
+			valuation: aggregates.ValuationHistorical,
+			beforeFunc: func(solana *mocks.SolanaGetter, exchange *mocks.ExchangeGetter, history *mocks.HistoricalRateGetter) {
+				solana.On("GetTransactions", mock.Anything, publicKey, page).
+					Return([]aggregates.Transaction{
+						{AmountLAM: 5000000000, Signature: "Signature1"},
+					}, nil)
+
+				history.AssertNotCalled(t, "GetRateAt")
+			},
+			want: []aggregates.Transaction{
+				{AmountLAM: 5000000000, Signature: "Signature1"},
+			},
+		},
+		{
+			name:      "error getting historical rate",
+			valuation: aggregates.ValuationHistorical,
+			beforeFunc: func(solana *mocks.SolanaGetter, exchange *mocks.ExchangeGetter, history *mocks.HistoricalRateGetter) {
+				solana.On("GetTransactions", mock.Anything, publicKey, page).
+					Return(transactions, nil)
+
+				history.On("GetRateAt", mock.Anything, "EUR", blockTime).
+					Return(aggregates.Rate{}, errors.New("history error"))
+			},
+			wantError: errors.New("error getting historical rate: history error"),
+		},
 		{
 			name: "error getting transactions from Solana",
-			beforeFunc: func(solana *mocks.SolanaGetter, exchange *mocks.ExchangeGetter) {
-				solana.On("GetTransactions", ctx, publicKey).
+			beforeFunc: func(solana *mocks.SolanaGetter, exchange *mocks.ExchangeGetter, history *mocks.HistoricalRateGetter) {
+				solana.On("GetTransactions", mock.Anything, publicKey, page).
 					Return(nil, errors.New("solana error"))
 
 				exchange.AssertNotCalled(t, "GetRate")
@@ -73,11 +143,11 @@ func TestTransactionsGetter_GetTransactions(t *testing.T) {
 		},
 		{
 			name: "error getting exchange rate",
-			beforeFunc: func(solana *mocks.SolanaGetter, exchange *mocks.ExchangeGetter) {
-				solana.On("GetTransactions", ctx, publicKey).
+			beforeFunc: func(solana *mocks.SolanaGetter, exchange *mocks.ExchangeGetter, history *mocks.HistoricalRateGetter) {
+				solana.On("GetTransactions", mock.Anything, publicKey, page).
 					Return(transactions, nil)
 
-				exchange.On("GetRate").
+				exchange.On("GetRate", mock.Anything, "EUR").
 					Return(aggregates.Rate{}, errors.New("exchange rate error"))
 			},
 			wantError: errors.New("error getting rate: exchange rate error"),
@@ -92,16 +162,24 @@ func TestTransactionsGetter_GetTransactions(t *testing.T) {
 			var (
 				solana   = mocks.NewSolanaGetter(t)
 				exchange = mocks.NewExchangeGetter(t)
+				history  = mocks.NewHistoricalRateGetter(t)
 			)
 
-			tt.beforeFunc(solana, exchange)
+			tt.beforeFunc(solana, exchange, history)
 
-			service := services.NewTransactionsGetter(solana, exchange)
+			service := services.NewTransactionsGetter(solana, exchange, history,
+				services.NewConverter(aggregates.RoundHalfEven), slog.Default())
 
-			result, err := service.GetTransactions(ctx, publicKey)
+			result, err := service.GetTransactions(ctx, aggregates.TransactionsQuery{
+				PublicKey: publicKey,
+				Currency:  "EUR",
+				Valuation: tt.valuation,
+				Page:      page,
+			})
 
 			solana.AssertExpectations(t)
 			exchange.AssertExpectations(t)
+			history.AssertExpectations(t)
 
 			if tt.wantError != nil {
 				assert.Error(t, err)
@@ -111,6 +189,10 @@ func TestTransactionsGetter_GetTransactions(t *testing.T) {
 
 			assert.NoError(t, err)
 			assert.Equal(t, tt.want, result)
+
+			for i, amount := range tt.wantAmount {
+				assert.Equal(t, amount, result[i].Amount.String())
+			}
 		})
 	}
 }
@@ -3,6 +3,11 @@ package services
 import (
 	"context"
 	"fmt"
+	"log/slog"
+	"strconv"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/jcleira/coding-challenge/internal/domain/aggregates"
 )
@@ -10,9 +15,12 @@ import (
 // TransactionsSender defines the dependencies for sending transactions to the
 // Solana blockchain.
 type TransactionsSender struct {
-	vault    WalletGetter
-	solana   SolanaSender
-	exchange ExchangeGetter
+	vault     WalletGetter
+	solana    SolanaSender
+	exchange  ExchangeGetter
+	audit     AuditRecorder
+	converter *Converter
+	logger    *slog.Logger
 }
 
 // NewTransactionsSender creates a new TransactionsSender.
@@ -20,34 +28,79 @@ func NewTransactionsSender(
 	vault WalletGetter,
 	solana SolanaSender,
 	exchange ExchangeGetter,
+	audit AuditRecorder,
+	converter *Converter,
+	logger *slog.Logger,
 ) *TransactionsSender {
 	return &TransactionsSender{
-		vault:    vault,
-		solana:   solana,
-		exchange: exchange,
+		vault:     vault,
+		solana:    solana,
+		exchange:  exchange,
+		audit:     audit,
+		converter: converter,
+		logger:    logger,
 	}
 }
 
 // SendTransaction sends a transaction to the Solana blockchain.
+//
+// Both the access to the signer private key and the send itself are recorded
+// in the audit log. Once the transaction is broadcast its signature is
+// returned even when the send can't be recorded, as the caller would send it
+// again otherwise, and along with the error of a confirmation timeout.
 func (ts *TransactionsSender) SendTransaction(
-	ctx context.Context, transaction aggregates.Transaction) (string, error) {
-	wallet, err := ts.vault.GetWallet(transaction.Signer)
+	ctx context.Context, transaction aggregates.Transaction) (_ string, err error) {
+	ctx, span := tracer.Start(ctx, "TransactionsSender.SendTransaction", trace.WithAttributes(
+		attribute.String("currency", transaction.Amount.Currency),
+	))
+	defer func() { endSpan(span, err) }()
+
+	event := aggregates.NewAuditEvent(ctx, aggregates.AuditActionTransactionSend, transaction.Signer)
+	event.Params["counter_party"] = transaction.CounterParty
+	event.Params["amount"] = transaction.Amount.String()
+
+	signature, err := ts.send(ctx, transaction, event.Params)
+	if signature == "" {
+		return "", recordAudit(ctx, ts.audit, event, err)
+	}
+
+	return signature, recordCommittedAudit(ctx, ts.audit, ts.logger, event, err)
+}
+
+// send performs the send, params is filled with the details worth recording
+// in the audit event.
+func (ts *TransactionsSender) send(ctx context.Context,
+	transaction aggregates.Transaction, params map[string]string) (string, error) {
+	wallet, err := ts.vault.GetWallet(ctx, transaction.Signer)
 	if err != nil {
-		return "", fmt.Errorf("error getting wallet: %w", err)
+		err = fmt.Errorf("error getting wallet: %w", err)
+	}
+
+	access := aggregates.NewAuditEvent(ctx, aggregates.AuditActionWalletAccess, transaction.Signer)
+	access.Params["purpose"] = aggregates.AuditActionTransactionSend
+	if err := recordAudit(ctx, ts.audit, access, err); err != nil {
+		return "", err
 	}
 
-	rate, err := ts.exchange.GetRate()
+	rate, err := ts.exchange.GetRate(ctx, transaction.Amount.Currency)
 	if err != nil {
 		return "", fmt.Errorf("error getting exchange rate: %w", err)
 	}
 
-	if err := transaction.SetLamportsAmount(rate); err != nil {
+	if err := ts.converter.SetLamportsAmount(&transaction, rate); err != nil {
 		return "", fmt.Errorf("error setting lamports amount: %w", err)
 	}
 
+	params["amount_lamports"] = strconv.FormatUint(uint64(transaction.AmountLAM), 10)
+
+	// A transaction that timed out confirming comes with its signature too, it
+	// might still be confirmed.
 	signature, err := ts.solana.SendTransaction(ctx, transaction, wallet)
+	if signature != "" {
+		params["signature"] = signature
+	}
 	if err != nil {
-		return "", fmt.Errorf("error sending transaction: %w", err)
+		return signature, fmt.Errorf("error sending transaction: %w", err)
 	}
 
 	return signature, nil
@@ -4,11 +4,13 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log/slog"
 	"math/big"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 
 	"github.com/jcleira/coding-challenge/internal/domain/aggregates"
 	"github.com/jcleira/coding-challenge/internal/domain/services"
@@ -22,12 +24,12 @@ func TestTransactionsSender_SendTransaction(t *testing.T) {
 	transaction := aggregates.Transaction{
 		Signer:       "Signer1",
 		CounterParty: "CounterParty1",
-		AmountEUR:    "10.12",
-		AmountLAM:    8197650870,
+		Amount:       aggregates.Money{Amount: 1012, Currency: "EUR"},
+		AmountLAM:    8197650871,
 	}
 
 	rate := aggregates.Rate{
-		Currency:  "USD",
+		Currency:  "EUR",
 		Value:     big.NewRat(12345, 10000),
 		ExpiredAt: time.Now().Add(1 * time.Hour),
 	}
@@ -36,48 +38,126 @@ func TestTransactionsSender_SendTransaction(t *testing.T) {
 		PublicKey: "testPublicKey",
 	}
 
+	auditOutcome := func(action, outcome string) interface{} {
+		return mock.MatchedBy(func(event aggregates.AuditEvent) bool {
+			return event.Action == action &&
+				event.Wallet == transaction.Signer &&
+				event.Outcome == outcome
+		})
+	}
+
 	tests := []struct {
 		name       string
-		beforeFunc func(*mocks.WalletGetter, *mocks.SolanaSender, *mocks.ExchangeGetter)
+		beforeFunc func(*mocks.WalletGetter, *mocks.SolanaSender, *mocks.ExchangeGetter, *mocks.AuditRecorder)
 		want       string
 		wantError  error
 	}{
 		{
 			name: "successful transaction send",
-			beforeFunc: func(vault *mocks.WalletGetter, solana *mocks.SolanaSender, exchange *mocks.ExchangeGetter) {
-				vault.On("GetWallet", transaction.Signer).
+			beforeFunc: func(vault *mocks.WalletGetter, solana *mocks.SolanaSender, exchange *mocks.ExchangeGetter, audit *mocks.AuditRecorder) {
+				vault.On("GetWallet", mock.Anything, transaction.Signer).
 					Return(wallet, nil)
 
-				exchange.On("GetRate").Return(rate, nil)
+				exchange.On("GetRate", mock.Anything, "EUR").Return(rate, nil)
+
+				solana.On("SendTransaction", mock.Anything, transaction, wallet).Return("signature", nil)
 
-				solana.On("SendTransaction", ctx, transaction, wallet).Return("signature", nil)
+				audit.On("Record", mock.Anything, auditOutcome(aggregates.AuditActionWalletAccess, aggregates.AuditOutcomeSuccess)).
+					Return(nil)
+				audit.On("Record", mock.Anything, auditOutcome(aggregates.AuditActionTransactionSend, aggregates.AuditOutcomeSuccess)).
+					Return(nil)
 			},
 			want: "signature",
 		},
 		{
 			name: "error getting wallet",
-			beforeFunc: func(vault *mocks.WalletGetter, solana *mocks.SolanaSender, exchange *mocks.ExchangeGetter) {
-				vault.On("GetWallet", transaction.Signer).
+			beforeFunc: func(vault *mocks.WalletGetter, solana *mocks.SolanaSender, exchange *mocks.ExchangeGetter, audit *mocks.AuditRecorder) {
+				vault.On("GetWallet", mock.Anything, transaction.Signer).
 					Return(aggregates.Wallet{}, errors.New("wallet error"))
 
 				exchange.AssertNotCalled(t, "GetRate")
 				solana.AssertNotCalled(t, "SendTransaction")
+
+				audit.On("Record", mock.Anything, auditOutcome(aggregates.AuditActionWalletAccess, aggregates.AuditOutcomeFailure)).
+					Return(nil)
+				audit.On("Record", mock.Anything, auditOutcome(aggregates.AuditActionTransactionSend, aggregates.AuditOutcomeFailure)).
+					Return(nil)
 			},
 			wantError: fmt.Errorf("error getting wallet: wallet error"),
 		},
 		{
 			name: "error getting exchange rate",
-			beforeFunc: func(vault *mocks.WalletGetter, solana *mocks.SolanaSender, exchange *mocks.ExchangeGetter) {
-				vault.On("GetWallet", transaction.Signer).
+			beforeFunc: func(vault *mocks.WalletGetter, solana *mocks.SolanaSender, exchange *mocks.ExchangeGetter, audit *mocks.AuditRecorder) {
+				vault.On("GetWallet", mock.Anything, transaction.Signer).
 					Return(wallet, nil)
 
-				exchange.On("GetRate").
+				exchange.On("GetRate", mock.Anything, "EUR").
 					Return(aggregates.Rate{}, errors.New("exchange rate error"))
 
 				solana.AssertNotCalled(t, "SendTransaction")
+
+				audit.On("Record", mock.Anything, auditOutcome(aggregates.AuditActionWalletAccess, aggregates.AuditOutcomeSuccess)).
+					Return(nil)
+				audit.On("Record", mock.Anything, auditOutcome(aggregates.AuditActionTransactionSend, aggregates.AuditOutcomeFailure)).
+					Return(nil)
 			},
 			wantError: fmt.Errorf("error getting exchange rate: exchange rate error"),
 		},
+		{
+			name: "error recording wallet access",
+			beforeFunc: func(vault *mocks.WalletGetter, solana *mocks.SolanaSender, exchange *mocks.ExchangeGetter, audit *mocks.AuditRecorder) {
+				vault.On("GetWallet", mock.Anything, transaction.Signer).
+					Return(wallet, nil)
+
+				exchange.AssertNotCalled(t, "GetRate")
+				solana.AssertNotCalled(t, "SendTransaction")
+
+				audit.On("Record", mock.Anything, auditOutcome(aggregates.AuditActionWalletAccess, aggregates.AuditOutcomeSuccess)).
+					Return(errors.New("audit error"))
+				audit.On("Record", mock.Anything, auditOutcome(aggregates.AuditActionTransactionSend, aggregates.AuditOutcomeFailure)).
+					Return(nil)
+			},
+			wantError: fmt.Errorf("error recording audit event: audit error"),
+		},
+		{
+			name: "error recording a completed send",
+			beforeFunc: func(vault *mocks.WalletGetter, solana *mocks.SolanaSender, exchange *mocks.ExchangeGetter, audit *mocks.AuditRecorder) {
+				vault.On("GetWallet", mock.Anything, transaction.Signer).
+					Return(wallet, nil)
+
+				exchange.On("GetRate", mock.Anything, "EUR").Return(rate, nil)
+
+				solana.On("SendTransaction", mock.Anything, transaction, wallet).Return("signature", nil)
+
+				audit.On("Record", mock.Anything, auditOutcome(aggregates.AuditActionWalletAccess, aggregates.AuditOutcomeSuccess)).
+					Return(nil)
+				audit.On("Record", mock.Anything, auditOutcome(aggregates.AuditActionTransactionSend, aggregates.AuditOutcomeSuccess)).
+					Return(errors.New("audit error"))
+			},
+			want: "signature",
+		},
+		{
+			name: "confirmation timeout",
+			beforeFunc: func(vault *mocks.WalletGetter, solana *mocks.SolanaSender, exchange *mocks.ExchangeGetter, audit *mocks.AuditRecorder) {
+				vault.On("GetWallet", mock.Anything, transaction.Signer).
+					Return(wallet, nil)
+
+				exchange.On("GetRate", mock.Anything, "EUR").Return(rate, nil)
+
+				solana.On("SendTransaction", mock.Anything, transaction, wallet).
+					Return("signature", aggregates.ErrTransactionConfirmationTimeout)
+
+				audit.On("Record", mock.Anything, auditOutcome(aggregates.AuditActionWalletAccess, aggregates.AuditOutcomeSuccess)).
+					Return(nil)
+				audit.On("Record", mock.Anything, mock.MatchedBy(func(event aggregates.AuditEvent) bool {
+					return event.Action == aggregates.AuditActionTransactionSend &&
+						event.Outcome == aggregates.AuditOutcomeFailure &&
+						event.Params["signature"] == "signature"
+				})).Return(nil)
+			},
+			want:      "signature",
+			wantError: fmt.Errorf("error sending transaction: %w", aggregates.ErrTransactionConfirmationTimeout),
+		},
 	}
 
 	for _, test := range tests {
@@ -89,21 +169,25 @@ func TestTransactionsSender_SendTransaction(t *testing.T) {
 				vault    = mocks.NewWalletGetter(t)
 				solana   = mocks.NewSolanaSender(t)
 				exchange = mocks.NewExchangeGetter(t)
+				audit    = mocks.NewAuditRecorder(t)
 			)
 
-			tt.beforeFunc(vault, solana, exchange)
+			tt.beforeFunc(vault, solana, exchange, audit)
 
-			service := services.NewTransactionsSender(vault, solana, exchange)
+			service := services.NewTransactionsSender(vault, solana, exchange, audit,
+				services.NewConverter(aggregates.RoundHalfEven), slog.Default())
 
 			result, err := service.SendTransaction(ctx, transaction)
 
 			vault.AssertExpectations(t)
 			solana.AssertExpectations(t)
 			exchange.AssertExpectations(t)
+			audit.AssertExpectations(t)
 
 			if tt.wantError != nil {
 				assert.Error(t, err)
 				assert.Equal(t, tt.wantError.Error(), err.Error())
+				assert.Equal(t, tt.want, result)
 				return
 			}
 
@@ -3,53 +3,55 @@ package services
 import (
 	"context"
 	"fmt"
-	"math/big"
-)
 
-const (
-	lamportsPerSol = 1000000000
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/jcleira/coding-challenge/internal/domain/aggregates"
 )
 
 // WalletBalanceGetter defines the dependencies for getting the balance of a
 // wallet.
 type WalletBalanceGetter struct {
-	solana   SolanaBalanceGetter
-	exchange ExchangeGetter
+	solana    SolanaBalanceGetter
+	exchange  ExchangeGetter
+	converter *Converter
 }
 
 // NewWalletBalanceGetter creates a new WalletBalanceGetter.
 func NewWalletBalanceGetter(
 	solana SolanaBalanceGetter,
 	exchange ExchangeGetter,
+	converter *Converter,
 ) *WalletBalanceGetter {
 	return &WalletBalanceGetter{
-		solana:   solana,
-		exchange: exchange,
+		solana:    solana,
+		exchange:  exchange,
+		converter: converter,
 	}
 }
 
-// GetBalance gets the balance of a wallet.
+// GetBalance gets the balance of a wallet in the given fiat currency.
 func (wbg *WalletBalanceGetter) GetBalance(
-	ctx context.Context, publicKey string) (string, error) {
+	ctx context.Context, publicKey, currency string) (_ string, err error) {
+	ctx, span := tracer.Start(ctx, "WalletBalanceGetter.GetBalance",
+		trace.WithAttributes(attribute.String("currency", currency)))
+	defer func() { endSpan(span, err) }()
+
 	balance, err := wbg.solana.GetBalance(ctx, publicKey)
 	if err != nil {
 		return "", fmt.Errorf("error getting balance: %w", err)
 	}
 
-	rate, err := wbg.exchange.GetRate()
+	rate, err := wbg.exchange.GetRate(ctx, currency)
 	if err != nil {
 		return "", fmt.Errorf("error getting rate: %w", err)
 	}
 
-	amountInSol := new(big.Rat).Quo(
-		new(big.Rat).SetUint64(balance),
-		new(big.Rat).SetInt64(lamportsPerSol),
-	)
-
-	amountInEUR := new(big.Rat).Mul(
-		amountInSol,
-		rate.Value,
-	)
+	amount, err := wbg.converter.ToFiat(aggregates.Lamports(balance), rate)
+	if err != nil {
+		return "", fmt.Errorf("error converting balance: %w", err)
+	}
 
-	return fmt.Sprintf("%s %s", "EUR", amountInEUR.FloatString(2)), nil
+	return amount.String(), nil
 }
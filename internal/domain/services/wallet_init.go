@@ -1,26 +1,36 @@
 package services
 
 import (
+	"context"
 	"fmt"
+
+	"github.com/jcleira/coding-challenge/internal/domain/aggregates"
 )
 
 // WalletInitializer defines the dependencies for initializing wallets.
 type WalletInitializer struct {
 	vault WalletCreator
+	audit AuditRecorder
 }
 
 // NewWalletInitializer creates a new WalletInitializer.
-func NewWalletInitializer(vault WalletCreator) *WalletInitializer {
+func NewWalletInitializer(vault WalletCreator, audit AuditRecorder) *WalletInitializer {
 	return &WalletInitializer{
 		vault: vault,
+		audit: audit,
 	}
 }
 
 // Initialize initializes a wallet.
-func (wi *WalletInitializer) Initialize() (string, error) {
-	wallet, err := wi.vault.CreateWallet()
+func (wi *WalletInitializer) Initialize(ctx context.Context) (string, error) {
+	wallet, err := wi.vault.CreateWallet(ctx)
 	if err != nil {
-		return "", fmt.Errorf("error creating wallet: %w", err)
+		err = fmt.Errorf("error creating wallet: %w", err)
+	}
+
+	event := aggregates.NewAuditEvent(ctx, aggregates.AuditActionWalletCreate, wallet.PublicKey)
+	if err := recordAudit(ctx, wi.audit, event, err); err != nil {
+		return "", err
 	}
 
 	return wallet.PublicKey, nil
@@ -1,11 +1,13 @@
 package services_test
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 
 	"github.com/jcleira/coding-challenge/internal/domain/aggregates"
 	"github.com/jcleira/coding-challenge/internal/domain/services"
@@ -15,28 +17,54 @@ import (
 func TestWalletInitializer_Initialize(t *testing.T) {
 	t.Parallel()
 
+	ctx := context.Background()
+
+	auditOutcome := func(outcome string) interface{} {
+		return mock.MatchedBy(func(event aggregates.AuditEvent) bool {
+			return event.Action == aggregates.AuditActionWalletCreate &&
+				event.Outcome == outcome
+		})
+	}
+
 	tests := []struct {
 		name       string
-		beforeFunc func(*mocks.WalletCreator)
+		beforeFunc func(*mocks.WalletCreator, *mocks.AuditRecorder)
 		want       string
 		wantError  error
 	}{
 		{
 			name: "successful wallet initialization",
-			beforeFunc: func(vault *mocks.WalletCreator) {
-				vault.On("CreateWallet").
+			beforeFunc: func(vault *mocks.WalletCreator, audit *mocks.AuditRecorder) {
+				vault.On("CreateWallet", mock.Anything).
 					Return(aggregates.Wallet{PublicKey: "testPublicKey"}, nil)
+
+				audit.On("Record", ctx, auditOutcome(aggregates.AuditOutcomeSuccess)).
+					Return(nil)
 			},
 			want: "testPublicKey",
 		},
 		{
 			name: "error creating wallet",
-			beforeFunc: func(vault *mocks.WalletCreator) {
-				vault.On("CreateWallet").
+			beforeFunc: func(vault *mocks.WalletCreator, audit *mocks.AuditRecorder) {
+				vault.On("CreateWallet", mock.Anything).
 					Return(aggregates.Wallet{}, errors.New("wallet creation error"))
+
+				audit.On("Record", ctx, auditOutcome(aggregates.AuditOutcomeFailure)).
+					Return(nil)
 			},
 			wantError: fmt.Errorf("error creating wallet: wallet creation error"),
 		},
+		{
+			name: "error recording audit event",
+			beforeFunc: func(vault *mocks.WalletCreator, audit *mocks.AuditRecorder) {
+				vault.On("CreateWallet", mock.Anything).
+					Return(aggregates.Wallet{PublicKey: "testPublicKey"}, nil)
+
+				audit.On("Record", ctx, auditOutcome(aggregates.AuditOutcomeSuccess)).
+					Return(errors.New("audit error"))
+			},
+			wantError: fmt.Errorf("error recording audit event: audit error"),
+		},
 	}
 
 	for _, test := range tests {
@@ -44,15 +72,19 @@ func TestWalletInitializer_Initialize(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			t.Parallel()
 
-			vault := mocks.NewWalletCreator(t)
+			var (
+				vault = mocks.NewWalletCreator(t)
+				audit = mocks.NewAuditRecorder(t)
+			)
 
-			tt.beforeFunc(vault)
+			tt.beforeFunc(vault, audit)
 
-			service := services.NewWalletInitializer(vault)
+			service := services.NewWalletInitializer(vault, audit)
 
-			result, err := service.Initialize()
+			result, err := service.Initialize(ctx)
 
 			vault.AssertExpectations(t)
+			audit.AssertExpectations(t)
 
 			if tt.wantError != nil {
 				assert.Error(t, err)
@@ -0,0 +1,212 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"sync"
+
+	"github.com/jcleira/coding-challenge/internal/domain/aggregates"
+)
+
+// WalletRotator defines the dependencies for rotating wallets, a rotation
+// generates a new key and sweeps the funds from the old one into it.
+type WalletRotator struct {
+	vault  WalletRotatorVault
+	solana SolanaSweeper
+	audit  AuditRecorder
+	logger *slog.Logger
+
+	// locks serializes the rotations of each wallet, so concurrent rotations
+	// don't sweep the same wallet.
+	locks walletLocks
+}
+
+// NewWalletRotator creates a new WalletRotator.
+func NewWalletRotator(
+	vault WalletRotatorVault,
+	solana SolanaSweeper,
+	audit AuditRecorder,
+	logger *slog.Logger,
+) *WalletRotator {
+	return &WalletRotator{
+		vault:  vault,
+		solana: solana,
+		audit:  audit,
+		logger: logger,
+	}
+}
+
+// Rotate rotates the wallet identified by publicKey, returning the public key
+// of the new wallet.
+//
+// The full balance minus the transfer fee is swept into the new wallet, then
+// the old key is retired in the vault, lookups by the old public key will be
+// redirected to the new wallet from then on. Concurrent rotations of a wallet
+// run one after the other, the later ones rotating the wallet that replaced
+// it. Once the funds are swept and the old key retired the new public key is
+// returned even when the rotation can't be recorded in the audit log.
+func (wr *WalletRotator) Rotate(ctx context.Context, publicKey string) (string, error) {
+	event := aggregates.NewAuditEvent(ctx, aggregates.AuditActionWalletRotate, publicKey)
+
+	newPublicKey, err := wr.rotate(ctx, publicKey, event.Params)
+	if newPublicKey == "" {
+		return "", recordAudit(ctx, wr.audit, event, err)
+	}
+
+	return newPublicKey, recordCommittedAudit(ctx, wr.audit, wr.logger, event, err)
+}
+
+// rotate performs the rotation, params is filled with the details worth
+// recording in the audit event.
+func (wr *WalletRotator) rotate(
+	ctx context.Context, publicKey string, params map[string]string) (string, error) {
+	oldWallet, unlock, err := wr.lockWallet(ctx, publicKey)
+	if err != nil {
+		return "", err
+	}
+	defer unlock()
+
+	// GetWallet follows previous rotations, so rotating an already retired key
+	// rotates the wallet that replaced it.
+	params["old_public_key"] = oldWallet.PublicKey
+
+	newWallet, err := wr.vault.CreateWallet(ctx)
+	if err != nil {
+		return "", fmt.Errorf("error creating wallet: %w", err)
+	}
+
+	params["new_public_key"] = newWallet.PublicKey
+
+	balance, err := wr.solana.GetBalance(ctx, oldWallet.PublicKey)
+	if err != nil {
+		return "", fmt.Errorf("error getting balance: %w", err)
+	}
+
+	fee, err := wr.solana.GetTransferFee(ctx, oldWallet.PublicKey, newWallet.PublicKey)
+	if err != nil {
+		return "", fmt.Errorf("error getting transfer fee: %w", err)
+	}
+
+	params["balance_lamports"] = strconv.FormatUint(balance, 10)
+	params["fee_lamports"] = strconv.FormatUint(fee, 10)
+
+	// There is nothing worth sweeping if the balance doesn't cover the fee,
+	// the rotation still goes ahead as the key is what we want to replace.
+	if balance > fee {
+		signature, err := wr.solana.SendTransaction(ctx,
+			aggregates.Transaction{
+				Signer:       oldWallet.PublicKey,
+				CounterParty: newWallet.PublicKey,
+				AmountLAM:    aggregates.Lamports(balance - fee),
+			},
+			oldWallet,
+		)
+		if signature != "" {
+			params["signature"] = signature
+		}
+		if err != nil {
+			return "", fmt.Errorf("error sweeping funds: %w", err)
+		}
+
+		params["swept_lamports"] = strconv.FormatUint(balance-fee, 10)
+	}
+
+	if err := wr.vault.RetireWallet(ctx, oldWallet.PublicKey, newWallet.PublicKey); err != nil {
+		return "", fmt.Errorf("error retiring wallet: %w", err)
+	}
+
+	return newWallet.PublicKey, nil
+}
+
+// lockWallet gets the wallet identified by publicKey, following the previous
+// rotations, and takes its rotation lock, returning the function releasing it.
+//
+// The wallet is read again once locked, a wallet rotated while waiting for
+// its lock is retried with the wallet that replaced it.
+func (wr *WalletRotator) lockWallet(
+	ctx context.Context, publicKey string) (aggregates.Wallet, func(), error) {
+	for {
+		wallet, err := wr.getWallet(ctx, publicKey)
+		if err != nil {
+			return aggregates.Wallet{}, nil, err
+		}
+
+		unlock := wr.locks.lock(wallet.PublicKey)
+
+		current, err := wr.getWallet(ctx, wallet.PublicKey)
+		if err != nil {
+			unlock()
+			return aggregates.Wallet{}, nil, err
+		}
+
+		if current.PublicKey == wallet.PublicKey {
+			return current, unlock, nil
+		}
+
+		unlock()
+		publicKey = current.PublicKey
+	}
+}
+
+// getWallet gets the wallet identified by publicKey, recording the access to
+// its private key in the audit log like the sends do.
+func (wr *WalletRotator) getWallet(ctx context.Context, publicKey string) (aggregates.Wallet, error) {
+	wallet, err := wr.vault.GetWallet(ctx, publicKey)
+	if err != nil {
+		err = fmt.Errorf("error getting wallet: %w", err)
+	}
+
+	access := aggregates.NewAuditEvent(ctx, aggregates.AuditActionWalletAccess, publicKey)
+	access.Params["purpose"] = aggregates.AuditActionWalletRotate
+	if err := recordAudit(ctx, wr.audit, access, err); err != nil {
+		return aggregates.Wallet{}, err
+	}
+
+	return wallet, nil
+}
+
+// walletLocks are mutexes per wallet public key, they are removed once no
+// one holds or waits for them.
+type walletLocks struct {
+	mu    sync.Mutex
+	locks map[string]*walletLock
+}
+
+// walletLock is the mutex of a wallet along with the number of holders and
+// waiters.
+type walletLock struct {
+	sync.Mutex
+	refs int
+}
+
+// lock locks the wallet, returning the function unlocking it.
+func (l *walletLocks) lock(publicKey string) func() {
+	l.mu.Lock()
+	if l.locks == nil {
+		l.locks = make(map[string]*walletLock)
+	}
+
+	lock, ok := l.locks[publicKey]
+	if !ok {
+		lock = &walletLock{}
+		l.locks[publicKey] = lock
+	}
+	lock.refs++
+	l.mu.Unlock()
+
+	lock.Lock()
+
+	return func() {
+		lock.Unlock()
+
+		l.mu.Lock()
+		defer l.mu.Unlock()
+
+		lock.refs--
+		if lock.refs == 0 {
+			delete(l.locks, publicKey)
+		}
+	}
+}
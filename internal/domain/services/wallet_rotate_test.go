@@ -0,0 +1,259 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/jcleira/coding-challenge/internal/domain/aggregates"
+	"github.com/jcleira/coding-challenge/internal/domain/services"
+	"github.com/jcleira/coding-challenge/mocks"
+)
+
+func TestWalletRotator_Rotate(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	oldWallet := aggregates.Wallet{PublicKey: "oldPublicKey"}
+	newWallet := aggregates.Wallet{PublicKey: "newPublicKey"}
+
+	sweep := aggregates.Transaction{
+		Signer:       oldWallet.PublicKey,
+		CounterParty: newWallet.PublicKey,
+		AmountLAM:    999995000,
+	}
+
+	auditOutcome := func(outcome string) interface{} {
+		return mock.MatchedBy(func(event aggregates.AuditEvent) bool {
+			return event.Action == aggregates.AuditActionWalletRotate &&
+				event.Wallet == oldWallet.PublicKey &&
+				event.Outcome == outcome
+		})
+	}
+
+	// accessOutcome matches the wallet access events of the rotations, the
+	// wallet is read again once its rotation lock is held.
+	accessOutcome := func(outcome string) interface{} {
+		return mock.MatchedBy(func(event aggregates.AuditEvent) bool {
+			return event.Action == aggregates.AuditActionWalletAccess &&
+				event.Wallet == oldWallet.PublicKey &&
+				event.Params["purpose"] == aggregates.AuditActionWalletRotate &&
+				event.Outcome == outcome
+		})
+	}
+
+	tests := []struct {
+		name       string
+		beforeFunc func(*mocks.WalletRotatorVault, *mocks.SolanaSweeper, *mocks.AuditRecorder)
+		want       string
+		wantError  error
+	}{
+		{
+			name: "successful rotation",
+			beforeFunc: func(vault *mocks.WalletRotatorVault, solana *mocks.SolanaSweeper, audit *mocks.AuditRecorder) {
+				vault.On("GetWallet", mock.Anything, oldWallet.PublicKey).Return(oldWallet, nil)
+				audit.On("Record", ctx, accessOutcome(aggregates.AuditOutcomeSuccess)).Return(nil).Twice()
+				vault.On("CreateWallet", mock.Anything).Return(newWallet, nil)
+				solana.On("GetBalance", ctx, oldWallet.PublicKey).Return(uint64(1000000000), nil)
+				solana.On("GetTransferFee", ctx, oldWallet.PublicKey, newWallet.PublicKey).
+					Return(uint64(5000), nil)
+				solana.On("SendTransaction", ctx, sweep, oldWallet).Return("signature", nil)
+				vault.On("RetireWallet", mock.Anything, oldWallet.PublicKey, newWallet.PublicKey).Return(nil)
+				audit.On("Record", ctx, auditOutcome(aggregates.AuditOutcomeSuccess)).Return(nil)
+			},
+			want: newWallet.PublicKey,
+		},
+		{
+			name: "successful rotation without funds to sweep",
+			beforeFunc: func(vault *mocks.WalletRotatorVault, solana *mocks.SolanaSweeper, audit *mocks.AuditRecorder) {
+				vault.On("GetWallet", mock.Anything, oldWallet.PublicKey).Return(oldWallet, nil)
+				audit.On("Record", ctx, accessOutcome(aggregates.AuditOutcomeSuccess)).Return(nil).Twice()
+				vault.On("CreateWallet", mock.Anything).Return(newWallet, nil)
+				solana.On("GetBalance", ctx, oldWallet.PublicKey).Return(uint64(5000), nil)
+				solana.On("GetTransferFee", ctx, oldWallet.PublicKey, newWallet.PublicKey).
+					Return(uint64(5000), nil)
+				vault.On("RetireWallet", mock.Anything, oldWallet.PublicKey, newWallet.PublicKey).Return(nil)
+				audit.On("Record", ctx, auditOutcome(aggregates.AuditOutcomeSuccess)).Return(nil)
+			},
+			want: newWallet.PublicKey,
+		},
+		{
+			name: "error sweeping funds",
+			beforeFunc: func(vault *mocks.WalletRotatorVault, solana *mocks.SolanaSweeper, audit *mocks.AuditRecorder) {
+				vault.On("GetWallet", mock.Anything, oldWallet.PublicKey).Return(oldWallet, nil)
+				audit.On("Record", ctx, accessOutcome(aggregates.AuditOutcomeSuccess)).Return(nil).Twice()
+				vault.On("CreateWallet", mock.Anything).Return(newWallet, nil)
+				solana.On("GetBalance", ctx, oldWallet.PublicKey).Return(uint64(1000000000), nil)
+				solana.On("GetTransferFee", ctx, oldWallet.PublicKey, newWallet.PublicKey).
+					Return(uint64(5000), nil)
+				solana.On("SendTransaction", ctx, sweep, oldWallet).
+					Return("", errors.New("send error"))
+				audit.On("Record", ctx, auditOutcome(aggregates.AuditOutcomeFailure)).Return(nil)
+			},
+			wantError: errors.New("error sweeping funds: send error"),
+		},
+		{
+			name: "error getting wallet",
+			beforeFunc: func(vault *mocks.WalletRotatorVault, solana *mocks.SolanaSweeper, audit *mocks.AuditRecorder) {
+				vault.On("GetWallet", mock.Anything, oldWallet.PublicKey).
+					Return(aggregates.Wallet{}, errors.New("wallet error"))
+				audit.On("Record", ctx, accessOutcome(aggregates.AuditOutcomeFailure)).Return(nil)
+				audit.On("Record", ctx, auditOutcome(aggregates.AuditOutcomeFailure)).Return(nil)
+			},
+			wantError: errors.New("error getting wallet: wallet error"),
+		},
+		{
+			name: "error recording a completed rotation",
+			beforeFunc: func(vault *mocks.WalletRotatorVault, solana *mocks.SolanaSweeper, audit *mocks.AuditRecorder) {
+				vault.On("GetWallet", mock.Anything, oldWallet.PublicKey).Return(oldWallet, nil)
+				audit.On("Record", ctx, accessOutcome(aggregates.AuditOutcomeSuccess)).Return(nil).Twice()
+				vault.On("CreateWallet", mock.Anything).Return(newWallet, nil)
+				solana.On("GetBalance", ctx, oldWallet.PublicKey).Return(uint64(0), nil)
+				solana.On("GetTransferFee", ctx, oldWallet.PublicKey, newWallet.PublicKey).
+					Return(uint64(5000), nil)
+				vault.On("RetireWallet", mock.Anything, oldWallet.PublicKey, newWallet.PublicKey).Return(nil)
+				audit.On("Record", ctx, auditOutcome(aggregates.AuditOutcomeSuccess)).
+					Return(errors.New("audit error"))
+			},
+			want: newWallet.PublicKey,
+		},
+		{
+			name: "error recording a rotation after sweeping funds",
+			beforeFunc: func(vault *mocks.WalletRotatorVault, solana *mocks.SolanaSweeper, audit *mocks.AuditRecorder) {
+				vault.On("GetWallet", mock.Anything, oldWallet.PublicKey).Return(oldWallet, nil)
+				audit.On("Record", ctx, accessOutcome(aggregates.AuditOutcomeSuccess)).Return(nil).Twice()
+				vault.On("CreateWallet", mock.Anything).Return(newWallet, nil)
+				solana.On("GetBalance", ctx, oldWallet.PublicKey).Return(uint64(1000000000), nil)
+				solana.On("GetTransferFee", ctx, oldWallet.PublicKey, newWallet.PublicKey).
+					Return(uint64(5000), nil)
+				solana.On("SendTransaction", ctx, sweep, oldWallet).Return("signature", nil)
+				vault.On("RetireWallet", mock.Anything, oldWallet.PublicKey, newWallet.PublicKey).Return(nil)
+				audit.On("Record", ctx, mock.MatchedBy(func(event aggregates.AuditEvent) bool {
+					return event.Action == aggregates.AuditActionWalletRotate &&
+						event.Params["signature"] == "signature"
+				})).Return(errors.New("audit error"))
+			},
+			want: newWallet.PublicKey,
+		},
+		{
+			name: "error recording wallet access",
+			beforeFunc: func(vault *mocks.WalletRotatorVault, solana *mocks.SolanaSweeper, audit *mocks.AuditRecorder) {
+				vault.On("GetWallet", mock.Anything, oldWallet.PublicKey).Return(oldWallet, nil)
+				audit.On("Record", ctx, accessOutcome(aggregates.AuditOutcomeSuccess)).
+					Return(errors.New("audit error"))
+				audit.On("Record", ctx, auditOutcome(aggregates.AuditOutcomeFailure)).Return(nil)
+			},
+			wantError: errors.New("error recording audit event: audit error"),
+		},
+	}
+
+	for _, test := range tests {
+		tt := test
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var (
+				vault  = mocks.NewWalletRotatorVault(t)
+				solana = mocks.NewSolanaSweeper(t)
+				audit  = mocks.NewAuditRecorder(t)
+			)
+
+			tt.beforeFunc(vault, solana, audit)
+
+			service := services.NewWalletRotator(vault, solana, audit, slog.Default())
+
+			result, err := service.Rotate(ctx, oldWallet.PublicKey)
+
+			vault.AssertExpectations(t)
+			solana.AssertExpectations(t)
+			audit.AssertExpectations(t)
+
+			if tt.wantError != nil {
+				assert.Error(t, err)
+				assert.Equal(t, tt.wantError.Error(), err.Error())
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, result)
+		})
+	}
+}
+
+func TestWalletRotator_RotateConcurrently(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	wallets := []aggregates.Wallet{
+		{PublicKey: "publicKey0"},
+		{PublicKey: "publicKey1"},
+		{PublicKey: "publicKey2"},
+	}
+
+	var (
+		vault  = mocks.NewWalletRotatorVault(t)
+		solana = mocks.NewSolanaSweeper(t)
+		audit  = mocks.NewAuditRecorder(t)
+	)
+
+	// current is the index of the wallet that replaced wallet 0, GetWallet
+	// follows the rotations like the vault does.
+	var current, created atomic.Int32
+
+	vault.On("GetWallet", mock.Anything, mock.Anything).
+		Return(func(context.Context, string) aggregates.Wallet {
+			return wallets[current.Load()]
+		}, nil)
+	vault.On("CreateWallet", mock.Anything).
+		Return(func(context.Context) aggregates.Wallet {
+			return wallets[created.Add(1)]
+		}, nil)
+	vault.On("RetireWallet", mock.Anything, mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) { current.Add(1) }).
+		Return(nil)
+
+	solana.On("GetBalance", ctx, mock.Anything).Return(uint64(1000000000), nil)
+	solana.On("GetTransferFee", ctx, mock.Anything, mock.Anything).Return(uint64(5000), nil)
+	solana.On("SendTransaction", ctx, mock.Anything, mock.Anything).
+		After(10*time.Millisecond).
+		Return("signature", nil)
+	audit.On("Record", ctx, mock.Anything).Return(nil)
+
+	service := services.NewWalletRotator(vault, solana, audit, slog.Default())
+
+	var wg sync.WaitGroup
+	results := make([]string, 2)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			result, err := service.Rotate(ctx, wallets[0].PublicKey)
+			assert.NoError(t, err)
+			results[i] = result
+		}(i)
+	}
+	wg.Wait()
+
+	// Each wallet was swept once, the second rotation rotated the wallet
+	// created by the first one.
+	assert.ElementsMatch(t, []string{wallets[1].PublicKey, wallets[2].PublicKey}, results)
+	solana.AssertNumberOfCalls(t, "SendTransaction", 2)
+
+	signers := make(map[string]bool)
+	for _, call := range solana.Calls {
+		if call.Method == "SendTransaction" {
+			signers[call.Arguments.Get(1).(aggregates.Transaction).Signer] = true
+		}
+	}
+	assert.Equal(t, map[string]bool{wallets[0].PublicKey: true, wallets[1].PublicKey: true}, signers)
+}
@@ -0,0 +1,832 @@
+// Package config loads the configuration of the service from a JSON file, the
+// environment and the command line flags, each one taking precedence over the
+// previous ones, and validates it on startup.
+//
+// Every field names its environment variable and its flag in its tags, the
+// fields tagged as reloadable can be changed while the service runs, the rest
+// require a restart. The secrets, such as VAULT_MASTER_KEY, aren't part of
+// the configuration, they're only read from the environment.
+package config
+
+import (
+	"encoding"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"math/big"
+	"net/url"
+	"os"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gagliardetto/solana-go/rpc"
+
+	"github.com/jcleira/coding-challenge/internal/domain/aggregates"
+	"github.com/jcleira/coding-challenge/internal/infra/logging"
+)
+
+// FileEnv is the environment variable with the path of the configuration
+// file, unless it's set with the -config flag.
+const FileEnv = "CONFIG_FILE"
+
+// ErrInvalidConfig is returned for a configuration that fails validation.
+var ErrInvalidConfig = errors.New("invalid configuration")
+
+// Cluster is a Solana cluster.
+type Cluster string
+
+// The clusters with a public RPC endpoint.
+const (
+	ClusterDevnet   Cluster = "devnet"
+	ClusterTestnet  Cluster = "testnet"
+	ClusterMainnet  Cluster = "mainnet"
+	ClusterLocalnet Cluster = "localnet"
+)
+
+// clusterRPCURLs are the public RPC endpoints of the clusters.
+var clusterRPCURLs = map[Cluster]string{
+	ClusterDevnet:   rpc.DevNet_RPC,
+	ClusterTestnet:  rpc.TestNet_RPC,
+	ClusterMainnet:  rpc.MainNetBeta_RPC,
+	ClusterLocalnet: rpc.LocalNet_RPC,
+}
+
+// Duration is a time.Duration read from strings such as 3s or 500ms.
+type Duration time.Duration
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (d *Duration) UnmarshalText(text []byte) error {
+	duration, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+
+	*d = Duration(duration)
+	return nil
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+// Rates are exchange rates keyed by fiat currency, read from strings such as
+// EUR=85.1,USD=92.
+type Rates map[string]*big.Rat
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (r *Rates) UnmarshalText(text []byte) error {
+	rates := make(Rates)
+
+	for _, pair := range strings.Split(string(text), ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+
+		currency, value, ok := strings.Cut(pair, "=")
+		if !ok {
+			return fmt.Errorf("invalid rate %q", pair)
+		}
+
+		rate, ok := new(big.Rat).SetString(strings.TrimSpace(value))
+		if !ok {
+			return fmt.Errorf("invalid rate %q", pair)
+		}
+
+		rates[strings.ToUpper(strings.TrimSpace(currency))] = rate
+	}
+
+	*r = rates
+	return nil
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (r Rates) MarshalText() ([]byte, error) {
+	pairs := make([]string, 0, len(r))
+	for currency, rate := range r {
+		pairs = append(pairs, currency+"="+rate.RatString())
+	}
+
+	slices.Sort(pairs)
+
+	return []byte(strings.Join(pairs, ",")), nil
+}
+
+// Quotas are daily quotas keyed by class of endpoints, read from strings such
+// as read=10000,send=500.
+type Quotas map[aggregates.RateClass]int
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (q *Quotas) UnmarshalText(text []byte) error {
+	quotas := make(Quotas)
+
+	for _, pair := range strings.Split(string(text), ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+
+		class, value, ok := strings.Cut(pair, "=")
+		if !ok {
+			return fmt.Errorf("invalid daily quota %q", pair)
+		}
+
+		quota, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil {
+			return fmt.Errorf("invalid daily quota %q", pair)
+		}
+
+		quotas[aggregates.RateClass(strings.TrimSpace(class))] = quota
+	}
+
+	*q = quotas
+	return nil
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (q Quotas) MarshalText() ([]byte, error) {
+	pairs := make([]string, 0, len(q))
+	for class, quota := range q {
+		pairs = append(pairs, string(class)+"="+strconv.Itoa(quota))
+	}
+
+	slices.Sort(pairs)
+
+	return []byte(strings.Join(pairs, ",")), nil
+}
+
+// Config is the configuration of the service.
+type Config struct {
+	Server     Server     `json:"server"`
+	Solana     Solana     `json:"solana"`
+	Exchange   Exchange   `json:"exchange"`
+	Conversion Conversion `json:"conversion"`
+	Auth       Auth       `json:"auth"`
+	RateLimit  RateLimit  `json:"rate_limit"`
+	Storage    Storage    `json:"storage"`
+	Log        Log        `json:"log"`
+}
+
+// Server configures the HTTP server.
+type Server struct {
+	Addr        string   `json:"addr" env:"SERVER_ADDR" flag:"addr" usage:"address the server listens on"`
+	ReadTimeout Duration `json:"read_timeout" env:"SERVER_READ_TIMEOUT" flag:"read-timeout" usage:"maximum time to read a request"`
+	IdleTimeout Duration `json:"idle_timeout" env:"SERVER_IDLE_TIMEOUT" flag:"idle-timeout" usage:"maximum time a keep-alive connection waits for the next request"`
+
+	// ShutdownTimeout bounds the draining of the in-flight requests on
+	// shutdown, it must leave time for the sends to be confirmed.
+	ShutdownTimeout Duration `json:"shutdown_timeout" env:"SERVER_SHUTDOWN_TIMEOUT" flag:"shutdown-timeout" usage:"maximum time to drain the in-flight requests on shutdown"`
+
+	// ShutdownDelay is the time the server keeps serving new requests on
+	// shutdown once the readiness probe fails, so the load balancers stop
+	// routing requests to it before it stops accepting them.
+	ShutdownDelay Duration `json:"shutdown_delay" env:"SERVER_SHUTDOWN_DELAY" flag:"shutdown-delay" usage:"time to keep serving requests on shutdown after the readiness probe fails"`
+}
+
+// Solana configures the Solana cluster and the transaction confirmations.
+type Solana struct {
+	Cluster Cluster `json:"cluster" env:"SOLANA_CLUSTER" flag:"solana-cluster" usage:"Solana cluster: devnet, testnet, mainnet or localnet"`
+
+	// RPCURLs replace the public RPC endpoint of the cluster, such as
+	// dedicated RPC providers, in order of preference. The calls fail over
+	// between them.
+	RPCURLs []string `json:"rpc_urls" env:"SOLANA_RPC_URLS" flag:"solana-rpc-urls" usage:"comma separated Solana RPC endpoints, the public one of the cluster when empty"`
+
+	// SendRPCURLs are the RPC URLs the transactions are sent to, those that
+	// broadcast them, every RPC URL when empty.
+	SendRPCURLs []string `json:"send_rpc_urls" env:"SOLANA_SEND_RPC_URLS" flag:"solana-send-rpc-urls" usage:"comma separated Solana RPC endpoints broadcasting the transactions, all of them when empty"`
+
+	Commitment           rpc.CommitmentType `json:"commitment" env:"SOLANA_COMMITMENT" flag:"solana-commitment" usage:"commitment level: processed, confirmed or finalized" reload:"true"`
+	ConfirmationTimeout  Duration           `json:"confirmation_timeout" env:"SOLANA_CONFIRMATION_TIMEOUT" flag:"solana-confirmation-timeout" usage:"time to wait for a sent transaction to be confirmed" reload:"true"`
+	ConfirmationInterval Duration           `json:"confirmation_interval" env:"SOLANA_CONFIRMATION_INTERVAL" flag:"solana-confirmation-interval" usage:"time between the checks of a sent transaction status" reload:"true"`
+
+	// RPCScoreHalfLife is the time it takes the score of an RPC endpoint to
+	// get halfway back to the one of a new endpoint, so the endpoints that
+	// failed or were slow are tried again.
+	RPCScoreHalfLife Duration `json:"rpc_score_half_life" env:"SOLANA_RPC_SCORE_HALF_LIFE" flag:"solana-rpc-score-half-life" usage:"time it takes the score of an RPC endpoint to get halfway back to the one of a new endpoint" reload:"true"`
+}
+
+// RPCEndpoints returns the RPC endpoints, RPCURLs or the public one of the
+// cluster.
+func (s Solana) RPCEndpoints() []string {
+	if len(s.RPCURLs) > 0 {
+		return s.RPCURLs
+	}
+
+	return []string{clusterRPCURLs[s.Cluster]}
+}
+
+// Broadcasts returns whether the transactions are sent to the RPC endpoint.
+func (s Solana) Broadcasts(endpoint string) bool {
+	return len(s.SendRPCURLs) == 0 || slices.Contains(s.SendRPCURLs, endpoint)
+}
+
+// Exchange configures the exchange rate providers and refreshes.
+type Exchange struct {
+	KrakenURL     string `json:"kraken_url" env:"EXCHANGE_KRAKEN_URL" flag:"exchange-kraken-url" usage:"Kraken ticker API URL"`
+	KrakenOHLCURL string `json:"kraken_ohlc_url" env:"EXCHANGE_KRAKEN_OHLC_URL" flag:"exchange-kraken-ohlc-url" usage:"Kraken OHLC API URL"`
+	CoinbaseURL   string `json:"coinbase_url" env:"EXCHANGE_COINBASE_URL" flag:"exchange-coinbase-url" usage:"Coinbase prices API URL"`
+	BinanceURL    string `json:"binance_url" env:"EXCHANGE_BINANCE_URL" flag:"exchange-binance-url" usage:"Binance ticker price API URL"`
+	CoinGeckoURL  string `json:"coingecko_url" env:"EXCHANGE_COINGECKO_URL" flag:"exchange-coingecko-url" usage:"CoinGecko simple price API URL"`
+
+	// Currencies are the fiat currencies supported by the exchange, as ISO
+	// 4217 codes.
+	Currencies []string `json:"currencies" env:"EXCHANGE_CURRENCIES" flag:"exchange-currencies" usage:"comma separated ISO 4217 codes of the supported currencies"`
+
+	MaxDeviation    float64  `json:"max_deviation" env:"EXCHANGE_MAX_DEVIATION" flag:"exchange-max-deviation" usage:"maximum relative deviation from the median of an accepted quote" reload:"true"`
+	RefreshInterval Duration `json:"refresh_interval" env:"EXCHANGE_REFRESH_INTERVAL" flag:"exchange-refresh-interval" usage:"time between the rate refreshes" reload:"true"`
+	RateExpiration  Duration `json:"rate_expiration" env:"EXCHANGE_RATE_EXPIRATION" flag:"exchange-rate-expiration" usage:"time after which a fetched rate expires" reload:"true"`
+
+	// StartDegraded allows the server to start when the rates can't be
+	// fetched, only the rate dependent endpoints fail until they recover.
+	StartDegraded bool `json:"start_degraded" env:"EXCHANGE_START_DEGRADED" flag:"exchange-start-degraded" usage:"start when the rates can't be fetched"`
+
+	// StaticRates replace the rate providers, for local development and
+	// tests.
+	StaticRates Rates `json:"static_rates" env:"EXCHANGE_STATIC_RATES" flag:"exchange-static-rates" usage:"comma separated rates, such as EUR=85.1, replacing the rate providers"`
+
+	// RateOverrides are set on startup for RateOverridesTTL, audited as set
+	// by the configuration.
+	RateOverrides    Rates    `json:"rate_overrides" env:"EXCHANGE_RATE_OVERRIDES" flag:"exchange-rate-overrides" usage:"comma separated rates, such as EUR=85.1, overriding the fetched ones on startup"`
+	RateOverridesTTL Duration `json:"rate_overrides_ttl" env:"EXCHANGE_RATE_OVERRIDES_TTL" flag:"exchange-rate-overrides-ttl" usage:"time the rate overrides set on startup last"`
+
+	SnapshotInterval Duration `json:"snapshot_interval" env:"EXCHANGE_SNAPSHOT_INTERVAL" flag:"exchange-snapshot-interval" usage:"time between the snapshots of the rates kept in the rate history"`
+}
+
+// Conversion configures the conversions between lamports and fiat amounts.
+type Conversion struct {
+	RoundingMode aggregates.RoundingMode `json:"rounding_mode" env:"CONVERSION_ROUNDING_MODE" flag:"conversion-rounding-mode" usage:"rounding of the converted amounts: half-even, half-up or floor"`
+}
+
+// Auth configures the authentication of the requests.
+type Auth struct {
+	// Disabled makes every endpoint public, for local development and the
+	// automated tests of the original specification, which carry no API
+	// keys.
+	Disabled bool `json:"disabled" env:"AUTH_DISABLED" flag:"auth-disabled" usage:"make every endpoint public"`
+
+	OIDC OIDC `json:"oidc"`
+}
+
+// OIDC configures the bearer tokens of an OIDC provider, they're accepted
+// along with the API keys when JWKSURL is set.
+type OIDC struct {
+	JWKSURL  string `json:"jwks_url" env:"OIDC_JWKS_URL" flag:"oidc-jwks-url" usage:"JWKS URL of the OIDC provider, the bearer tokens are rejected when empty"`
+	Issuer   string `json:"issuer" env:"OIDC_ISSUER" flag:"oidc-issuer" usage:"issuer of the bearer tokens"`
+	Audience string `json:"audience" env:"OIDC_AUDIENCE" flag:"oidc-audience" usage:"audience of the bearer tokens"`
+
+	WalletsClaim string `json:"wallets_claim" env:"OIDC_WALLETS_CLAIM" flag:"oidc-wallets-claim" usage:"token claim listing the wallets of its owner"`
+	TenantClaim  string `json:"tenant_claim" env:"OIDC_TENANT_CLAIM" flag:"oidc-tenant-claim" usage:"token claim naming the tenant of its owner"`
+
+	// DefaultTenant is the tenant of the tokens without the tenant claim,
+	// they're rejected when it's empty.
+	DefaultTenant string `json:"default_tenant" env:"OIDC_DEFAULT_TENANT" flag:"oidc-default-tenant" usage:"tenant of the tokens without the tenant claim, they're rejected when empty"`
+}
+
+// Enabled returns whether the bearer tokens are accepted.
+func (o OIDC) Enabled() bool {
+	return o.JWKSURL != ""
+}
+
+// RateLimit configures the rate limits of each client per class of
+// endpoints, token buckets refilled at the rate per second holding up to the
+// burst. The auth limit applies per address to every authenticated endpoint
+// before the credentials are checked.
+type RateLimit struct {
+	AuthRate  float64 `json:"auth_rate" env:"RATE_LIMIT_AUTH_RATE" flag:"rate-limit-auth-rate" usage:"requests per second of an address to the authenticated endpoints"`
+	AuthBurst int     `json:"auth_burst" env:"RATE_LIMIT_AUTH_BURST" flag:"rate-limit-auth-burst" usage:"burst of requests of an address to the authenticated endpoints"`
+	ReadRate  float64 `json:"read_rate" env:"RATE_LIMIT_READ_RATE" flag:"rate-limit-read-rate" usage:"requests per second of a client to the read endpoints"`
+	ReadBurst int     `json:"read_burst" env:"RATE_LIMIT_READ_BURST" flag:"rate-limit-read-burst" usage:"burst of requests of a client to the read endpoints"`
+	SendRate  float64 `json:"send_rate" env:"RATE_LIMIT_SEND_RATE" flag:"rate-limit-send-rate" usage:"requests per second of a client to the send endpoints"`
+	SendBurst int     `json:"send_burst" env:"RATE_LIMIT_SEND_BURST" flag:"rate-limit-send-burst" usage:"burst of requests of a client to the send endpoints"`
+
+	// DailyQuotas are counted in the quotas file, the classes without one
+	// have no quota.
+	DailyQuotas Quotas `json:"daily_quotas" env:"RATE_LIMIT_DAILY_QUOTAS" flag:"rate-limit-daily-quotas" usage:"comma separated daily quotas per class of endpoints, such as read=10000,send=500"`
+}
+
+// rateClasses are the classes of endpoints with a rate limit.
+var rateClasses = []aggregates.RateClass{
+	aggregates.RateClassAuth,
+	aggregates.RateClassRead,
+	aggregates.RateClassSend,
+}
+
+// Limits returns the rate limits per class of endpoints.
+func (r RateLimit) Limits() map[aggregates.RateClass]aggregates.RateLimit {
+	limits := map[aggregates.RateClass]aggregates.RateLimit{
+		aggregates.RateClassAuth: {Rate: r.AuthRate, Burst: r.AuthBurst},
+		aggregates.RateClassRead: {Rate: r.ReadRate, Burst: r.ReadBurst},
+		aggregates.RateClassSend: {Rate: r.SendRate, Burst: r.SendBurst},
+	}
+
+	for class, quota := range r.DailyQuotas {
+		limit := limits[class]
+		limit.DailyQuota = quota
+		limits[class] = limit
+	}
+
+	return limits
+}
+
+// Storage configures the paths of the files the service stores.
+type Storage struct {
+	VaultPath         string `json:"vault_path" env:"VAULT_PATH" flag:"vault-path" usage:"directory of the wallets"`
+	AuditLogPath      string `json:"audit_log_path" env:"AUDIT_LOG_PATH" flag:"audit-log-path" usage:"audit log file"`
+	ExchangeRatesPath string `json:"exchange_rates_path" env:"EXCHANGE_RATES_PATH" flag:"exchange-rates-path" usage:"file of the last known exchange rates"`
+	RateHistoryPath   string `json:"rate_history_path" env:"RATE_HISTORY_PATH" flag:"rate-history-path" usage:"directory of the historical rates"`
+	APIKeysPath       string `json:"api_keys_path" env:"API_KEYS_PATH" flag:"api-keys-path" usage:"file of the API key hashes"`
+
+	// QuotasPath should point to a volume shared by the replicas.
+	QuotasPath string `json:"quotas_path" env:"QUOTA_STORE_PATH" flag:"quotas-path" usage:"file of the daily request counts"`
+
+	// QuotasFlushInterval is the time between the writes of the request
+	// counts to the quotas file, the quotas can be exceeded by the requests
+	// of an interval on the other replicas.
+	QuotasFlushInterval Duration `json:"quotas_flush_interval" env:"QUOTAS_FLUSH_INTERVAL" flag:"quotas-flush-interval" usage:"time between the writes of the daily request counts"`
+}
+
+// Log configures the logger.
+type Log struct {
+	Format string `json:"format" env:"LOG_FORMAT" flag:"log-format" usage:"log format: json or text"`
+	Level  string `json:"level" env:"LOG_LEVEL" flag:"log-level" usage:"minimum log level: debug, info, warn or error" reload:"true"`
+}
+
+// Default returns the default configuration, for local development against
+// devnet.
+func Default() Config {
+	return Config{
+		Server: Server{
+			Addr:            ":8888",
+			ReadTimeout:     Duration(10 * time.Second),
+			IdleTimeout:     Duration(2 * time.Minute),
+			ShutdownTimeout: Duration(30 * time.Second),
+			ShutdownDelay:   Duration(5 * time.Second),
+		},
+		Solana: Solana{
+			Cluster:              ClusterDevnet,
+			Commitment:           rpc.CommitmentConfirmed,
+			ConfirmationTimeout:  Duration(3 * time.Second),
+			ConfirmationInterval: Duration(500 * time.Millisecond),
+			RPCScoreHalfLife:     Duration(time.Minute),
+		},
+		Exchange: Exchange{
+			KrakenURL:        "https://api.kraken.com/0/public/Ticker",
+			KrakenOHLCURL:    "https://api.kraken.com/0/public/OHLC",
+			CoinbaseURL:      "https://api.coinbase.com/v2/prices",
+			BinanceURL:       "https://api.binance.com/api/v3/ticker/price",
+			CoinGeckoURL:     "https://api.coingecko.com/api/v3/simple/price",
+			Currencies:       []string{"EUR", "USD", "GBP"},
+			MaxDeviation:     0.02,
+			RefreshInterval:  Duration(5 * time.Second),
+			RateExpiration:   Duration(20 * time.Second),
+			RateOverridesTTL: Duration(time.Hour),
+			SnapshotInterval: Duration(time.Minute),
+		},
+		Conversion: Conversion{
+			RoundingMode: aggregates.DefaultRoundingMode,
+		},
+		Auth: Auth{
+			OIDC: OIDC{
+				WalletsClaim: "wallets",
+				TenantClaim:  "tenant",
+			},
+		},
+		RateLimit: RateLimit{
+			AuthRate:  50,
+			AuthBurst: 100,
+			ReadRate:  10,
+			ReadBurst: 20,
+			SendRate:  1,
+			SendBurst: 5,
+		},
+		Storage: Storage{
+			VaultPath:           "./tmp/wallets",
+			AuditLogPath:        "./tmp/audit.log",
+			ExchangeRatesPath:   "./tmp/exchange_rates.json",
+			RateHistoryPath:     "./tmp/rates",
+			APIKeysPath:         "./tmp/api_keys.json",
+			QuotasPath:          "./tmp/quotas.json",
+			QuotasFlushInterval: Duration(5 * time.Second),
+		},
+		Log: Log{
+			Format: logging.FormatJSON,
+			Level:  "info",
+		},
+	}
+}
+
+// Load loads the configuration from the defaults, then the JSON file named by
+// the -config flag or the CONFIG_FILE environment variable, then the
+// environment and finally the flags in args, and validates it.
+//
+// The flags are those of the fields, such as -addr or -solana-cluster, see
+// -help for the whole list.
+func Load(args []string) (Config, error) {
+	cfg := Default()
+
+	flags := flag.NewFlagSet("coding-challenge", flag.ContinueOnError)
+	path := flags.String("config", "", "JSON configuration file, also set with "+FileEnv)
+
+	var flagged []func(*Config) error
+	for _, f := range fields() {
+		if f.flag == "" {
+			continue
+		}
+
+		f := f
+		flags.Var(&fieldFlag{
+			isBool: f.kind == reflect.Bool,
+			set: func(raw string) error {
+				flagged = append(flagged, func(cfg *Config) error {
+					return f.set(cfg, raw)
+				})
+				return nil
+			},
+		}, f.flag, f.usage)
+	}
+
+	if err := flags.Parse(args); err != nil {
+		return Config{}, err
+	}
+
+	if flags.NArg() > 0 {
+		return Config{}, fmt.Errorf("unexpected arguments %v", flags.Args())
+	}
+
+	if *path == "" {
+		*path = os.Getenv(FileEnv)
+	}
+
+	if *path != "" {
+		if err := loadFile(*path, &cfg); err != nil {
+			return Config{}, err
+		}
+	}
+
+	for _, f := range fields() {
+		raw, ok := os.LookupEnv(f.env)
+		if f.env == "" || !ok {
+			continue
+		}
+
+		if err := f.set(&cfg, raw); err != nil {
+			return Config{}, fmt.Errorf("error reading %s: %w", f.env, err)
+		}
+	}
+
+	for _, set := range flagged {
+		if err := set(&cfg); err != nil {
+			return Config{}, err
+		}
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return Config{}, err
+	}
+
+	return cfg, nil
+}
+
+// loadFile loads the JSON file at path over cfg, unknown fields are rejected
+// so typos don't go unnoticed.
+func loadFile(path string, cfg *Config) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("error opening config file: %w", err)
+	}
+	defer file.Close()
+
+	decoder := json.NewDecoder(file)
+	decoder.DisallowUnknownFields()
+
+	if err := decoder.Decode(cfg); err != nil {
+		return fmt.Errorf("error decoding config file %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// Validate validates the configuration, reporting every invalid field.
+func (c Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	check(c.Server.Addr != "", "server.addr is required")
+	check(c.Server.ReadTimeout > 0, "server.read_timeout must be positive")
+	check(c.Server.IdleTimeout > 0, "server.idle_timeout must be positive")
+	check(c.Server.ShutdownTimeout > c.Solana.ConfirmationTimeout,
+		"server.shutdown_timeout must be longer than the confirmation timeout")
+	check(c.Server.ShutdownDelay >= 0, "server.shutdown_delay must not be negative")
+
+	_, known := clusterRPCURLs[c.Solana.Cluster]
+	check(known, "solana.cluster %q must be devnet, testnet, mainnet or localnet", c.Solana.Cluster)
+
+	endpoints := make(map[string]bool, len(c.Solana.RPCURLs))
+	for _, endpoint := range c.Solana.RPCURLs {
+		check(validURL(endpoint), "solana.rpc_urls %q must be an http or https URL", endpoint)
+		check(!endpoints[endpoint], "solana.rpc_urls %q is repeated", endpoint)
+		endpoints[endpoint] = true
+	}
+
+	for _, endpoint := range c.Solana.SendRPCURLs {
+		check(slices.Contains(c.Solana.RPCEndpoints(), endpoint),
+			"solana.send_rpc_urls %q must be one of the RPC endpoints", endpoint)
+	}
+
+	switch c.Solana.Commitment {
+	case rpc.CommitmentProcessed, rpc.CommitmentConfirmed, rpc.CommitmentFinalized:
+	default:
+		errs = append(errs, fmt.Errorf(
+			"solana.commitment %q must be processed, confirmed or finalized", c.Solana.Commitment))
+	}
+
+	check(c.Solana.ConfirmationTimeout > 0, "solana.confirmation_timeout must be positive")
+	check(c.Solana.ConfirmationInterval > 0 &&
+		c.Solana.ConfirmationInterval < c.Solana.ConfirmationTimeout,
+		"solana.confirmation_interval must be positive and shorter than the timeout")
+	check(c.Solana.RPCScoreHalfLife > 0, "solana.rpc_score_half_life must be positive")
+
+	for _, provider := range []struct{ name, url string }{
+		{"exchange.kraken_url", c.Exchange.KrakenURL},
+		{"exchange.kraken_ohlc_url", c.Exchange.KrakenOHLCURL},
+		{"exchange.coinbase_url", c.Exchange.CoinbaseURL},
+		{"exchange.binance_url", c.Exchange.BinanceURL},
+		{"exchange.coingecko_url", c.Exchange.CoinGeckoURL},
+	} {
+		check(validURL(provider.url), "%s %q must be an http or https URL", provider.name, provider.url)
+	}
+
+	check(len(c.Exchange.Currencies) > 0, "exchange.currencies is required")
+
+	seen := make(map[string]bool, len(c.Exchange.Currencies))
+	for _, currency := range c.Exchange.Currencies {
+		check(validCurrency(currency), "exchange.currencies %q must be an ISO 4217 code", currency)
+		check(!seen[currency], "exchange.currencies %q is repeated", currency)
+		seen[currency] = true
+	}
+
+	check(c.Exchange.MaxDeviation > 0 && c.Exchange.MaxDeviation < 1,
+		"exchange.max_deviation must be between 0 and 1")
+	check(c.Exchange.RefreshInterval > 0, "exchange.refresh_interval must be positive")
+	check(c.Exchange.RateExpiration > c.Exchange.RefreshInterval,
+		"exchange.rate_expiration must be longer than the refresh interval")
+	check(c.Exchange.RateOverridesTTL > 0, "exchange.rate_overrides_ttl must be positive")
+	check(c.Exchange.SnapshotInterval > 0, "exchange.snapshot_interval must be positive")
+
+	for _, rates := range []struct {
+		name  string
+		rates Rates
+	}{
+		{"exchange.static_rates", c.Exchange.StaticRates},
+		{"exchange.rate_overrides", c.Exchange.RateOverrides},
+	} {
+		for currency, rate := range rates.rates {
+			check(slices.Contains(c.Exchange.Currencies, currency),
+				"%s %q must be one of the currencies", rates.name, currency)
+			check(rate != nil && rate.Sign() > 0, "%s %q must be positive", rates.name, currency)
+		}
+	}
+
+	_, err := aggregates.ParseRoundingMode(string(c.Conversion.RoundingMode))
+	check(c.Conversion.RoundingMode != "" && err == nil,
+		"conversion.rounding_mode %q must be half-even, half-up or floor", c.Conversion.RoundingMode)
+
+	if c.Auth.OIDC.Enabled() {
+		check(validURL(c.Auth.OIDC.JWKSURL),
+			"auth.oidc.jwks_url %q must be an http or https URL", c.Auth.OIDC.JWKSURL)
+		check(c.Auth.OIDC.Issuer != "", "auth.oidc.issuer is required along with the JWKS URL")
+		check(c.Auth.OIDC.Audience != "", "auth.oidc.audience is required along with the JWKS URL")
+	}
+
+	check(c.Auth.OIDC.WalletsClaim != "", "auth.oidc.wallets_claim is required")
+	check(c.Auth.OIDC.TenantClaim != "", "auth.oidc.tenant_claim is required")
+	check(c.Auth.OIDC.DefaultTenant == "" || aggregates.ValidateTenant(c.Auth.OIDC.DefaultTenant) == nil,
+		"auth.oidc.default_tenant %q must be a valid tenant", c.Auth.OIDC.DefaultTenant)
+
+	for _, limit := range []struct {
+		name  string
+		rate  float64
+		burst int
+	}{
+		{"auth", c.RateLimit.AuthRate, c.RateLimit.AuthBurst},
+		{"read", c.RateLimit.ReadRate, c.RateLimit.ReadBurst},
+		{"send", c.RateLimit.SendRate, c.RateLimit.SendBurst},
+	} {
+		check(limit.rate > 0, "rate_limit.%s_rate must be positive", limit.name)
+		check(limit.burst > 0, "rate_limit.%s_burst must be positive", limit.name)
+	}
+
+	for class, quota := range c.RateLimit.DailyQuotas {
+		check(slices.Contains(rateClasses, class), "rate_limit.daily_quotas %q must be auth, read or send", class)
+		check(quota >= 0, "rate_limit.daily_quotas %q must not be negative", class)
+	}
+
+	for _, storage := range []struct{ name, path string }{
+		{"storage.vault_path", c.Storage.VaultPath},
+		{"storage.audit_log_path", c.Storage.AuditLogPath},
+		{"storage.exchange_rates_path", c.Storage.ExchangeRatesPath},
+		{"storage.rate_history_path", c.Storage.RateHistoryPath},
+		{"storage.api_keys_path", c.Storage.APIKeysPath},
+		{"storage.quotas_path", c.Storage.QuotasPath},
+	} {
+		check(storage.path != "", "%s is required", storage.name)
+	}
+
+	check(c.Storage.QuotasFlushInterval > 0, "storage.quotas_flush_interval must be positive")
+
+	check(c.Log.Format == logging.FormatJSON || c.Log.Format == logging.FormatText,
+		"log.format %q must be json or text", c.Log.Format)
+
+	_, err = logging.ParseLevel(c.Log.Level)
+	check(err == nil, "log.level %q must be debug, info, warn or error", c.Log.Level)
+
+	if len(errs) > 0 {
+		return fmt.Errorf("%w: %w", ErrInvalidConfig, errors.Join(errs...))
+	}
+
+	return nil
+}
+
+// Reload returns the current configuration with the reloadable fields of the
+// loaded one, along with the names of the fields that changed but can't be
+// reloaded, they keep their current value until the service restarts.
+//
+// The reloaded configuration is validated as a whole, as the reloadable
+// fields are checked against the current value of the others, and the
+// current one is returned along with the error when it's invalid.
+func Reload(current, loaded Config) (Config, []string, error) {
+	reloaded := current
+
+	currentValue := reflect.ValueOf(&current).Elem()
+	loadedValue := reflect.ValueOf(&loaded).Elem()
+	reloadedValue := reflect.ValueOf(&reloaded).Elem()
+
+	var ignored []string
+	for _, f := range fields() {
+		currentField := currentValue.FieldByIndex(f.index)
+		loadedField := loadedValue.FieldByIndex(f.index)
+
+		if reflect.DeepEqual(currentField.Interface(), loadedField.Interface()) {
+			continue
+		}
+
+		if !f.reload {
+			ignored = append(ignored, f.name)
+			continue
+		}
+
+		reloadedValue.FieldByIndex(f.index).Set(loadedField)
+	}
+
+	if err := reloaded.Validate(); err != nil {
+		return current, ignored, err
+	}
+
+	return reloaded, ignored, nil
+}
+
+// field is a leaf field of the configuration.
+type field struct {
+	// name is the path of the field in the JSON file, such as server.addr.
+	name  string
+	index []int
+	kind  reflect.Kind
+
+	env    string
+	flag   string
+	usage  string
+	reload bool
+}
+
+// set sets the field of cfg from its string representation, a comma
+// separated list for the slices.
+func (f field) set(cfg *Config, raw string) error {
+	value := reflect.ValueOf(cfg).Elem().FieldByIndex(f.index)
+
+	if unmarshaler, ok := value.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		if err := unmarshaler.UnmarshalText([]byte(raw)); err != nil {
+			return fmt.Errorf("error parsing %s: %w", f.name, err)
+		}
+		return nil
+	}
+
+	switch f.kind {
+	case reflect.String:
+		value.SetString(raw)
+	case reflect.Bool:
+		parsed, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("error parsing %s: %w", f.name, err)
+		}
+		value.SetBool(parsed)
+	case reflect.Int:
+		parsed, err := strconv.Atoi(raw)
+		if err != nil {
+			return fmt.Errorf("error parsing %s: %w", f.name, err)
+		}
+		value.SetInt(int64(parsed))
+	case reflect.Float64:
+		parsed, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return fmt.Errorf("error parsing %s: %w", f.name, err)
+		}
+		value.SetFloat(parsed)
+	case reflect.Slice:
+		var items []string
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		value.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("unsupported kind %s of %s", f.kind, f.name)
+	}
+
+	return nil
+}
+
+// fields returns the leaf fields of the configuration, in declaration order.
+func fields() []field {
+	return structFields(reflect.TypeOf(Config{}), "", nil)
+}
+
+// structFields returns the leaf fields of the struct type t, prefixing their
+// names and indexes with the ones of the struct.
+func structFields(t reflect.Type, prefix string, index []int) []field {
+	var leaves []field
+	for i := 0; i < t.NumField(); i++ {
+		structField := t.Field(i)
+
+		name, _, _ := strings.Cut(structField.Tag.Get("json"), ",")
+		if prefix != "" {
+			name = prefix + "." + name
+		}
+
+		fieldIndex := append(append([]int(nil), index...), i)
+
+		if structField.Type.Kind() == reflect.Struct {
+			leaves = append(leaves, structFields(structField.Type, name, fieldIndex)...)
+			continue
+		}
+
+		leaves = append(leaves, field{
+			name:   name,
+			index:  fieldIndex,
+			kind:   structField.Type.Kind(),
+			env:    structField.Tag.Get("env"),
+			flag:   structField.Tag.Get("flag"),
+			usage:  structField.Tag.Get("usage"),
+			reload: structField.Tag.Get("reload") == "true",
+		})
+	}
+
+	return leaves
+}
+
+// fieldFlag is the flag.Value of a field, recording the values set on the
+// command line to apply them after the file and the environment.
+type fieldFlag struct {
+	isBool bool
+	set    func(string) error
+}
+
+// String implements flag.Value.
+func (f *fieldFlag) String() string { return "" }
+
+// Set implements flag.Value.
+func (f *fieldFlag) Set(raw string) error { return f.set(raw) }
+
+// IsBoolFlag allows the boolean flags without a value, such as
+// -exchange-start-degraded.
+func (f *fieldFlag) IsBoolFlag() bool { return f.isBool }
+
+// validURL returns whether raw is an absolute http or https URL.
+func validURL(raw string) bool {
+	parsed, err := url.Parse(raw)
+	return err == nil && (parsed.Scheme == "http" || parsed.Scheme == "https") && parsed.Host != ""
+}
+
+// validCurrency returns whether currency looks like an ISO 4217 code, three
+// upper case letters.
+func validCurrency(currency string) bool {
+	if len(currency) != 3 {
+		return false
+	}
+
+	for _, r := range currency {
+		if r < 'A' || r > 'Z' {
+			return false
+		}
+	}
+
+	return true
+}
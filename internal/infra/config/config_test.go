@@ -0,0 +1,449 @@
+package config_test
+
+import (
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jcleira/coding-challenge/internal/domain/aggregates"
+	"github.com/jcleira/coding-challenge/internal/infra/config"
+)
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{
+		"server": {"addr": ":9000"},
+		"solana": {"cluster": "testnet", "confirmation_timeout": "10s"},
+		"exchange": {"currencies": ["EUR"], "max_deviation": 0.05}
+	}`), 0600))
+
+	tests := []struct {
+		name    string
+		env     map[string]string
+		args    []string
+		want    func(*config.Config)
+		wantErr bool
+	}{
+		{
+			name: "defaults",
+			want: func(*config.Config) {},
+		},
+		{
+			name: "file from the flag",
+			args: []string{"-config", path},
+			want: func(cfg *config.Config) {
+				cfg.Server.Addr = ":9000"
+				cfg.Solana.Cluster = config.ClusterTestnet
+				cfg.Solana.ConfirmationTimeout = config.Duration(10 * time.Second)
+				cfg.Exchange.Currencies = []string{"EUR"}
+				cfg.Exchange.MaxDeviation = 0.05
+			},
+		},
+		{
+			name: "environment over the file",
+			env: map[string]string{
+				"CONFIG_FILE":         path,
+				"SERVER_ADDR":         ":9001",
+				"EXCHANGE_CURRENCIES": "EUR, USD",
+				"SOLANA_RPC_URLS":     "https://rpc.example.com,https://rpc.example.org",
+				"LOG_LEVEL":           "debug",
+			},
+			want: func(cfg *config.Config) {
+				cfg.Server.Addr = ":9001"
+				cfg.Solana.Cluster = config.ClusterTestnet
+				cfg.Solana.ConfirmationTimeout = config.Duration(10 * time.Second)
+				cfg.Solana.RPCURLs = []string{"https://rpc.example.com", "https://rpc.example.org"}
+				cfg.Exchange.Currencies = []string{"EUR", "USD"}
+				cfg.Exchange.MaxDeviation = 0.05
+				cfg.Log.Level = "debug"
+			},
+		},
+		{
+			name: "flags over the environment",
+			env:  map[string]string{"SERVER_ADDR": ":9001", "SOLANA_COMMITMENT": "processed"},
+			args: []string{
+				"-config", path,
+				"-addr", ":9002",
+				"-solana-cluster", "mainnet",
+				"-exchange-start-degraded",
+			},
+			want: func(cfg *config.Config) {
+				cfg.Server.Addr = ":9002"
+				cfg.Solana.Cluster = config.ClusterMainnet
+				cfg.Solana.Commitment = rpc.CommitmentProcessed
+				cfg.Solana.ConfirmationTimeout = config.Duration(10 * time.Second)
+				cfg.Exchange.Currencies = []string{"EUR"}
+				cfg.Exchange.MaxDeviation = 0.05
+				cfg.Exchange.StartDegraded = true
+			},
+		},
+		{
+			name: "lists from the environment",
+			env: map[string]string{
+				"EXCHANGE_STATIC_RATES":   "EUR=85.1, usd=92",
+				"EXCHANGE_RATE_OVERRIDES": "GBP=70",
+				"RATE_LIMIT_DAILY_QUOTAS": "read=10000,send=500",
+				"RATE_LIMIT_SEND_BURST":   "10",
+				"AUTH_DISABLED":           "true",
+			},
+			want: func(cfg *config.Config) {
+				cfg.Exchange.StaticRates = config.Rates{"EUR": big.NewRat(851, 10), "USD": big.NewRat(92, 1)}
+				cfg.Exchange.RateOverrides = config.Rates{"GBP": big.NewRat(70, 1)}
+				cfg.RateLimit.DailyQuotas = config.Quotas{aggregates.RateClassRead: 10000, aggregates.RateClassSend: 500}
+				cfg.RateLimit.SendBurst = 10
+				cfg.Auth.Disabled = true
+			},
+		},
+		{
+			name: "OIDC from the flags",
+			args: []string{
+				"-oidc-jwks-url", "https://idp.example.com/jwks",
+				"-oidc-issuer", "https://idp.example.com",
+				"-oidc-audience", "payments",
+				"-oidc-default-tenant", "acme",
+			},
+			want: func(cfg *config.Config) {
+				cfg.Auth.OIDC.JWKSURL = "https://idp.example.com/jwks"
+				cfg.Auth.OIDC.Issuer = "https://idp.example.com"
+				cfg.Auth.OIDC.Audience = "payments"
+				cfg.Auth.OIDC.DefaultTenant = "acme"
+			},
+		},
+		{
+			name:    "unparseable rates",
+			env:     map[string]string{"EXCHANGE_STATIC_RATES": "EUR"},
+			wantErr: true,
+		},
+		{
+			name:    "unparseable quotas",
+			env:     map[string]string{"RATE_LIMIT_DAILY_QUOTAS": "read=many"},
+			wantErr: true,
+		},
+		{
+			name:    "missing file",
+			args:    []string{"-config", filepath.Join(t.TempDir(), "missing.json")},
+			wantErr: true,
+		},
+		{
+			name:    "unparseable environment",
+			env:     map[string]string{"SOLANA_CONFIRMATION_TIMEOUT": "soon"},
+			wantErr: true,
+		},
+		{
+			name:    "unknown flag",
+			args:    []string{"-port", "8888"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid",
+			args:    []string{"-solana-cluster", "moonnet"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+
+			cfg, err := config.Load(tt.args)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+
+			want := config.Default()
+			tt.want(&want)
+			assert.Equal(t, want, cfg)
+		})
+	}
+}
+
+func TestConfig_Validate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		modify  func(*config.Config)
+		wantErr bool
+	}{
+		{
+			name:   "defaults",
+			modify: func(*config.Config) {},
+		},
+		{
+			name: "custom RPC URLs",
+			modify: func(cfg *config.Config) {
+				cfg.Solana.RPCURLs = []string{"https://rpc.example.com", "https://rpc.example.org"}
+				cfg.Solana.SendRPCURLs = []string{"https://rpc.example.org"}
+			},
+		},
+		{
+			name: "invalid RPC URL",
+			modify: func(cfg *config.Config) {
+				cfg.Solana.RPCURLs = []string{"rpc.example.com"}
+			},
+			wantErr: true,
+		},
+		{
+			name: "repeated RPC URL",
+			modify: func(cfg *config.Config) {
+				cfg.Solana.RPCURLs = []string{"https://rpc.example.com", "https://rpc.example.com"}
+			},
+			wantErr: true,
+		},
+		{
+			name: "send RPC URL not in the RPC URLs",
+			modify: func(cfg *config.Config) {
+				cfg.Solana.RPCURLs = []string{"https://rpc.example.com"}
+				cfg.Solana.SendRPCURLs = []string{"https://rpc.example.org"}
+			},
+			wantErr: true,
+		},
+		{
+			name: "shutdown shorter than the confirmation timeout",
+			modify: func(cfg *config.Config) {
+				cfg.Server.ShutdownTimeout = config.Duration(time.Second)
+			},
+			wantErr: true,
+		},
+		{
+			name: "negative shutdown delay",
+			modify: func(cfg *config.Config) {
+				cfg.Server.ShutdownDelay = config.Duration(-time.Second)
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid commitment",
+			modify: func(cfg *config.Config) {
+				cfg.Solana.Commitment = "recent"
+			},
+			wantErr: true,
+		},
+		{
+			name: "interval longer than the timeout",
+			modify: func(cfg *config.Config) {
+				cfg.Solana.ConfirmationInterval = config.Duration(time.Minute)
+			},
+			wantErr: true,
+		},
+		{
+			name: "no RPC score half-life",
+			modify: func(cfg *config.Config) {
+				cfg.Solana.RPCScoreHalfLife = 0
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid currency",
+			modify: func(cfg *config.Config) {
+				cfg.Exchange.Currencies = []string{"EUR", "euro"}
+			},
+			wantErr: true,
+		},
+		{
+			name: "repeated currency",
+			modify: func(cfg *config.Config) {
+				cfg.Exchange.Currencies = []string{"EUR", "EUR"}
+			},
+			wantErr: true,
+		},
+		{
+			name: "rates expiring before the next refresh",
+			modify: func(cfg *config.Config) {
+				cfg.Exchange.RateExpiration = config.Duration(time.Second)
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid max deviation",
+			modify: func(cfg *config.Config) {
+				cfg.Exchange.MaxDeviation = 0
+			},
+			wantErr: true,
+		},
+		{
+			name: "missing path",
+			modify: func(cfg *config.Config) {
+				cfg.Storage.VaultPath = ""
+			},
+			wantErr: true,
+		},
+		{
+			name: "no quotas flush interval",
+			modify: func(cfg *config.Config) {
+				cfg.Storage.QuotasFlushInterval = 0
+			},
+			wantErr: true,
+		},
+		{
+			name: "rate of an unsupported currency",
+			modify: func(cfg *config.Config) {
+				cfg.Exchange.StaticRates = config.Rates{"JPY": big.NewRat(15000, 1)}
+			},
+			wantErr: true,
+		},
+		{
+			name: "negative rate override",
+			modify: func(cfg *config.Config) {
+				cfg.Exchange.RateOverrides = config.Rates{"EUR": big.NewRat(-1, 1)}
+			},
+			wantErr: true,
+		},
+		{
+			name: "no rate overrides ttl",
+			modify: func(cfg *config.Config) {
+				cfg.Exchange.RateOverridesTTL = 0
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid rounding mode",
+			modify: func(cfg *config.Config) {
+				cfg.Conversion.RoundingMode = "ceil"
+			},
+			wantErr: true,
+		},
+		{
+			name: "OIDC",
+			modify: func(cfg *config.Config) {
+				cfg.Auth.OIDC.JWKSURL = "https://idp.example.com/jwks"
+				cfg.Auth.OIDC.Issuer = "https://idp.example.com"
+				cfg.Auth.OIDC.Audience = "payments"
+			},
+		},
+		{
+			name: "OIDC without audience",
+			modify: func(cfg *config.Config) {
+				cfg.Auth.OIDC.JWKSURL = "https://idp.example.com/jwks"
+				cfg.Auth.OIDC.Issuer = "https://idp.example.com"
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid OIDC default tenant",
+			modify: func(cfg *config.Config) {
+				cfg.Auth.OIDC.DefaultTenant = "Acme Inc"
+			},
+			wantErr: true,
+		},
+		{
+			name: "no burst",
+			modify: func(cfg *config.Config) {
+				cfg.RateLimit.ReadBurst = 0
+			},
+			wantErr: true,
+		},
+		{
+			name: "quota of an unknown class",
+			modify: func(cfg *config.Config) {
+				cfg.RateLimit.DailyQuotas = config.Quotas{"write": 100}
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid log level",
+			modify: func(cfg *config.Config) {
+				cfg.Log.Level = "loud"
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			cfg := config.Default()
+			tt.modify(&cfg)
+
+			err := cfg.Validate()
+			if tt.wantErr {
+				assert.ErrorIs(t, err, config.ErrInvalidConfig)
+				return
+			}
+
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestSolana_RPCEndpoints(t *testing.T) {
+	t.Parallel()
+
+	solana := config.Default().Solana
+	assert.Equal(t, []string{rpc.DevNet_RPC}, solana.RPCEndpoints())
+
+	solana.Cluster = config.ClusterMainnet
+	assert.Equal(t, []string{rpc.MainNetBeta_RPC}, solana.RPCEndpoints())
+
+	solana.RPCURLs = []string{"https://rpc.example.com", "https://rpc.example.org"}
+	assert.Equal(t, solana.RPCURLs, solana.RPCEndpoints())
+}
+
+func TestSolana_Broadcasts(t *testing.T) {
+	t.Parallel()
+
+	solana := config.Default().Solana
+	solana.RPCURLs = []string{"https://rpc.example.com", "https://rpc.example.org"}
+	assert.True(t, solana.Broadcasts("https://rpc.example.com"))
+	assert.True(t, solana.Broadcasts("https://rpc.example.org"))
+
+	solana.SendRPCURLs = []string{"https://rpc.example.org"}
+	assert.False(t, solana.Broadcasts("https://rpc.example.com"))
+	assert.True(t, solana.Broadcasts("https://rpc.example.org"))
+}
+
+func TestReload(t *testing.T) {
+	t.Parallel()
+
+	current := config.Default()
+
+	loaded := config.Default()
+	loaded.Log.Level = "debug"
+	loaded.Solana.Commitment = rpc.CommitmentFinalized
+	loaded.Exchange.MaxDeviation = 0.05
+	loaded.Server.Addr = ":9000"
+	loaded.Exchange.Currencies = []string{"EUR"}
+
+	reloaded, ignored, err := config.Reload(current, loaded)
+	require.NoError(t, err)
+
+	want := config.Default()
+	want.Log.Level = "debug"
+	want.Solana.Commitment = rpc.CommitmentFinalized
+	want.Exchange.MaxDeviation = 0.05
+
+	assert.Equal(t, want, reloaded)
+	assert.Equal(t, []string{"server.addr", "exchange.currencies"}, ignored)
+}
+
+func TestReload_Invalid(t *testing.T) {
+	t.Parallel()
+
+	current := config.Default()
+
+	// The loaded configuration is valid on its own, but its confirmation
+	// timeout is longer than the current shutdown timeout, which can't be
+	// reloaded.
+	loaded := config.Default()
+	loaded.Server.ShutdownTimeout = config.Duration(2 * time.Minute)
+	loaded.Solana.ConfirmationTimeout = config.Duration(time.Minute)
+	require.NoError(t, loaded.Validate())
+
+	reloaded, ignored, err := config.Reload(current, loaded)
+	assert.ErrorIs(t, err, config.ErrInvalidConfig)
+	assert.Equal(t, current, reloaded)
+	assert.Equal(t, []string{"server.shutdown_timeout"}, ignored)
+}
@@ -0,0 +1,211 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/jcleira/coding-challenge/internal/domain/aggregates"
+)
+
+// APIKeyManager defines the methods to manage API keys.
+type APIKeyManager interface {
+	Issue(ctx context.Context,
+		name string, scopes []aggregates.Scope, wallets []string) (aggregates.APIKey, string, error)
+	List(ctx context.Context) ([]aggregates.APIKey, error)
+	Revoke(ctx context.Context, id string) error
+}
+
+// APIKeysHandler define the dependencies handling API key management
+// requests.
+type APIKeysHandler struct {
+	manager APIKeyManager
+}
+
+// NewAPIKeysHandler creates a new APIKeysHandler.
+func NewAPIKeysHandler(manager APIKeyManager) *APIKeysHandler {
+	return &APIKeysHandler{
+		manager: manager,
+	}
+}
+
+// IssueHandler is the http handler func issuing API keys, the token is only
+// returned in this response.
+func (h *APIKeysHandler) IssueHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		request := httpAPIKeyIssueRequest{}
+
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			writeError(w, r, invalidRequestBody(err))
+			return
+		}
+
+		scopes, err := aggregates.ParseScopes(strings.Join(request.Scopes, ","))
+		if err != nil {
+			writeError(w, r, aggregates.NewValidationError("scopes", err))
+			return
+		}
+
+		key, token, err := h.manager.Issue(r.Context(), request.Name, scopes, request.Wallets)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+
+		writeAPIKeyResponse(w, r, http.StatusCreated, httpAPIKeyIssueResponse{
+			httpAPIKey: newHTTPAPIKey(key),
+			Token:      token,
+		})
+	}
+}
+
+// ListHandler is the http handler func listing the API keys.
+func (h *APIKeysHandler) ListHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		keys, err := h.manager.List(r.Context())
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+
+		response := httpAPIKeysResponse{
+			APIKeys: make([]httpAPIKey, 0, len(keys)),
+		}
+
+		for _, key := range keys {
+			response.APIKeys = append(response.APIKeys, newHTTPAPIKey(key))
+		}
+
+		writeAPIKeyResponse(w, r, http.StatusOK, response)
+	}
+}
+
+// RevokeHandler is the http handler func revoking the API key of the {id}
+// path parameter.
+func (h *APIKeysHandler) RevokeHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := h.manager.Revoke(r.Context(), PathParam(r, "id")); err != nil {
+			writeError(w, r, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// writeAPIKeyResponse writes the JSON response with the status.
+func writeAPIKeyResponse(w http.ResponseWriter, r *http.Request, status int, body interface{}) {
+	response, err := json.Marshal(body)
+	if err != nil {
+		writeError(w, r, fmt.Errorf("error marshalling response: %w", err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if _, err := w.Write(response); err != nil {
+		slog.ErrorContext(r.Context(), "error writing response", "error", err)
+	}
+}
+
+// httpAPIKeyIssueRequest is the http request issuing an API key.
+type httpAPIKeyIssueRequest struct {
+	Name    string   `json:"name"`
+	Scopes  []string `json:"scopes" description:"read-balance, read-transactions, send, init or admin"`
+	Wallets []string `json:"wallets,omitempty" description:"Public keys of the wallets the key can operate on"`
+}
+
+// httpAPIKey is the http version of an API key, without its hash.
+type httpAPIKey struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Scopes     []string   `json:"scopes"`
+	Wallets    []string   `json:"wallets"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
+
+// newHTTPAPIKey converts a domain API key to its http version.
+func newHTTPAPIKey(key aggregates.APIKey) httpAPIKey {
+	response := httpAPIKey{
+		ID:        key.ID,
+		Name:      key.Name,
+		Scopes:    make([]string, 0, len(key.Scopes)),
+		Wallets:   key.Wallets,
+		CreatedAt: key.CreatedAt,
+	}
+
+	if response.Wallets == nil {
+		response.Wallets = []string{}
+	}
+
+	for _, scope := range key.Scopes {
+		response.Scopes = append(response.Scopes, string(scope))
+	}
+
+	if !key.LastUsedAt.IsZero() {
+		response.LastUsedAt = &key.LastUsedAt
+	}
+
+	if !key.RevokedAt.IsZero() {
+		response.RevokedAt = &key.RevokedAt
+	}
+
+	return response
+}
+
+// httpAPIKeyIssueResponse is the http response of an issued API key.
+type httpAPIKeyIssueResponse struct {
+	httpAPIKey
+	Token string `json:"token"`
+}
+
+// httpAPIKeysResponse is the http response listing the API keys.
+type httpAPIKeysResponse struct {
+	APIKeys []httpAPIKey `json:"api_keys"`
+}
+
+// Routes returns the routes of the API key management.
+func (h *APIKeysHandler) Routes() []Route {
+	return []Route{
+		{
+			Method:   http.MethodPost,
+			Path:     "/admin/api_keys",
+			Summary:  "Issue an API key, its token is only returned once",
+			Request:  httpAPIKeyIssueRequest{},
+			Status:   http.StatusCreated,
+			Response: httpAPIKeyIssueResponse{},
+			Scope:    aggregates.ScopeAdmin,
+			Handler:  h.IssueHandler(),
+		},
+		{
+			Method:   http.MethodGet,
+			Path:     "/admin/api_keys",
+			Summary:  "List the API keys, with their last use",
+			Response: httpAPIKeysResponse{},
+			Scope:    aggregates.ScopeAdmin,
+			Handler:  h.ListHandler(),
+		},
+		{
+			Method:  http.MethodDelete,
+			Path:    "/admin/api_keys/{id}",
+			Summary: "Revoke an API key",
+			Parameters: []Parameter{
+				{
+					Name:     "id",
+					In:       "path",
+					Required: true,
+					Schema:   &Schema{Type: "string"},
+				},
+			},
+			Status:  http.StatusNoContent,
+			Scope:   aggregates.ScopeAdmin,
+			Handler: h.RevokeHandler(),
+		},
+	}
+}
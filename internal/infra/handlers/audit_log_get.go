@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/jcleira/coding-challenge/internal/domain/aggregates"
+)
+
+// AuditLogGetter defines the methods for querying the audit log.
+type AuditLogGetter interface {
+	GetEvents(ctx context.Context, filter aggregates.AuditFilter) ([]aggregates.AuditEvent, error)
+}
+
+// AuditLogGetterHandler define the dependencies handling audit log requests.
+type AuditLogGetterHandler struct {
+	getter AuditLogGetter
+}
+
+// NewAuditLogGetterHandler creates a new AuditLogGetterHandler.
+func NewAuditLogGetterHandler(getter AuditLogGetter) *AuditLogGetterHandler {
+	return &AuditLogGetterHandler{
+		getter: getter,
+	}
+}
+
+// Handler is the http handler func for querying the audit log, by wallet and
+// time range. Every filter is optional.
+func (h *AuditLogGetterHandler) Handler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		request := httpAuditLogRequest{}
+
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			writeError(w, r, invalidRequestBody(err))
+			return
+		}
+
+		events, err := h.getter.GetEvents(r.Context(), aggregates.AuditFilter{
+			Wallet: request.Wallet,
+			From:   request.From,
+			To:     request.To,
+		})
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+
+		httpEvents := make([]httpAuditEvent, len(events))
+		for i, event := range events {
+			httpEvents[i] = httpAuditEventFromDomainEvent(event)
+		}
+
+		response, err := json.Marshal(
+			httpAuditEventsResponse{
+				HTTPAuditEvents: httpEvents,
+			})
+		if err != nil {
+			writeError(w, r, fmt.Errorf("error marshalling response: %w", err))
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if _, err := w.Write(response); err != nil {
+			slog.ErrorContext(r.Context(), "error writing response", "error", err)
+		}
+	}
+}
+
+// httpAuditLogRequest is the http request filtering the audit events.
+type httpAuditLogRequest struct {
+	Wallet string    `json:"wallet,omitempty"`
+	From   time.Time `json:"from,omitempty"`
+	To     time.Time `json:"to,omitempty"`
+}
+
+// httpAuditEventsResponse is the http version for a list of domain audit
+// events.
+type httpAuditEventsResponse struct {
+	HTTPAuditEvents []httpAuditEvent `json:"events"`
+}
+
+// httpAuditEvent is the http version for a domain audit event.
+type httpAuditEvent struct {
+	Time      time.Time         `json:"time"`
+	Actor     string            `json:"actor"`
+	RequestID string            `json:"request_id,omitempty"`
+	Action    string            `json:"action"`
+	Wallet    string            `json:"wallet,omitempty"`
+	Params    map[string]string `json:"params,omitempty"`
+	Outcome   string            `json:"outcome"`
+	Error     string            `json:"error,omitempty"`
+}
+
+// httpAuditEventFromDomainEvent converts a domain audit event to an http audit
+// event.
+func httpAuditEventFromDomainEvent(event aggregates.AuditEvent) httpAuditEvent {
+	return httpAuditEvent{
+		Time:      event.Time,
+		Actor:     event.Actor,
+		RequestID: event.RequestID,
+		Action:    event.Action,
+		Wallet:    event.Wallet,
+		Params:    event.Params,
+		Outcome:   event.Outcome,
+		Error:     event.Error,
+	}
+}
+
+// Routes returns the routes of the audit log.
+func (h *AuditLogGetterHandler) Routes() []Route {
+	return []Route{
+		{
+			Method:   http.MethodPost,
+			Path:     "/audit",
+			Summary:  "List the audit events, optionally by wallet and time range",
+			Request:  httpAuditLogRequest{},
+			Response: httpAuditEventsResponse{},
+			Scope:    aggregates.ScopeAdmin,
+			Handler:  h.Handler(),
+		},
+	}
+}
@@ -0,0 +1,111 @@
+package handlers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bradleyjkemp/cupaloy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jcleira/coding-challenge/internal/domain/aggregates"
+	"github.com/jcleira/coding-challenge/internal/infra/handlers"
+	"github.com/jcleira/coding-challenge/mocks"
+)
+
+type mockAuditRequest struct {
+	Wallet string `json:"wallet"`
+}
+
+func TestAuditLogGetterHandler_Handle(t *testing.T) {
+	t.Parallel()
+
+	eventTime := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		title          string
+		requestBody    *mockAuditRequest
+		beforeFunc     func(*mocks.AuditLogGetter)
+		wantStatusCode int
+	}{
+		{
+			title: "successful audit events retrieval",
+			requestBody: &mockAuditRequest{
+				Wallet: "testPublicKey",
+			},
+			beforeFunc: func(getter *mocks.AuditLogGetter) {
+				getter.On("GetEvents", mock.Anything, aggregates.AuditFilter{Wallet: "testPublicKey"}).
+					Return([]aggregates.AuditEvent{
+						{
+							Time:      eventTime,
+							Actor:     "127.0.0.1",
+							RequestID: "testRequestID",
+							Action:    aggregates.AuditActionTransactionSend,
+							Wallet:    "testPublicKey",
+							Params:    map[string]string{"signature": "testSignature"},
+							Outcome:   aggregates.AuditOutcomeSuccess,
+						},
+					}, nil)
+			},
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			title: "internal server error on audit events retrieval",
+			requestBody: &mockAuditRequest{
+				Wallet: "testPublicKey",
+			},
+			beforeFunc: func(getter *mocks.AuditLogGetter) {
+				getter.On("GetEvents", mock.Anything, aggregates.AuditFilter{Wallet: "testPublicKey"}).
+					Return(nil, errors.New("internal server error"))
+			},
+			wantStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	cupaloy := cupaloy.New(
+		cupaloy.SnapshotSubdirectory("./.snapshots/audit-log-get-test"))
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.title, func(t *testing.T) {
+			t.Parallel()
+
+			getter := &mocks.AuditLogGetter{}
+			test.beforeFunc(getter)
+
+			handler := handlers.NewAuditLogGetterHandler(getter)
+
+			mux := http.NewServeMux()
+			mux.Handle("/", handler.Handler())
+
+			server := httptest.NewServer(mux)
+			defer server.Close()
+
+			requestBody, _ := json.Marshal(test.requestBody)
+			req, err := http.NewRequest(http.MethodPost, server.URL, bytes.NewBuffer(requestBody))
+			assert.NoError(t, err)
+
+			resp, err := http.DefaultClient.Do(req)
+			assert.NoError(t, err)
+
+			assert.Equal(t, test.wantStatusCode, resp.StatusCode)
+
+			body, err := ioutil.ReadAll(resp.Body)
+			assert.NoError(t, err)
+			resp.Body.Close()
+
+			require.NoError(t, cupaloy.SnapshotMulti(
+				getSnapshotFileName(test.title),
+				string(body)))
+
+			assert.True(t, getter.AssertExpectations(t))
+		})
+	}
+}
@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/jcleira/coding-challenge/internal/domain/aggregates"
+)
+
+// APIKeyHeader is the header carrying the API key of a request.
+const APIKeyHeader = "X-API-Key"
+
+// APIKeyAuthenticator defines the methods for authenticating API keys.
+type APIKeyAuthenticator interface {
+	Authenticate(ctx context.Context, token string) (aggregates.APIKey, error)
+}
+
+// BearerAuthenticator defines the methods for authenticating bearer tokens,
+// such as OIDC JWTs.
+type BearerAuthenticator interface {
+	Authenticate(ctx context.Context, token string) (aggregates.Principal, error)
+}
+
+// WalletOwnershipChecker defines the methods for checking the wallets belong
+// to the tenant carried by the context.
+type WalletOwnershipChecker interface {
+	HasWallet(ctx context.Context, publicKey string) (bool, error)
+}
+
+// requireScope is a middleware that authenticates the principal of the
+// request, from its bearer token or its API key, and checks it's been granted
+// the scope. The principal and its tenant are available to the next handlers
+// in the request context, and its actor is recorded in the audit log. Every
+// request passes when there are no authenticators.
+func (rt *Router) requireScope(scope aggregates.Scope, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if rt.authenticator == nil && rt.bearerAuthenticator == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		principal, err := rt.authenticate(r)
+		if err != nil {
+			if errors.Is(err, aggregates.ErrUnauthenticated) && rt.bearerAuthenticator != nil {
+				w.Header().Set("WWW-Authenticate", "Bearer")
+			}
+
+			writeError(w, r, err)
+			return
+		}
+
+		if !principal.HasScope(scope) {
+			writeError(w, r, fmt.Errorf("%w: %s lacks the %s scope",
+				aggregates.ErrForbidden, principal.Actor, scope))
+			return
+		}
+
+		ctx := aggregates.ContextWithPrincipal(r.Context(), principal)
+		ctx = aggregates.ContextWithActor(ctx, principal.Actor)
+		ctx = aggregates.ContextWithTenant(ctx, principal.Tenant)
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// authenticate returns the principal of the bearer token or the API key of
+// the request, whichever is enabled and present, the bearer token first.
+func (rt *Router) authenticate(r *http.Request) (aggregates.Principal, error) {
+	authorization := r.Header.Get("Authorization")
+	if token, ok := strings.CutPrefix(authorization, "Bearer "); ok && rt.bearerAuthenticator != nil {
+		return rt.bearerAuthenticator.Authenticate(r.Context(), strings.TrimSpace(token))
+	}
+
+	if token := r.Header.Get(APIKeyHeader); token != "" && rt.authenticator != nil {
+		key, err := rt.authenticator.Authenticate(r.Context(), token)
+		if err != nil {
+			return aggregates.Principal{}, err
+		}
+
+		return key.Principal(), nil
+	}
+
+	return aggregates.Principal{}, fmt.Errorf("%w: missing credentials", aggregates.ErrUnauthenticated)
+}
+
+// requireWallet is a middleware that checks the principal of the request can
+// operate on the wallet of the request, the {pubkey} path parameter or the
+// public_key of the body, and that the wallet belongs to its tenant. Requests
+// without a principal have been let through by requireScope, so they pass.
+func (rt *Router) requireWallet(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal, ok := aggregates.PrincipalFromContext(r.Context())
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		wallet, err := requestWallet(r)
+		if err != nil {
+			writeError(w, r, invalidRequestBody(err))
+			return
+		}
+
+		if !principal.AllowsWallet(wallet) {
+			writeError(w, r, fmt.Errorf("%w: %s isn't granted the wallet %s",
+				aggregates.ErrForbidden, principal.Actor, wallet))
+			return
+		}
+
+		// Missing wallets are left to the request validation.
+		if rt.wallets != nil && wallet != "" {
+			owned, err := rt.wallets.HasWallet(r.Context(), wallet)
+			if err != nil {
+				writeError(w, r, fmt.Errorf("error checking wallet ownership: %w", err))
+				return
+			}
+
+			if !owned {
+				writeError(w, r, fmt.Errorf("%w: %s", aggregates.ErrWalletNotFound, wallet))
+				return
+			}
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// requestWallet returns the wallet public key of the request, restoring its
+// body for the next handlers.
+func requestWallet(r *http.Request) (string, error) {
+	if pubkey := PathParam(r, "pubkey"); pubkey != "" {
+		return pubkey, nil
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxRequestBodySize))
+	if err != nil {
+		return "", err
+	}
+
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	var request struct {
+		PublicKey string `json:"public_key"`
+	}
+
+	if len(bytes.TrimSpace(body)) == 0 {
+		return "", nil
+	}
+
+	if err := json.Unmarshal(body, &request); err != nil {
+		return "", err
+	}
+
+	return request.PublicKey, nil
+}
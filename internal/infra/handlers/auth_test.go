@@ -0,0 +1,349 @@
+package handlers_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jcleira/coding-challenge/internal/domain/aggregates"
+	"github.com/jcleira/coding-challenge/internal/infra/handlers"
+	"github.com/jcleira/coding-challenge/mocks"
+)
+
+func TestRouter_RequireAPIKeys(t *testing.T) {
+	t.Parallel()
+
+	shopKey := aggregates.APIKey{
+		ID:      "shop",
+		Scopes:  []aggregates.Scope{aggregates.ScopeReadBalance},
+		Wallets: []string{"shopWallet"},
+	}
+
+	adminKey := aggregates.APIKey{
+		ID:     "admin",
+		Scopes: []aggregates.Scope{aggregates.ScopeAdmin},
+	}
+
+	tests := []struct {
+		title          string
+		method         string
+		path           string
+		token          string
+		requestBody    string
+		beforeFunc     func(*mocks.APIKeyAuthenticator, *mocks.WalletBalanceGetter, *mocks.ExchangeRateGetter)
+		wantStatusCode int
+		wantCode       string
+	}{
+		{
+			title:          "missing API key",
+			method:         http.MethodPost,
+			path:           "/balance",
+			requestBody:    `{"public_key":"shopWallet"}`,
+			wantStatusCode: http.StatusUnauthorized,
+			wantCode:       handlers.ErrorCodeUnauthenticated,
+		},
+		{
+			title:       "invalid API key",
+			method:      http.MethodPost,
+			path:        "/balance",
+			token:       "invalid",
+			requestBody: `{"public_key":"shopWallet"}`,
+			beforeFunc: func(auth *mocks.APIKeyAuthenticator, _ *mocks.WalletBalanceGetter, _ *mocks.ExchangeRateGetter) {
+				auth.On("Authenticate", mock.Anything, "invalid").
+					Return(aggregates.APIKey{}, aggregates.ErrUnauthenticated)
+			},
+			wantStatusCode: http.StatusUnauthorized,
+			wantCode:       handlers.ErrorCodeUnauthenticated,
+		},
+		{
+			title:       "granted wallet in the body",
+			method:      http.MethodPost,
+			path:        "/balance",
+			token:       "shop",
+			requestBody: `{"public_key":"shopWallet"}`,
+			beforeFunc: func(auth *mocks.APIKeyAuthenticator, getter *mocks.WalletBalanceGetter, _ *mocks.ExchangeRateGetter) {
+				auth.On("Authenticate", mock.Anything, "shop").Return(shopKey, nil)
+				getter.On("GetBalance", mock.Anything, "shopWallet", "EUR").Return("EUR 12.34", nil)
+			},
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			title:       "other wallet in the body",
+			method:      http.MethodPost,
+			path:        "/balance",
+			token:       "shop",
+			requestBody: `{"public_key":"otherWallet"}`,
+			beforeFunc: func(auth *mocks.APIKeyAuthenticator, _ *mocks.WalletBalanceGetter, _ *mocks.ExchangeRateGetter) {
+				auth.On("Authenticate", mock.Anything, "shop").Return(shopKey, nil)
+			},
+			wantStatusCode: http.StatusForbidden,
+			wantCode:       handlers.ErrorCodeForbidden,
+		},
+		{
+			title:  "other wallet in the path",
+			method: http.MethodGet,
+			path:   "/v1/wallets/otherWallet/balance",
+			token:  "shop",
+			beforeFunc: func(auth *mocks.APIKeyAuthenticator, _ *mocks.WalletBalanceGetter, _ *mocks.ExchangeRateGetter) {
+				auth.On("Authenticate", mock.Anything, "shop").Return(shopKey, nil)
+			},
+			wantStatusCode: http.StatusForbidden,
+			wantCode:       handlers.ErrorCodeForbidden,
+		},
+		{
+			title:  "missing scope",
+			method: http.MethodPost,
+			path:   "/init",
+			token:  "shop",
+			beforeFunc: func(auth *mocks.APIKeyAuthenticator, _ *mocks.WalletBalanceGetter, _ *mocks.ExchangeRateGetter) {
+				auth.On("Authenticate", mock.Anything, "shop").Return(shopKey, nil)
+			},
+			wantStatusCode: http.StatusForbidden,
+			wantCode:       handlers.ErrorCodeForbidden,
+		},
+		{
+			title:  "admin on any wallet",
+			method: http.MethodGet,
+			path:   "/v1/wallets/otherWallet/balance",
+			token:  "admin",
+			beforeFunc: func(auth *mocks.APIKeyAuthenticator, getter *mocks.WalletBalanceGetter, _ *mocks.ExchangeRateGetter) {
+				auth.On("Authenticate", mock.Anything, "admin").Return(adminKey, nil)
+				getter.On("GetBalance", mock.Anything, "otherWallet", "EUR").Return("EUR 12.34", nil)
+			},
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			title:  "public route",
+			method: http.MethodGet,
+			path:   "/exchange_rate",
+			beforeFunc: func(_ *mocks.APIKeyAuthenticator, _ *mocks.WalletBalanceGetter, getter *mocks.ExchangeRateGetter) {
+				getter.On("GetRate", mock.Anything, "EUR").Return(aggregates.Rate{}, aggregates.ErrRateExpired)
+			},
+			wantStatusCode: http.StatusUnprocessableEntity,
+			wantCode:       handlers.ErrorCodeRateExpired,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.title, func(t *testing.T) {
+			t.Parallel()
+
+			var (
+				authenticator = mocks.NewAPIKeyAuthenticator(t)
+				balanceGetter = mocks.NewWalletBalanceGetter(t)
+				rateGetter    = mocks.NewExchangeRateGetter(t)
+			)
+
+			if test.beforeFunc != nil {
+				test.beforeFunc(authenticator, balanceGetter, rateGetter)
+			}
+
+			router := handlers.NewRouter()
+			router.RequireAPIKeys(authenticator)
+			router.Register(handlers.NewWalletBalanceGetterHandler(balanceGetter).Routes()...)
+			router.Register(handlers.NewWalletInitializerHandler(mocks.NewWalletInitializer(t)).Routes()...)
+			router.Register(handlers.NewExchangeRateGetterHandler(rateGetter).Routes()...)
+
+			req := httptest.NewRequest(test.method, test.path, bytes.NewBufferString(test.requestBody))
+			if test.token != "" {
+				req.Header.Set(handlers.APIKeyHeader, test.token)
+			}
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			require.Equal(t, test.wantStatusCode, w.Code, w.Body.String())
+
+			if test.wantCode != "" {
+				var response struct {
+					Code string `json:"code"`
+				}
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				assert.Equal(t, test.wantCode, response.Code)
+			}
+		})
+	}
+}
+
+func TestRouter_RequireBearerTokens(t *testing.T) {
+	t.Parallel()
+
+	principal := aggregates.Principal{
+		Actor:   "oidc:user",
+		Scopes:  []aggregates.Scope{aggregates.ScopeReadBalance},
+		Wallets: []string{"userWallet"},
+	}
+
+	tests := []struct {
+		title            string
+		path             string
+		authorization    string
+		beforeFunc       func(*mocks.BearerAuthenticator, *mocks.WalletBalanceGetter)
+		wantStatusCode   int
+		wantAuthenticate bool
+	}{
+		{
+			title:         "granted wallet",
+			path:          "/v1/wallets/userWallet/balance",
+			authorization: "Bearer token",
+			beforeFunc: func(auth *mocks.BearerAuthenticator, getter *mocks.WalletBalanceGetter) {
+				auth.On("Authenticate", mock.Anything, "token").Return(principal, nil)
+				getter.On("GetBalance", mock.Anything, "userWallet", "EUR").Return("EUR 12.34", nil)
+			},
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			title:         "other wallet",
+			path:          "/v1/wallets/otherWallet/balance",
+			authorization: "Bearer token",
+			beforeFunc: func(auth *mocks.BearerAuthenticator, _ *mocks.WalletBalanceGetter) {
+				auth.On("Authenticate", mock.Anything, "token").Return(principal, nil)
+			},
+			wantStatusCode: http.StatusForbidden,
+		},
+		{
+			title:         "invalid token",
+			path:          "/v1/wallets/userWallet/balance",
+			authorization: "Bearer token",
+			beforeFunc: func(auth *mocks.BearerAuthenticator, _ *mocks.WalletBalanceGetter) {
+				auth.On("Authenticate", mock.Anything, "token").
+					Return(aggregates.Principal{}, aggregates.ErrUnauthenticated)
+			},
+			wantStatusCode:   http.StatusUnauthorized,
+			wantAuthenticate: true,
+		},
+		{
+			title:            "other authorization scheme",
+			path:             "/v1/wallets/userWallet/balance",
+			authorization:    "Basic dXNlcjpwYXNz",
+			wantStatusCode:   http.StatusUnauthorized,
+			wantAuthenticate: true,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.title, func(t *testing.T) {
+			t.Parallel()
+
+			var (
+				authenticator = mocks.NewBearerAuthenticator(t)
+				balanceGetter = mocks.NewWalletBalanceGetter(t)
+			)
+
+			if test.beforeFunc != nil {
+				test.beforeFunc(authenticator, balanceGetter)
+			}
+
+			router := handlers.NewRouter()
+			router.RequireBearerTokens(authenticator)
+			router.Register(handlers.NewWalletBalanceGetterHandler(balanceGetter).Routes()...)
+
+			req := httptest.NewRequest(http.MethodGet, test.path, nil)
+			req.Header.Set("Authorization", test.authorization)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			require.Equal(t, test.wantStatusCode, w.Code, w.Body.String())
+
+			if test.wantAuthenticate {
+				assert.Equal(t, "Bearer", w.Header().Get("WWW-Authenticate"))
+			}
+		})
+	}
+}
+
+func TestRouter_RequireTenantWallets(t *testing.T) {
+	t.Parallel()
+
+	adminKey := aggregates.APIKey{
+		ID:     "admin",
+		Tenant: "acme",
+		Scopes: []aggregates.Scope{aggregates.ScopeAdmin},
+	}
+
+	acme := mock.MatchedBy(func(ctx context.Context) bool {
+		return aggregates.TenantFromContext(ctx) == "acme"
+	})
+
+	tests := []struct {
+		title          string
+		path           string
+		beforeFunc     func(*mocks.WalletOwnershipChecker, *mocks.WalletBalanceGetter)
+		wantStatusCode int
+		wantCode       string
+	}{
+		{
+			title: "wallet of the tenant",
+			path:  "/v1/wallets/acmeWallet/balance",
+			beforeFunc: func(checker *mocks.WalletOwnershipChecker, getter *mocks.WalletBalanceGetter) {
+				checker.On("HasWallet", acme, "acmeWallet").Return(true, nil)
+				getter.On("GetBalance", acme, "acmeWallet", "EUR").Return("EUR 12.34", nil)
+			},
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			title: "wallet of another tenant",
+			path:  "/v1/wallets/globexWallet/balance",
+			beforeFunc: func(checker *mocks.WalletOwnershipChecker, _ *mocks.WalletBalanceGetter) {
+				checker.On("HasWallet", acme, "globexWallet").Return(false, nil)
+			},
+			wantStatusCode: http.StatusNotFound,
+			wantCode:       handlers.ErrorCodeWalletNotFound,
+		},
+		{
+			title: "invalid public key",
+			path:  "/v1/wallets/invalid/balance",
+			beforeFunc: func(checker *mocks.WalletOwnershipChecker, _ *mocks.WalletBalanceGetter) {
+				checker.On("HasWallet", acme, "invalid").Return(false, aggregates.ErrInvalidPublicKey)
+			},
+			wantStatusCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.title, func(t *testing.T) {
+			t.Parallel()
+
+			var (
+				authenticator = mocks.NewAPIKeyAuthenticator(t)
+				checker       = mocks.NewWalletOwnershipChecker(t)
+				balanceGetter = mocks.NewWalletBalanceGetter(t)
+			)
+
+			authenticator.On("Authenticate", mock.Anything, "admin").Return(adminKey, nil)
+			test.beforeFunc(checker, balanceGetter)
+
+			router := handlers.NewRouter()
+			router.RequireAPIKeys(authenticator)
+			router.RequireTenantWallets(checker)
+			router.Register(handlers.NewWalletBalanceGetterHandler(balanceGetter).Routes()...)
+
+			req := httptest.NewRequest(http.MethodGet, test.path, nil)
+			req.Header.Set(handlers.APIKeyHeader, "admin")
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			require.Equal(t, test.wantStatusCode, w.Code, w.Body.String())
+
+			if test.wantCode != "" {
+				var response struct {
+					Code string `json:"code"`
+				}
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				assert.Equal(t, test.wantCode, response.Code)
+			}
+		})
+	}
+}
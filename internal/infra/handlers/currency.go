@@ -0,0 +1,18 @@
+package handlers
+
+import (
+	"strings"
+
+	"github.com/jcleira/coding-challenge/internal/domain/aggregates"
+)
+
+// requestCurrency returns the fiat currency of a request, as an upper cased
+// ISO 4217 code, defaulting to aggregates.DefaultCurrency when missing.
+func requestCurrency(currency string) string {
+	currency = strings.ToUpper(strings.TrimSpace(currency))
+	if currency == "" {
+		return aggregates.DefaultCurrency
+	}
+
+	return currency
+}
@@ -0,0 +1,217 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/jcleira/coding-challenge/internal/domain/aggregates"
+)
+
+// Error codes are the stable, machine readable codes of the error responses,
+// clients are expected to switch on them instead of the messages.
+const (
+	ErrorCodeInvalidRequest         = "invalid_request"
+	ErrorCodeInvalidAmount          = "invalid_amount"
+	ErrorCodeInvalidPublicKey       = "invalid_public_key"
+	ErrorCodeInvalidRateOverride    = "invalid_rate_override"
+	ErrorCodeCurrencyNotSupported   = "currency_not_supported"
+	ErrorCodeValuationNotSupported  = "valuation_not_supported"
+	ErrorCodeWalletNotFound         = "wallet_not_found"
+	ErrorCodeWalletRetired          = "wallet_retired"
+	ErrorCodeRateExpired            = "rate_expired"
+	ErrorCodeHistoricalRateNotFound = "historical_rate_not_found"
+	ErrorCodeConfirmationTimeout    = "confirmation_timeout"
+	ErrorCodeSolanaUnavailable      = "solana_unavailable"
+	ErrorCodeMethodNotAllowed       = "method_not_allowed"
+	ErrorCodeNotFound               = "not_found"
+	ErrorCodeInvalidSignature       = "invalid_signature"
+	ErrorCodeInternal               = "internal_error"
+	ErrorCodeStreamingNotSupported  = "streaming_not_supported"
+	ErrorCodeAuditLogTampered       = "audit_log_tampered"
+	ErrorCodeUnauthenticated        = "unauthenticated"
+	ErrorCodeForbidden              = "forbidden"
+	ErrorCodeInvalidScope           = "invalid_scope"
+	ErrorCodeAPIKeyNotFound         = "api_key_not_found"
+	ErrorCodeRateLimited            = "rate_limited"
+	ErrorCodeQuotaExceeded          = "quota_exceeded"
+)
+
+var (
+	// errMethodNotAllowed is returned when the endpoint doesn't support the
+	// request method.
+	errMethodNotAllowed = errors.New("method not allowed")
+
+	// errNotFound is returned when no endpoint matches the request path.
+	errNotFound = errors.New("not found")
+
+	// errStreamingNotSupported is returned when the response writer can't
+	// flush partial responses.
+	errStreamingNotSupported = errors.New("streaming not supported")
+)
+
+// httpErrorMapping is the http status, code and message of an error.
+type httpErrorMapping struct {
+	err     error
+	status  int
+	code    string
+	message string
+}
+
+// httpErrorMappings map the domain and handler errors to their http
+// responses, errors are matched with errors.Is in order, so wrapped errors
+// are mapped too. Unmapped errors are internal errors.
+var httpErrorMappings = []httpErrorMapping{
+	{errMethodNotAllowed, http.StatusMethodNotAllowed, ErrorCodeMethodNotAllowed, "Method not allowed"},
+	{errNotFound, http.StatusNotFound, ErrorCodeNotFound, "Not found"},
+	{errStreamingNotSupported, http.StatusInternalServerError, ErrorCodeStreamingNotSupported, "Streaming not supported"},
+	{aggregates.ErrInvalidAmount, http.StatusBadRequest, ErrorCodeInvalidAmount, "Invalid amount"},
+	{aggregates.ErrInvalidPublicKey, http.StatusBadRequest, ErrorCodeInvalidPublicKey, "Invalid public key"},
+	{aggregates.ErrInvalidSignature, http.StatusBadRequest, ErrorCodeInvalidSignature, "Invalid signature"},
+	{aggregates.ErrInvalidRateOverride, http.StatusBadRequest, ErrorCodeInvalidRateOverride, "Invalid rate override"},
+	{aggregates.ErrCurrencyNotSupported, http.StatusBadRequest, ErrorCodeCurrencyNotSupported, "Currency not supported"},
+	{aggregates.ErrValuationNotSupported, http.StatusBadRequest, ErrorCodeValuationNotSupported, "Valuation not supported"},
+	{aggregates.ErrWalletNotFound, http.StatusNotFound, ErrorCodeWalletNotFound, "Wallet not found"},
+	{aggregates.ErrWalletRetired, http.StatusConflict, ErrorCodeWalletRetired, "Wallet retired"},
+	{aggregates.ErrRateExpired, http.StatusUnprocessableEntity, ErrorCodeRateExpired, "Currency rate expired"},
+	{aggregates.ErrHistoricalRateNotFound, http.StatusUnprocessableEntity, ErrorCodeHistoricalRateNotFound, "Historical rate not found"},
+	{aggregates.ErrTransactionConfirmationTimeout, http.StatusGatewayTimeout, ErrorCodeConfirmationTimeout, "Transaction confirmation timeout"},
+	{aggregates.ErrSolanaUnavailable, http.StatusServiceUnavailable, ErrorCodeSolanaUnavailable, "Solana unavailable"},
+	{aggregates.ErrUnauthenticated, http.StatusUnauthorized, ErrorCodeUnauthenticated, "Unauthenticated"},
+	{aggregates.ErrForbidden, http.StatusForbidden, ErrorCodeForbidden, "Forbidden"},
+	{aggregates.ErrInvalidScope, http.StatusBadRequest, ErrorCodeInvalidScope, "Invalid scope"},
+	{aggregates.ErrAPIKeyNotFound, http.StatusNotFound, ErrorCodeAPIKeyNotFound, "API key not found"},
+	{aggregates.ErrRateLimited, http.StatusTooManyRequests, ErrorCodeRateLimited, "Too many requests"},
+	{aggregates.ErrQuotaExceeded, http.StatusTooManyRequests, ErrorCodeQuotaExceeded, "Daily quota exceeded"},
+	{aggregates.ErrAuditLogTampered, http.StatusInternalServerError, ErrorCodeAuditLogTampered, "Audit log tampered"},
+}
+
+// detailedError is an error with details for the error response, such as the
+// signature of a transaction that timed out confirming.
+type detailedError struct {
+	err     error
+	details map[string]string
+}
+
+// Error implements the error interface.
+func (e *detailedError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap returns the wrapped error, so it's still mapped.
+func (e *detailedError) Unwrap() error {
+	return e.err
+}
+
+// httpError is the error envelope of every error response.
+type httpError struct {
+	Code      string            `json:"code"`
+	Message   string            `json:"message"`
+	RequestID string            `json:"request_id"`
+	Details   map[string]string `json:"details,omitempty"`
+}
+
+// writeError writes the error envelope for err, with the status code of its
+// mapping. Validation errors name the offending field in the details, and
+// detailed errors add theirs.
+//
+// Internal errors are logged, but their causes aren't exposed to the client.
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	requestID := aggregates.RequestIDFromContext(r.Context())
+
+	status := http.StatusInternalServerError
+	response := httpError{
+		Code:      ErrorCodeInternal,
+		Message:   "Internal server error",
+		RequestID: requestID,
+	}
+
+	for _, mapping := range httpErrorMappings {
+		if errors.Is(err, mapping.err) {
+			status = mapping.status
+			response.Code = mapping.code
+			response.Message = mapping.message
+			break
+		}
+	}
+
+	var validationErr *aggregates.ValidationError
+	if errors.As(err, &validationErr) {
+		status = http.StatusBadRequest
+		if response.Code == ErrorCodeInternal {
+			response.Code = ErrorCodeInvalidRequest
+			response.Message = "Invalid request"
+		}
+
+		response.Details = map[string]string{
+			"field":  validationErr.Field,
+			"reason": validationErr.Err.Error(),
+		}
+	}
+
+	var detailedErr *detailedError
+	if errors.As(err, &detailedErr) {
+		if response.Details == nil {
+			response.Details = make(map[string]string, len(detailedErr.details))
+		}
+
+		for key, value := range detailedErr.details {
+			response.Details[key] = value
+		}
+	}
+
+	span := trace.SpanFromContext(r.Context())
+	span.SetAttributes(attribute.String("error.code", response.Code))
+
+	if status >= http.StatusInternalServerError {
+		slog.ErrorContext(r.Context(), "error handling request",
+			"error", err, "path", r.URL.Path)
+		span.RecordError(err)
+	}
+
+	body, marshalErr := json.Marshal(response)
+	if marshalErr != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	if _, err := w.Write(body); err != nil {
+		slog.ErrorContext(r.Context(), "error writing response", "error", err)
+	}
+}
+
+// invalidRequestBody returns the validation error for a request body that
+// can't be decoded.
+func invalidRequestBody(err error) error {
+	return aggregates.NewValidationError("body", err)
+}
+
+// Recoverer is a middleware that turns panics in the handlers into internal
+// error responses, instead of dropping the connection.
+func Recoverer(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			recovered := recover()
+			if recovered == nil {
+				return
+			}
+
+			// ErrAbortHandler is how handlers abort a response on purpose.
+			if recovered == http.ErrAbortHandler {
+				panic(recovered)
+			}
+
+			writeError(w, r, fmt.Errorf("panic: %v", recovered))
+		}()
+
+		next.ServeHTTP(w, r)
+	})
+}
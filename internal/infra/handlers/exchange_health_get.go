@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/jcleira/coding-challenge/internal/domain/aggregates"
+)
+
+// ExchangeHealthGetter defines the methods to get the exchange rates health.
+type ExchangeHealthGetter interface {
+	GetHealth() aggregates.RateHealth
+}
+
+// ExchangeHealthGetterHandler handles the exchange rates health requests.
+type ExchangeHealthGetterHandler struct {
+	getter ExchangeHealthGetter
+}
+
+// NewExchangeHealthGetterHandler creates a new ExchangeHealthGetterHandler.
+func NewExchangeHealthGetterHandler(getter ExchangeHealthGetter) *ExchangeHealthGetterHandler {
+	return &ExchangeHealthGetterHandler{
+		getter: getter,
+	}
+}
+
+// Handler is the http handler func for the exchange rates health.
+//
+// It responds with 503 Service Unavailable when the rates are expired, so it
+// can be used as a readiness probe, stale rates are still usable.
+func (h *ExchangeHealthGetterHandler) Handler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		health := h.getter.GetHealth()
+
+		response, err := json.Marshal(httpRateHealthFromDomainHealth(health))
+		if err != nil {
+			writeError(w, r, fmt.Errorf("error marshalling response: %w", err))
+			return
+		}
+
+		status := http.StatusOK
+		if health.State == aggregates.RateHealthExpired {
+			status = http.StatusServiceUnavailable
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+
+		if _, err := w.Write(response); err != nil {
+			slog.ErrorContext(r.Context(), "error writing response", "error", err)
+		}
+	}
+}
+
+// httpRateHealth is the http version for the domain rate health.
+type httpRateHealth struct {
+	State               string     `json:"state"`
+	LastSuccess         *time.Time `json:"last_success,omitempty"`
+	LastError           string     `json:"last_error,omitempty"`
+	LastErrorAt         *time.Time `json:"last_error_at,omitempty"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	OpenCircuits        []string   `json:"open_circuits,omitempty"`
+}
+
+// httpRateHealthFromDomainHealth converts a domain rate health to an http
+// rate health, zero times are omitted.
+func httpRateHealthFromDomainHealth(health aggregates.RateHealth) httpRateHealth {
+	httpHealth := httpRateHealth{
+		State:               string(health.State),
+		LastError:           health.LastError,
+		ConsecutiveFailures: health.ConsecutiveFailures,
+		OpenCircuits:        health.OpenCircuits,
+	}
+
+	if !health.LastSuccess.IsZero() {
+		httpHealth.LastSuccess = &health.LastSuccess
+	}
+
+	if !health.LastErrorAt.IsZero() {
+		httpHealth.LastErrorAt = &health.LastErrorAt
+	}
+
+	return httpHealth
+}
+
+// Routes returns the routes of the exchange health.
+func (h *ExchangeHealthGetterHandler) Routes() []Route {
+	return []Route{
+		{
+			Method:   http.MethodGet,
+			Path:     "/exchange_rate/health",
+			Summary:  "Get the health of the exchange rates, answered with 503 when expired",
+			Response: httpRateHealth{},
+			Handler:  h.Handler(),
+		},
+	}
+}
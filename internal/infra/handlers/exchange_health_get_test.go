@@ -0,0 +1,89 @@
+package handlers_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bradleyjkemp/cupaloy"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jcleira/coding-challenge/internal/domain/aggregates"
+	"github.com/jcleira/coding-challenge/internal/infra/handlers"
+	"github.com/jcleira/coding-challenge/mocks"
+)
+
+func TestExchangeHealthGetterHandler_Handle(t *testing.T) {
+	t.Parallel()
+
+	lastSuccess := time.Date(2023, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		title          string
+		health         aggregates.RateHealth
+		wantStatusCode int
+	}{
+		{
+			title: "fresh",
+			health: aggregates.RateHealth{
+				State:       aggregates.RateHealthFresh,
+				LastSuccess: lastSuccess,
+			},
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			title: "stale",
+			health: aggregates.RateHealth{
+				State:               aggregates.RateHealthStale,
+				LastSuccess:         lastSuccess,
+				LastError:           "error fetching EUR rate",
+				LastErrorAt:         lastSuccess.Add(10 * time.Second),
+				ConsecutiveFailures: 2,
+				OpenCircuits:        []string{"kraken/EUR"},
+			},
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			title: "expired",
+			health: aggregates.RateHealth{
+				State:               aggregates.RateHealthExpired,
+				LastError:           "error fetching EUR rate",
+				LastErrorAt:         lastSuccess,
+				ConsecutiveFailures: 1,
+			},
+			wantStatusCode: http.StatusServiceUnavailable,
+		},
+	}
+
+	cupaloy := cupaloy.New(
+		cupaloy.SnapshotSubdirectory("./.snapshots/exchange-health-test"))
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.title, func(t *testing.T) {
+			t.Parallel()
+
+			getter := mocks.NewExchangeHealthGetter(t)
+			getter.On("GetHealth").Return(test.health)
+
+			handler := handlers.NewExchangeHealthGetterHandler(getter)
+
+			server := httptest.NewServer(handler.Handler())
+			defer server.Close()
+
+			resp, err := http.Get(server.URL)
+			require.NoError(t, err)
+
+			require.Equal(t, test.wantStatusCode, resp.StatusCode)
+
+			body, err := ioutil.ReadAll(resp.Body)
+			require.NoError(t, err)
+
+			require.NoError(t, cupaloy.SnapshotMulti(
+				getSnapshotFileName(test.title),
+				string(body)))
+		})
+	}
+}
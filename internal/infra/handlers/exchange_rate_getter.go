@@ -1,16 +1,21 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"log/slog"
 	"net/http"
+	"strings"
 
 	"github.com/jcleira/coding-challenge/internal/domain/aggregates"
 )
 
 // ExchangeRateGetter defines the methods to get exchange rates.
 type ExchangeRateGetter interface {
-	GetRate() (float64, error)
+	GetRate(ctx context.Context, currency string) (aggregates.Rate, error)
 }
 
 // ExchangeRateGetterHandler handles the exchange rate getter.
@@ -26,40 +31,85 @@ func NewExchangeRateGetterHandler(getter ExchangeRateGetter) *ExchangeRateGetter
 }
 
 // Handler handles the exchange rate getter.
+//
+// The request body is optional, as the original endpoint didn't take any, the
+// rate is returned under a sol_<currency> key, such as sol_eur, along with the
+// sources that contributed to it, and whether it's a rate overridden by hand.
 func (h *ExchangeRateGetterHandler) Handler() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		rate, err := h.getter.GetRate()
+		request := httpExchangeRateRequest{}
+
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil && !errors.Is(err, io.EOF) {
+			writeError(w, r, invalidRequestBody(err))
+			return
+		}
+
+		currency := requestCurrency(request.Currency)
+
+		rate, err := h.getter.GetRate(r.Context(), currency)
 		if err != nil {
-			switch err {
-			case aggregates.ErrCurrencyNotSupported:
-				http.Error(w, "Currency not supported", http.StatusBadRequest)
-				return
-			case aggregates.ErrRateExpired:
-				http.Error(w, "Currency Rate Expired", http.StatusUnprocessableEntity)
-				return
-			default:
-				http.Error(w, "Error getting exchange rate", http.StatusInternalServerError)
-				return
-			}
+			writeError(w, r, err)
+			return
 		}
 
+		// Ignoring exact checks here
+		value, _ := rate.Value.Float64()
+
 		response, err := json.Marshal(
-			struct {
-				Rate float64 `json:"sol_eur"`
-			}{
-				Rate: rate,
+			map[string]interface{}{
+				"sol_" + strings.ToLower(currency): value,
+				"sources":                          rate.Sources,
+				"overridden":                       rate.Overridden,
 			},
 		)
 		if err != nil {
-			http.Error(w, "Error marshalling response", http.StatusInternalServerError)
+			writeError(w, r, fmt.Errorf("error marshalling response: %w", err))
 			return
 		}
 
-		w.WriteHeader(http.StatusOK)
 		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
 
 		if _, err = w.Write(response); err != nil {
-			slog.Error("Error writing response", err)
+			slog.ErrorContext(r.Context(), "error writing response", "error", err)
 		}
 	}
 }
+
+// httpExchangeRateRequest is the http request for an exchange rate.
+type httpExchangeRateRequest struct {
+	Currency string `json:"currency,omitempty"`
+}
+
+// httpExchangeRateResponseSchema is the schema of the exchange rate
+// responses, whose rate property is named after the currency, such as
+// sol_eur.
+var httpExchangeRateResponseSchema = &Schema{
+	Type: "object",
+	Properties: map[string]*Schema{
+		"sources":    {Type: "array", Items: &Schema{Type: "string"}, Nullable: true},
+		"overridden": {Type: "boolean"},
+	},
+	Required: []string{"sources", "overridden"},
+	AdditionalProperties: &Schema{
+		Type:        "number",
+		Description: "The SOL rate in the currency, named sol_ followed by the lower cased currency",
+	},
+}
+
+// Routes returns the routes of the exchange rates.
+func (h *ExchangeRateGetterHandler) Routes() []Route {
+	routes := make([]Route, 0, 2)
+	for _, method := range []string{http.MethodGet, http.MethodPost} {
+		routes = append(routes, Route{
+			Method:   method,
+			Path:     "/exchange_rate",
+			Summary:  "Get the SOL exchange rate, in EUR unless another currency is requested",
+			Request:  httpExchangeRateRequest{},
+			Response: httpExchangeRateResponseSchema,
+			Handler:  h.Handler(),
+		})
+	}
+
+	return routes
+}
@@ -4,11 +4,13 @@ import (
 	"errors"
 	"fmt"
 	"io/ioutil"
+	"math/big"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
 	"github.com/bradleyjkemp/cupaloy"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 
 	"github.com/jcleira/coding-challenge/internal/domain/aggregates"
@@ -31,32 +33,36 @@ func TestExchangeRateGetterHandler_Handle(t *testing.T) {
 		{
 			title: "success",
 			beforeFunc: func(s *settingsTestExchangeRateHandler) {
-				s.exchangeRateGetter.On("GetRate").
-					Return(1.2345, nil)
+				s.exchangeRateGetter.On("GetRate", mock.Anything, "EUR").
+					Return(aggregates.Rate{
+						Currency: "EUR",
+						Value:    big.NewRat(12345, 10000),
+						Sources:  []string{"coinbase", "kraken"},
+					}, nil)
 			},
 			wantStatusCode: http.StatusOK,
 		},
 		{
 			title: "currency not supported",
 			beforeFunc: func(s *settingsTestExchangeRateHandler) {
-				s.exchangeRateGetter.On("GetRate").
-					Return(0.0, aggregates.ErrCurrencyNotSupported)
+				s.exchangeRateGetter.On("GetRate", mock.Anything, "EUR").
+					Return(aggregates.Rate{}, aggregates.ErrCurrencyNotSupported)
 			},
 			wantStatusCode: http.StatusBadRequest,
 		},
 		{
 			title: "rate expired error",
 			beforeFunc: func(s *settingsTestExchangeRateHandler) {
-				s.exchangeRateGetter.On("GetRate").
-					Return(0.0, aggregates.ErrRateExpired)
+				s.exchangeRateGetter.On("GetRate", mock.Anything, "EUR").
+					Return(aggregates.Rate{}, aggregates.ErrRateExpired)
 			},
 			wantStatusCode: http.StatusUnprocessableEntity,
 		},
 		{
 			title: "internal server error",
 			beforeFunc: func(s *settingsTestExchangeRateHandler) {
-				s.exchangeRateGetter.On("GetRate").
-					Return(0.0, errors.New("internal error"))
+				s.exchangeRateGetter.On("GetRate", mock.Anything, "EUR").
+					Return(aggregates.Rate{}, errors.New("internal error"))
 			},
 			wantStatusCode: http.StatusInternalServerError,
 		},
@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"time"
+
+	"github.com/jcleira/coding-challenge/internal/domain/aggregates"
+)
+
+// ExchangeRateOverrider defines the methods to override exchange rates by
+// hand.
+type ExchangeRateOverrider interface {
+	SetOverride(ctx context.Context,
+		currency string, value *big.Rat, ttl time.Duration) (aggregates.Rate, error)
+	ClearOverride(ctx context.Context, currency string) error
+}
+
+// ExchangeRateOverrideHandler define the dependencies handling exchange rate
+// override requests.
+type ExchangeRateOverrideHandler struct {
+	overrider ExchangeRateOverrider
+}
+
+// NewExchangeRateOverrideHandler creates a new ExchangeRateOverrideHandler.
+func NewExchangeRateOverrideHandler(overrider ExchangeRateOverrider) *ExchangeRateOverrideHandler {
+	return &ExchangeRateOverrideHandler{
+		overrider: overrider,
+	}
+}
+
+// Handler is the http handler func for the exchange rate overrides, POST sets
+// an override for a currency and DELETE removes it.
+//
+// The rate is a decimal string and the ttl a Go duration, such as 30m.
+func (h *ExchangeRateOverrideHandler) Handler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		request := httpRateOverrideRequest{}
+
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			writeError(w, r, invalidRequestBody(err))
+			return
+		}
+
+		currency := requestCurrency(request.Currency)
+
+		switch r.Method {
+		case http.MethodPost:
+			value, ok := new(big.Rat).SetString(request.Rate)
+			if !ok {
+				writeError(w, r, aggregates.NewValidationError("rate",
+					fmt.Errorf("%w: %q is not a number", aggregates.ErrInvalidRateOverride, request.Rate)))
+				return
+			}
+
+			ttl, err := time.ParseDuration(request.TTL)
+			if err != nil {
+				writeError(w, r, aggregates.NewValidationError("ttl",
+					fmt.Errorf("%w: %w", aggregates.ErrInvalidRateOverride, err)))
+				return
+			}
+
+			rate, err := h.overrider.SetOverride(r.Context(), currency, value, ttl)
+			if err != nil {
+				writeError(w, r, err)
+				return
+			}
+
+			// Ignoring exact checks here
+			floatValue, _ := rate.Value.Float64()
+
+			response, err := json.Marshal(
+				httpRateOverrideResponse{
+					Currency:   rate.Currency,
+					Rate:       floatValue,
+					ExpiredAt:  rate.ExpiredAt,
+					Overridden: rate.Overridden,
+				},
+			)
+			if err != nil {
+				writeError(w, r, fmt.Errorf("error marshalling response: %w", err))
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			if _, err := w.Write(response); err != nil {
+				slog.ErrorContext(r.Context(), "error writing response", "error", err)
+			}
+
+		case http.MethodDelete:
+			if err := h.overrider.ClearOverride(r.Context(), currency); err != nil {
+				writeError(w, r, err)
+				return
+			}
+
+			w.WriteHeader(http.StatusNoContent)
+
+		default:
+			writeError(w, r, errMethodNotAllowed)
+		}
+	}
+}
+
+// httpRateOverrideRequest is the http request setting a rate override, the
+// clearing requests are decoded with it too, ignoring the rate and ttl.
+type httpRateOverrideRequest struct {
+	Currency string `json:"currency,omitempty"`
+	Rate     string `json:"rate" description:"Decimal rate, such as 85.10"`
+	TTL      string `json:"ttl" description:"Go duration, such as 30m"`
+}
+
+// httpRateClearRequest is the http request clearing a rate override.
+type httpRateClearRequest struct {
+	Currency string `json:"currency,omitempty"`
+}
+
+// httpRateOverrideResponse is the http version for an overridden rate.
+type httpRateOverrideResponse struct {
+	Currency   string    `json:"currency"`
+	Rate       float64   `json:"rate"`
+	ExpiredAt  time.Time `json:"expired_at"`
+	Overridden bool      `json:"overridden"`
+}
+
+// Routes returns the routes of the exchange rate overrides.
+func (h *ExchangeRateOverrideHandler) Routes() []Route {
+	return []Route{
+		{
+			Method:   http.MethodPost,
+			Path:     "/admin/exchange_rate/override",
+			Summary:  "Override the exchange rate of a currency for a while",
+			Request:  httpRateOverrideRequest{},
+			Response: httpRateOverrideResponse{},
+			Scope:    aggregates.ScopeAdmin,
+			Handler:  h.Handler(),
+		},
+		{
+			Method:  http.MethodDelete,
+			Path:    "/admin/exchange_rate/override",
+			Summary: "Clear the exchange rate override of a currency",
+			Request: httpRateClearRequest{},
+			Status:  http.StatusNoContent,
+			Scope:   aggregates.ScopeAdmin,
+			Handler: h.Handler(),
+		},
+	}
+}
@@ -0,0 +1,145 @@
+package handlers_test
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bradleyjkemp/cupaloy"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jcleira/coding-challenge/internal/domain/aggregates"
+	"github.com/jcleira/coding-challenge/internal/infra/handlers"
+	"github.com/jcleira/coding-challenge/mocks"
+)
+
+func TestExchangeRateOverrideHandler_Handle(t *testing.T) {
+	t.Parallel()
+
+	expiredAt := time.Date(2023, 10, 1, 13, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		title          string
+		method         string
+		requestBody    string
+		beforeFunc     func(*mocks.ExchangeRateOverrider)
+		wantStatusCode int
+	}{
+		{
+			title:       "successful override",
+			method:      http.MethodPost,
+			requestBody: `{"currency":"eur","rate":"85.10","ttl":"1h"}`,
+			beforeFunc: func(overrider *mocks.ExchangeRateOverrider) {
+				overrider.On("SetOverride", mock.Anything, "EUR", big.NewRat(851, 10), time.Hour).
+					Return(aggregates.Rate{
+						Currency:   "EUR",
+						Value:      big.NewRat(851, 10),
+						Sources:    []string{"override"},
+						ExpiredAt:  expiredAt,
+						Overridden: true,
+					}, nil)
+			},
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			title:          "invalid rate",
+			method:         http.MethodPost,
+			requestBody:    `{"currency":"EUR","rate":"abc","ttl":"1h"}`,
+			beforeFunc:     func(*mocks.ExchangeRateOverrider) {},
+			wantStatusCode: http.StatusBadRequest,
+		},
+		{
+			title:          "invalid ttl",
+			method:         http.MethodPost,
+			requestBody:    `{"currency":"EUR","rate":"85.10","ttl":"forever"}`,
+			beforeFunc:     func(*mocks.ExchangeRateOverrider) {},
+			wantStatusCode: http.StatusBadRequest,
+		},
+		{
+			title:       "invalid override",
+			method:      http.MethodPost,
+			requestBody: `{"currency":"EUR","rate":"0","ttl":"1h"}`,
+			beforeFunc: func(overrider *mocks.ExchangeRateOverrider) {
+				overrider.On("SetOverride", mock.Anything, "EUR", big.NewRat(0, 1), time.Hour).
+					Return(aggregates.Rate{}, aggregates.ErrInvalidRateOverride)
+			},
+			wantStatusCode: http.StatusBadRequest,
+		},
+		{
+			title:       "successful clear",
+			method:      http.MethodDelete,
+			requestBody: `{"currency":"EUR"}`,
+			beforeFunc: func(overrider *mocks.ExchangeRateOverrider) {
+				overrider.On("ClearOverride", mock.Anything, "EUR").Return(nil)
+			},
+			wantStatusCode: http.StatusNoContent,
+		},
+		{
+			title:       "currency not supported on clear",
+			method:      http.MethodDelete,
+			requestBody: `{"currency":"JPY"}`,
+			beforeFunc: func(overrider *mocks.ExchangeRateOverrider) {
+				overrider.On("ClearOverride", mock.Anything, "JPY").
+					Return(aggregates.ErrCurrencyNotSupported)
+			},
+			wantStatusCode: http.StatusBadRequest,
+		},
+		{
+			title:       "internal server error on clear",
+			method:      http.MethodDelete,
+			requestBody: `{"currency":"EUR"}`,
+			beforeFunc: func(overrider *mocks.ExchangeRateOverrider) {
+				overrider.On("ClearOverride", mock.Anything, "EUR").
+					Return(errors.New("internal server error"))
+			},
+			wantStatusCode: http.StatusInternalServerError,
+		},
+		{
+			title:          "method not allowed",
+			method:         http.MethodPut,
+			requestBody:    `{"currency":"EUR"}`,
+			beforeFunc:     func(*mocks.ExchangeRateOverrider) {},
+			wantStatusCode: http.StatusMethodNotAllowed,
+		},
+	}
+
+	cupaloy := cupaloy.New(
+		cupaloy.SnapshotSubdirectory("./.snapshots/exchange-rate-override-test"))
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.title, func(t *testing.T) {
+			t.Parallel()
+
+			overrider := mocks.NewExchangeRateOverrider(t)
+			test.beforeFunc(overrider)
+
+			handler := handlers.NewExchangeRateOverrideHandler(overrider)
+
+			server := httptest.NewServer(handler.Handler())
+			defer server.Close()
+
+			req, err := http.NewRequest(test.method, server.URL,
+				bytes.NewBufferString(test.requestBody))
+			require.NoError(t, err)
+
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+
+			require.Equal(t, test.wantStatusCode, resp.StatusCode)
+
+			body, err := ioutil.ReadAll(resp.Body)
+			require.NoError(t, err)
+
+			require.NoError(t, cupaloy.SnapshotMulti(
+				getSnapshotFileName(test.title),
+				string(body)))
+		})
+	}
+}
@@ -0,0 +1,234 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/jcleira/coding-challenge/internal/domain/aggregates"
+)
+
+const (
+	// rateStreamHeartbeat is the time between heartbeats on an idle stream,
+	// it keeps proxies from closing the connection and detects dead clients.
+	rateStreamHeartbeat = 15 * time.Second
+
+	// rateStreamWriteTimeout is the maximum time a client can take to accept
+	// a message, a slower client is disconnected.
+	rateStreamWriteTimeout = 10 * time.Second
+)
+
+// ExchangeRateStreamer defines the methods to subscribe to exchange rate
+// updates.
+type ExchangeRateStreamer interface {
+	Subscribe() (<-chan aggregates.Rate, func())
+}
+
+// ExchangeRateStreamHandler handles the exchange rate stream.
+type ExchangeRateStreamHandler struct {
+	streamer ExchangeRateStreamer
+	upgrader websocket.Upgrader
+}
+
+// NewExchangeRateStreamHandler creates a new ExchangeRateStreamHandler.
+func NewExchangeRateStreamHandler(streamer ExchangeRateStreamer) *ExchangeRateStreamHandler {
+	return &ExchangeRateStreamHandler{
+		streamer: streamer,
+	}
+}
+
+// Handler handles the exchange rate stream, pushing every rate update to the
+// client.
+//
+// Updates are sent as Server-Sent Events, or as WebSocket messages when the
+// request is a WebSocket upgrade. The optional currency query parameter limits
+// the updates to a single fiat currency.
+//
+// Clients that can't keep up with the updates are disconnected, they're
+// expected to reconnect, as EventSource does by default.
+func (h *ExchangeRateStreamHandler) Handler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		currency := strings.ToUpper(strings.TrimSpace(r.URL.Query().Get("currency")))
+
+		if websocket.IsWebSocketUpgrade(r) {
+			h.serveWebSocket(w, r, currency)
+			return
+		}
+
+		h.serveSSE(w, r, currency)
+	}
+}
+
+// serveSSE streams the rate updates as Server-Sent Events.
+func (h *ExchangeRateStreamHandler) serveSSE(
+	w http.ResponseWriter, r *http.Request, currency string) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, r, errStreamingNotSupported)
+		return
+	}
+
+	updates, unsubscribe := h.streamer.Subscribe()
+	defer unsubscribe()
+
+	controller := http.NewResponseController(w)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(rateStreamHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		var message string
+
+		select {
+		case rate, ok := <-updates:
+			if !ok {
+				return
+			}
+
+			if currency != "" && rate.Currency != currency {
+				continue
+			}
+
+			data, err := json.Marshal(httpRateUpdateFromDomainRate(rate))
+			if err != nil {
+				slog.ErrorContext(r.Context(), "error marshalling rate update", "error", err)
+				continue
+			}
+
+			message = fmt.Sprintf("event: rate\ndata: %s\n\n", data)
+
+		case <-heartbeat.C:
+			message = ": heartbeat\n\n"
+
+		case <-r.Context().Done():
+			return
+		}
+
+		// Not every ResponseWriter supports deadlines, in that case a slow
+		// client is only detected once its updates buffer fills up.
+		_ = controller.SetWriteDeadline(time.Now().Add(rateStreamWriteTimeout))
+
+		if _, err := fmt.Fprint(w, message); err != nil {
+			slog.WarnContext(r.Context(), "error writing rate update", "error", err)
+			return
+		}
+
+		flusher.Flush()
+	}
+}
+
+// serveWebSocket streams the rate updates as WebSocket text messages, one
+// JSON rate update per message.
+func (h *ExchangeRateStreamHandler) serveWebSocket(
+	w http.ResponseWriter, r *http.Request, currency string) {
+	conn, err := h.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// The upgrader already replied with an error.
+		slog.WarnContext(r.Context(), "error upgrading to websocket", "error", err)
+		return
+	}
+	defer conn.Close()
+
+	updates, unsubscribe := h.streamer.Subscribe()
+	defer unsubscribe()
+
+	// Reading is required to process the control messages, the client isn't
+	// expected to send anything else, so the connection is done on any error.
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+
+	heartbeat := time.NewTicker(rateStreamHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case rate, ok := <-updates:
+			if !ok {
+				_ = conn.WriteControl(websocket.CloseMessage,
+					websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "too slow"),
+					time.Now().Add(rateStreamWriteTimeout))
+				return
+			}
+
+			if currency != "" && rate.Currency != currency {
+				continue
+			}
+
+			_ = conn.SetWriteDeadline(time.Now().Add(rateStreamWriteTimeout))
+
+			if err := conn.WriteJSON(httpRateUpdateFromDomainRate(rate)); err != nil {
+				slog.WarnContext(r.Context(), "error writing rate update", "error", err)
+				return
+			}
+
+		case <-heartbeat.C:
+			err := conn.WriteControl(websocket.PingMessage, nil,
+				time.Now().Add(rateStreamWriteTimeout))
+			if err != nil {
+				return
+			}
+
+		case <-closed:
+			return
+		}
+	}
+}
+
+// httpRateUpdate is the http version for a domain rate update.
+type httpRateUpdate struct {
+	Currency  string    `json:"currency"`
+	Value     float64   `json:"value"`
+	Sources   []string  `json:"sources"`
+	Time      time.Time `json:"time"`
+	ExpiredAt time.Time `json:"expired_at"`
+}
+
+// httpRateUpdateFromDomainRate converts a domain rate to an http rate update.
+func httpRateUpdateFromDomainRate(rate aggregates.Rate) httpRateUpdate {
+	// Ignoring exact checks here, as in the exchange rate endpoint.
+	value, _ := rate.Value.Float64()
+
+	return httpRateUpdate{
+		Currency:  rate.Currency,
+		Value:     value,
+		Sources:   rate.Sources,
+		Time:      rate.Time,
+		ExpiredAt: rate.ExpiredAt,
+	}
+}
+
+// Routes returns the routes of the exchange rate stream.
+func (h *ExchangeRateStreamHandler) Routes() []Route {
+	return []Route{
+		{
+			Method:  http.MethodGet,
+			Path:    "/exchange_rate/stream",
+			Summary: "Stream the exchange rate updates as Server-Sent Events, or WebSocket messages on upgrade",
+			Parameters: []Parameter{
+				queryParameter("currency", "Only stream the updates of this currency", "string"),
+			},
+			Response:    httpRateUpdate{},
+			ContentType: "text/event-stream",
+			Handler:     h.Handler(),
+		},
+	}
+}
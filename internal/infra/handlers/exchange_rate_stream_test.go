@@ -0,0 +1,97 @@
+package handlers_test
+
+import (
+	"bufio"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jcleira/coding-challenge/internal/domain/aggregates"
+	"github.com/jcleira/coding-challenge/internal/infra/handlers"
+	"github.com/jcleira/coding-challenge/mocks"
+)
+
+// newTestRateStream returns a stream handler server whose subscribers receive
+// an EUR and a USD update, in that order.
+func newTestRateStream(t *testing.T) *httptest.Server {
+	rateTime := time.Date(2023, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	updates := make(chan aggregates.Rate, 2)
+	for _, currency := range []string{"EUR", "USD"} {
+		updates <- aggregates.Rate{
+			Currency:  currency,
+			Value:     big.NewRat(8525, 100),
+			Sources:   []string{"kraken"},
+			Time:      rateTime,
+			ExpiredAt: rateTime.Add(20 * time.Second),
+		}
+	}
+
+	streamer := mocks.NewExchangeRateStreamer(t)
+	streamer.On("Subscribe").Return((<-chan aggregates.Rate)(updates), func() {})
+
+	server := httptest.NewServer(handlers.NewExchangeRateStreamHandler(streamer).Handler())
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestExchangeRateStreamHandler_SSE(t *testing.T) {
+	t.Parallel()
+
+	server := newTestRateStream(t)
+
+	resp, err := http.Get(server.URL + "?currency=usd")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	reader := bufio.NewReader(resp.Body)
+
+	var event []string
+	for {
+		line, err := reader.ReadString('\n')
+		require.NoError(t, err)
+
+		line = strings.TrimSuffix(line, "\n")
+		if line == "" {
+			break
+		}
+
+		event = append(event, line)
+	}
+
+	assert.Equal(t, []string{
+		"event: rate",
+		`data: {"currency":"USD","value":85.25,"sources":["kraken"],` +
+			`"time":"2023-10-01T12:00:00Z","expired_at":"2023-10-01T12:00:20Z"}`,
+	}, event)
+}
+
+func TestExchangeRateStreamHandler_WebSocket(t *testing.T) {
+	t.Parallel()
+
+	server := newTestRateStream(t)
+
+	conn, _, err := websocket.DefaultDialer.Dial(
+		"ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	for _, currency := range []string{"EUR", "USD"} {
+		var update map[string]interface{}
+		require.NoError(t, conn.ReadJSON(&update))
+
+		assert.Equal(t, currency, update["currency"])
+		assert.Equal(t, 85.25, update["value"])
+	}
+}
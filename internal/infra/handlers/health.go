@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/jcleira/coding-challenge/internal/domain/aggregates"
+)
+
+// readinessTimeout bounds the readiness checks, so a hanging dependency
+// fails the probe instead of timing it out.
+const readinessTimeout = 2 * time.Second
+
+const (
+	// healthStatusOK is the status of a live process, or of a ready service
+	// and its available dependencies.
+	healthStatusOK = "ok"
+
+	// healthStatusUnavailable is the status of an unready service and its
+	// unavailable dependencies.
+	healthStatusUnavailable = "unavailable"
+
+	// healthStatusDraining is the status of a service shutting down.
+	healthStatusDraining = "draining"
+)
+
+// ReadinessChecker defines the methods to check the readiness of the
+// service.
+type ReadinessChecker interface {
+	CheckReadiness(ctx context.Context) aggregates.Readiness
+}
+
+// HealthHandler handles the liveness and readiness probes.
+type HealthHandler struct {
+	checker ReadinessChecker
+
+	// draining is set once the service is shutting down.
+	draining atomic.Bool
+}
+
+// NewHealthHandler creates a new HealthHandler.
+func NewHealthHandler(checker ReadinessChecker) *HealthHandler {
+	return &HealthHandler{
+		checker: checker,
+	}
+}
+
+// Drain makes the readiness probe fail from now on, so the load balancer
+// stops sending new requests while the in-flight ones finish.
+func (h *HealthHandler) Drain() {
+	h.draining.Store(true)
+}
+
+// Liveness is the http handler func for the liveness probe, it succeeds as
+// long as the process serves requests, whatever the state of its
+// dependencies, so it isn't restarted for an outage it can't fix.
+func (h *HealthHandler) Liveness() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		writeHealth(w, r, http.StatusOK, httpHealth{Status: healthStatusOK})
+	}
+}
+
+// Readiness is the http handler func for the readiness probe, it responds
+// with 503 Service Unavailable when some dependency is unavailable, or when
+// the service is shutting down.
+func (h *HealthHandler) Readiness() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if h.draining.Load() {
+			writeHealth(w, r, http.StatusServiceUnavailable,
+				httpHealth{Status: healthStatusDraining})
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), readinessTimeout)
+		defer cancel()
+
+		readiness := h.checker.CheckReadiness(ctx)
+
+		status := http.StatusOK
+		if !readiness.Ready() {
+			status = http.StatusServiceUnavailable
+		}
+
+		writeHealth(w, r, status, httpHealthFromDomainReadiness(readiness))
+	}
+}
+
+// httpHealth is the http version for the domain readiness.
+type httpHealth struct {
+	Status string            `json:"status"`
+	Checks []httpHealthCheck `json:"checks,omitempty"`
+}
+
+// httpHealthCheck is the http version for a domain readiness check.
+type httpHealthCheck struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// httpHealthFromDomainReadiness converts a domain readiness to an http
+// health.
+func httpHealthFromDomainReadiness(readiness aggregates.Readiness) httpHealth {
+	health := httpHealth{Status: healthStatusOK}
+	if !readiness.Ready() {
+		health.Status = healthStatusUnavailable
+	}
+
+	for _, check := range readiness.Checks {
+		httpCheck := httpHealthCheck{Name: check.Name, Status: healthStatusOK}
+		if check.Err != nil {
+			httpCheck.Status = healthStatusUnavailable
+			httpCheck.Error = check.Err.Error()
+		}
+
+		health.Checks = append(health.Checks, httpCheck)
+	}
+
+	return health
+}
+
+// writeHealth writes the health with the status code.
+func writeHealth(w http.ResponseWriter, r *http.Request, status int, health httpHealth) {
+	response, err := json.Marshal(health)
+	if err != nil {
+		writeError(w, r, fmt.Errorf("error marshalling response: %w", err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+
+	if _, err := w.Write(response); err != nil {
+		slog.ErrorContext(r.Context(), "error writing response", "error", err)
+	}
+}
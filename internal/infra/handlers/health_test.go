@@ -0,0 +1,107 @@
+package handlers_test
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bradleyjkemp/cupaloy"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jcleira/coding-challenge/internal/domain/aggregates"
+	"github.com/jcleira/coding-challenge/internal/infra/handlers"
+	"github.com/jcleira/coding-challenge/mocks"
+)
+
+func TestHealthHandler(t *testing.T) {
+	t.Parallel()
+
+	ready := aggregates.Readiness{
+		Checks: []aggregates.ReadinessCheck{
+			{Name: aggregates.ReadinessCheckSolana},
+			{Name: aggregates.ReadinessCheckExchange},
+			{Name: aggregates.ReadinessCheckVault},
+		},
+	}
+
+	unready := aggregates.Readiness{
+		Checks: []aggregates.ReadinessCheck{
+			{Name: aggregates.ReadinessCheckSolana, Err: errors.New("error getting RPC health: 429 Too Many Requests")},
+			{Name: aggregates.ReadinessCheckExchange, Err: aggregates.ErrRateExpired},
+			{Name: aggregates.ReadinessCheckVault},
+		},
+	}
+
+	tests := []struct {
+		title          string
+		liveness       bool
+		readiness      *aggregates.Readiness
+		draining       bool
+		wantStatusCode int
+	}{
+		{
+			title:          "liveness",
+			liveness:       true,
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			title:          "ready",
+			readiness:      &ready,
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			title:          "unready",
+			readiness:      &unready,
+			wantStatusCode: http.StatusServiceUnavailable,
+		},
+		{
+			title:          "draining",
+			draining:       true,
+			wantStatusCode: http.StatusServiceUnavailable,
+		},
+	}
+
+	cupaloy := cupaloy.New(
+		cupaloy.SnapshotSubdirectory("./.snapshots/health-test"))
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.title, func(t *testing.T) {
+			t.Parallel()
+
+			checker := mocks.NewReadinessChecker(t)
+			if test.readiness != nil {
+				checker.On("CheckReadiness", mock.Anything).Return(*test.readiness)
+			}
+
+			handler := handlers.NewHealthHandler(checker)
+			if test.draining {
+				handler.Drain()
+			}
+
+			probe := handler.Readiness()
+			if test.liveness {
+				probe = handler.Liveness()
+			}
+
+			server := httptest.NewServer(probe)
+			defer server.Close()
+
+			resp, err := http.Get(server.URL)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			require.Equal(t, test.wantStatusCode, resp.StatusCode)
+
+			body, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+
+			require.NoError(t, cupaloy.SnapshotMulti(
+				getSnapshotFileName(test.title),
+				string(body)))
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package handlers_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jcleira/coding-challenge/internal/infra/handlers"
+	"github.com/jcleira/coding-challenge/internal/infra/metrics"
+)
+
+func TestRouter_Metrics(t *testing.T) {
+	t.Parallel()
+
+	router := handlers.NewRouter()
+	router.HandleFunc(http.MethodPost, "/v1/metrics-test/{pubkey}",
+		func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusCreated)
+		})
+	router.HandleFunc(http.MethodGet, "/v1/metrics-test/{pubkey}/stream",
+		func(w http.ResponseWriter, r *http.Request) {
+			flusher, ok := w.(http.Flusher)
+			require.True(t, ok)
+
+			_, _ = w.Write([]byte("event"))
+			flusher.Flush()
+		})
+
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	requests := []struct {
+		method string
+		path   string
+	}{
+		{method: http.MethodPost, path: "/v1/metrics-test/testPublicKey"},
+		{method: http.MethodGet, path: "/v1/metrics-test/testPublicKey/stream"},
+		{method: http.MethodGet, path: "/v1/metrics-test-unknown"},
+	}
+
+	for _, request := range requests {
+		req, err := http.NewRequest(request.method, server.URL+request.path, nil)
+		require.NoError(t, err)
+
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+	}
+
+	recorder := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(recorder,
+		httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	body, err := io.ReadAll(recorder.Body)
+	require.NoError(t, err)
+
+	assert.Contains(t, string(body),
+		`http_requests_total{method="POST",route="/v1/metrics-test/{pubkey}",status="201"}`)
+	assert.Contains(t, string(body),
+		`http_requests_total{method="GET",route="/v1/metrics-test/{pubkey}/stream",status="200"}`)
+	assert.Contains(t, string(body),
+		`http_requests_total{method="GET",route="unmatched",status="404"}`)
+	assert.NotContains(t, string(body), "testPublicKey")
+}
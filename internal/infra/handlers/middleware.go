@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net"
+	"net/http"
+	"regexp"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/jcleira/coding-challenge/internal/domain/aggregates"
+)
+
+// RequestIDHeader is the header used to propagate request IDs.
+const RequestIDHeader = "X-Request-ID"
+
+// requestIDPattern are the request IDs taken from the clients, they end up in
+// the logs, the traces and the audit log, so anything else is replaced.
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._-]{1,128}$`)
+
+// tracer is the tracer of the HTTP server spans.
+var tracer = otel.Tracer("github.com/jcleira/coding-challenge/internal/infra/handlers")
+
+// RequestContext is a middleware that stores the request scoped values the
+// domain layer relies on in the request context, such as the request ID and
+// the actor performing the request.
+//
+// The request ID is taken from the X-Request-ID header, or generated when
+// missing or not matching requestIDPattern, and echoed back in the response. Until requests are authenticated
+// the actor is the client address.
+func RequestContext(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := r.Header.Get(RequestIDHeader)
+		if !requestIDPattern.MatchString(requestID) {
+			requestID = newRequestID()
+		}
+
+		w.Header().Set(RequestIDHeader, requestID)
+
+		ctx := aggregates.ContextWithRequestID(r.Context(), requestID)
+
+		if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+			ctx = aggregates.ContextWithActor(ctx, host)
+		}
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// Trace is a middleware that starts a server span for every request, which
+// the services and repositories continue through the request context. The
+// trace of the W3C traceparent header is continued when there is one.
+//
+// The span is named after the method until the Router renames it after the
+// route the request matched, responses with a 5xx status flag it as failed.
+func Trace(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(),
+			propagation.HeaderCarrier(r.Header))
+
+		ctx, span := tracer.Start(ctx, r.Method,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(r.Method),
+				semconv.URLPath(r.URL.Path),
+				attribute.String("request.id", aggregates.RequestIDFromContext(ctx)),
+			),
+		)
+		defer span.End()
+
+		recorder := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(recorder, r.WithContext(ctx))
+
+		status := recorder.Status()
+		span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+	})
+}
+
+// newRequestID generates a random request ID.
+func newRequestID() string {
+	b := make([]byte, 16)
+
+	// crypto/rand.Read never returns an error on the supported platforms.
+	_, _ = rand.Read(b)
+
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,184 @@
+package handlers_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/jcleira/coding-challenge/internal/domain/aggregates"
+	"github.com/jcleira/coding-challenge/internal/infra/handlers"
+)
+
+func TestRequestContext(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name          string
+		requestID     string
+		wantRequestID string
+	}{
+		{
+			name:          "propagates the request ID header",
+			requestID:     "testRequestID",
+			wantRequestID: "testRequestID",
+		},
+		{
+			name: "generates a request ID when missing",
+		},
+		{
+			name:      "replaces a request ID with invalid characters",
+			requestID: "id\" injected=\"value",
+		},
+		{
+			name:      "replaces a request ID too long",
+			requestID: strings.Repeat("a", 129),
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			var requestID, actor string
+
+			handler := handlers.RequestContext(http.HandlerFunc(
+				func(w http.ResponseWriter, r *http.Request) {
+					requestID = aggregates.RequestIDFromContext(r.Context())
+					actor = aggregates.ActorFromContext(r.Context())
+				}))
+
+			req := httptest.NewRequest(http.MethodPost, "/", nil)
+			if test.requestID != "" {
+				req.Header.Set(handlers.RequestIDHeader, test.requestID)
+			}
+
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, req)
+
+			assert.NotEmpty(t, requestID)
+			if test.wantRequestID != "" {
+				assert.Equal(t, test.wantRequestID, requestID)
+			} else {
+				assert.NotEqual(t, test.requestID, requestID)
+			}
+			assert.Equal(t, requestID, recorder.Header().Get(handlers.RequestIDHeader))
+			assert.Equal(t, "192.0.2.1", actor)
+		})
+	}
+}
+
+func TestRecoverer(t *testing.T) {
+	t.Parallel()
+
+	handler := handlers.RequestContext(handlers.Recoverer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			panic("test panic")
+		})))
+
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+	req.Header.Set(handlers.RequestIDHeader, "testRequestID")
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, req)
+
+	assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+	assert.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+	assert.JSONEq(t,
+		`{"code":"internal_error","message":"Internal server error","request_id":"testRequestID"}`,
+		recorder.Body.String())
+}
+
+func TestTrace(t *testing.T) {
+	spans := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	router := handlers.NewRouter()
+	router.HandleFunc(http.MethodGet, "/v1/wallets/{pubkey}/balance",
+		func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		})
+	router.HandleFunc(http.MethodGet, "/v1/wallets/{pubkey}/transactions",
+		func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadGateway)
+		})
+
+	handler := handlers.RequestContext(handlers.Trace(router))
+
+	tests := []struct {
+		name        string
+		path        string
+		traceparent string
+		wantName    string
+		wantStatus  int64
+		wantCode    codes.Code
+		wantTraceID string
+	}{
+		{
+			name:       "names the span after the route",
+			path:       "/v1/wallets/testPublicKey/balance",
+			wantName:   "GET /v1/wallets/{pubkey}/balance",
+			wantStatus: http.StatusOK,
+			wantCode:   codes.Unset,
+		},
+		{
+			name:        "continues the traceparent trace",
+			path:        "/v1/wallets/testPublicKey/balance",
+			traceparent: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+			wantName:    "GET /v1/wallets/{pubkey}/balance",
+			wantStatus:  http.StatusOK,
+			wantCode:    codes.Unset,
+			wantTraceID: "4bf92f3577b34da6a3ce929d0e0e4736",
+		},
+		{
+			name:       "flags server errors",
+			path:       "/v1/wallets/testPublicKey/transactions",
+			wantName:   "GET /v1/wallets/{pubkey}/transactions",
+			wantStatus: http.StatusBadGateway,
+			wantCode:   codes.Error,
+		},
+		{
+			name:       "unmatched paths keep the method name",
+			path:       "/unknown",
+			wantName:   "GET",
+			wantStatus: http.StatusNotFound,
+			wantCode:   codes.Unset,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, test.path, nil)
+			if test.traceparent != "" {
+				req.Header.Set("traceparent", test.traceparent)
+			}
+
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			ended := spans.Ended()
+			require.NotEmpty(t, ended)
+
+			span := ended[len(ended)-1]
+			assert.Equal(t, test.wantName, span.Name())
+			assert.Equal(t, test.wantCode, span.Status().Code)
+			assert.Contains(t, span.Attributes(),
+				attribute.Int64("http.response.status_code", test.wantStatus))
+
+			if test.wantTraceID != "" {
+				assert.Equal(t, test.wantTraceID, span.SpanContext().TraceID().String())
+				assert.True(t, span.Parent().IsRemote())
+			}
+		})
+	}
+}
@@ -0,0 +1,654 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jcleira/coding-challenge/internal/domain/aggregates"
+)
+
+// maxRequestBodySize is the maximum size of the request bodies validated
+// against the OpenAPI document.
+const maxRequestBodySize = 1 << 20
+
+// Route is an endpoint of the API, along with the description of its
+// request and responses used to build the OpenAPI document.
+//
+// Request and Response are either values of the Go types encoded in the
+// bodies, whose schemas are derived from their json tags, or a *Schema.
+// Fields tagged omitempty are optional, every other field is required.
+type Route struct {
+	Method     string
+	Path       string
+	Summary    string
+	Parameters []Parameter
+	Request    interface{}
+
+	// Status is the status code of the successful responses, 200 when
+	// unset.
+	Status int
+
+	// Response is the body of the successful responses, nil when there is
+	// none.
+	Response interface{}
+
+	// ContentType is the content type of the successful responses,
+	// application/json when unset.
+	ContentType string
+
+	// Scope is the API key scope required by the route, it's public when
+	// unset.
+	Scope aggregates.Scope
+
+	Handler http.HandlerFunc
+}
+
+// Parameter is a path or query parameter of a Route.
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required"`
+	Schema      *Schema `json:"schema"`
+}
+
+// pubkeyParameter is the path parameter of the wallet public key.
+var pubkeyParameter = Parameter{
+	Name:        "pubkey",
+	In:          "path",
+	Description: "Base58 public key of the wallet",
+	Required:    true,
+	Schema:      &Schema{Type: "string"},
+}
+
+// queryParameter returns an optional query parameter of the type.
+func queryParameter(name, description, typ string) Parameter {
+	return Parameter{
+		Name:        name,
+		In:          "query",
+		Description: description,
+		Schema:      &Schema{Type: typ},
+	}
+}
+
+// Schema is the subset of the OpenAPI schema object used by the API.
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+}
+
+// OpenAPIDocument is an OpenAPI 3 document.
+type OpenAPIDocument struct {
+	OpenAPI    string                                 `json:"openapi"`
+	Info       OpenAPIInfo                            `json:"info"`
+	Paths      map[string]map[string]OpenAPIOperation `json:"paths"`
+	Components OpenAPIComponents                      `json:"components"`
+}
+
+// OpenAPIInfo is the metadata of an OpenAPI document.
+type OpenAPIInfo struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+// OpenAPIComponents are the reusable schemas and security schemes of an
+// OpenAPI document.
+type OpenAPIComponents struct {
+	Schemas         map[string]*Schema               `json:"schemas"`
+	SecuritySchemes map[string]OpenAPISecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+// OpenAPISecurityScheme is a security scheme of an OpenAPI document.
+type OpenAPISecurityScheme struct {
+	Type         string `json:"type"`
+	Name         string `json:"name,omitempty"`
+	In           string `json:"in,omitempty"`
+	Scheme       string `json:"scheme,omitempty"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+	Description  string `json:"description,omitempty"`
+}
+
+// OpenAPIOperation is an operation of an OpenAPI document.
+type OpenAPIOperation struct {
+	Summary     string                     `json:"summary,omitempty"`
+	Description string                     `json:"description,omitempty"`
+	OperationID string                     `json:"operationId"`
+	Parameters  []Parameter                `json:"parameters,omitempty"`
+	RequestBody *OpenAPIRequestBody        `json:"requestBody,omitempty"`
+	Responses   map[string]OpenAPIResponse `json:"responses"`
+	Security    []map[string][]string      `json:"security,omitempty"`
+}
+
+// OpenAPIRequestBody is the request body of an OpenAPI operation.
+type OpenAPIRequestBody struct {
+	Required bool                        `json:"required"`
+	Content  map[string]OpenAPIMediaType `json:"content"`
+}
+
+// OpenAPIResponse is a response of an OpenAPI operation.
+type OpenAPIResponse struct {
+	Description string                      `json:"description"`
+	Content     map[string]OpenAPIMediaType `json:"content,omitempty"`
+}
+
+// OpenAPIMediaType is the schema of a request or response content type.
+type OpenAPIMediaType struct {
+	Schema *Schema `json:"schema,omitempty"`
+}
+
+// errorSchemaRef is the reference to the error envelope schema.
+const errorSchemaRef = "#/components/schemas/Error"
+
+const (
+	// apiKeySecurityScheme is the name of the API key security scheme.
+	apiKeySecurityScheme = "apiKey"
+
+	// bearerSecurityScheme is the name of the OIDC bearer token security
+	// scheme.
+	bearerSecurityScheme = "bearer"
+)
+
+// NewOpenAPIDocument builds the OpenAPI document describing the routes.
+func NewOpenAPIDocument(info OpenAPIInfo, routes []Route) OpenAPIDocument {
+	document := OpenAPIDocument{
+		OpenAPI: "3.0.3",
+		Info:    info,
+		Paths:   make(map[string]map[string]OpenAPIOperation),
+		Components: OpenAPIComponents{
+			Schemas: map[string]*Schema{
+				"Error": schemaFor(reflect.TypeOf(httpError{}), false),
+			},
+			SecuritySchemes: map[string]OpenAPISecurityScheme{
+				apiKeySecurityScheme: {
+					Type:        "apiKey",
+					Name:        APIKeyHeader,
+					In:          "header",
+					Description: "API key, its scopes and wallets are checked for each operation",
+				},
+				bearerSecurityScheme: {
+					Type:         "http",
+					Scheme:       "bearer",
+					BearerFormat: "JWT",
+					Description:  "OIDC token, its scope and wallets claims are checked for each operation",
+				},
+			},
+		},
+	}
+
+	for _, route := range routes {
+		operations, ok := document.Paths[route.Path]
+		if !ok {
+			operations = make(map[string]OpenAPIOperation)
+			document.Paths[route.Path] = operations
+		}
+
+		operations[strings.ToLower(route.Method)] = route.operation()
+	}
+
+	return document
+}
+
+// operation returns the OpenAPI operation of the route.
+func (route Route) operation() OpenAPIOperation {
+	operation := OpenAPIOperation{
+		Summary:     route.Summary,
+		OperationID: route.operationID(),
+		Parameters:  route.Parameters,
+		Responses: map[string]OpenAPIResponse{
+			"default": {
+				Description: "Error",
+				Content: map[string]OpenAPIMediaType{
+					"application/json": {Schema: &Schema{Ref: errorSchemaRef}},
+				},
+			},
+		},
+	}
+
+	if route.Scope != "" {
+		operation.Description = fmt.Sprintf("Requires an API key or a bearer token with the %s scope.", route.Scope)
+		operation.Security = []map[string][]string{
+			{apiKeySecurityScheme: {}},
+			{bearerSecurityScheme: {}},
+		}
+	}
+
+	if schema := route.requestSchema(); schema != nil {
+		operation.RequestBody = &OpenAPIRequestBody{
+			Required: len(schema.Required) > 0,
+			Content: map[string]OpenAPIMediaType{
+				"application/json": {Schema: schema},
+			},
+		}
+	}
+
+	response := OpenAPIResponse{
+		Description: http.StatusText(route.status()),
+	}
+
+	if schema := route.responseSchema(); schema != nil {
+		response.Content = map[string]OpenAPIMediaType{
+			route.contentType(): {Schema: schema},
+		}
+	}
+
+	operation.Responses[strconv.Itoa(route.status())] = response
+
+	return operation
+}
+
+// operationID returns a unique identifier of the route, such as
+// post_v1_wallets_pubkey_transfers.
+func (route Route) operationID() string {
+	id := strings.ToLower(route.Method) + strings.NewReplacer(
+		"/", "_", "{", "", "}", "").Replace(route.Path)
+
+	return strings.TrimSuffix(id, "_")
+}
+
+// status returns the status code of the route successful responses.
+func (route Route) status() int {
+	if route.Status == 0 {
+		return http.StatusOK
+	}
+
+	return route.Status
+}
+
+// contentType returns the content type of the route successful responses.
+func (route Route) contentType() string {
+	if route.ContentType == "" {
+		return "application/json"
+	}
+
+	return route.ContentType
+}
+
+// requestSchema returns the schema of the route request body, nil when it has
+// none.
+func (route Route) requestSchema() *Schema {
+	return schemaOf(route.Request, true)
+}
+
+// responseSchema returns the schema of the route successful response body,
+// nil when it has none.
+func (route Route) responseSchema() *Schema {
+	return schemaOf(route.Response, false)
+}
+
+// schemaOf returns the schema of a value, which can be a *Schema itself.
+func schemaOf(value interface{}, request bool) *Schema {
+	switch value := value.(type) {
+	case nil:
+		return nil
+	case *Schema:
+		return value
+	default:
+		return schemaFor(reflect.TypeOf(value), request)
+	}
+}
+
+// schemaFor derives the schema of a Go type from its json tags. Required
+// strings of request bodies can't be empty.
+func schemaFor(t reflect.Type, request bool) *Schema {
+	if t == reflect.TypeOf(time.Time{}) {
+		return &Schema{Type: "string", Format: "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		schema := schemaFor(t.Elem(), request)
+		schema.Nullable = true
+		return schema
+
+	case reflect.String:
+		return &Schema{Type: "string"}
+
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: schemaFor(t.Elem(), request), Nullable: true}
+
+	case reflect.Map:
+		return &Schema{
+			Type:                 "object",
+			AdditionalProperties: schemaFor(t.Elem(), request),
+			Nullable:             true,
+		}
+
+	case reflect.Struct:
+		schema := &Schema{
+			Type:       "object",
+			Properties: make(map[string]*Schema),
+		}
+
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+
+			// The fields of embedded structs are promoted, as encoding/json
+			// does.
+			if field.Anonymous && field.Type.Kind() == reflect.Struct && field.Tag.Get("json") == "" {
+				embedded := schemaFor(field.Type, request)
+				for name, property := range embedded.Properties {
+					schema.Properties[name] = property
+				}
+				schema.Required = append(schema.Required, embedded.Required...)
+				continue
+			}
+
+			name, options, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if !field.IsExported() || name == "-" {
+				continue
+			}
+
+			if name == "" {
+				name = field.Name
+			}
+
+			property := schemaFor(field.Type, request)
+			property.Description = field.Tag.Get("description")
+
+			if !strings.Contains(options, "omitempty") {
+				schema.Required = append(schema.Required, name)
+
+				if request && property.Type == "string" {
+					minLength := 1
+					property.MinLength = &minLength
+				}
+			}
+
+			schema.Properties[name] = property
+		}
+
+		return schema
+
+	default:
+		// Any value, such as an interface{}.
+		return &Schema{}
+	}
+}
+
+// validate validates a decoded JSON value, decoded with json.Number numbers,
+// against the schema, errors are prefixed with the path of the offending
+// value.
+func (s *Schema) validate(path string, value interface{}) error {
+	if value == nil {
+		if s.Nullable || s.Type == "" {
+			return nil
+		}
+
+		return &schemaError{path: path, reason: "must not be null"}
+	}
+
+	switch s.Type {
+	case "object":
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return &schemaError{path: path, reason: "must be an object"}
+		}
+
+		for _, name := range s.Required {
+			if _, ok := object[name]; !ok {
+				return &schemaError{path: joinPath(path, name), reason: "is required"}
+			}
+		}
+
+		names := make([]string, 0, len(object))
+		for name := range object {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			property, ok := s.Properties[name]
+			if !ok {
+				property = s.AdditionalProperties
+			}
+
+			if property == nil {
+				continue
+			}
+
+			if err := property.validate(joinPath(path, name), object[name]); err != nil {
+				return err
+			}
+		}
+
+	case "array":
+		array, ok := value.([]interface{})
+		if !ok {
+			return &schemaError{path: path, reason: "must be an array"}
+		}
+
+		for i, item := range array {
+			if err := s.Items.validate(fmt.Sprintf("%s[%d]", path, i), item); err != nil {
+				return err
+			}
+		}
+
+	case "string":
+		str, ok := value.(string)
+		if !ok {
+			return &schemaError{path: path, reason: "must be a string"}
+		}
+
+		if s.MinLength != nil && len(str) < *s.MinLength {
+			return &schemaError{path: path, reason: "must not be empty"}
+		}
+
+		if s.Format == "date-time" {
+			if _, err := time.Parse(time.RFC3339, str); err != nil {
+				return &schemaError{path: path, reason: "must be an RFC 3339 date-time"}
+			}
+		}
+
+	case "integer":
+		number, ok := value.(json.Number)
+		if !ok {
+			return &schemaError{path: path, reason: "must be an integer"}
+		}
+
+		if _, err := number.Int64(); err != nil {
+			return &schemaError{path: path, reason: "must be an integer"}
+		}
+
+	case "number":
+		if _, ok := value.(json.Number); !ok {
+			return &schemaError{path: path, reason: "must be a number"}
+		}
+
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return &schemaError{path: path, reason: "must be a boolean"}
+		}
+	}
+
+	return nil
+}
+
+// ValidateResponse validates a successful response body of the route for the
+// method and path against the document.
+func (d OpenAPIDocument) ValidateResponse(method, path string, body []byte) error {
+	operation, ok := d.Paths[path][strings.ToLower(method)]
+	if !ok {
+		return fmt.Errorf("no operation for %s %s", method, path)
+	}
+
+	for status, response := range operation.Responses {
+		if status == "default" {
+			continue
+		}
+
+		media, ok := response.Content["application/json"]
+		if !ok {
+			return fmt.Errorf("no JSON response for %s %s", method, path)
+		}
+
+		value, err := decodeJSON(body)
+		if err != nil {
+			return err
+		}
+
+		return media.Schema.validateStrict("", value)
+	}
+
+	return fmt.Errorf("no successful response for %s %s", method, path)
+}
+
+// validateStrict validates the value against the schema, like validate, but
+// rejecting the object properties that aren't described by the schema.
+func (s *Schema) validateStrict(path string, value interface{}) error {
+	if err := s.validate(path, value); err != nil {
+		return err
+	}
+
+	switch value := value.(type) {
+	case map[string]interface{}:
+		for name, property := range value {
+			schema, ok := s.Properties[name]
+			if !ok {
+				schema = s.AdditionalProperties
+			}
+
+			if schema == nil {
+				return &schemaError{path: joinPath(path, name), reason: "is not documented"}
+			}
+
+			if err := schema.validateStrict(joinPath(path, name), property); err != nil {
+				return err
+			}
+		}
+
+	case []interface{}:
+		for i, item := range value {
+			if err := s.Items.validateStrict(fmt.Sprintf("%s[%d]", path, i), item); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// schemaError is a value not matching its schema.
+type schemaError struct {
+	path   string
+	reason string
+}
+
+// Error returns the error message.
+func (e *schemaError) Error() string {
+	if e.path == "" {
+		return e.reason
+	}
+
+	return e.path + " " + e.reason
+}
+
+// joinPath joins an object property to a value path.
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+
+	return path + "." + name
+}
+
+// decodeJSON decodes a JSON value keeping the numbers as json.Number.
+func decodeJSON(data []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+
+	return value, nil
+}
+
+// validateRequest is a middleware that validates the request body against
+// the schema before it reaches the handler, answering 400 with the offending
+// field otherwise. Empty bodies are accepted when nothing is required.
+func validateRequest(schema *Schema, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxRequestBodySize+1))
+		if err != nil {
+			writeError(w, r, invalidRequestBody(err))
+			return
+		}
+
+		if len(body) > maxRequestBodySize {
+			writeError(w, r, invalidRequestBody(errors.New("request body too large")))
+			return
+		}
+
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		if len(bytes.TrimSpace(body)) == 0 && len(schema.Required) == 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		value, err := decodeJSON(body)
+		if err != nil {
+			writeError(w, r, invalidRequestBody(err))
+			return
+		}
+
+		if err := schema.validate("", value); err != nil {
+			var schemaErr *schemaError
+			if errors.As(err, &schemaErr) && schemaErr.path != "" {
+				writeError(w, r, aggregates.NewValidationError(
+					schemaErr.path, errors.New(schemaErr.reason)))
+				return
+			}
+
+			writeError(w, r, invalidRequestBody(err))
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// OpenAPIHandler serves the OpenAPI document as JSON.
+func OpenAPIHandler(document OpenAPIDocument) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		response, err := json.Marshal(document)
+		if err != nil {
+			writeError(w, r, fmt.Errorf("error marshalling response: %w", err))
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if _, err := w.Write(response); err != nil {
+			slog.ErrorContext(r.Context(), "error writing response", "error", err)
+		}
+	}
+}
@@ -0,0 +1,245 @@
+package handlers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bradleyjkemp/cupaloy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jcleira/coding-challenge/internal/domain/aggregates"
+	"github.com/jcleira/coding-challenge/internal/infra/handlers"
+	"github.com/jcleira/coding-challenge/mocks"
+)
+
+// openAPIMocks are the mocked dependencies of the documented handlers.
+type openAPIMocks struct {
+	balanceGetter      *mocks.WalletBalanceGetter
+	transactionsGetter *mocks.TransactionsGetter
+	transactionsSender *mocks.TransactionsSender
+	rateOverrider      *mocks.ExchangeRateOverrider
+}
+
+// newOpenAPIRouter returns a router with every documented route registered,
+// and the OpenAPI document of those routes.
+func newOpenAPIRouter(t *testing.T) (*handlers.Router, handlers.OpenAPIDocument, openAPIMocks) {
+	t.Helper()
+
+	deps := openAPIMocks{
+		balanceGetter:      mocks.NewWalletBalanceGetter(t),
+		transactionsGetter: mocks.NewTransactionsGetter(t),
+		transactionsSender: mocks.NewTransactionsSender(t),
+		rateOverrider:      mocks.NewExchangeRateOverrider(t),
+	}
+
+	router := handlers.NewRouter()
+	router.Register(handlers.NewWalletInitializerHandler(mocks.NewWalletInitializer(t)).Routes()...)
+	router.Register(handlers.NewWalletRotatorHandler(mocks.NewWalletRotator(t)).Routes()...)
+	router.Register(handlers.NewWalletBalanceGetterHandler(deps.balanceGetter).Routes()...)
+	router.Register(handlers.NewTransactionsSenderHandler(
+		deps.transactionsSender, []string{"EUR", "USD"}).Routes()...)
+	router.Register(handlers.NewTransactionsGetterHandler(deps.transactionsGetter).Routes()...)
+	router.Register(handlers.NewAuditLogGetterHandler(mocks.NewAuditLogGetter(t)).Routes()...)
+	router.Register(handlers.NewExchangeRateGetterHandler(mocks.NewExchangeRateGetter(t)).Routes()...)
+	router.Register(handlers.NewExchangeHealthGetterHandler(mocks.NewExchangeHealthGetter(t)).Routes()...)
+	router.Register(handlers.NewExchangeRateOverrideHandler(deps.rateOverrider).Routes()...)
+	router.Register(handlers.NewExchangeRateStreamHandler(mocks.NewExchangeRateStreamer(t)).Routes()...)
+	router.Register(handlers.NewAPIKeysHandler(mocks.NewAPIKeyManager(t)).Routes()...)
+	router.Register(handlers.ProfilingRoutes()...)
+
+	document := handlers.NewOpenAPIDocument(
+		handlers.OpenAPIInfo{Title: "Solana payments", Version: "1.0.0"}, router.Routes())
+	router.HandleFunc(http.MethodGet, "/openapi.json", handlers.OpenAPIHandler(document))
+
+	return router, document, deps
+}
+
+func TestOpenAPIHandler(t *testing.T) {
+	t.Parallel()
+
+	router, _, _ := newOpenAPIRouter(t)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+
+	var document interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &document))
+
+	body, err := json.MarshalIndent(document, "", "  ")
+	require.NoError(t, err)
+
+	snapshotter := cupaloy.New(cupaloy.SnapshotSubdirectory("./.snapshots/openapi-test"))
+	snapshotter.SnapshotT(t, string(body))
+}
+
+func TestOpenAPIDocument_ValidateResponse(t *testing.T) {
+	t.Parallel()
+
+	blockTime := time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	tests := []struct {
+		title       string
+		method      string
+		path        string
+		route       string
+		requestBody string
+		beforeFunc  func(openAPIMocks)
+		wantStatus  int
+	}{
+		{
+			title:       "balance",
+			method:      http.MethodPost,
+			path:        "/balance",
+			route:       "/balance",
+			requestBody: `{"public_key":"testPublicKey"}`,
+			beforeFunc: func(deps openAPIMocks) {
+				deps.balanceGetter.On("GetBalance", mock.Anything, "testPublicKey", "EUR").
+					Return("EUR 12.34", nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			title:  "transactions",
+			method: http.MethodGet,
+			path:   "/v1/wallets/testPublicKey/transactions",
+			route:  "/v1/wallets/{pubkey}/transactions",
+			beforeFunc: func(deps openAPIMocks) {
+				deps.transactionsGetter.On("GetTransactions", mock.Anything, mock.Anything).
+					Return([]aggregates.Transaction{
+						{
+							BlockTime:    blockTime,
+							CounterParty: "testCounterParty",
+							Amount:       aggregates.Money{Amount: 10000, Currency: "EUR"},
+							ExchangeRate: big.NewRat(8587262, 100000),
+							Signature:    "testSignature",
+						},
+					}, nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			title:       "send",
+			method:      http.MethodPost,
+			path:        "/send",
+			route:       "/send",
+			requestBody: `{"public_key":"testPublicKey","to":"testTo","amount":"EUR 5.05"}`,
+			beforeFunc: func(deps openAPIMocks) {
+				deps.transactionsSender.On("SendTransaction", mock.Anything, mock.Anything).
+					Return("testSignature", nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			title:       "rate override",
+			method:      http.MethodPost,
+			path:        "/admin/exchange_rate/override",
+			route:       "/admin/exchange_rate/override",
+			requestBody: `{"currency":"EUR","rate":"85.10","ttl":"30m"}`,
+			beforeFunc: func(deps openAPIMocks) {
+				deps.rateOverrider.On("SetOverride", mock.Anything, "EUR", mock.Anything, 30*time.Minute).
+					Return(aggregates.Rate{
+						Currency:   "EUR",
+						Value:      big.NewRat(851, 10),
+						Sources:    []string{"override"},
+						ExpiredAt:  blockTime,
+						Overridden: true,
+					}, nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.title, func(t *testing.T) {
+			t.Parallel()
+
+			router, document, deps := newOpenAPIRouter(t)
+			test.beforeFunc(deps)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(
+				test.method, test.path, bytes.NewBufferString(test.requestBody)))
+
+			response := w.Result()
+			defer response.Body.Close()
+
+			body, err := ioutil.ReadAll(response.Body)
+			require.NoError(t, err)
+
+			require.Equal(t, test.wantStatus, response.StatusCode, string(body))
+			assert.NoError(t, document.ValidateResponse(test.method, test.route, body))
+		})
+	}
+}
+
+func TestRouter_RegisterValidatesRequests(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		title       string
+		path        string
+		requestBody string
+		wantField   string
+	}{
+		{
+			title:       "missing required field",
+			path:        "/send",
+			requestBody: `{"public_key":"testPublicKey","amount":"EUR 5.05"}`,
+			wantField:   "to",
+		},
+		{
+			title:       "empty required field",
+			path:        "/send",
+			requestBody: `{"public_key":"testPublicKey","to":"","amount":"EUR 5.05"}`,
+			wantField:   "to",
+		},
+		{
+			title:       "wrong field type",
+			path:        "/balance",
+			requestBody: `{"public_key":42}`,
+			wantField:   "public_key",
+		},
+		{
+			title:       "malformed body",
+			path:        "/balance",
+			requestBody: `{"public_key":`,
+			wantField:   "body",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.title, func(t *testing.T) {
+			t.Parallel()
+
+			router, _, _ := newOpenAPIRouter(t)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(
+				http.MethodPost, test.path, bytes.NewBufferString(test.requestBody)))
+
+			require.Equal(t, http.StatusBadRequest, w.Code)
+
+			var response struct {
+				Code    string            `json:"code"`
+				Details map[string]string `json:"details"`
+			}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+
+			assert.Equal(t, handlers.ErrorCodeInvalidRequest, response.Code)
+			assert.Equal(t, test.wantField, response.Details["field"])
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/pprof"
+
+	"github.com/jcleira/coding-challenge/internal/domain/aggregates"
+)
+
+// ProfilingRoutes returns the routes of the runtime profiles of net/http/pprof,
+// they require the admin scope as the profiles expose the process internals.
+func ProfilingRoutes() []Route {
+	profileParameter := Parameter{
+		Name:        "profile",
+		In:          "path",
+		Description: "Name of the runtime profile, such as heap or goroutine",
+		Required:    true,
+		Schema:      &Schema{Type: "string"},
+	}
+
+	return []Route{
+		{
+			Method:  http.MethodGet,
+			Path:    "/debug/pprof",
+			Summary: "List the runtime profiles",
+			Scope:   aggregates.ScopeAdmin,
+			Handler: pprof.Index,
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    "/debug/pprof/cmdline",
+			Summary: "Get the command line of the process",
+			Scope:   aggregates.ScopeAdmin,
+			Handler: pprof.Cmdline,
+		},
+		{
+			Method:     http.MethodGet,
+			Path:       "/debug/pprof/profile",
+			Summary:    "Get a CPU profile, for the duration in the seconds query parameter",
+			Parameters: []Parameter{queryParameter("seconds", "Duration of the profile, 30 seconds by default", "integer")},
+			Scope:      aggregates.ScopeAdmin,
+			Handler:    pprof.Profile,
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    "/debug/pprof/symbol",
+			Summary: "Look up the program counters in the query",
+			Scope:   aggregates.ScopeAdmin,
+			Handler: pprof.Symbol,
+		},
+		{
+			Method:  http.MethodPost,
+			Path:    "/debug/pprof/symbol",
+			Summary: "Look up the program counters in the body",
+			Scope:   aggregates.ScopeAdmin,
+			Handler: pprof.Symbol,
+		},
+		{
+			Method:     http.MethodGet,
+			Path:       "/debug/pprof/trace",
+			Summary:    "Get an execution trace, for the duration in the seconds query parameter",
+			Parameters: []Parameter{queryParameter("seconds", "Duration of the trace, 1 second by default", "integer")},
+			Scope:      aggregates.ScopeAdmin,
+			Handler:    pprof.Trace,
+		},
+		{
+			Method:     http.MethodGet,
+			Path:       "/debug/pprof/{profile}",
+			Summary:    "Get a runtime profile, such as heap or goroutine",
+			Parameters: []Parameter{profileParameter},
+			Scope:      aggregates.ScopeAdmin,
+			Handler:    pprof.Index,
+		},
+	}
+}
@@ -0,0 +1,90 @@
+package handlers_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/jcleira/coding-challenge/internal/domain/aggregates"
+	"github.com/jcleira/coding-challenge/internal/infra/handlers"
+	"github.com/jcleira/coding-challenge/mocks"
+)
+
+func TestProfilingRoutes(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		title          string
+		path           string
+		token          string
+		key            aggregates.APIKey
+		wantStatusCode int
+	}{
+		{
+			title:          "missing API key",
+			path:           "/debug/pprof/goroutine",
+			wantStatusCode: http.StatusUnauthorized,
+		},
+		{
+			title: "non admin API key",
+			path:  "/debug/pprof/goroutine",
+			token: "shop",
+			key: aggregates.APIKey{
+				ID:      "shop",
+				Scopes:  []aggregates.Scope{aggregates.ScopeReadBalance},
+				Wallets: []string{"shopWallet"},
+			},
+			wantStatusCode: http.StatusForbidden,
+		},
+		{
+			title:          "profile index",
+			path:           "/debug/pprof",
+			token:          "admin",
+			key:            aggregates.APIKey{ID: "admin", Scopes: []aggregates.Scope{aggregates.ScopeAdmin}},
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			title:          "named profile",
+			path:           "/debug/pprof/goroutine?debug=1",
+			token:          "admin",
+			key:            aggregates.APIKey{ID: "admin", Scopes: []aggregates.Scope{aggregates.ScopeAdmin}},
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			title:          "unknown profile",
+			path:           "/debug/pprof/unknown",
+			token:          "admin",
+			key:            aggregates.APIKey{ID: "admin", Scopes: []aggregates.Scope{aggregates.ScopeAdmin}},
+			wantStatusCode: http.StatusNotFound,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.title, func(t *testing.T) {
+			t.Parallel()
+
+			authenticator := mocks.NewAPIKeyAuthenticator(t)
+			if test.token != "" {
+				authenticator.On("Authenticate", mock.Anything, test.token).Return(test.key, nil)
+			}
+
+			router := handlers.NewRouter()
+			router.RequireAPIKeys(authenticator)
+			router.Register(handlers.ProfilingRoutes()...)
+
+			req := httptest.NewRequest(http.MethodGet, test.path, nil)
+			if test.token != "" {
+				req.Header.Set(handlers.APIKeyHeader, test.token)
+			}
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, test.wantStatusCode, w.Code, w.Body.String())
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/jcleira/coding-challenge/internal/domain/aggregates"
+)
+
+// RequestLimiter defines the methods for limiting the requests of the
+// clients per class of endpoints.
+type RequestLimiter interface {
+	Allow(ctx context.Context,
+		client string, class aggregates.RateClass) (aggregates.RateLimitDecision, error)
+}
+
+// limitRequests is a middleware that checks the request against the rate
+// limit of its client for the class of endpoints, answering 429 with a
+// Retry-After header when it's exceeded. The state of the bucket is reported
+// in the X-RateLimit-* headers. Every request passes when there is no
+// limiter.
+func (rt *Router) limitRequests(class aggregates.RateClass, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if rt.limiter == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		decision, err := rt.limiter.Allow(r.Context(), requestClient(r), class)
+		if err != nil && !errors.Is(err, aggregates.ErrRateLimited) &&
+			!errors.Is(err, aggregates.ErrQuotaExceeded) {
+			writeError(w, r, fmt.Errorf("error checking rate limit: %w", err))
+			return
+		}
+
+		if decision.Limit > 0 {
+			w.Header().Set("X-RateLimit-Limit", strconv.Itoa(decision.Limit))
+			w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(decision.Remaining))
+			w.Header().Set("X-RateLimit-Reset", strconv.Itoa(ceilSeconds(decision.Reset)))
+		}
+
+		if !decision.Allowed {
+			w.Header().Set("Retry-After", strconv.Itoa(ceilSeconds(decision.RetryAfter)))
+			writeError(w, r, err)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// rateClass returns the class of endpoints of the route for the rate limits,
+// the routes requiring the send scope are sends, every other route is a read.
+func (route Route) rateClass() aggregates.RateClass {
+	if route.Scope == aggregates.ScopeSend {
+		return aggregates.RateClassSend
+	}
+
+	return aggregates.RateClassRead
+}
+
+// requestClient returns the client the request is accounted to, its
+// principal when it's authenticated, or its address otherwise.
+func requestClient(r *http.Request) string {
+	if principal, ok := aggregates.PrincipalFromContext(r.Context()); ok {
+		return principal.Actor
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	return "ip:" + host
+}
+
+// ceilSeconds returns the duration in whole seconds, rounded up, as the
+// headers carry seconds.
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,178 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jcleira/coding-challenge/internal/domain/aggregates"
+	"github.com/jcleira/coding-challenge/internal/infra/handlers"
+	"github.com/jcleira/coding-challenge/mocks"
+)
+
+func TestRouter_LimitRequests(t *testing.T) {
+	t.Parallel()
+
+	shopKey := aggregates.APIKey{
+		ID:      "shop",
+		Scopes:  []aggregates.Scope{aggregates.ScopeReadBalance},
+		Wallets: []string{"shopWallet"},
+	}
+
+	tests := []struct {
+		title          string
+		path           string
+		token          string
+		beforeFunc     func(*mocks.APIKeyAuthenticator, *mocks.RequestLimiter, *mocks.WalletBalanceGetter)
+		wantStatusCode int
+		wantHeaders    map[string]string
+		wantCode       string
+	}{
+		{
+			title: "allowed request",
+			path:  "/v1/wallets/shopWallet/balance",
+			token: "shop",
+			beforeFunc: func(auth *mocks.APIKeyAuthenticator, limiter *mocks.RequestLimiter, getter *mocks.WalletBalanceGetter) {
+				auth.On("Authenticate", mock.Anything, "shop").Return(shopKey, nil)
+				limiter.On("Allow", mock.Anything, "ip:192.0.2.1", aggregates.RateClassAuth).
+					Return(aggregates.RateLimitDecision{Allowed: true, Limit: 100, Remaining: 99}, nil)
+				limiter.On("Allow", mock.Anything, "api-key:shop", aggregates.RateClassRead).
+					Return(aggregates.RateLimitDecision{
+						Allowed:   true,
+						Limit:     20,
+						Remaining: 19,
+						Reset:     100 * time.Millisecond,
+					}, nil)
+				getter.On("GetBalance", mock.Anything, "shopWallet", "EUR").Return("EUR 12.34", nil)
+			},
+			wantStatusCode: http.StatusOK,
+			wantHeaders: map[string]string{
+				"X-RateLimit-Limit":     "20",
+				"X-RateLimit-Remaining": "19",
+				"X-RateLimit-Reset":     "1",
+			},
+		},
+		{
+			title: "rate limited request",
+			path:  "/v1/wallets/shopWallet/balance",
+			token: "shop",
+			beforeFunc: func(auth *mocks.APIKeyAuthenticator, limiter *mocks.RequestLimiter, _ *mocks.WalletBalanceGetter) {
+				auth.On("Authenticate", mock.Anything, "shop").Return(shopKey, nil)
+				limiter.On("Allow", mock.Anything, "ip:192.0.2.1", aggregates.RateClassAuth).
+					Return(aggregates.RateLimitDecision{Allowed: true, Limit: 100, Remaining: 99}, nil)
+				limiter.On("Allow", mock.Anything, "api-key:shop", aggregates.RateClassRead).
+					Return(aggregates.RateLimitDecision{
+						Limit:      20,
+						RetryAfter: 1500 * time.Millisecond,
+						Reset:      2 * time.Second,
+					}, fmt.Errorf("%w: api-key:shop", aggregates.ErrRateLimited))
+			},
+			wantStatusCode: http.StatusTooManyRequests,
+			wantHeaders: map[string]string{
+				"Retry-After":           "2",
+				"X-RateLimit-Remaining": "0",
+			},
+			wantCode: handlers.ErrorCodeRateLimited,
+		},
+		{
+			title: "daily quota exceeded",
+			path:  "/v1/wallets/shopWallet/balance",
+			token: "shop",
+			beforeFunc: func(auth *mocks.APIKeyAuthenticator, limiter *mocks.RequestLimiter, _ *mocks.WalletBalanceGetter) {
+				auth.On("Authenticate", mock.Anything, "shop").Return(shopKey, nil)
+				limiter.On("Allow", mock.Anything, "ip:192.0.2.1", aggregates.RateClassAuth).
+					Return(aggregates.RateLimitDecision{Allowed: true, Limit: 100, Remaining: 99}, nil)
+				limiter.On("Allow", mock.Anything, "api-key:shop", aggregates.RateClassRead).
+					Return(aggregates.RateLimitDecision{Limit: 20, Remaining: 19, RetryAfter: time.Hour},
+						fmt.Errorf("%w: api-key:shop", aggregates.ErrQuotaExceeded))
+			},
+			wantStatusCode: http.StatusTooManyRequests,
+			wantHeaders:    map[string]string{"Retry-After": "3600"},
+			wantCode:       handlers.ErrorCodeQuotaExceeded,
+		},
+		{
+			title: "anonymous requests are accounted to their address",
+			path:  "/exchange_rate",
+			beforeFunc: func(_ *mocks.APIKeyAuthenticator, limiter *mocks.RequestLimiter, _ *mocks.WalletBalanceGetter) {
+				limiter.On("Allow", mock.Anything, "ip:192.0.2.1", aggregates.RateClassRead).
+					Return(aggregates.RateLimitDecision{Limit: 20, RetryAfter: time.Second},
+						fmt.Errorf("%w: ip:192.0.2.1", aggregates.ErrRateLimited))
+			},
+			wantStatusCode: http.StatusTooManyRequests,
+			wantCode:       handlers.ErrorCodeRateLimited,
+		},
+		{
+			title: "unauthenticated requests are accounted to their address",
+			path:  "/v1/wallets/shopWallet/balance",
+			beforeFunc: func(_ *mocks.APIKeyAuthenticator, limiter *mocks.RequestLimiter, _ *mocks.WalletBalanceGetter) {
+				limiter.On("Allow", mock.Anything, "ip:192.0.2.1", aggregates.RateClassAuth).
+					Return(aggregates.RateLimitDecision{Allowed: true, Limit: 100, Remaining: 99}, nil)
+			},
+			wantStatusCode: http.StatusUnauthorized,
+			wantCode:       handlers.ErrorCodeUnauthenticated,
+		},
+		{
+			title: "failed authentications are limited per address",
+			path:  "/v1/wallets/shopWallet/balance",
+			token: "guess",
+			beforeFunc: func(_ *mocks.APIKeyAuthenticator, limiter *mocks.RequestLimiter, _ *mocks.WalletBalanceGetter) {
+				limiter.On("Allow", mock.Anything, "ip:192.0.2.1", aggregates.RateClassAuth).
+					Return(aggregates.RateLimitDecision{Limit: 100, RetryAfter: 20 * time.Millisecond},
+						fmt.Errorf("%w: ip:192.0.2.1", aggregates.ErrRateLimited))
+			},
+			wantStatusCode: http.StatusTooManyRequests,
+			wantHeaders:    map[string]string{"Retry-After": "1"},
+			wantCode:       handlers.ErrorCodeRateLimited,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.title, func(t *testing.T) {
+			t.Parallel()
+
+			var (
+				authenticator = mocks.NewAPIKeyAuthenticator(t)
+				limiter       = mocks.NewRequestLimiter(t)
+				balanceGetter = mocks.NewWalletBalanceGetter(t)
+			)
+
+			test.beforeFunc(authenticator, limiter, balanceGetter)
+
+			router := handlers.NewRouter()
+			router.RequireAPIKeys(authenticator)
+			router.LimitRequests(limiter)
+			router.Register(handlers.NewWalletBalanceGetterHandler(balanceGetter).Routes()...)
+			router.Register(handlers.NewExchangeRateGetterHandler(mocks.NewExchangeRateGetter(t)).Routes()...)
+
+			req := httptest.NewRequest(http.MethodGet, test.path, nil)
+			if test.token != "" {
+				req.Header.Set(handlers.APIKeyHeader, test.token)
+			}
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			require.Equal(t, test.wantStatusCode, w.Code, w.Body.String())
+
+			for name, value := range test.wantHeaders {
+				assert.Equal(t, value, w.Header().Get(name), name)
+			}
+
+			if test.wantCode != "" {
+				var response struct {
+					Code string `json:"code"`
+				}
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				assert.Equal(t, test.wantCode, response.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,249 @@
+package handlers
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/jcleira/coding-challenge/internal/domain/aggregates"
+	"github.com/jcleira/coding-challenge/internal/infra/logging"
+	"github.com/jcleira/coding-challenge/internal/infra/metrics"
+)
+
+// pathParamsContextKey is the context key of the path parameters of a
+// request, it's unexported to prevent collisions with other packages.
+type pathParamsContextKey struct{}
+
+// route is a handler registered for a method and a path pattern.
+type route struct {
+	method   string
+	pattern  string
+	segments []string
+	handler  http.Handler
+}
+
+// unmatchedRoute is the route label of the requests not matching any route,
+// so unknown paths don't grow the metric labels.
+const unmatchedRoute = "unmatched"
+
+// Router is an http.Handler that routes requests by method and path.
+//
+// Patterns are matched segment by segment, a segment in braces, such as
+// {pubkey}, matches any single non empty segment and is available to the
+// handler with PathParam. Requests to a known path with an unsupported method
+// are answered with 405 and an Allow header, unknown paths with 404.
+type Router struct {
+	routes []route
+
+	// documented are the routes registered with their description.
+	documented []Route
+
+	// authenticator and bearerAuthenticator authenticate the API keys and
+	// the bearer tokens of the routes with a scope, when both are nil every
+	// route is public.
+	authenticator       APIKeyAuthenticator
+	bearerAuthenticator BearerAuthenticator
+
+	// wallets checks the wallets of the requests belong to the tenant of
+	// their principal, when it's nil the principal grants are trusted.
+	wallets WalletOwnershipChecker
+
+	// limiter limits the requests of each client to the registered routes,
+	// when it's nil they aren't limited.
+	limiter RequestLimiter
+}
+
+// NewRouter creates a new Router.
+func NewRouter() *Router {
+	return &Router{}
+}
+
+// Handle registers the handler for the method and the path pattern.
+func (rt *Router) Handle(method, pattern string, handler http.Handler) {
+	rt.routes = append(rt.routes, route{
+		method:   method,
+		pattern:  pattern,
+		segments: splitPath(pattern),
+		handler:  handler,
+	})
+}
+
+// HandleFunc registers the handler func for the method and the path pattern.
+func (rt *Router) HandleFunc(method, pattern string, handler http.HandlerFunc) {
+	rt.Handle(method, pattern, handler)
+}
+
+// RequireAPIKeys requires the requests to the routes with a scope to carry
+// an API key granting it, authenticated with the authenticator.
+func (rt *Router) RequireAPIKeys(authenticator APIKeyAuthenticator) {
+	rt.authenticator = authenticator
+}
+
+// RequireBearerTokens accepts the bearer tokens authenticated with the
+// authenticator, such as OIDC JWTs, on the routes with a scope, along with
+// the API keys when they are required too.
+func (rt *Router) RequireBearerTokens(authenticator BearerAuthenticator) {
+	rt.bearerAuthenticator = authenticator
+}
+
+// RequireTenantWallets requires the wallets of the authenticated requests to
+// belong to the tenant of their principal, checked with the checker. Wallets
+// of other tenants are answered as not found, so they can't be probed.
+func (rt *Router) RequireTenantWallets(checker WalletOwnershipChecker) {
+	rt.wallets = checker
+}
+
+// LimitRequests limits the requests of each client to the registered routes
+// with the limiter, per class of endpoints. The authenticated requests are
+// accounted to their principal, the anonymous ones to their address.
+func (rt *Router) LimitRequests(limiter RequestLimiter) {
+	rt.limiter = limiter
+}
+
+// Register registers the routes, validating the request bodies against their
+// schemas before they reach the handlers.
+//
+// The routes with a scope require an API key or a bearer token granting it,
+// when they operate on a wallet it must be granted the wallet too, and the
+// wallet must belong to its tenant. The
+// credentials are checked before the request body, so anonymous requests
+// learn nothing about it, and before the rate limits, so requests are
+// accounted to their principal. They're limited per address before that, so
+// failed authentications are limited too.
+func (rt *Router) Register(routes ...Route) {
+	for _, route := range routes {
+		var handler http.Handler = route.Handler
+		if route.Scope.WalletScoped() {
+			handler = rt.requireWallet(handler)
+		}
+
+		if schema := route.requestSchema(); schema != nil {
+			handler = validateRequest(schema, handler)
+		}
+
+		handler = rt.limitRequests(route.rateClass(), handler)
+
+		if route.Scope != "" {
+			handler = rt.requireScope(route.Scope, handler)
+			handler = rt.limitRequests(aggregates.RateClassAuth, handler)
+		}
+
+		rt.Handle(route.Method, route.Path, handler)
+		rt.documented = append(rt.documented, route)
+	}
+}
+
+// Routes returns the routes registered with Register.
+func (rt *Router) Routes() []Route {
+	return rt.documented
+}
+
+// ServeHTTP dispatches the request to the handler of the first route matching
+// its method and path, recording its status and latency per route pattern.
+func (rt *Router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	recorder := &statusRecorder{ResponseWriter: w}
+
+	pattern := rt.serve(recorder, r)
+
+	metrics.ObserveHTTPRequest(pattern, r.Method, recorder.Status(), time.Since(start))
+}
+
+// serve dispatches the request, returning the pattern of the route it matched.
+func (rt *Router) serve(w http.ResponseWriter, r *http.Request) string {
+	segments := splitPath(r.URL.Path)
+
+	var allowed []string
+	for _, route := range rt.routes {
+		params, ok := matchSegments(route.segments, segments)
+		if !ok {
+			continue
+		}
+
+		if route.method != r.Method {
+			allowed = append(allowed, route.method)
+			continue
+		}
+
+		if len(params) > 0 {
+			ctx := context.WithValue(r.Context(), pathParamsContextKey{}, params)
+
+			// The wallet of the request is added to every line logged for it.
+			if wallet, ok := params["pubkey"]; ok {
+				ctx = logging.ContextWithAttrs(ctx, slog.String("wallet", wallet))
+			}
+
+			r = r.WithContext(ctx)
+		}
+
+		span := trace.SpanFromContext(r.Context())
+		span.SetName(r.Method + " " + route.pattern)
+		span.SetAttributes(semconv.HTTPRoute(route.pattern))
+
+		route.handler.ServeHTTP(w, r)
+		return route.pattern
+	}
+
+	if len(allowed) > 0 {
+		sort.Strings(allowed)
+		w.Header().Set("Allow", strings.Join(allowed, ", "))
+		writeError(w, r, errMethodNotAllowed)
+		return unmatchedRoute
+	}
+
+	writeError(w, r, errNotFound)
+	return unmatchedRoute
+}
+
+// PathParam returns the value of the path parameter name of the request
+// route, or an empty string when there is none.
+func PathParam(r *http.Request, name string) string {
+	params, _ := r.Context().Value(pathParamsContextKey{}).(map[string]string)
+	return params[name]
+}
+
+// splitPath splits a path in its segments, ignoring the leading and trailing
+// slashes.
+func splitPath(path string) []string {
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return nil
+	}
+
+	return strings.Split(path, "/")
+}
+
+// matchSegments matches the path segments against the pattern segments,
+// returning the values of the pattern parameters.
+func matchSegments(pattern, path []string) (map[string]string, bool) {
+	if len(pattern) != len(path) {
+		return nil, false
+	}
+
+	var params map[string]string
+	for i, segment := range pattern {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			if path[i] == "" {
+				return nil, false
+			}
+
+			if params == nil {
+				params = make(map[string]string)
+			}
+			params[segment[1:len(segment)-1]] = path[i]
+			continue
+		}
+
+		if segment != path[i] {
+			return nil, false
+		}
+	}
+
+	return params, true
+}
@@ -0,0 +1,96 @@
+package handlers_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/jcleira/coding-challenge/internal/infra/handlers"
+)
+
+func TestRouter(t *testing.T) {
+	t.Parallel()
+
+	router := handlers.NewRouter()
+	router.HandleFunc(http.MethodPost, "/balance",
+		func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte("legacy balance"))
+		})
+	router.HandleFunc(http.MethodGet, "/v1/wallets/{pubkey}/balance",
+		func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte("balance of " + handlers.PathParam(r, "pubkey")))
+		})
+	router.HandleFunc(http.MethodPost, "/v1/wallets/{pubkey}/transfers",
+		func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte("transfer from " + handlers.PathParam(r, "pubkey")))
+		})
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		wantStatus int
+		wantBody   string
+		wantAllow  string
+	}{
+		{
+			name:       "legacy endpoint",
+			method:     http.MethodPost,
+			path:       "/balance",
+			wantStatus: http.StatusOK,
+			wantBody:   "legacy balance",
+		},
+		{
+			name:       "path parameter",
+			method:     http.MethodGet,
+			path:       "/v1/wallets/testPublicKey/balance",
+			wantStatus: http.StatusOK,
+			wantBody:   "balance of testPublicKey",
+		},
+		{
+			name:       "trailing slash",
+			method:     http.MethodPost,
+			path:       "/v1/wallets/testPublicKey/transfers/",
+			wantStatus: http.StatusOK,
+			wantBody:   "transfer from testPublicKey",
+		},
+		{
+			name:       "method not allowed",
+			method:     http.MethodGet,
+			path:       "/balance",
+			wantStatus: http.StatusMethodNotAllowed,
+			wantBody:   `{"code":"method_not_allowed","message":"Method not allowed","request_id":""}`,
+			wantAllow:  http.MethodPost,
+		},
+		{
+			name:       "empty path parameter",
+			method:     http.MethodGet,
+			path:       "/v1/wallets//balance",
+			wantStatus: http.StatusNotFound,
+			wantBody:   `{"code":"not_found","message":"Not found","request_id":""}`,
+		},
+		{
+			name:       "unknown path",
+			method:     http.MethodGet,
+			path:       "/v1/wallets/testPublicKey",
+			wantStatus: http.StatusNotFound,
+			wantBody:   `{"code":"not_found","message":"Not found","request_id":""}`,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, httptest.NewRequest(test.method, test.path, nil))
+
+			assert.Equal(t, test.wantStatus, recorder.Code)
+			assert.Equal(t, test.wantBody, recorder.Body.String())
+			assert.Equal(t, test.wantAllow, recorder.Header().Get("Allow"))
+		})
+	}
+}
@@ -3,8 +3,10 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"log/slog"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/jcleira/coding-challenge/internal/domain/aggregates"
@@ -13,9 +15,13 @@ import (
 // TransactionsGetter defines the methods for getting transactions from the Solana
 // blockchain.
 type TransactionsGetter interface {
-	GetTransactions(ctx context.Context, publicKey string) ([]aggregates.Transaction, error)
+	GetTransactions(ctx context.Context, query aggregates.TransactionsQuery) ([]aggregates.Transaction, error)
 }
 
+// defaultTransactionsLimit is the page size of the transactions listings that
+// don't set one.
+const defaultTransactionsLimit = 100
+
 // TransactionsGetterHandler define the dependencies handling transactions get requests.
 type TransactionsGetterHandler struct {
 	getter TransactionsGetter
@@ -29,63 +35,189 @@ func NewTransactionsGetterHandler(getter TransactionsGetter) *TransactionsGetter
 }
 
 // Handler is the http handler func  for getting transactions.
+//
+// The optional valuation field selects the rate used to convert the amounts,
+// the current one by default, or the one at each transaction block time when
+// set to historical.
 func (h *TransactionsGetterHandler) Handler() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		request := struct {
-			PublicKey string `json:"public_key"`
-		}{}
+		request := httpTransactionsRequest{}
 
 		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
-			http.Error(w, err.Error(), http.StatusBadRequest)
+			writeError(w, r, invalidRequestBody(err))
 			return
 		}
 
-		transactions, err := h.getter.GetTransactions(context.Background(), request.PublicKey)
+		valuation, err := aggregates.ParseValuation(request.Valuation)
 		if err != nil {
-			http.Error(w, "Error getting transactions", http.StatusInternalServerError)
+			writeError(w, r, aggregates.NewValidationError("valuation", err))
 			return
 		}
 
-		httpTransactions := make([]httpTransaction, len(transactions))
-		for i, transaction := range transactions {
-			httpTransactions[i] = httpTransactionFromDomainTransaction(transaction)
-		}
+		h.serve(w, r, aggregates.TransactionsQuery{
+			PublicKey: request.PublicKey,
+			Currency:  requestCurrency(request.Currency),
+			Valuation: valuation,
+		})
+	}
+}
+
+// V1Handler is the http handler func for getting a page of the transactions
+// of the wallet in the pubkey path parameter.
+//
+// The query parameters are the optional currency and valuation, as in
+// Handler, the page size in limit, and the signature of the last transaction
+// of the previous page in before.
+func (h *TransactionsGetterHandler) V1Handler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		params := r.URL.Query()
 
-		response, err := json.Marshal(
-			httpTransactionsResponse{
-				HTTPTransactions: httpTransactions,
-			})
+		valuation, err := aggregates.ParseValuation(params.Get("valuation"))
 		if err != nil {
-			http.Error(w, "Error marshalling response", http.StatusInternalServerError)
+			writeError(w, r, aggregates.NewValidationError("valuation", err))
 			return
 		}
 
-		w.Header().Set("Content-Type", "application/json")
-		if _, err := w.Write(response); err != nil {
-			slog.Error("error writing response", err)
+		limit, err := parseTransactionsLimit(params.Get("limit"))
+		if err != nil {
+			writeError(w, r, aggregates.NewValidationError("limit", err))
+			return
 		}
+
+		h.serve(w, r, aggregates.TransactionsQuery{
+			PublicKey: PathParam(r, "pubkey"),
+			Currency:  requestCurrency(params.Get("currency")),
+			Valuation: valuation,
+			Page: aggregates.TransactionsPage{
+				Limit:  limit,
+				Before: params.Get("before"),
+			},
+		})
 	}
 }
 
+// serve writes the transactions matching the query.
+func (h *TransactionsGetterHandler) serve(
+	w http.ResponseWriter, r *http.Request, query aggregates.TransactionsQuery) {
+	transactions, err := h.getter.GetTransactions(r.Context(), query)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	httpTransactions := make([]httpTransaction, len(transactions))
+	for i, transaction := range transactions {
+		httpTransactions[i] = httpTransactionFromDomainTransaction(transaction)
+	}
+
+	httpResponse := httpTransactionsResponse{
+		HTTPTransactions: httpTransactions,
+	}
+
+	// A full page means there might be older transactions.
+	if query.Page.Limit > 0 && len(transactions) == query.Page.Limit {
+		httpResponse.NextBefore = transactions[len(transactions)-1].Signature
+	}
+
+	response, err := json.Marshal(httpResponse)
+	if err != nil {
+		writeError(w, r, fmt.Errorf("error marshalling response: %w", err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if _, err := w.Write(response); err != nil {
+		slog.ErrorContext(r.Context(), "error writing response", "error", err)
+	}
+}
+
+// parseTransactionsLimit parses the page size of a transactions listing,
+// defaulting to defaultTransactionsLimit when empty.
+func parseTransactionsLimit(limit string) (int, error) {
+	if limit == "" {
+		return defaultTransactionsLimit, nil
+	}
+
+	value, err := strconv.Atoi(limit)
+	if err != nil || value < 1 || value > aggregates.MaxTransactionsLimit {
+		return 0, fmt.Errorf("%q must be a number between 1 and %d",
+			limit, aggregates.MaxTransactionsLimit)
+	}
+
+	return value, nil
+}
+
+// httpTransactionsRequest is the http request listing the transactions of a
+// wallet.
+type httpTransactionsRequest struct {
+	PublicKey string `json:"public_key"`
+	Currency  string `json:"currency,omitempty"`
+	Valuation string `json:"valuation,omitempty" description:"current or historical"`
+}
+
 // httpTransactionsResponse is the http version for a list of domain transactions.
 type httpTransactionsResponse struct {
 	HTTPTransactions []httpTransaction `json:"transactions"`
+
+	// NextBefore is the before parameter of the next page, when there might
+	// be one.
+	NextBefore string `json:"next_before,omitempty"`
 }
 
 // httpTransaction is the http version for a domain transaction.
 type httpTransaction struct {
 	Created      time.Time `json:"created"`
-	Amount       string    `json:"amount"`
-	CounterParty string    `json:"counter_party"`
+	Amount       string    `json:"amount,omitempty"`
+	Rate         float64   `json:"rate,omitempty"`
+	CounterParty string    `json:"counterparty"`
 	Signature    string    `json:"signature"`
 }
 
 // httpTransactionFromDomainTransaction converts a domain transaction to an http transaction.
+// Transactions without an exchange rate couldn't be valued, and are rendered
+// without amount and rate.
 func httpTransactionFromDomainTransaction(transaction aggregates.Transaction) httpTransaction {
-	return httpTransaction{
+	httpTransaction := httpTransaction{
 		Created:      transaction.BlockTime,
-		Amount:       transaction.AmountEUR,
 		CounterParty: transaction.CounterParty,
 		Signature:    transaction.Signature,
 	}
+
+	if transaction.ExchangeRate != nil {
+		httpTransaction.Amount = transaction.Amount.String()
+		// Ignoring exact checks here
+		httpTransaction.Rate, _ = transaction.ExchangeRate.Float64()
+	}
+
+	return httpTransaction
+}
+
+// Routes returns the routes listing transactions.
+func (h *TransactionsGetterHandler) Routes() []Route {
+	return []Route{
+		{
+			Method:   http.MethodPost,
+			Path:     "/transactions",
+			Summary:  "List the transactions of a wallet, newest first",
+			Request:  httpTransactionsRequest{},
+			Response: httpTransactionsResponse{},
+			Scope:    aggregates.ScopeReadTransactions,
+			Handler:  h.Handler(),
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    "/v1/wallets/{pubkey}/transactions",
+			Summary: "List a page of the transactions of a wallet, newest first",
+			Parameters: []Parameter{
+				pubkeyParameter,
+				queryParameter("currency", "Fiat currency of the amounts, EUR by default", "string"),
+				queryParameter("valuation", "current or historical, current by default", "string"),
+				queryParameter("limit", "Page size, from 1 to 1000, 100 by default", "integer"),
+				queryParameter("before", "Signature of the last transaction of the previous page", "string"),
+			},
+			Response: httpTransactionsResponse{},
+			Scope:    aggregates.ScopeReadTransactions,
+			Handler:  h.V1Handler(),
+		},
+	}
 }
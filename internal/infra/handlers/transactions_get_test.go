@@ -2,10 +2,11 @@ package handlers_test
 
 import (
 	"bytes"
-	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io/ioutil"
+	"math/big"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -13,6 +14,7 @@ import (
 
 	"github.com/bradleyjkemp/cupaloy"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 
 	"github.com/jcleira/coding-challenge/internal/domain/aggregates"
@@ -41,12 +43,38 @@ func TestTransactionsGetterHandler_Handle(t *testing.T) {
 				PublicKey: "testPublicKey",
 			},
 			beforeFunc: func(getter *mocks.TransactionsGetter) {
-				getter.On("GetTransactions", context.Background(), "testPublicKey").
+				getter.On("GetTransactions", mock.Anything, aggregates.TransactionsQuery{
+					PublicKey: "testPublicKey",
+					Currency:  "EUR",
+					Valuation: aggregates.ValuationCurrent,
+				}).
+					Return([]aggregates.Transaction{
+						{
+							BlockTime:    blockTime,
+							CounterParty: "testCounterParty",
+							Amount:       aggregates.Money{Amount: 10000, Currency: "EUR"},
+							ExchangeRate: big.NewRat(8587262, 100000),
+							Signature:    "testSignature",
+						},
+					}, nil)
+			},
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			title: "transaction without valuation",
+			requestBody: &mockRequest{
+				PublicKey: "testPublicKey",
+			},
+			beforeFunc: func(getter *mocks.TransactionsGetter) {
+				getter.On("GetTransactions", mock.Anything, aggregates.TransactionsQuery{
+					PublicKey: "testPublicKey",
+					Currency:  "EUR",
+					Valuation: aggregates.ValuationCurrent,
+				}).
 					Return([]aggregates.Transaction{
 						{
 							BlockTime:    blockTime,
 							CounterParty: "testCounterParty",
-							AmountEUR:    "100.00",
 							Signature:    "testSignature",
 						},
 					}, nil)
@@ -59,7 +87,11 @@ func TestTransactionsGetterHandler_Handle(t *testing.T) {
 				PublicKey: "testPublicKey",
 			},
 			beforeFunc: func(getter *mocks.TransactionsGetter) {
-				getter.On("GetTransactions", context.Background(), "testPublicKey").
+				getter.On("GetTransactions", mock.Anything, aggregates.TransactionsQuery{
+					PublicKey: "testPublicKey",
+					Currency:  "EUR",
+					Valuation: aggregates.ValuationCurrent,
+				}).
 					Return(nil, errors.New("internal server error"))
 			},
 			wantStatusCode: http.StatusInternalServerError,
@@ -105,3 +137,114 @@ func TestTransactionsGetterHandler_Handle(t *testing.T) {
 		})
 	}
 }
+
+func TestTransactionsGetterHandler_V1Handle(t *testing.T) {
+	t.Parallel()
+
+	blockTime := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	transactions := []aggregates.Transaction{
+		{
+			BlockTime:    blockTime,
+			CounterParty: "testCounterParty",
+			Amount:       aggregates.Money{Amount: 10000, Currency: "EUR"},
+			ExchangeRate: big.NewRat(8587262, 100000),
+			Signature:    "testSignature1",
+		},
+		{
+			BlockTime:    blockTime.Add(-time.Hour),
+			CounterParty: "testCounterParty",
+			Amount:       aggregates.Money{Amount: -505, Currency: "EUR"},
+			ExchangeRate: big.NewRat(8587262, 100000),
+			Signature:    "testSignature2",
+		},
+	}
+
+	tests := []struct {
+		title          string
+		query          string
+		beforeFunc     func(*mocks.TransactionsGetter)
+		wantStatusCode int
+	}{
+		{
+			title: "full page with the next page cursor",
+			query: "?limit=2&before=testSignature0&currency=usd",
+			beforeFunc: func(getter *mocks.TransactionsGetter) {
+				getter.On("GetTransactions", mock.Anything, aggregates.TransactionsQuery{
+					PublicKey: "testPublicKey",
+					Currency:  "USD",
+					Valuation: aggregates.ValuationCurrent,
+					Page:      aggregates.TransactionsPage{Limit: 2, Before: "testSignature0"},
+				}).Return(transactions, nil)
+			},
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			title: "last page with the default limit",
+			query: "",
+			beforeFunc: func(getter *mocks.TransactionsGetter) {
+				getter.On("GetTransactions", mock.Anything, aggregates.TransactionsQuery{
+					PublicKey: "testPublicKey",
+					Currency:  "EUR",
+					Valuation: aggregates.ValuationCurrent,
+					Page:      aggregates.TransactionsPage{Limit: 100},
+				}).Return(transactions, nil)
+			},
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			title: "bad request with invalid limit",
+			query: "?limit=1001",
+			beforeFunc: func(getter *mocks.TransactionsGetter) {
+				getter.AssertNotCalled(t, "GetTransactions")
+			},
+			wantStatusCode: http.StatusBadRequest,
+		},
+		{
+			title: "bad request with invalid cursor",
+			query: "?before=invalid",
+			beforeFunc: func(getter *mocks.TransactionsGetter) {
+				getter.On("GetTransactions", mock.Anything, mock.Anything).
+					Return(nil, fmt.Errorf("error getting transactions: %w",
+						aggregates.ErrInvalidSignature))
+			},
+			wantStatusCode: http.StatusBadRequest,
+		},
+	}
+
+	cupaloy := cupaloy.New(
+		cupaloy.SnapshotSubdirectory("./.snapshots/transactions-get-v1-test"))
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.title, func(t *testing.T) {
+			t.Parallel()
+
+			getter := &mocks.TransactionsGetter{}
+			test.beforeFunc(getter)
+
+			handler := handlers.NewTransactionsGetterHandler(getter)
+
+			router := handlers.NewRouter()
+			router.HandleFunc(http.MethodGet, "/v1/wallets/{pubkey}/transactions", handler.V1Handler())
+
+			server := httptest.NewServer(router)
+			defer server.Close()
+
+			resp, err := http.Get(server.URL + "/v1/wallets/testPublicKey/transactions" + test.query)
+			require.NoError(t, err)
+
+			require.Equal(t, test.wantStatusCode, resp.StatusCode)
+
+			body, err := ioutil.ReadAll(resp.Body)
+			require.NoError(t, err)
+			resp.Body.Close()
+
+			require.NoError(t, cupaloy.SnapshotMulti(
+				getSnapshotFileName(test.title),
+				string(body)))
+
+			assert.True(t, getter.AssertExpectations(t))
+		})
+	}
+}
@@ -3,9 +3,9 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"log/slog"
 	"net/http"
-	"strings"
 
 	"github.com/jcleira/coding-challenge/internal/domain/aggregates"
 )
@@ -19,62 +19,146 @@ type TransactionsSender interface {
 // TransactionsSenderHandler handles sending transactions to the Solana blockchain.
 type TransactionsSenderHandler struct {
 	TransactionsSender TransactionsSender
+
+	// currencies are the fiat currencies accepted in the amounts to send.
+	currencies []string
 }
 
-// NewTransactionsSenderHandler creates a new TransactionsSenderHandler.
-func NewTransactionsSenderHandler(transactionsSender TransactionsSender) *TransactionsSenderHandler {
+// NewTransactionsSenderHandler creates a new TransactionsSenderHandler that
+// accepts amounts in the given fiat currencies.
+func NewTransactionsSenderHandler(
+	transactionsSender TransactionsSender, currencies []string) *TransactionsSenderHandler {
 	return &TransactionsSenderHandler{
 		TransactionsSender: transactionsSender,
+		currencies:         currencies,
 	}
 }
 
 // Handler handles sending transactions to the Solana blockchain.
 func (th *TransactionsSenderHandler) Handler() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		request := struct {
-			PublicKey string `json:"public_key"`
-			To        string `json:"to"`
-			Amount    string `json:"amount"`
-		}{}
+		request := httpSendRequest{}
 
 		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
-			http.Error(w, err.Error(), http.StatusBadRequest)
+			writeError(w, r, invalidRequestBody(err))
 			return
 		}
 
-		splitted := strings.Split(request.Amount, " ")
-		if len(splitted) != 2 {
-			http.Error(w, "Invalid amount", http.StatusBadRequest)
+		th.serve(w, r, http.StatusOK,
+			request.PublicKey, request.To, request.Amount, request.Currency)
+	}
+}
+
+// V1Handler handles the transfers from the wallet in the pubkey path
+// parameter, the body carries the to, amount and optional currency fields, as
+// in Handler. Transfers are answered with 201 Created.
+func (th *TransactionsSenderHandler) V1Handler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		request := httpTransferRequest{}
+
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			writeError(w, r, invalidRequestBody(err))
 			return
 		}
 
-		amountEUR := splitted[1]
+		th.serve(w, r, http.StatusCreated,
+			PathParam(r, "pubkey"), request.To, request.Amount, request.Currency)
+	}
+}
 
-		transaction := aggregates.Transaction{
-			Signer:       request.PublicKey,
-			CounterParty: request.To,
-			AmountEUR:    amountEUR,
-		}
+// serve sends the amount from the signer to the counter party, and writes the
+// transaction signature with the status code. The signature of a transaction
+// that failed after being broadcast, such as one that timed out confirming, is
+// written in the error details, so the client can look it up instead of
+// sending it again.
+func (th *TransactionsSenderHandler) serve(w http.ResponseWriter, r *http.Request,
+	status int, signer, to, rawAmount, currency string) {
+	amount, err := aggregates.ParseAmount(rawAmount, th.currencies)
+	if err != nil {
+		writeError(w, r, aggregates.NewValidationError("amount", err))
+		return
+	}
 
-		signature, err := th.TransactionsSender.SendTransaction(r.Context(), transaction)
-		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-			return
-		}
+	// The amount carries its own currency, such as "USD 5.00", the optional
+	// currency field is only accepted when both agree.
+	if currency != "" && requestCurrency(currency) != amount.Currency {
+		writeError(w, r, aggregates.NewValidationError("currency",
+			fmt.Errorf("%s doesn't match the amount currency %s",
+				currency, amount.Currency)))
+		return
+	}
 
-		response, err := json.Marshal(
-			struct {
-				Signature string `json:"signature"`
-			}{Signature: signature},
-		)
-		if err != nil {
-			http.Error(w, "Error marshalling response", http.StatusInternalServerError)
-			return
-		}
+	transaction := aggregates.Transaction{
+		Signer:       signer,
+		CounterParty: to,
+		Amount:       amount,
+	}
 
-		w.Header().Set("Content-Type", "application/json")
-		if _, err := w.Write(response); err != nil {
-			slog.Error("Error writing response", err)
+	signature, err := th.TransactionsSender.SendTransaction(r.Context(), transaction)
+	if err != nil {
+		if signature != "" {
+			err = &detailedError{err: err, details: map[string]string{"signature": signature}}
 		}
+
+		writeError(w, r, err)
+		return
+	}
+
+	response, err := json.Marshal(httpSendResponse{Signature: signature})
+	if err != nil {
+		writeError(w, r, fmt.Errorf("error marshalling response: %w", err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if _, err := w.Write(response); err != nil {
+		slog.ErrorContext(r.Context(), "error writing response", "error", err)
+	}
+}
+
+// httpSendRequest is the http request sending a transaction.
+type httpSendRequest struct {
+	PublicKey string `json:"public_key"`
+	To        string `json:"to"`
+	Amount    string `json:"amount" description:"Amount with its currency, such as EUR 5.05"`
+	Currency  string `json:"currency,omitempty"`
+}
+
+// httpTransferRequest is the http request of a transfer from a wallet.
+type httpTransferRequest struct {
+	To       string `json:"to"`
+	Amount   string `json:"amount" description:"Amount with its currency, such as EUR 5.05"`
+	Currency string `json:"currency,omitempty"`
+}
+
+// httpSendResponse is the http response of a sent transaction.
+type httpSendResponse struct {
+	Signature string `json:"signature"`
+}
+
+// Routes returns the routes sending transactions.
+func (th *TransactionsSenderHandler) Routes() []Route {
+	return []Route{
+		{
+			Method:   http.MethodPost,
+			Path:     "/send",
+			Summary:  "Send a fiat amount of SOL, blocking until the transaction is confirmed",
+			Request:  httpSendRequest{},
+			Response: httpSendResponse{},
+			Scope:    aggregates.ScopeSend,
+			Handler:  th.Handler(),
+		},
+		{
+			Method:     http.MethodPost,
+			Path:       "/v1/wallets/{pubkey}/transfers",
+			Summary:    "Transfer a fiat amount of SOL, blocking until the transaction is confirmed",
+			Parameters: []Parameter{pubkeyParameter},
+			Request:    httpTransferRequest{},
+			Status:     http.StatusCreated,
+			Response:   httpSendResponse{},
+			Scope:      aggregates.ScopeSend,
+			Handler:    th.V1Handler(),
+		},
 	}
 }
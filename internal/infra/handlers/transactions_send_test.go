@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
@@ -14,6 +15,7 @@ import (
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 
+	"github.com/jcleira/coding-challenge/internal/domain/aggregates"
 	"github.com/jcleira/coding-challenge/internal/infra/handlers"
 	"github.com/jcleira/coding-challenge/mocks"
 )
@@ -22,6 +24,7 @@ type mockSendRequest struct {
 	PublicKey string `json:"public_key"`
 	To        string `json:"to"`
 	Amount    string `json:"amount"`
+	Currency  string `json:"currency,omitempty"`
 }
 
 func TestTransactionsSenderHandler_Handle(t *testing.T) {
@@ -38,7 +41,7 @@ func TestTransactionsSenderHandler_Handle(t *testing.T) {
 			requestBody: &mockSendRequest{
 				PublicKey: "testPublicKey",
 				To:        "testReceiver",
-				Amount:    "100 EUR",
+				Amount:    "EUR 100",
 			},
 			beforeFunc: func(sender *mocks.TransactionsSender) {
 				sender.On("SendTransaction",
@@ -69,12 +72,91 @@ func TestTransactionsSenderHandler_Handle(t *testing.T) {
 			},
 			wantStatusCode: http.StatusBadRequest,
 		},
+		{
+			title: "bad request with mismatched currency",
+			requestBody: &mockSendRequest{
+				PublicKey: "testPublicKey",
+				To:        "testReceiver",
+				Amount:    "USD 100",
+				Currency:  "EUR",
+			},
+			beforeFunc: func(sender *mocks.TransactionsSender) {
+				sender.AssertNotCalled(t, "SendTransaction")
+			},
+			wantStatusCode: http.StatusBadRequest,
+		},
+		{
+			title: "bad request with currency not supported",
+			requestBody: &mockSendRequest{
+				PublicKey: "testPublicKey",
+				To:        "testReceiver",
+				Amount:    "GBP 100",
+			},
+			beforeFunc: func(sender *mocks.TransactionsSender) {
+				sender.AssertNotCalled(t, "SendTransaction")
+			},
+			wantStatusCode: http.StatusBadRequest,
+		},
+		{
+			title: "bad request with currency no longer supported by the exchange",
+			requestBody: &mockSendRequest{
+				PublicKey: "testPublicKey",
+				To:        "testReceiver",
+				Amount:    "USD 100",
+			},
+			beforeFunc: func(sender *mocks.TransactionsSender) {
+				sender.On("SendTransaction",
+					mock.Anything, mock.AnythingOfType("aggregates.Transaction")).
+					Return("", fmt.Errorf("error getting exchange rate: %w", aggregates.ErrCurrencyNotSupported))
+			},
+			wantStatusCode: http.StatusBadRequest,
+		},
+		{
+			title: "bad request with negative amount",
+			requestBody: &mockSendRequest{
+				PublicKey: "testPublicKey",
+				To:        "testReceiver",
+				Amount:    "EUR -3",
+			},
+			beforeFunc: func(sender *mocks.TransactionsSender) {
+				sender.AssertNotCalled(t, "SendTransaction")
+			},
+			wantStatusCode: http.StatusBadRequest,
+		},
+		{
+			title: "bad request with exponent amount",
+			requestBody: &mockSendRequest{
+				PublicKey: "testPublicKey",
+				To:        "testReceiver",
+				Amount:    "EUR 1e9",
+			},
+			beforeFunc: func(sender *mocks.TransactionsSender) {
+				sender.AssertNotCalled(t, "SendTransaction")
+			},
+			wantStatusCode: http.StatusBadRequest,
+		},
+		{
+			title: "successful transaction sending with currency symbol",
+			requestBody: &mockSendRequest{
+				PublicKey: "testPublicKey",
+				To:        "testReceiver",
+				Amount:    "€5.05",
+			},
+			beforeFunc: func(sender *mocks.TransactionsSender) {
+				sender.On("SendTransaction", mock.Anything, aggregates.Transaction{
+					Signer:       "testPublicKey",
+					CounterParty: "testReceiver",
+					Amount:       aggregates.Money{Amount: 505, Currency: "EUR"},
+				}).Return("testSignature", nil)
+			},
+			wantStatusCode: http.StatusOK,
+		},
 		{
 			title: "internal server error on transaction sending",
 			requestBody: &mockSendRequest{
 				PublicKey: "testPublicKey",
 				To:        "testReceiver",
-				Amount:    "100 EUR",
+				Amount:    "EUR 100",
 			},
 			beforeFunc: func(sender *mocks.TransactionsSender) {
 				sender.On("SendTransaction",
@@ -83,6 +165,36 @@ func TestTransactionsSenderHandler_Handle(t *testing.T) {
 			},
 			wantStatusCode: http.StatusInternalServerError,
 		},
+		{
+			title: "gateway timeout on transaction confirmation",
+			requestBody: &mockSendRequest{
+				PublicKey: "testPublicKey",
+				To:        "testReceiver",
+				Amount:    "EUR 100",
+			},
+			beforeFunc: func(sender *mocks.TransactionsSender) {
+				sender.On("SendTransaction",
+					mock.Anything, mock.AnythingOfType("aggregates.Transaction")).
+					Return("", fmt.Errorf("error sending transaction: %w",
+						aggregates.ErrTransactionConfirmationTimeout))
+			},
+			wantStatusCode: http.StatusGatewayTimeout,
+		},
+		{
+			title: "gateway timeout on transaction confirmation with signature",
+			requestBody: &mockSendRequest{
+				PublicKey: "testPublicKey",
+				To:        "testReceiver",
+				Amount:    "EUR 100",
+			},
+			beforeFunc: func(sender *mocks.TransactionsSender) {
+				sender.On("SendTransaction",
+					mock.Anything, mock.AnythingOfType("aggregates.Transaction")).
+					Return("testSignature", fmt.Errorf("error sending transaction: %w",
+						aggregates.ErrTransactionConfirmationTimeout))
+			},
+			wantStatusCode: http.StatusGatewayTimeout,
+		},
 	}
 
 	cupaloy := cupaloy.New(
@@ -96,7 +208,7 @@ func TestTransactionsSenderHandler_Handle(t *testing.T) {
 			sender := &mocks.TransactionsSender{}
 			test.beforeFunc(sender)
 
-			handler := handlers.NewTransactionsSenderHandler(sender)
+			handler := handlers.NewTransactionsSenderHandler(sender, []string{"EUR", "USD"})
 
 			mux := http.NewServeMux()
 			mux.Handle("/", handler.Handler())
@@ -125,3 +237,35 @@ func TestTransactionsSenderHandler_Handle(t *testing.T) {
 		})
 	}
 }
+
+func TestTransactionsSenderHandler_V1Handle(t *testing.T) {
+	t.Parallel()
+
+	sender := &mocks.TransactionsSender{}
+	sender.On("SendTransaction", mock.Anything, aggregates.Transaction{
+		Signer:       "testPublicKey",
+		CounterParty: "testReceiver",
+		Amount:       aggregates.Money{Amount: 505, Currency: "EUR"},
+	}).Return("testSignature", nil)
+
+	handler := handlers.NewTransactionsSenderHandler(sender, []string{"EUR"})
+
+	router := handlers.NewRouter()
+	router.HandleFunc(http.MethodPost, "/v1/wallets/{pubkey}/transfers", handler.V1Handler())
+
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	resp, err := http.Post(server.URL+"/v1/wallets/testPublicKey/transfers",
+		"application/json", bytes.NewBufferString(`{"to":"testReceiver","amount":"EUR 5.05"}`))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	body, err := ioutil.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	assert.JSONEq(t, `{"signature":"testSignature"}`, string(body))
+	assert.True(t, sender.AssertExpectations(t))
+}
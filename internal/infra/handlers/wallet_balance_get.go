@@ -3,13 +3,16 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"log/slog"
 	"net/http"
+
+	"github.com/jcleira/coding-challenge/internal/domain/aggregates"
 )
 
 // WalletBalanceGetter defines the interface for getting wallet balances.
 type WalletBalanceGetter interface {
-	GetBalance(ctx context.Context, pubKey string) (string, error)
+	GetBalance(ctx context.Context, pubKey, currency string) (string, error)
 }
 
 // WalletBalanceGetterHandler define the dependencies to perform http requests
@@ -29,34 +32,82 @@ func NewWalletBalanceGetterHandler(
 // Handler is the http handler func  for getting the balance of wallets.
 func (wih *WalletBalanceGetterHandler) Handler() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		request := struct {
-			PublicKey string `json:"public_key"`
-		}{}
+		request := httpBalanceRequest{}
 
 		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
-			http.Error(w, err.Error(), http.StatusBadRequest)
+			writeError(w, r, invalidRequestBody(err))
 			return
 		}
 
-		balance, err := wih.walletBalanceGetter.GetBalance(r.Context(), request.PublicKey)
-		if err != nil {
-			http.Error(w, "Error initializing wallet", http.StatusInternalServerError)
-			return
-		}
+		wih.serve(w, r, request.PublicKey, requestCurrency(request.Currency))
+	}
+}
 
-		response, err := json.Marshal(struct {
-			PublicKey string `json:"balance"`
-		}{
-			PublicKey: balance,
-		})
-		if err != nil {
-			http.Error(w, "Error marshalling response", http.StatusInternalServerError)
-			return
-		}
+// V1Handler is the http handler func for getting the balance of the wallet in
+// the pubkey path parameter, in the optional currency query parameter.
+func (wih *WalletBalanceGetterHandler) V1Handler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		wih.serve(w, r, PathParam(r, "pubkey"), requestCurrency(r.URL.Query().Get("currency")))
+	}
+}
 
-		w.Header().Set("Content-Type", "application/json")
-		if _, err := w.Write(response); err != nil {
-			slog.Error("error writing response", err)
-		}
+// serve writes the balance of the wallet in the currency.
+func (wih *WalletBalanceGetterHandler) serve(
+	w http.ResponseWriter, r *http.Request, publicKey, currency string) {
+	balance, err := wih.walletBalanceGetter.GetBalance(r.Context(), publicKey, currency)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	response, err := json.Marshal(httpBalanceResponse{
+		Balance: balance,
+	})
+	if err != nil {
+		writeError(w, r, fmt.Errorf("error marshalling response: %w", err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if _, err := w.Write(response); err != nil {
+		slog.ErrorContext(r.Context(), "error writing response", "error", err)
+	}
+}
+
+// httpBalanceRequest is the http request for the balance of a wallet.
+type httpBalanceRequest struct {
+	PublicKey string `json:"public_key"`
+	Currency  string `json:"currency,omitempty"`
+}
+
+// httpBalanceResponse is the http response with the balance of a wallet.
+type httpBalanceResponse struct {
+	Balance string `json:"balance" description:"Balance with its currency, such as EUR 18.11"`
+}
+
+// Routes returns the routes of the wallet balances.
+func (wih *WalletBalanceGetterHandler) Routes() []Route {
+	return []Route{
+		{
+			Method:   http.MethodPost,
+			Path:     "/balance",
+			Summary:  "Get the balance of a wallet",
+			Request:  httpBalanceRequest{},
+			Response: httpBalanceResponse{},
+			Scope:    aggregates.ScopeReadBalance,
+			Handler:  wih.Handler(),
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    "/v1/wallets/{pubkey}/balance",
+			Summary: "Get the balance of a wallet",
+			Parameters: []Parameter{
+				pubkeyParameter,
+				queryParameter("currency", "Fiat currency of the balance, EUR by default", "string"),
+			},
+			Response: httpBalanceResponse{},
+			Scope:    aggregates.ScopeReadBalance,
+			Handler:  wih.V1Handler(),
+		},
 	}
 }
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
@@ -14,6 +15,7 @@ import (
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 
+	"github.com/jcleira/coding-challenge/internal/domain/aggregates"
 	"github.com/jcleira/coding-challenge/internal/infra/handlers"
 	"github.com/jcleira/coding-challenge/mocks"
 )
@@ -37,7 +39,7 @@ func TestWalletBalanceGetterHandler_Handle(t *testing.T) {
 				PublicKey: "testPublicKey",
 			},
 			beforeFunc: func(balanceGetter *mocks.WalletBalanceGetter) {
-				balanceGetter.On("GetBalance", mock.Anything, "testPublicKey").
+				balanceGetter.On("GetBalance", mock.Anything, "testPublicKey", "EUR").
 					Return("10.10", nil)
 			},
 			wantStatusCode: http.StatusOK,
@@ -48,11 +50,44 @@ func TestWalletBalanceGetterHandler_Handle(t *testing.T) {
 				PublicKey: "testPublicKey",
 			},
 			beforeFunc: func(balanceGetter *mocks.WalletBalanceGetter) {
-				balanceGetter.On("GetBalance", mock.Anything, "testPublicKey").
-					Return("10.10", errors.New("internal server error"))
+				balanceGetter.On("GetBalance", mock.Anything, "testPublicKey", "EUR").
+					Return("", errors.New("internal server error"))
 			},
 			wantStatusCode: http.StatusInternalServerError,
 		},
+		{
+			title: "bad request on invalid public key",
+			requestBody: &mockBalanceRequest{
+				PublicKey: "testPublicKey",
+			},
+			beforeFunc: func(balanceGetter *mocks.WalletBalanceGetter) {
+				balanceGetter.On("GetBalance", mock.Anything, "testPublicKey", "EUR").
+					Return("", fmt.Errorf("error getting balance: %w", aggregates.ErrInvalidPublicKey))
+			},
+			wantStatusCode: http.StatusBadRequest,
+		},
+		{
+			title: "unprocessable entity on expired rate",
+			requestBody: &mockBalanceRequest{
+				PublicKey: "testPublicKey",
+			},
+			beforeFunc: func(balanceGetter *mocks.WalletBalanceGetter) {
+				balanceGetter.On("GetBalance", mock.Anything, "testPublicKey", "EUR").
+					Return("", fmt.Errorf("error getting rate: %w", aggregates.ErrRateExpired))
+			},
+			wantStatusCode: http.StatusUnprocessableEntity,
+		},
+		{
+			title: "service unavailable on solana unavailable",
+			requestBody: &mockBalanceRequest{
+				PublicKey: "testPublicKey",
+			},
+			beforeFunc: func(balanceGetter *mocks.WalletBalanceGetter) {
+				balanceGetter.On("GetBalance", mock.Anything, "testPublicKey", "EUR").
+					Return("", fmt.Errorf("error getting balance: %w", aggregates.ErrSolanaUnavailable))
+			},
+			wantStatusCode: http.StatusServiceUnavailable,
+		},
 	}
 
 	cupaloy := cupaloy.New(
@@ -95,3 +130,31 @@ func TestWalletBalanceGetterHandler_Handle(t *testing.T) {
 		})
 	}
 }
+
+func TestWalletBalanceGetterHandler_V1Handle(t *testing.T) {
+	t.Parallel()
+
+	balanceGetter := &mocks.WalletBalanceGetter{}
+	balanceGetter.On("GetBalance", mock.Anything, "testPublicKey", "USD").
+		Return("USD 10.10", nil)
+
+	handler := handlers.NewWalletBalanceGetterHandler(balanceGetter)
+
+	router := handlers.NewRouter()
+	router.HandleFunc(http.MethodGet, "/v1/wallets/{pubkey}/balance", handler.V1Handler())
+
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/v1/wallets/testPublicKey/balance?currency=USD")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	body, err := ioutil.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	assert.JSONEq(t, `{"balance":"USD 10.10"}`, string(body))
+	assert.True(t, balanceGetter.AssertExpectations(t))
+}
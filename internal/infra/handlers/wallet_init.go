@@ -1,14 +1,18 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
 	"log/slog"
 	"net/http"
+
+	"github.com/jcleira/coding-challenge/internal/domain/aggregates"
 )
 
 // WalletInitializer defines the dependencies for initializing wallets.
 type WalletInitializer interface {
-	Initialize() (string, error)
+	Initialize(ctx context.Context) (string, error)
 }
 
 // WalletInitializerHandler define the dependencies handling wallet init requests.
@@ -26,25 +30,47 @@ func NewWalletInitializerHandler(initializer WalletInitializer) *WalletInitializ
 // Handler is the http handler func  for initializing wallets.
 func (wih *WalletInitializerHandler) Handler() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		publicKey, err := wih.initializer.Initialize()
+		publicKey, err := wih.initializer.Initialize(r.Context())
 		if err != nil {
-			http.Error(w, "Error initializing wallet", http.StatusInternalServerError)
+			writeError(w, r, err)
 			return
 		}
 
-		response, err := json.Marshal(struct {
-			PublicKey string `json:"public_key"`
-		}{
+		response, err := json.Marshal(httpWalletResponse{
 			PublicKey: publicKey,
 		})
 		if err != nil {
-			http.Error(w, "Error marshalling response", http.StatusInternalServerError)
+			writeError(w, r, fmt.Errorf("error marshalling response: %w", err))
 			return
 		}
 
 		w.Header().Set("Content-Type", "application/json")
 		if _, err := w.Write(response); err != nil {
-			slog.Error("error writing response", err)
+			slog.ErrorContext(r.Context(), "error writing response", "error", err)
 		}
 	}
 }
+
+// httpWalletRequest is the http request for a wallet operation.
+type httpWalletRequest struct {
+	PublicKey string `json:"public_key"`
+}
+
+// httpWalletResponse is the http response of the wallet operations.
+type httpWalletResponse struct {
+	PublicKey string `json:"public_key"`
+}
+
+// Routes returns the routes of the wallet initialization.
+func (wih *WalletInitializerHandler) Routes() []Route {
+	return []Route{
+		{
+			Method:   http.MethodPost,
+			Path:     "/init",
+			Summary:  "Create a wallet, storing its private key",
+			Response: httpWalletResponse{},
+			Scope:    aggregates.ScopeInit,
+			Handler:  wih.Handler(),
+		},
+	}
+}
@@ -9,6 +9,7 @@ import (
 
 	"github.com/bradleyjkemp/cupaloy"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 
 	"github.com/jcleira/coding-challenge/internal/infra/handlers"
@@ -26,14 +27,14 @@ func TestWalletInitializerHandler_Handle(t *testing.T) {
 		{
 			title: "successful wallet initialization",
 			beforeFunc: func(initializer *mocks.WalletInitializer) {
-				initializer.On("Initialize").Return("testPublicKey", nil)
+				initializer.On("Initialize", mock.Anything).Return("testPublicKey", nil)
 			},
 			wantStatusCode: http.StatusOK,
 		},
 		{
 			title: "error during wallet initialization",
 			beforeFunc: func(initializer *mocks.WalletInitializer) {
-				initializer.On("Initialize").Return("", errors.New("initialization error"))
+				initializer.On("Initialize", mock.Anything).Return("", errors.New("initialization error"))
 			},
 			wantStatusCode: http.StatusInternalServerError,
 		},
@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/jcleira/coding-challenge/internal/domain/aggregates"
+)
+
+// WalletRotator defines the interface for rotating wallets.
+type WalletRotator interface {
+	Rotate(ctx context.Context, publicKey string) (string, error)
+}
+
+// WalletRotatorHandler define the dependencies handling wallet rotation
+// requests.
+type WalletRotatorHandler struct {
+	rotator WalletRotator
+}
+
+// NewWalletRotatorHandler creates a new WalletRotatorHandler.
+func NewWalletRotatorHandler(rotator WalletRotator) *WalletRotatorHandler {
+	return &WalletRotatorHandler{
+		rotator: rotator,
+	}
+}
+
+// Handler is the http handler func for rotating wallets.
+func (wrh *WalletRotatorHandler) Handler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		request := httpWalletRequest{}
+
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			writeError(w, r, invalidRequestBody(err))
+			return
+		}
+
+		publicKey, err := wrh.rotator.Rotate(r.Context(), request.PublicKey)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+
+		response, err := json.Marshal(httpWalletResponse{
+			PublicKey: publicKey,
+		})
+		if err != nil {
+			writeError(w, r, fmt.Errorf("error marshalling response: %w", err))
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if _, err := w.Write(response); err != nil {
+			slog.ErrorContext(r.Context(), "error writing response", "error", err)
+		}
+	}
+}
+
+// Routes returns the routes of the wallet rotation.
+func (wrh *WalletRotatorHandler) Routes() []Route {
+	return []Route{
+		{
+			Method:   http.MethodPost,
+			Path:     "/rotate",
+			Summary:  "Rotate the key of a wallet, moving its funds to a new one",
+			Request:  httpWalletRequest{},
+			Response: httpWalletResponse{},
+			Scope:    aggregates.ScopeAdmin,
+			Handler:  wrh.Handler(),
+		},
+	}
+}
@@ -0,0 +1,110 @@
+package handlers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bradleyjkemp/cupaloy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jcleira/coding-challenge/internal/domain/aggregates"
+	"github.com/jcleira/coding-challenge/internal/infra/handlers"
+	"github.com/jcleira/coding-challenge/mocks"
+)
+
+type mockRotateRequest struct {
+	PublicKey string `json:"public_key"`
+}
+
+func TestWalletRotatorHandler_Handle(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		title          string
+		requestBody    *mockRotateRequest
+		beforeFunc     func(*mocks.WalletRotator)
+		wantStatusCode int
+	}{
+		{
+			title: "successful wallet rotation",
+			requestBody: &mockRotateRequest{
+				PublicKey: "testPublicKey",
+			},
+			beforeFunc: func(rotator *mocks.WalletRotator) {
+				rotator.On("Rotate", mock.Anything, "testPublicKey").
+					Return("newTestPublicKey", nil)
+			},
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			title: "internal server error on wallet rotation",
+			requestBody: &mockRotateRequest{
+				PublicKey: "testPublicKey",
+			},
+			beforeFunc: func(rotator *mocks.WalletRotator) {
+				rotator.On("Rotate", mock.Anything, "testPublicKey").
+					Return("", errors.New("internal server error"))
+			},
+			wantStatusCode: http.StatusInternalServerError,
+		},
+		{
+			title: "not found error on wallet rotation",
+			requestBody: &mockRotateRequest{
+				PublicKey: "testPublicKey",
+			},
+			beforeFunc: func(rotator *mocks.WalletRotator) {
+				rotator.On("Rotate", mock.Anything, "testPublicKey").
+					Return("", fmt.Errorf("error getting wallet: %w", aggregates.ErrWalletNotFound))
+			},
+			wantStatusCode: http.StatusNotFound,
+		},
+	}
+
+	cupaloy := cupaloy.New(
+		cupaloy.SnapshotSubdirectory("./.snapshots/wallet-rotate-test"))
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.title, func(t *testing.T) {
+			t.Parallel()
+
+			rotator := &mocks.WalletRotator{}
+			test.beforeFunc(rotator)
+
+			handler := handlers.NewWalletRotatorHandler(rotator)
+
+			mux := http.NewServeMux()
+			mux.Handle("/", handler.Handler())
+
+			server := httptest.NewServer(mux)
+			defer server.Close()
+
+			requestBody, _ := json.Marshal(test.requestBody)
+			req, err := http.NewRequest(http.MethodPost, server.URL, bytes.NewBuffer(requestBody))
+			assert.NoError(t, err)
+
+			resp, err := http.DefaultClient.Do(req)
+			assert.NoError(t, err)
+
+			assert.Equal(t, test.wantStatusCode, resp.StatusCode)
+
+			body, err := ioutil.ReadAll(resp.Body)
+			assert.NoError(t, err)
+			resp.Body.Close()
+
+			require.NoError(t, cupaloy.SnapshotMulti(
+				getSnapshotFileName(test.title),
+				string(body)))
+
+			assert.True(t, rotator.AssertExpectations(t))
+		})
+	}
+}
@@ -0,0 +1,204 @@
+// Package logging sets up the structured logger of the service, enriching
+// every record with the request scoped values of its context and redacting
+// the secrets that could leak into them.
+package logging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/gagliardetto/solana-go"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/jcleira/coding-challenge/internal/domain/aggregates"
+)
+
+const (
+	// FormatJSON logs a JSON object per line, meant for production.
+	FormatJSON = "json"
+
+	// FormatText logs key=value pairs, meant for development.
+	FormatText = "text"
+)
+
+// redacted replaces the values of the secrets.
+const redacted = "[REDACTED]"
+
+// ErrInvalidFormat is returned for an unknown log format.
+var ErrInvalidFormat = errors.New("invalid log format")
+
+// sensitiveKeys are the attribute keys, and the header names, whose values
+// are never logged, compared case insensitively.
+var sensitiveKeys = map[string]bool{
+	"authorization": true,
+	"x-api-key":     true,
+	"cookie":        true,
+	"set-cookie":    true,
+	"api_key":       true,
+	"token":         true,
+	"secret":        true,
+	"password":      true,
+	"private_key":   true,
+	"privatekey":    true,
+	"master_key":    true,
+}
+
+// attrsContextKey is the context key of the attributes added with
+// ContextWithAttrs, it's unexported to prevent collisions with other
+// packages.
+type attrsContextKey struct{}
+
+// New creates a new logger writing to w in the format, json or text, from
+// the level, a *slog.LevelVar allows changing it while the logger is in use.
+func New(w io.Writer, format string, level slog.Leveler) (*slog.Logger, error) {
+	options := &slog.HandlerOptions{Level: level}
+
+	var handler slog.Handler
+	switch format {
+	case FormatJSON:
+		handler = slog.NewJSONHandler(w, options)
+	case FormatText:
+		handler = slog.NewTextHandler(w, options)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrInvalidFormat, format)
+	}
+
+	return slog.New(NewHandler(handler)), nil
+}
+
+// ParseLevel parses a log level, such as info or debug.
+func ParseLevel(level string) (slog.Level, error) {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(level)); err != nil {
+		return 0, fmt.Errorf("error parsing log level: %w", err)
+	}
+
+	return lvl, nil
+}
+
+// ContextWithAttrs returns a copy of ctx carrying the attributes, which are
+// added to every record logged with it, such as the wallet of a request.
+func ContextWithAttrs(ctx context.Context, attrs ...slog.Attr) context.Context {
+	current, _ := ctx.Value(attrsContextKey{}).([]slog.Attr)
+
+	merged := make([]slog.Attr, 0, len(current)+len(attrs))
+	merged = append(merged, current...)
+	merged = append(merged, attrs...)
+
+	return context.WithValue(ctx, attrsContextKey{}, merged)
+}
+
+// Handler is a slog.Handler adding the request ID, the trace and the
+// attributes carried by the context of the records, and redacting the
+// secrets, before passing them to the next handler.
+//
+// The values of the sensitive keys, such as authorization or private_key,
+// the Solana private keys and the sensitive HTTP headers are redacted
+// wherever they are, even inside groups.
+type Handler struct {
+	next slog.Handler
+}
+
+// NewHandler creates a new Handler passing the records to next.
+func NewHandler(next slog.Handler) *Handler {
+	return &Handler{next: next}
+}
+
+// Enabled implements slog.Handler.
+func (h *Handler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+// Handle implements slog.Handler.
+func (h *Handler) Handle(ctx context.Context, record slog.Record) error {
+	redactedRecord := slog.NewRecord(record.Time, record.Level, record.Message, record.PC)
+
+	if requestID := aggregates.RequestIDFromContext(ctx); requestID != "" {
+		redactedRecord.AddAttrs(slog.String("request_id", requestID))
+	}
+
+	if span := trace.SpanContextFromContext(ctx); span.IsValid() {
+		redactedRecord.AddAttrs(
+			slog.String("trace_id", span.TraceID().String()),
+			slog.String("span_id", span.SpanID().String()),
+		)
+	}
+
+	if attrs, ok := ctx.Value(attrsContextKey{}).([]slog.Attr); ok {
+		for _, attr := range attrs {
+			redactedRecord.AddAttrs(redact(attr))
+		}
+	}
+
+	record.Attrs(func(attr slog.Attr) bool {
+		redactedRecord.AddAttrs(redact(attr))
+		return true
+	})
+
+	return h.next.Handle(ctx, redactedRecord)
+}
+
+// WithAttrs implements slog.Handler.
+func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	redactedAttrs := make([]slog.Attr, len(attrs))
+	for i, attr := range attrs {
+		redactedAttrs[i] = redact(attr)
+	}
+
+	return &Handler{next: h.next.WithAttrs(redactedAttrs)}
+}
+
+// WithGroup implements slog.Handler.
+func (h *Handler) WithGroup(name string) slog.Handler {
+	return &Handler{next: h.next.WithGroup(name)}
+}
+
+// redact returns the attribute with its value redacted when it's a secret,
+// the groups are redacted recursively.
+func redact(attr slog.Attr) slog.Attr {
+	attr.Value = attr.Value.Resolve()
+
+	if sensitiveKeys[strings.ToLower(attr.Key)] {
+		return slog.String(attr.Key, redacted)
+	}
+
+	switch attr.Value.Kind() {
+	case slog.KindGroup:
+		group := attr.Value.Group()
+
+		redactedGroup := make([]slog.Attr, len(group))
+		for i, groupAttr := range group {
+			redactedGroup[i] = redact(groupAttr)
+		}
+
+		return slog.Attr{Key: attr.Key, Value: slog.GroupValue(redactedGroup...)}
+
+	case slog.KindAny:
+		switch value := attr.Value.Any().(type) {
+		case solana.PrivateKey, *solana.PrivateKey:
+			return slog.String(attr.Key, redacted)
+		case http.Header:
+			return slog.Any(attr.Key, redactHeader(value))
+		}
+	}
+
+	return attr
+}
+
+// redactHeader returns a copy of the header with the values of the sensitive
+// headers redacted.
+func redactHeader(header http.Header) http.Header {
+	redactedHeader := header.Clone()
+	for name := range redactedHeader {
+		if sensitiveKeys[strings.ToLower(name)] {
+			redactedHeader[name] = []string{redacted}
+		}
+	}
+
+	return redactedHeader
+}
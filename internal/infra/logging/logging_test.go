@@ -0,0 +1,211 @@
+package logging_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jcleira/coding-challenge/internal/domain/aggregates"
+	"github.com/jcleira/coding-challenge/internal/infra/logging"
+)
+
+func TestHandler(t *testing.T) {
+	t.Parallel()
+
+	privateKey, err := solana.NewRandomPrivateKey()
+	require.NoError(t, err)
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		log  func(ctx context.Context, logger *slog.Logger)
+		want map[string]interface{}
+	}{
+		{
+			name: "adds the request ID and the context attributes",
+			ctx: logging.ContextWithAttrs(
+				aggregates.ContextWithRequestID(context.Background(), "testRequestID"),
+				slog.String("wallet", "testPublicKey")),
+			log: func(ctx context.Context, logger *slog.Logger) {
+				logger.InfoContext(ctx, "test", "signature", "testSignature")
+			},
+			want: map[string]interface{}{
+				"request_id": "testRequestID",
+				"wallet":     "testPublicKey",
+				"signature":  "testSignature",
+			},
+		},
+		{
+			name: "redacts the sensitive keys",
+			ctx:  context.Background(),
+			log: func(ctx context.Context, logger *slog.Logger) {
+				logger.InfoContext(ctx, "test", "Authorization", "Bearer testToken", "token", "testToken")
+			},
+			want: map[string]interface{}{
+				"Authorization": "[REDACTED]",
+				"token":         "[REDACTED]",
+			},
+		},
+		{
+			name: "redacts the private keys",
+			ctx:  context.Background(),
+			log: func(ctx context.Context, logger *slog.Logger) {
+				logger.InfoContext(ctx, "test", "key", privateKey)
+			},
+			want: map[string]interface{}{
+				"key": "[REDACTED]",
+			},
+		},
+		{
+			name: "logs only the public key of the wallets",
+			ctx:  context.Background(),
+			log: func(ctx context.Context, logger *slog.Logger) {
+				logger.InfoContext(ctx, "test", "wallet", aggregates.Wallet{
+					PrivateKey: privateKey,
+					PublicKey:  "testPublicKey",
+				})
+			},
+			want: map[string]interface{}{
+				"wallet": map[string]interface{}{"public_key": "testPublicKey"},
+			},
+		},
+		{
+			name: "redacts the sensitive headers",
+			ctx:  context.Background(),
+			log: func(ctx context.Context, logger *slog.Logger) {
+				logger.InfoContext(ctx, "test", "headers", http.Header{
+					"X-Api-Key":    {"testAPIKey"},
+					"Content-Type": {"application/json"},
+				})
+			},
+			want: map[string]interface{}{
+				"headers": map[string]interface{}{
+					"X-Api-Key":    []interface{}{"[REDACTED]"},
+					"Content-Type": []interface{}{"application/json"},
+				},
+			},
+		},
+		{
+			name: "redacts inside groups and logger attributes",
+			ctx:  context.Background(),
+			log: func(ctx context.Context, logger *slog.Logger) {
+				logger.With("private_key", "testPrivateKey").InfoContext(ctx, "test",
+					slog.Group("request", "authorization", "Bearer testToken", "path", "/"))
+			},
+			want: map[string]interface{}{
+				"private_key": "[REDACTED]",
+				"request": map[string]interface{}{
+					"authorization": "[REDACTED]",
+					"path":          "/",
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var buf bytes.Buffer
+
+			logger, err := logging.New(&buf, logging.FormatJSON, slog.LevelInfo)
+			require.NoError(t, err)
+
+			tt.log(tt.ctx, logger)
+
+			var line map[string]interface{}
+			require.NoError(t, json.Unmarshal(buf.Bytes(), &line))
+
+			for key, want := range tt.want {
+				assert.Equal(t, want, line[key], key)
+			}
+
+			assert.NotContains(t, buf.String(), "testToken")
+			assert.NotContains(t, buf.String(), privateKey.String())
+		})
+	}
+}
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		format  string
+		wantErr bool
+	}{
+		{name: "json", format: logging.FormatJSON},
+		{name: "text", format: logging.FormatText},
+		{name: "invalid format", format: "xml", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			logger, err := logging.New(&bytes.Buffer{}, tt.format, slog.LevelInfo)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.NotNil(t, logger)
+		})
+	}
+}
+
+func TestNew_LevelVar(t *testing.T) {
+	t.Parallel()
+
+	var level slog.LevelVar
+
+	logger, err := logging.New(&bytes.Buffer{}, logging.FormatJSON, &level)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	assert.False(t, logger.Enabled(ctx, slog.LevelDebug))
+
+	level.Set(slog.LevelDebug)
+	assert.True(t, logger.Enabled(ctx, slog.LevelDebug))
+}
+
+func TestParseLevel(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		level   string
+		want    slog.Level
+		wantErr bool
+	}{
+		{name: "info", level: "info", want: slog.LevelInfo},
+		{name: "debug", level: "DEBUG", want: slog.LevelDebug},
+		{name: "invalid", level: "loud", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			level, err := logging.ParseLevel(tt.level)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, level)
+		})
+	}
+}
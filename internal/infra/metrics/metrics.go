@@ -0,0 +1,219 @@
+// Package metrics defines the Prometheus metrics of the service, and the
+// helpers the handlers and repositories use to record them, so the metric
+// names and label values are kept in a single place.
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const (
+	// OutcomeSuccess is the outcome label of the operations that completed.
+	OutcomeSuccess = "success"
+
+	// OutcomeError is the outcome label of the operations that failed.
+	OutcomeError = "error"
+)
+
+const (
+	// SendConfirmed is the outcome of the sends confirmed by the cluster.
+	SendConfirmed = "confirmed"
+
+	// SendTimeout is the outcome of the sends that weren't confirmed in time,
+	// they might still be confirmed later.
+	SendTimeout = "timeout"
+
+	// SendFailed is the outcome of the sends that failed before or while
+	// confirming.
+	SendFailed = "failed"
+)
+
+// Registry is the registry of the service metrics, along with the Go runtime
+// and process metrics.
+var Registry = prometheus.NewRegistry()
+
+var (
+	httpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "HTTP requests by route, method and status code.",
+	}, []string{"route", "method", "status"})
+
+	httpRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "HTTP request latency by route, method and status code.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"route", "method", "status"})
+
+	rpcCalls = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "solana_rpc_calls_total",
+		Help: "Solana RPC calls by method and outcome.",
+	}, []string{"method", "outcome"})
+
+	rpcCallDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "solana_rpc_call_duration_seconds",
+		Help:    "Solana RPC call latency by method.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method"})
+
+	rpcEndpointFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "solana_rpc_endpoint_failures_total",
+		Help: "Solana RPC calls failed over to another endpoint, by endpoint host and reason.",
+	}, []string{"endpoint", "reason"})
+
+	providerFetchFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "exchange_provider_fetch_failures_total",
+		Help: "Failed exchange rate fetches by provider and currency.",
+	}, []string{"provider", "currency"})
+
+	sends = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "send_transactions_total",
+		Help: "Transactions sent by outcome, confirmed, timeout or failed.",
+	}, []string{"outcome"})
+
+	sendConfirmationDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "send_confirmation_duration_seconds",
+		Help:    "Time from submitting a transaction to its outcome, by outcome.",
+		Buckets: []float64{0.25, 0.5, 1, 2, 3, 5, 10, 30},
+	}, []string{"outcome"})
+
+	vaultOperations = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "vault_operations_total",
+		Help: "Vault operations by operation and outcome.",
+	}, []string{"operation", "outcome"})
+
+	auditEvents = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "audit_events_total",
+		Help: "Audit events recorded by outcome, an error is an event missing from the audit log.",
+	}, []string{"outcome"})
+
+	rateAges = &rateAgeCollector{
+		desc: prometheus.NewDesc("exchange_rate_age_seconds",
+			"Time since the exchange rate of the currency was observed.",
+			[]string{"currency"}, nil),
+		times: make(map[string]time.Time),
+	}
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequests,
+		httpRequestDuration,
+		rpcCalls,
+		rpcCallDuration,
+		rpcEndpointFailures,
+		providerFetchFailures,
+		sends,
+		sendConfirmationDuration,
+		vaultOperations,
+		auditEvents,
+		rateAges,
+	)
+}
+
+// Handler returns the handler serving the metrics in the Prometheus
+// exposition format.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+}
+
+// ObserveHTTPRequest records a request to the route pattern, such as
+// /v1/wallets/{pubkey}/balance, so the label values stay bounded.
+func ObserveHTTPRequest(route, method string, status int, duration time.Duration) {
+	code := strconv.Itoa(status)
+
+	httpRequests.WithLabelValues(route, method, code).Inc()
+	httpRequestDuration.WithLabelValues(route, method, code).Observe(duration.Seconds())
+}
+
+// ObserveRPCCall records a call to the Solana RPC method.
+func ObserveRPCCall(method string, duration time.Duration, err error) {
+	rpcCalls.WithLabelValues(method, outcome(err)).Inc()
+	rpcCallDuration.WithLabelValues(method).Observe(duration.Seconds())
+}
+
+// RecordRPCEndpointFailure records a call failed over from the Solana RPC
+// endpoint, the endpoint is its host so the URL secrets aren't exposed.
+func RecordRPCEndpointFailure(endpoint, reason string) {
+	rpcEndpointFailures.WithLabelValues(endpoint, reason).Inc()
+}
+
+// RecordProviderFetchFailure records a failed fetch of the currency rate
+// from the provider.
+func RecordProviderFetchFailure(provider, currency string) {
+	providerFetchFailures.WithLabelValues(provider, currency).Inc()
+}
+
+// SetRateTime records when the rate of the currency was observed, its age is
+// computed when the metrics are scraped.
+func SetRateTime(currency string, observedAt time.Time) {
+	rateAges.set(currency, observedAt)
+}
+
+// ObserveSend records the outcome of a transaction send, with the time it
+// took since the transaction was submitted.
+func ObserveSend(sendOutcome string, duration time.Duration) {
+	sends.WithLabelValues(sendOutcome).Inc()
+	sendConfirmationDuration.WithLabelValues(sendOutcome).Observe(duration.Seconds())
+}
+
+// RecordVaultOperation records a vault operation, such as get or create.
+func RecordVaultOperation(operation string, err error) {
+	vaultOperations.WithLabelValues(operation, outcome(err)).Inc()
+}
+
+// RecordAuditEvent records the outcome of recording an audit event.
+func RecordAuditEvent(err error) {
+	auditEvents.WithLabelValues(outcome(err)).Inc()
+}
+
+// outcome returns the outcome label of an operation from its error.
+func outcome(err error) string {
+	if err != nil {
+		return OutcomeError
+	}
+
+	return OutcomeSuccess
+}
+
+// rateAgeCollector collects the age of the exchange rates, which grows
+// between updates, so it's computed on each scrape.
+type rateAgeCollector struct {
+	desc *prometheus.Desc
+
+	mu    sync.Mutex
+	times map[string]time.Time
+}
+
+// set records when the rate of the currency was observed.
+func (c *rateAgeCollector) set(currency string, observedAt time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.times[currency] = observedAt
+}
+
+// Describe implements prometheus.Collector.
+func (c *rateAgeCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+// Collect implements prometheus.Collector.
+func (c *rateAgeCollector) Collect(ch chan<- prometheus.Metric) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	for currency, observedAt := range c.times {
+		ch <- prometheus.MustNewConstMetric(c.desc,
+			prometheus.GaugeValue, now.Sub(observedAt).Seconds(), currency)
+	}
+}
@@ -0,0 +1,101 @@
+package metrics_test
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jcleira/coding-challenge/internal/infra/metrics"
+)
+
+func TestHandler(t *testing.T) {
+	metrics.ObserveRPCCall("getBalance", 20*time.Millisecond, nil)
+	metrics.ObserveRPCCall("getBalance", 20*time.Millisecond, errors.New("rpc error"))
+	metrics.RecordRPCEndpointFailure("api.devnet.solana.com", "throttled")
+	metrics.RecordProviderFetchFailure("coinbase", "EUR")
+	metrics.SetRateTime("EUR", time.Now().Add(-time.Minute))
+	metrics.ObserveSend(metrics.SendTimeout, 3*time.Second)
+	metrics.RecordVaultOperation("get", nil)
+	metrics.RecordAuditEvent(errors.New("disk full"))
+
+	recorder := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(recorder,
+		httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	data, err := io.ReadAll(recorder.Body)
+	require.NoError(t, err)
+
+	body := string(data)
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{
+			name: "rpc call success",
+			want: `solana_rpc_calls_total{method="getBalance",outcome="success"} 1`,
+		},
+		{
+			name: "rpc call error",
+			want: `solana_rpc_calls_total{method="getBalance",outcome="error"} 1`,
+		},
+		{
+			name: "rpc call latency",
+			want: `solana_rpc_call_duration_seconds_count{method="getBalance"} 2`,
+		},
+		{
+			name: "rpc endpoint failure",
+			want: `solana_rpc_endpoint_failures_total{endpoint="api.devnet.solana.com",reason="throttled"} 1`,
+		},
+		{
+			name: "provider fetch failure",
+			want: `exchange_provider_fetch_failures_total{currency="EUR",provider="coinbase"} 1`,
+		},
+		{
+			name: "send outcome",
+			want: `send_transactions_total{outcome="timeout"} 1`,
+		},
+		{
+			name: "send confirmation latency",
+			want: `send_confirmation_duration_seconds_sum{outcome="timeout"} 3`,
+		},
+		{
+			name: "vault operation",
+			want: `vault_operations_total{operation="get",outcome="success"} 1`,
+		},
+		{
+			name: "audit event",
+			want: `audit_events_total{outcome="error"} 1`,
+		},
+		{
+			name: "go runtime",
+			want: "go_goroutines",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Contains(t, body, tt.want)
+		})
+	}
+
+	t.Run("rate age", func(t *testing.T) {
+		matches := regexp.MustCompile(
+			`exchange_rate_age_seconds{currency="EUR"} (\S+)`).FindStringSubmatch(body)
+		require.Len(t, matches, 2)
+
+		age, err := strconv.ParseFloat(matches[1], 64)
+		require.NoError(t, err)
+		assert.GreaterOrEqual(t, age, 60.0)
+	})
+}
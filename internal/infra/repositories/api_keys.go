@@ -0,0 +1,294 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/jcleira/coding-challenge/internal/domain/aggregates"
+)
+
+// FileAPIKeyStore is an API key store keeping the keys in a JSON file, only
+// the hashes of the key secrets are stored. The file can be shared by the
+// replicas, which take an exclusive lock on it while they update it.
+//
+// The keys are cached for the lookups, and read again once the file changes,
+// so a key revoked by another replica or the command line is rejected from
+// the next lookup on.
+type FileAPIKeyStore struct {
+	Path string
+
+	mu sync.Mutex
+
+	// keys are the stored keys as of info, the file they were read from.
+	keys map[string]storedAPIKey
+	info os.FileInfo
+}
+
+// NewFileAPIKeyStore creates a new FileAPIKeyStore storing the keys in the
+// file at path.
+func NewFileAPIKeyStore(path string) (*FileAPIKeyStore, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("error creating API key store directory: %w", err)
+	}
+
+	return &FileAPIKeyStore{Path: path}, nil
+}
+
+// Create stores a new key.
+func (s *FileAPIKeyStore) Create(_ context.Context, key aggregates.APIKey) error {
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	keys, err := s.load()
+	if err != nil {
+		return err
+	}
+
+	if _, ok := keys[key.ID]; ok {
+		return fmt.Errorf("API key %s already exists", key.ID)
+	}
+
+	keys[key.ID] = newStoredAPIKey(key)
+
+	return s.save(keys)
+}
+
+// Get returns the key with the id, or ErrAPIKeyNotFound.
+func (s *FileAPIKeyStore) Get(_ context.Context, id string) (aggregates.APIKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	keys, err := s.cached()
+	if err != nil {
+		return aggregates.APIKey{}, err
+	}
+
+	key, ok := keys[id]
+	if !ok {
+		return aggregates.APIKey{}, fmt.Errorf("%w: %s", aggregates.ErrAPIKeyNotFound, id)
+	}
+
+	return key.toAggregate(id), nil
+}
+
+// List returns every key, sorted by creation time.
+func (s *FileAPIKeyStore) List(_ context.Context) ([]aggregates.APIKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	keys, err := s.cached()
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]aggregates.APIKey, 0, len(keys))
+	for id, key := range keys {
+		list = append(list, key.toAggregate(id))
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].CreatedAt.Equal(list[j].CreatedAt) {
+			return list[i].ID < list[j].ID
+		}
+
+		return list[i].CreatedAt.Before(list[j].CreatedAt)
+	})
+
+	return list, nil
+}
+
+// Update replaces an existing key, or returns ErrAPIKeyNotFound.
+func (s *FileAPIKeyStore) Update(_ context.Context, key aggregates.APIKey) error {
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	keys, err := s.load()
+	if err != nil {
+		return err
+	}
+
+	if _, ok := keys[key.ID]; !ok {
+		return fmt.Errorf("%w: %s", aggregates.ErrAPIKeyNotFound, key.ID)
+	}
+
+	keys[key.ID] = newStoredAPIKey(key)
+
+	return s.save(keys)
+}
+
+// Touch sets the last use of the key with the id to at, unless it was used
+// later, or returns ErrAPIKeyNotFound. Only the last use of the stored key is
+// changed, so a concurrent revocation isn't undone.
+func (s *FileAPIKeyStore) Touch(_ context.Context, id string, at time.Time) error {
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	keys, err := s.load()
+	if err != nil {
+		return err
+	}
+
+	key, ok := keys[id]
+	if !ok {
+		return fmt.Errorf("%w: %s", aggregates.ErrAPIKeyNotFound, id)
+	}
+
+	if !at.After(key.LastUsedAt) {
+		return nil
+	}
+
+	key.LastUsedAt = at
+	keys[id] = key
+
+	return s.save(keys)
+}
+
+// lock takes the store mutex and the file lock shared with the other
+// replicas, returning the function releasing both.
+func (s *FileAPIKeyStore) lock() (func(), error) {
+	s.mu.Lock()
+
+	unlock, err := lockFile(s.Path + ".lock")
+	if err != nil {
+		s.mu.Unlock()
+		return nil, fmt.Errorf("error locking API key store: %w", err)
+	}
+
+	return func() {
+		unlock()
+		s.mu.Unlock()
+	}, nil
+}
+
+// cached returns the cached keys, which are loaded again when the file
+// changed since they were, it must be called with the store mutex held.
+func (s *FileAPIKeyStore) cached() (map[string]storedAPIKey, error) {
+	info, err := os.Stat(s.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		s.keys, s.info = nil, nil
+		return make(map[string]storedAPIKey), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading API key store: %w", err)
+	}
+
+	// The file is replaced on every save, but it could be edited in place too.
+	if s.info != nil && os.SameFile(s.info, info) &&
+		s.info.ModTime().Equal(info.ModTime()) && s.info.Size() == info.Size() {
+		return s.keys, nil
+	}
+
+	keys, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+
+	s.keys, s.info = keys, info
+
+	return keys, nil
+}
+
+// load loads the stored keys, a missing file means no keys.
+func (s *FileAPIKeyStore) load() (map[string]storedAPIKey, error) {
+	keys := make(map[string]storedAPIKey)
+
+	data, err := os.ReadFile(s.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return keys, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading API key store: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return nil, fmt.Errorf("error decoding API key store: %w", err)
+	}
+
+	return keys, nil
+}
+
+// save replaces the stored keys, it writes to a temporary file first so a
+// crash can't leave a truncated file behind.
+func (s *FileAPIKeyStore) save(keys map[string]storedAPIKey) error {
+	data, err := json.Marshal(keys)
+	if err != nil {
+		return fmt.Errorf("error encoding API keys: %w", err)
+	}
+
+	tmpFilename := s.Path + ".tmp"
+	if err := os.WriteFile(tmpFilename, data, 0600); err != nil {
+		return fmt.Errorf("error writing API key store: %w", err)
+	}
+
+	if err := os.Rename(tmpFilename, s.Path); err != nil {
+		return fmt.Errorf("error renaming API key store: %w", err)
+	}
+
+	s.keys, s.info = nil, nil
+
+	return nil
+}
+
+// storedAPIKey is the storage version of a domain API key, keyed by its ID.
+type storedAPIKey struct {
+	Tenant     string             `json:"tenant,omitempty"`
+	Name       string             `json:"name"`
+	Hash       string             `json:"hash"`
+	Scopes     []aggregates.Scope `json:"scopes"`
+	Wallets    []string           `json:"wallets"`
+	CreatedAt  time.Time          `json:"created_at"`
+	LastUsedAt time.Time          `json:"last_used_at"`
+	RevokedAt  time.Time          `json:"revoked_at"`
+}
+
+// newStoredAPIKey converts a domain API key to its storage version.
+func newStoredAPIKey(key aggregates.APIKey) storedAPIKey {
+	return storedAPIKey{
+		Tenant:     key.Tenant,
+		Name:       key.Name,
+		Hash:       key.Hash,
+		Scopes:     key.Scopes,
+		Wallets:    key.Wallets,
+		CreatedAt:  key.CreatedAt,
+		LastUsedAt: key.LastUsedAt,
+		RevokedAt:  key.RevokedAt,
+	}
+}
+
+// toAggregate converts the stored key with the id to a domain API key, the
+// keys issued before tenants existed belong to the default tenant.
+func (k storedAPIKey) toAggregate(id string) aggregates.APIKey {
+	tenant := k.Tenant
+	if tenant == "" {
+		tenant = aggregates.DefaultTenant
+	}
+
+	return aggregates.APIKey{
+		ID:         id,
+		Tenant:     tenant,
+		Name:       k.Name,
+		Hash:       k.Hash,
+		Scopes:     slices.Clone(k.Scopes),
+		Wallets:    slices.Clone(k.Wallets),
+		CreatedAt:  k.CreatedAt,
+		LastUsedAt: k.LastUsedAt,
+		RevokedAt:  k.RevokedAt,
+	}
+}
@@ -0,0 +1,167 @@
+package repositories_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jcleira/coding-challenge/internal/domain/aggregates"
+	"github.com/jcleira/coding-challenge/internal/infra/repositories"
+)
+
+func TestFileAPIKeyStore(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "keys", "api_keys.json")
+
+	store, err := repositories.NewFileAPIKeyStore(path)
+	require.NoError(t, err)
+
+	keys, err := store.List(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, keys)
+
+	key, token, err := aggregates.NewAPIKey("shop",
+		[]aggregates.Scope{aggregates.ScopeSend}, []string{"wallet"})
+	require.NoError(t, err)
+
+	require.NoError(t, store.Create(ctx, key))
+	assert.Error(t, store.Create(ctx, key))
+
+	// Only the hash of the secret is stored.
+	_, secret, err := aggregates.ParseAPIKeyToken(token)
+	require.NoError(t, err)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), secret)
+
+	key.LastUsedAt = time.Date(2023, 10, 1, 12, 0, 0, 0, time.UTC)
+	require.NoError(t, store.Update(ctx, key))
+
+	// A new store reads the keys from the file.
+	store, err = repositories.NewFileAPIKeyStore(path)
+	require.NoError(t, err)
+
+	stored, err := store.Get(ctx, key.ID)
+	require.NoError(t, err)
+	assert.Equal(t, key.Hash, stored.Hash)
+	assert.Equal(t, key.Scopes, stored.Scopes)
+	assert.Equal(t, key.Wallets, stored.Wallets)
+	assert.True(t, key.LastUsedAt.Equal(stored.LastUsedAt))
+	assert.True(t, stored.Verify(secret))
+
+	keys, err = store.List(ctx)
+	require.NoError(t, err)
+	assert.Len(t, keys, 1)
+
+	_, err = store.Get(ctx, "unknown")
+	assert.True(t, errors.Is(err, aggregates.ErrAPIKeyNotFound), "got %v", err)
+
+	err = store.Update(ctx, aggregates.APIKey{ID: "unknown"})
+	assert.True(t, errors.Is(err, aggregates.ErrAPIKeyNotFound), "got %v", err)
+}
+
+func TestFileAPIKeyStore_TouchRacingRevoke(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "api_keys.json")
+
+	// Two stores on the same file stand for two replicas, only sharing the
+	// file lock.
+	authenticating, err := repositories.NewFileAPIKeyStore(path)
+	require.NoError(t, err)
+
+	revoking, err := repositories.NewFileAPIKeyStore(path)
+	require.NoError(t, err)
+
+	key, _, err := aggregates.NewAPIKey("shop", []aggregates.Scope{aggregates.ScopeSend}, nil)
+	require.NoError(t, err)
+	require.NoError(t, authenticating.Create(ctx, key))
+
+	// The key is authenticated before the revocation, and its last use is
+	// tracked while it's revoked.
+	authenticated, err := authenticating.Get(ctx, key.ID)
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			at := time.Now().UTC().Add(time.Duration(i) * time.Millisecond)
+			assert.NoError(t, authenticating.Touch(ctx, authenticated.ID, at))
+		}(i)
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		revoked := authenticated
+		revoked.RevokedAt = time.Now().UTC()
+		assert.NoError(t, revoking.Update(ctx, revoked))
+	}()
+	wg.Wait()
+
+	stored, err := revoking.Get(ctx, key.ID)
+	require.NoError(t, err)
+	assert.True(t, stored.Revoked())
+
+	// The last use never goes back in time.
+	lastUsedAt := time.Now().UTC().Add(time.Hour)
+	require.NoError(t, authenticating.Touch(ctx, key.ID, lastUsedAt))
+	require.NoError(t, authenticating.Touch(ctx, key.ID, lastUsedAt.Add(-time.Hour)))
+
+	stored, err = revoking.Get(ctx, key.ID)
+	require.NoError(t, err)
+	assert.True(t, lastUsedAt.Equal(stored.LastUsedAt))
+
+	err = authenticating.Touch(ctx, "unknown", time.Now())
+	assert.True(t, errors.Is(err, aggregates.ErrAPIKeyNotFound), "got %v", err)
+}
+
+func TestFileAPIKeyStore_CachedKeys(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "api_keys.json")
+
+	authenticating, err := repositories.NewFileAPIKeyStore(path)
+	require.NoError(t, err)
+
+	revoking, err := repositories.NewFileAPIKeyStore(path)
+	require.NoError(t, err)
+
+	key, _, err := aggregates.NewAPIKey("shop", []aggregates.Scope{aggregates.ScopeSend}, nil)
+	require.NoError(t, err)
+	require.NoError(t, revoking.Create(ctx, key))
+
+	stored, err := authenticating.Get(ctx, key.ID)
+	require.NoError(t, err)
+	assert.False(t, stored.Revoked())
+
+	// A key revoked by another replica is rejected from the next lookup.
+	stored.RevokedAt = time.Now().UTC()
+	require.NoError(t, revoking.Update(ctx, stored))
+
+	stored, err = authenticating.Get(ctx, key.ID)
+	require.NoError(t, err)
+	assert.True(t, stored.Revoked())
+
+	// Removing the file removes the keys.
+	require.NoError(t, os.Remove(path))
+
+	_, err = authenticating.Get(ctx, key.ID)
+	assert.True(t, errors.Is(err, aggregates.ErrAPIKeyNotFound), "got %v", err)
+}
@@ -0,0 +1,353 @@
+package repositories
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/jcleira/coding-challenge/internal/domain/aggregates"
+	"github.com/jcleira/coding-challenge/internal/infra/metrics"
+)
+
+// AuditLog is an append only log of audit events stored as JSON lines in a
+// file, which the server and the command line tools share, the appends take
+// an exclusive lock on the file.
+//
+// Every entry includes the hash of the previous one and its own hash, which
+// covers both its content and the previous hash. Changing, removing or
+// reordering any entry breaks the chain from that entry onwards, which is
+// what Verify checks, but entries removed from the end of the log go
+// unnoticed. The hashes aren't keyed either, so anyone able to write the file
+// can rewrite it with a valid chain.
+type AuditLog struct {
+	Path string
+
+	mu sync.Mutex
+
+	// lastSeq and lastHash are the sequence number and hash of the last entry
+	// in the log as of size, the size of the file when they were read. They're
+	// read again when the file changes, as other processes append to it too.
+	lastSeq  uint64
+	lastHash string
+	size     int64
+}
+
+// NewAuditLog creates a new AuditLog writing to the file at path.
+func NewAuditLog(path string) (*AuditLog, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("error creating audit log directory: %w", err)
+	}
+
+	a := &AuditLog{Path: path}
+
+	unlock, err := lockFile(a.Path + ".lock")
+	if err != nil {
+		return nil, fmt.Errorf("error locking audit log: %w", err)
+	}
+	defer unlock()
+
+	if err := a.loadLast(); err != nil {
+		return nil, fmt.Errorf("error loading audit log: %w", err)
+	}
+
+	return a, nil
+}
+
+// Record appends an audit event to the log, counting the events that couldn't
+// be recorded in the metrics.
+func (a *AuditLog) Record(_ context.Context, event aggregates.AuditEvent) error {
+	err := a.record(event)
+	metrics.RecordAuditEvent(err)
+
+	return err
+}
+
+// record appends an audit event to the log, under the lock shared with the
+// other processes appending to it.
+func (a *AuditLog) record(event aggregates.AuditEvent) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	unlock, err := lockFile(a.Path + ".lock")
+	if err != nil {
+		return fmt.Errorf("error locking audit log: %w", err)
+	}
+	defer unlock()
+
+	if err := a.loadLast(); err != nil {
+		return fmt.Errorf("error reading audit log: %w", err)
+	}
+
+	entry := auditEntryFromDomainEvent(event)
+	entry.Seq = a.lastSeq + 1
+	entry.PrevHash = a.lastHash
+
+	hash, err := entry.hash()
+	if err != nil {
+		return fmt.Errorf("error hashing audit entry: %w", err)
+	}
+	entry.Hash = hash
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("error encoding audit entry: %w", err)
+	}
+	data = append(data, '\n')
+
+	file, err := os.OpenFile(a.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("error opening audit log: %w", err)
+	}
+	defer file.Close()
+
+	if _, err := file.Write(data); err != nil {
+		return fmt.Errorf("error writing audit entry: %w", err)
+	}
+
+	if err := file.Sync(); err != nil {
+		return fmt.Errorf("error syncing audit log: %w", err)
+	}
+
+	a.lastSeq = entry.Seq
+	a.lastHash = entry.Hash
+	a.size += int64(len(data))
+
+	return nil
+}
+
+// loadLast reads the last entry of the log when the file changed since it was
+// last read, only the entries appended since then are read. It must be called
+// with the file lock held.
+func (a *AuditLog) loadLast() error {
+	info, err := os.Stat(a.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		a.lastSeq, a.lastHash, a.size = 0, "", 0
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error reading audit log size: %w", err)
+	}
+
+	if info.Size() == a.size {
+		return nil
+	}
+
+	// A log that shrank was replaced, it's read from its start.
+	if info.Size() < a.size {
+		a.lastSeq, a.lastHash, a.size = 0, "", 0
+	}
+
+	err = a.scan(a.size, info.Size(), func(entry auditEntry) error {
+		a.lastSeq = entry.Seq
+		a.lastHash = entry.Hash
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	a.size = info.Size()
+
+	return nil
+}
+
+// snapshot returns the size of the log, taken under the file lock so it only
+// covers whole entries. Reading up to it doesn't block the appends.
+func (a *AuditLog) snapshot() (int64, error) {
+	unlock, err := lockFile(a.Path + ".lock")
+	if err != nil {
+		return 0, fmt.Errorf("error locking audit log: %w", err)
+	}
+	defer unlock()
+
+	info, err := os.Stat(a.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("error reading audit log size: %w", err)
+	}
+
+	return info.Size(), nil
+}
+
+// Query returns the audit events matching the filter, oldest first.
+func (a *AuditLog) Query(
+	_ context.Context, filter aggregates.AuditFilter) ([]aggregates.AuditEvent, error) {
+	size, err := a.snapshot()
+	if err != nil {
+		return nil, err
+	}
+
+	events := []aggregates.AuditEvent{}
+
+	err = a.scan(0, size, func(entry auditEntry) error {
+		event := entry.toDomainEvent()
+		if filter.Matches(event) {
+			events = append(events, event)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error reading audit log: %w", err)
+	}
+
+	return events, nil
+}
+
+// Verify walks the whole log checking the hash chain, it returns an error
+// wrapping aggregates.ErrAuditLogTampered on the first entry that doesn't
+// verify. The entries appended while it runs aren't verified.
+func (a *AuditLog) Verify() error {
+	size, err := a.snapshot()
+	if err != nil {
+		return err
+	}
+
+	var (
+		prevSeq  uint64
+		prevHash string
+	)
+
+	return a.scan(0, size, func(entry auditEntry) error {
+		if entry.Seq != prevSeq+1 {
+			return fmt.Errorf("%w: entry %d follows entry %d",
+				aggregates.ErrAuditLogTampered, entry.Seq, prevSeq)
+		}
+
+		if entry.PrevHash != prevHash {
+			return fmt.Errorf("%w: entry %d previous hash mismatch",
+				aggregates.ErrAuditLogTampered, entry.Seq)
+		}
+
+		hash, err := entry.hash()
+		if err != nil {
+			return fmt.Errorf("error hashing audit entry: %w", err)
+		}
+
+		if entry.Hash != hash {
+			return fmt.Errorf("%w: entry %d hash mismatch",
+				aggregates.ErrAuditLogTampered, entry.Seq)
+		}
+
+		prevSeq = entry.Seq
+		prevHash = entry.Hash
+
+		return nil
+	})
+}
+
+// scan calls fn for every entry in the log between the offsets from and to, a
+// missing file is an empty log. The line numbers of the errors count from
+// offset from.
+func (a *AuditLog) scan(from, to int64, fn func(auditEntry) error) error {
+	file, err := os.Open(a.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error opening audit log: %w", err)
+	}
+	defer file.Close()
+
+	if _, err := file.Seek(from, io.SeekStart); err != nil {
+		return fmt.Errorf("error seeking audit log: %w", err)
+	}
+
+	scanner := bufio.NewScanner(io.LimitReader(file, to-from))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	for line := 1; scanner.Scan(); line++ {
+		var entry auditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return fmt.Errorf("%w: error decoding line %d: %v",
+				aggregates.ErrAuditLogTampered, line, err)
+		}
+
+		if err := fn(entry); err != nil {
+			return err
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("error scanning audit log: %w", err)
+	}
+
+	return nil
+}
+
+// auditEntry is the storage version of a domain audit event.
+type auditEntry struct {
+	Seq       uint64            `json:"seq"`
+	Time      time.Time         `json:"time"`
+	Tenant    string            `json:"tenant,omitempty"`
+	Actor     string            `json:"actor,omitempty"`
+	RequestID string            `json:"request_id,omitempty"`
+	Action    string            `json:"action"`
+	Wallet    string            `json:"wallet,omitempty"`
+	Params    map[string]string `json:"params,omitempty"`
+	Outcome   string            `json:"outcome"`
+	Error     string            `json:"error,omitempty"`
+	PrevHash  string            `json:"prev_hash"`
+	Hash      string            `json:"hash"`
+}
+
+// hash computes the hash of the entry, it covers every field but the hash
+// itself. encoding/json sorts map keys, so the encoding is deterministic.
+func (e auditEntry) hash() (string, error) {
+	e.Hash = ""
+
+	data, err := json.Marshal(e)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(data)
+
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// toDomainEvent converts the audit entry to a domain audit event, the entries
+// recorded before tenants existed belong to the default tenant.
+func (e auditEntry) toDomainEvent() aggregates.AuditEvent {
+	tenant := e.Tenant
+	if tenant == "" {
+		tenant = aggregates.DefaultTenant
+	}
+
+	return aggregates.AuditEvent{
+		Time:      e.Time,
+		Tenant:    tenant,
+		Actor:     e.Actor,
+		RequestID: e.RequestID,
+		Action:    e.Action,
+		Wallet:    e.Wallet,
+		Params:    e.Params,
+		Outcome:   e.Outcome,
+		Error:     e.Error,
+	}
+}
+
+// auditEntryFromDomainEvent converts a domain audit event to an audit entry.
+func auditEntryFromDomainEvent(event aggregates.AuditEvent) auditEntry {
+	return auditEntry{
+		Time:      event.Time,
+		Tenant:    event.Tenant,
+		Actor:     event.Actor,
+		RequestID: event.RequestID,
+		Action:    event.Action,
+		Wallet:    event.Wallet,
+		Params:    event.Params,
+		Outcome:   event.Outcome,
+		Error:     event.Error,
+	}
+}
@@ -0,0 +1,206 @@
+package repositories_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jcleira/coding-challenge/internal/domain/aggregates"
+	"github.com/jcleira/coding-challenge/internal/infra/repositories"
+)
+
+func TestAuditLog_RecordAndQuery(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+
+	auditLog, err := repositories.NewAuditLog(path)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	now := time.Now().UTC()
+
+	events := []aggregates.AuditEvent{
+		{Time: now.Add(-2 * time.Hour), Action: aggregates.AuditActionWalletCreate, Wallet: "wallet1", Outcome: aggregates.AuditOutcomeSuccess},
+		{Time: now.Add(-1 * time.Hour), Action: aggregates.AuditActionTransactionSend, Wallet: "wallet1", Outcome: aggregates.AuditOutcomeFailure},
+		{Time: now, Action: aggregates.AuditActionWalletCreate, Wallet: "wallet2", Outcome: aggregates.AuditOutcomeSuccess},
+		{Time: now, Tenant: "acme", Action: aggregates.AuditActionWalletCreate, Wallet: "wallet3", Outcome: aggregates.AuditOutcomeSuccess},
+	}
+
+	for _, event := range events {
+		require.NoError(t, auditLog.Record(ctx, event))
+	}
+
+	tests := []struct {
+		name       string
+		filter     aggregates.AuditFilter
+		wantEvents []aggregates.AuditEvent
+	}{
+		{
+			name:       "no filter",
+			filter:     aggregates.AuditFilter{},
+			wantEvents: events,
+		},
+		{
+			name:       "by wallet",
+			filter:     aggregates.AuditFilter{Wallet: "wallet1"},
+			wantEvents: events[:2],
+		},
+		{
+			name:       "events without tenant belong to the default one",
+			filter:     aggregates.AuditFilter{Tenant: aggregates.DefaultTenant},
+			wantEvents: events[:3],
+		},
+		{
+			name:       "by tenant",
+			filter:     aggregates.AuditFilter{Tenant: "acme"},
+			wantEvents: events[3:],
+		},
+		{
+			name:       "by time range",
+			filter:     aggregates.AuditFilter{From: now.Add(-90 * time.Minute), To: now.Add(-30 * time.Minute)},
+			wantEvents: events[1:2],
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := auditLog.Query(ctx, tt.filter)
+			require.NoError(t, err)
+
+			require.Len(t, got, len(tt.wantEvents))
+			for i := range got {
+				assert.Equal(t, tt.wantEvents[i].Action, got[i].Action)
+				assert.Equal(t, tt.wantEvents[i].Wallet, got[i].Wallet)
+				assert.True(t, tt.wantEvents[i].Time.Equal(got[i].Time))
+			}
+		})
+	}
+}
+
+func TestAuditLog_Verify(t *testing.T) {
+	tests := []struct {
+		name    string
+		tamper  func(lines []string) []string
+		wantErr error
+	}{
+		{
+			name:   "untouched log",
+			tamper: func(lines []string) []string { return lines },
+		},
+		{
+			name: "modified entry",
+			tamper: func(lines []string) []string {
+				lines[1] = strings.Replace(lines[1], "wallet1", "wallet9", 1)
+				return lines
+			},
+			wantErr: aggregates.ErrAuditLogTampered,
+		},
+		{
+			name: "removed entry",
+			tamper: func(lines []string) []string {
+				return append(lines[:1], lines[2:]...)
+			},
+			wantErr: aggregates.ErrAuditLogTampered,
+		},
+		{
+			name: "reordered entries",
+			tamper: func(lines []string) []string {
+				lines[0], lines[1] = lines[1], lines[0]
+				return lines
+			},
+			wantErr: aggregates.ErrAuditLogTampered,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "audit.log")
+
+			auditLog, err := repositories.NewAuditLog(path)
+			require.NoError(t, err)
+
+			for i := 0; i < 3; i++ {
+				require.NoError(t, auditLog.Record(context.Background(), aggregates.AuditEvent{
+					Time:    time.Now().UTC(),
+					Action:  aggregates.AuditActionWalletAccess,
+					Wallet:  "wallet1",
+					Outcome: aggregates.AuditOutcomeSuccess,
+				}))
+			}
+
+			data, err := os.ReadFile(path)
+			require.NoError(t, err)
+
+			lines := tt.tamper(strings.Split(strings.TrimSpace(string(data)), "\n"))
+			require.NoError(t, os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0600))
+
+			err = auditLog.Verify()
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestAuditLog_ChainSurvivesRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	event := aggregates.AuditEvent{
+		Time:    time.Now().UTC(),
+		Action:  aggregates.AuditActionWalletCreate,
+		Outcome: aggregates.AuditOutcomeSuccess,
+	}
+
+	auditLog, err := repositories.NewAuditLog(path)
+	require.NoError(t, err)
+	require.NoError(t, auditLog.Record(context.Background(), event))
+
+	reopened, err := repositories.NewAuditLog(path)
+	require.NoError(t, err)
+	require.NoError(t, reopened.Record(context.Background(), event))
+
+	assert.NoError(t, reopened.Verify())
+}
+
+func TestAuditLog_SharedFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+
+	// The server and the command line tools open their own audit log on the
+	// same file.
+	server, err := repositories.NewAuditLog(path)
+	require.NoError(t, err)
+
+	command, err := repositories.NewAuditLog(path)
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	for _, auditLog := range []*repositories.AuditLog{server, command, server, command} {
+		wg.Add(1)
+		go func(auditLog *repositories.AuditLog) {
+			defer wg.Done()
+
+			for i := 0; i < 10; i++ {
+				assert.NoError(t, auditLog.Record(context.Background(), aggregates.AuditEvent{
+					Time:    time.Now().UTC(),
+					Action:  aggregates.AuditActionAPIKeyIssue,
+					Outcome: aggregates.AuditOutcomeSuccess,
+				}))
+			}
+		}(auditLog)
+	}
+	wg.Wait()
+
+	assert.NoError(t, server.Verify())
+
+	events, err := command.Query(context.Background(), aggregates.AuditFilter{})
+	require.NoError(t, err)
+	assert.Len(t, events, 40)
+}
@@ -0,0 +1,92 @@
+package repositories
+
+import (
+	"sync"
+	"time"
+)
+
+// CircuitBreaker stops calling a failing dependency for a while, so a provider
+// that is down doesn't slow down every refresh with its timeouts.
+//
+// The breaker opens after Threshold consecutive failures. Once Cooldown has
+// passed it lets a single call through, closing again if it succeeds and
+// reopening if it fails.
+type CircuitBreaker struct {
+	Threshold int
+	Cooldown  time.Duration
+
+	failures int
+	openedAt time.Time
+	probing  bool
+
+	mutex sync.Mutex
+}
+
+// NewCircuitBreaker creates a new closed CircuitBreaker.
+func NewCircuitBreaker(threshold int, cooldown time.Duration) *CircuitBreaker {
+	return &CircuitBreaker{
+		Threshold: threshold,
+		Cooldown:  cooldown,
+	}
+}
+
+// Allow reports whether a call can be made, it must be followed by a call to
+// Success or Failure when it returns true.
+func (c *CircuitBreaker) Allow() bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.failures < c.Threshold {
+		return true
+	}
+
+	if c.probing || time.Since(c.openedAt) < c.Cooldown {
+		return false
+	}
+
+	c.probing = true
+
+	return true
+}
+
+// Success records a successful call, closing the breaker.
+func (c *CircuitBreaker) Success() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.failures = 0
+	c.probing = false
+}
+
+// Failure records a failed call, opening the breaker when it reaches the
+// threshold.
+func (c *CircuitBreaker) Failure() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.failures++
+	c.probing = false
+
+	if c.failures >= c.Threshold {
+		c.openedAt = time.Now()
+	}
+}
+
+// Abandon records a call whose outcome is unknown, such as one canceled by
+// its caller, letting another call probe the dependency when it was the
+// probe.
+func (c *CircuitBreaker) Abandon() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.probing = false
+}
+
+// Open reports whether the breaker is open, that is calls are being skipped
+// or only a probe call is allowed.
+func (c *CircuitBreaker) Open() bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.failures >= c.Threshold
+}
@@ -0,0 +1,75 @@
+package repositories_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/jcleira/coding-challenge/internal/infra/repositories"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	breaker := repositories.NewCircuitBreaker(2, 50*time.Millisecond)
+
+	assert.True(t, breaker.Allow())
+	breaker.Failure()
+	assert.True(t, breaker.Allow())
+	breaker.Failure()
+
+	// Open after reaching the threshold.
+	assert.True(t, breaker.Open())
+	assert.False(t, breaker.Allow())
+
+	// A single probe is allowed after the cooldown, a failure reopens it.
+	time.Sleep(60 * time.Millisecond)
+	assert.True(t, breaker.Allow())
+	assert.False(t, breaker.Allow())
+	breaker.Failure()
+	assert.False(t, breaker.Allow())
+
+	// A successful probe closes it.
+	time.Sleep(60 * time.Millisecond)
+	assert.True(t, breaker.Allow())
+	breaker.Success()
+	assert.False(t, breaker.Open())
+	assert.True(t, breaker.Allow())
+}
+
+func TestCircuitBreaker_Abandon(t *testing.T) {
+	breaker := repositories.NewCircuitBreaker(1, 50*time.Millisecond)
+
+	assert.True(t, breaker.Allow())
+	breaker.Failure()
+
+	// An abandoned probe lets the next call probe, keeping it open.
+	time.Sleep(60 * time.Millisecond)
+	assert.True(t, breaker.Allow())
+	breaker.Abandon()
+	assert.True(t, breaker.Open())
+	assert.True(t, breaker.Allow())
+}
+
+func TestBackoff_Delay(t *testing.T) {
+	backoff := repositories.Backoff{Base: time.Second, Max: 10 * time.Second}
+
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{attempt: 1, want: time.Second},
+		{attempt: 2, want: 2 * time.Second},
+		{attempt: 3, want: 4 * time.Second},
+		{attempt: 4, want: 8 * time.Second},
+		{attempt: 5, want: 10 * time.Second},
+		{attempt: 50, want: 10 * time.Second},
+	}
+
+	for _, tt := range tests {
+		for i := 0; i < 10; i++ {
+			delay := backoff.Delay(tt.attempt)
+			assert.GreaterOrEqual(t, delay, tt.want/2)
+			assert.LessOrEqual(t, delay, tt.want)
+		}
+	}
+}
@@ -2,97 +2,274 @@ package repositories
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"math/big"
-	"net/http"
+	"sort"
 	"sync"
 	"time"
 
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
 	"github.com/jcleira/coding-challenge/internal/domain/aggregates"
+	"github.com/jcleira/coding-challenge/internal/infra/metrics"
+	"github.com/jcleira/coding-challenge/internal/infra/tracing"
 )
 
 const (
-	// exchangeTickerTime is the time between each exchange rate fetch.
-	exchangeTickerTime = 5 * time.Second
+	// exchangeDefaultRefreshInterval is the time between each exchange rate
+	// fetch, unless the settings set another one.
+	exchangeDefaultRefreshInterval = 5 * time.Second
 
-	// exchangeRateExpirationTime is the time after which the exchange rate
-	// expires. The value is set to 20 seconds for testing purposes. In a real
-	// world scenario, this value should be set to X? (1 minute?) I'm not sure.
+	// exchangeDefaultRateExpiration is the time after which the exchange rate
+	// expires, unless the settings set another one. The value is set to 20
+	// seconds for testing purposes. In a real world scenario, this value
+	// should be set to X? (1 minute?) I'm not sure.
 	//
 	// 20 seconds is a good value for testing, as it allows up to 4 failures.
-	exchangeRateExpirationTime = 20 * time.Second
-)
+	exchangeDefaultRateExpiration = 20 * time.Second
 
-var (
-	// supportedCurrencies is a list of currencies supported by the exchange.
-	// In this example, we only support SOLEUR, but eventually this list will
-	// grow.
-	//
-	// Using a global variable here is not ideal, but it's good enough for this
-	// example, as we don't have any database or other storage mechanism.
-	//
-	// TODO: Use a database to store the supported currencies.
-	supportedCurrencies = []string{"SOLEUR"}
+	// exchangeRetryBaseTime and exchangeRetryMaxTime bound the backoff between
+	// the refreshes of a currency after a failure. The backoff is capped
+	// further to a quarter of the rate expiration, so a failing currency is
+	// retried a few times before its rate expires.
+	exchangeRetryBaseTime = 1 * time.Second
+	exchangeRetryMaxTime  = 1 * time.Minute
+
+	// exchangeBreakerThreshold is the number of consecutive failures after
+	// which a provider is skipped for a currency, for exchangeBreakerCooldown.
+	exchangeBreakerThreshold = 3
+	exchangeBreakerCooldown  = 30 * time.Second
+
+	// exchangeOverrideSource is the source of the rates set by hand.
+	exchangeOverrideSource = "override"
 )
 
+// Exchange keeps the SOL exchange rates for a set of fiat currencies up to
+// date.
+//
+// Rates are fetched from every provider in parallel, quotes deviating from the
+// median by more than the maximum deviation are dropped, and the median of the
+// remaining quotes is published along with the providers that contributed to
+// it. A single provider failing or returning bad data doesn't stall the rates.
+// When an even number of providers is split, with both middle quotes too far
+// from the median, the mean of the two middle quotes is published.
+//
+// Every provider is guarded by a circuit breaker per currency, and the
+// currencies that failed to refresh are retried with an exponential backoff
+// instead of the regular interval, while the rest keep refreshing as usual.
 type Exchange struct {
-	APIURL string
+	Providers []RateProvider
+
+	// Currencies is the list of fiat currencies supported by the exchange, as
+	// ISO 4217 codes, such as EUR or USD.
+	Currencies []string
 
-	// rates is a map of currency pairs to exchange rates.
-	// For example, rates["SOLEUR"] = 1.2 means that 1 SOL is worth 1.2 EUR.
+	// rates is a map of fiat currencies to exchange rates.
+	// For example, rates["EUR"] = 1.2 means that 1 SOL is worth 1.2 EUR.
 	// Using a map here is not idead as the map is not thread-safe, but it's
 	// good enough for this example as it's syncronized with a mutex.
 	rates map[string]aggregates.Rate
 
+	// overrides is a map of fiat currencies to the rates set by hand, they
+	// take precedence over the fetched rates until they expire.
+	overrides map[string]aggregates.Rate
+
+	// store persists the rates after every refresh, it's optional.
+	store RateStore
+
+	// feed publishes every refreshed rate to its subscribers.
+	feed *RateFeed
+
+	// breakers maps provider names and currencies, as breakerKey, to their
+	// circuit breakers.
+	breakers map[string]*CircuitBreaker
+
+	logger *slog.Logger
+
+	// lastSuccess, lastError and lastErrorAt track the refreshes for the
+	// exchange health, and failures maps currencies to their consecutive
+	// failed refreshes.
+	lastSuccess time.Time
+	lastError   string
+	lastErrorAt time.Time
+	failures    map[string]int
+
 	rateMutex sync.RWMutex
+
+	settings      ExchangeSettings
+	settingsMutex sync.RWMutex
+
+	// reconfigured wakes the refresh loop up when the settings change, so a
+	// new refresh interval doesn't wait for the previous one to elapse.
+	reconfigured chan struct{}
+
+	// cancel stops the refresh loop, which closes stopped once it's done.
+	cancel  context.CancelFunc
+	stopped chan struct{}
 }
 
-// NewExchange creates a new exchange with the given API URL.
-//
-// The exchange will perform an initial fetch of the exchange rates, to prevent
-// the service to operate with non initialized rates.
+// ExchangeSettings are the settings of an Exchange that can be changed while
+// it runs.
+type ExchangeSettings struct {
+	// MaxDeviation is the maximum relative deviation from the median for a
+	// quote to be accepted, for example 0.05 accepts quotes within 5% of the
+	// median.
+	MaxDeviation float64
+
+	// RefreshInterval is the time between each exchange rate fetch, 5 seconds
+	// when it's zero. A rate that missed a refresh is considered stale.
+	RefreshInterval time.Duration
+
+	// RateExpiration is the time after which a fetched rate expires, 20
+	// seconds when it's zero.
+	RateExpiration time.Duration
+}
+
+// withDefaults returns the settings with the defaults of the unset durations.
+func (s ExchangeSettings) withDefaults() ExchangeSettings {
+	if s.RefreshInterval == 0 {
+		s.RefreshInterval = exchangeDefaultRefreshInterval
+	}
+
+	if s.RateExpiration == 0 {
+		s.RateExpiration = exchangeDefaultRateExpiration
+	}
+
+	return s
+}
+
+// ExchangeOptions are the optional settings of an Exchange.
+type ExchangeOptions struct {
+	// Store persists the rates, the last known rates are loaded from it on
+	// startup, keeping their original expiration.
+	Store RateStore
+
+	// StartDegraded allows the exchange to start when the initial fetch fails
+	// and there are no valid stored rates. Until a refresh succeeds, the
+	// rates without a valid value, including the ones never fetched, fail
+	// with aggregates.ErrRateExpired.
+	StartDegraded bool
+
+	// Logger logs the refreshes, slog.Default() is used when it's nil.
+	Logger *slog.Logger
+}
+
+// NewExchange creates a new exchange fetching rates from the given providers,
+// supporting the given fiat currencies.
 //
-// Then the exchange will start a goroutine that will fetch the exchange rates
-// every 5 seconds, I'm using a ticker here, but a better approach would be to
-// use a cron job, with an external database to store the rates.
+// The exchange will load the stored rates, if any, and perform an initial
+// fetch of the exchange rates. When the fetch fails and some currency is left
+// without a valid rate, the exchange refuses to start unless it's allowed to
+// start degraded.
 //
-// The thing that I don't like about this approach is that there is no proper
-// error handling in the goroutine, so if the exchange rate API fails, the
-// request will start failing.
-func NewExchange(ctx context.Context, apiURL string) (*Exchange, error) {
+// Then the exchange will start a goroutine that will refresh the exchange
+// rates every refresh interval, backing off when the refreshes fail.
+func NewExchange(ctx context.Context, providers []RateProvider,
+	currencies []string, settings ExchangeSettings, options ExchangeOptions) (*Exchange, error) {
+	if len(providers) == 0 {
+		return nil, errors.New("at least one rate provider is required")
+	}
+
 	e := &Exchange{
-		APIURL: apiURL,
+		Providers:    providers,
+		Currencies:   currencies,
+		rates:        make(map[string]aggregates.Rate),
+		overrides:    make(map[string]aggregates.Rate),
+		store:        options.Store,
+		feed:         NewRateFeed(),
+		breakers:     make(map[string]*CircuitBreaker),
+		failures:     make(map[string]int),
+		logger:       options.Logger,
+		settings:     settings.withDefaults(),
+		reconfigured: make(chan struct{}, 1),
+		stopped:      make(chan struct{}),
+	}
+
+	if e.logger == nil {
+		e.logger = slog.Default()
 	}
 
-	rates := make(map[string]aggregates.Rate)
-	for _, currency := range supportedCurrencies {
-		rate, err := e.fetchRate(currency)
+	for _, provider := range providers {
+		for _, currency := range currencies {
+			e.breakers[breakerKey(provider.Name(), currency)] = NewCircuitBreaker(
+				exchangeBreakerThreshold, exchangeBreakerCooldown)
+		}
+	}
+
+	if e.store != nil {
+		rates, err := e.store.Load()
 		if err != nil {
-			return nil, fmt.Errorf("error fetching rate: %w", err)
+			return nil, fmt.Errorf("error loading stored rates: %w", err)
 		}
 
-		rates[currency] = rate
+		for _, currency := range e.Currencies {
+			if rate, ok := rates[currency]; ok {
+				e.rates[currency] = rate
+				metrics.SetRateTime(currency, rate.Time)
+			}
+		}
 	}
 
-	e.rates = rates
+	if err := e.joinFailures(e.refresh(ctx, e.Currencies)); err != nil {
+		if !options.StartDegraded && e.Health().State == aggregates.RateHealthExpired {
+			return nil, fmt.Errorf("error fetching rates: %w", err)
+		}
 
+		e.logger.WarnContext(ctx, "starting exchange degraded", "error", err)
+	}
+
+	ctx, e.cancel = context.WithCancel(ctx)
 	go e.start(ctx)
 
 	return e, nil
 }
 
-func (e *Exchange) GetRate() (aggregates.Rate, error) {
+// Stop stops refreshing the rates, cancelling an ongoing refresh,
+// and closes the feed so the subscribers are done. The last rates are still
+// served until they expire.
+func (e *Exchange) Stop() {
+	e.cancel()
+	<-e.stopped
+}
+
+// GetRate gets the SOL exchange rate for the given fiat currency, an override
+// is used instead of the fetched rate while it's valid.
+func (e *Exchange) GetRate(ctx context.Context, currency string) (aggregates.Rate, error) {
+	_, span := tracer.Start(ctx, "Exchange.GetRate",
+		trace.WithAttributes(attribute.String("currency", currency)))
+
+	rate, err := e.rate(currency)
+	if err == nil {
+		span.SetAttributes(
+			attribute.StringSlice("exchange.rate.sources", rate.Sources),
+			attribute.Bool("exchange.rate.overridden", rate.Overridden),
+			attribute.Float64("exchange.rate.age_seconds", time.Since(rate.Time).Seconds()),
+		)
+	}
+	tracing.EndSpan(span, err)
+
+	return rate, err
+}
+
+// rate returns the current rate of the currency, the override while it's
+// valid or the fetched rate until it expires.
+func (e *Exchange) rate(currency string) (aggregates.Rate, error) {
+	if !e.supports(currency) {
+		return aggregates.Rate{}, aggregates.ErrCurrencyNotSupported
+	}
+
 	e.rateMutex.RLock()
 	defer e.rateMutex.RUnlock()
 
-	// Hardcoded to SOLEUR for now, but eventually this will be a parameter.
-	rate, ok := e.rates[supportedCurrencies[0]]
-	if !ok {
-		return aggregates.Rate{}, aggregates.ErrCurrencyNotSupported
+	if override, ok := e.overrides[currency]; ok && override.ExpiredAt.After(time.Now()) {
+		return override, nil
 	}
 
+	// A rate never fetched has a zero expiration, so it's expired too.
+	rate := e.rates[currency]
 	if rate.ExpiredAt.Before(time.Now()) {
 		return aggregates.Rate{}, aggregates.ErrRateExpired
 	}
@@ -100,69 +277,502 @@ func (e *Exchange) GetRate() (aggregates.Rate, error) {
 	return rate, nil
 }
 
+// SetOverride overrides the SOL exchange rate for the given fiat currency
+// with value, for the ttl duration. Overrides aren't persisted, they're meant
+// to pin a rate by hand during an incident.
+func (e *Exchange) SetOverride(
+	currency string, value *big.Rat, ttl time.Duration) (aggregates.Rate, error) {
+	if !e.supports(currency) {
+		return aggregates.Rate{}, aggregates.ErrCurrencyNotSupported
+	}
+
+	if value == nil || value.Sign() <= 0 || ttl <= 0 {
+		return aggregates.Rate{}, aggregates.ErrInvalidRateOverride
+	}
+
+	now := time.Now()
+
+	override := aggregates.Rate{
+		Currency:   currency,
+		Value:      new(big.Rat).Set(value),
+		Sources:    []string{exchangeOverrideSource},
+		Time:       now,
+		ExpiredAt:  now.Add(ttl),
+		Overridden: true,
+	}
+
+	e.rateMutex.Lock()
+	e.overrides[currency] = override
+	e.rateMutex.Unlock()
+
+	e.feed.Publish(override)
+
+	return override, nil
+}
+
+// ClearOverride removes the override of the SOL exchange rate for the given
+// fiat currency, if any. The fetched rate it hid is published to the feed, so
+// the subscribers don't keep the override until the next refresh.
+func (e *Exchange) ClearOverride(currency string) error {
+	if !e.supports(currency) {
+		return aggregates.ErrCurrencyNotSupported
+	}
+
+	e.rateMutex.Lock()
+	_, overridden := e.overrides[currency]
+	delete(e.overrides, currency)
+	rate := e.rates[currency]
+	e.rateMutex.Unlock()
+
+	// There is nothing to publish when no rate was fetched yet.
+	if overridden && rate.Value != nil {
+		e.feed.Publish(rate)
+	}
+
+	return nil
+}
+
+// Subscribe subscribes to the rate updates, every refreshed rate is sent to
+// the returned channel until the returned function is called.
+//
+// Subscribers that don't keep up with the updates are dropped, closing the
+// channel.
+func (e *Exchange) Subscribe() (<-chan aggregates.Rate, func()) {
+	return e.feed.Subscribe()
+}
+
+// Configure replaces the settings, they apply from the next refresh on, which
+// is scheduled again with the new refresh interval.
+func (e *Exchange) Configure(settings ExchangeSettings) {
+	e.settingsMutex.Lock()
+	e.settings = settings.withDefaults()
+	e.settingsMutex.Unlock()
+
+	select {
+	case e.reconfigured <- struct{}{}:
+	default:
+	}
+}
+
+// currentSettings returns the current settings.
+func (e *Exchange) currentSettings() ExchangeSettings {
+	e.settingsMutex.RLock()
+	defer e.settingsMutex.RUnlock()
+
+	return e.settings
+}
+
+// Health returns the health of the exchange rates, a rate is stale when it
+// missed at least one refresh. The consecutive failures are the ones of the
+// currency failing the longest.
+func (e *Exchange) Health() aggregates.RateHealth {
+	staleTime := 2 * e.currentSettings().RefreshInterval
+
+	e.rateMutex.RLock()
+	defer e.rateMutex.RUnlock()
+
+	now := time.Now()
+
+	state := aggregates.RateHealthFresh
+	for _, currency := range e.Currencies {
+		rate, ok := e.rates[currency]
+		if !ok || rate.ExpiredAt.Before(now) {
+			state = aggregates.RateHealthExpired
+			break
+		}
+
+		if now.Sub(rate.Time) > staleTime {
+			state = aggregates.RateHealthStale
+		}
+	}
+
+	var openCircuits []string
+	for key, breaker := range e.breakers {
+		if breaker.Open() {
+			openCircuits = append(openCircuits, key)
+		}
+	}
+	sort.Strings(openCircuits)
+
+	failures := 0
+	for _, currencyFailures := range e.failures {
+		failures = max(failures, currencyFailures)
+	}
+
+	return aggregates.RateHealth{
+		State:               state,
+		LastSuccess:         e.lastSuccess,
+		LastError:           e.lastError,
+		LastErrorAt:         e.lastErrorAt,
+		ConsecutiveFailures: failures,
+		OpenCircuits:        openCircuits,
+	}
+}
+
+// start refreshes the rates until the context is done. Every currency is
+// scheduled on its own, waiting the regular interval after a successful
+// refresh and backing off after a failed one, so a failing currency doesn't
+// hold back the others.
 func (e *Exchange) start(ctx context.Context) {
-	ticker := time.NewTicker(exchangeTickerTime)
+	defer close(e.stopped)
+	defer e.feed.Close()
+
+	next := make(map[string]time.Time, len(e.Currencies))
+	for _, currency := range e.Currencies {
+		next[currency] = time.Now().Add(e.currentSettings().RefreshInterval)
+	}
+
+	timer := time.NewTimer(e.currentSettings().RefreshInterval)
+	defer timer.Stop()
+
 	for {
 		select {
-		case <-ticker.C:
-			e.rateMutex.Lock()
-			for _, currency := range supportedCurrencies {
-				rate, err := e.fetchRate(currency)
-				if err != nil {
-					slog.Error("error fetching rate: ", err)
+		case <-timer.C:
+			now := time.Now()
+
+			var due []string
+			for _, currency := range e.Currencies {
+				if !next[currency].After(now) {
+					due = append(due, currency)
+				}
+			}
+
+			failed := e.refresh(ctx, due)
+
+			settings := e.currentSettings()
+			backoff := Backoff{Base: exchangeRetryBaseTime, Max: retryMaxTime(settings)}
+
+			for _, currency := range due {
+				err, ok := failed[currency]
+				if !ok {
+					next[currency] = now.Add(settings.RefreshInterval)
 					continue
 				}
-				e.rates[currency] = rate
+
+				failures := e.consecutiveFailures(currency)
+				delay := backoff.Delay(failures)
+				next[currency] = now.Add(delay)
+
+				e.logger.ErrorContext(ctx, "error refreshing rate", "currency", currency,
+					"error", err, "failures", failures, "retry_in", delay)
+			}
+
+			timer.Reset(time.Until(earliest(next)))
+
+		case <-e.reconfigured:
+			// The failing currencies keep backing off, the new interval
+			// applies to them once they recover.
+			now := time.Now()
+			interval := e.currentSettings().RefreshInterval
+			for _, currency := range e.Currencies {
+				if e.consecutiveFailures(currency) == 0 {
+					next[currency] = now.Add(interval)
+				}
 			}
-			e.rateMutex.Unlock()
+
+			if !timer.Stop() {
+				<-timer.C
+			}
+			timer.Reset(time.Until(earliest(next)))
 
 		case <-ctx.Done():
-			ticker.Stop()
 			return
 		}
 	}
 }
 
-func (e *Exchange) fetchRate(currency string) (aggregates.Rate, error) {
-	url := fmt.Sprintf("%s?pair=%s", e.APIURL, currency)
-	resp, err := http.Get(url)
+// retryMaxTime returns the maximum backoff between the refreshes of a failing
+// currency, a quarter of the rate expiration up to exchangeRetryMaxTime.
+func retryMaxTime(settings ExchangeSettings) time.Duration {
+	return max(exchangeRetryBaseTime, min(exchangeRetryMaxTime, settings.RateExpiration/4))
+}
+
+// earliest returns the earliest of the scheduled refreshes.
+func earliest(next map[string]time.Time) time.Time {
+	var first time.Time
+	for _, at := range next {
+		if first.IsZero() || at.Before(first) {
+			first = at
+		}
+	}
+
+	return first
+}
+
+// consecutiveFailures returns the consecutive failed refreshes of the
+// currency.
+func (e *Exchange) consecutiveFailures(currency string) int {
+	e.rateMutex.RLock()
+	defer e.rateMutex.RUnlock()
+
+	return e.failures[currency]
+}
+
+// joinFailures joins the errors of the failed currencies, in the order of the
+// supported currencies.
+func (e *Exchange) joinFailures(failed map[string]error) error {
+	var errs []error
+	for _, currency := range e.Currencies {
+		if err, ok := failed[currency]; ok {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// refresh fetches the rates of the currencies, the lock is only held to
+// update the results, so readers are never blocked by the providers. The
+// fetched rates are then published to the feed subscribers.
+//
+// It returns the errors of the currencies that failed to refresh.
+func (e *Exchange) refresh(ctx context.Context, currencies []string) map[string]error {
+	ctx, span := tracer.Start(ctx, "Exchange.refresh",
+		trace.WithAttributes(attribute.StringSlice("currencies", currencies)))
+
+	rates := make(map[string]aggregates.Rate, len(currencies))
+	failed := make(map[string]error)
+
+	for _, currency := range currencies {
+		rate, err := e.fetchRate(ctx, currency)
+		if err != nil {
+			failed[currency] = fmt.Errorf("error fetching %s rate: %w", currency, err)
+			continue
+		}
+
+		rates[currency] = rate
+	}
+
+	err := e.joinFailures(failed)
+
+	e.rateMutex.Lock()
+
+	for currency, rate := range rates {
+		e.rates[currency] = rate
+		e.failures[currency] = 0
+		metrics.SetRateTime(currency, rate.Time)
+	}
+
+	for currency := range failed {
+		e.failures[currency]++
+	}
+
 	if err != nil {
-		return aggregates.Rate{}, fmt.Errorf("error making request to exchange rate api: %w", err)
+		e.lastError = err.Error()
+		e.lastErrorAt = time.Now()
+	} else {
+		e.lastSuccess = time.Now()
+	}
+
+	stored := make(map[string]aggregates.Rate, len(e.rates))
+	for currency, rate := range e.rates {
+		stored[currency] = rate
+	}
+
+	// Subscribers keep seeing the overrides while they're valid, as that's
+	// what GetRate returns.
+	published := make([]aggregates.Rate, 0, len(rates))
+	for _, currency := range currencies {
+		rate, ok := rates[currency]
+		if !ok {
+			continue
+		}
+
+		if override, ok := e.overrides[currency]; ok && override.ExpiredAt.After(time.Now()) {
+			continue
+		}
+
+		published = append(published, rate)
+	}
+
+	e.rateMutex.Unlock()
+
+	for _, rate := range published {
+		e.feed.Publish(rate)
+	}
+
+	if e.store != nil && len(rates) != 0 {
+		if err := e.store.Save(stored); err != nil {
+			e.logger.ErrorContext(ctx, "error storing rates", "error", err)
+		}
+	}
+
+	tracing.EndSpan(span, err)
+
+	return failed
+}
+
+// supports reports whether the currency is supported by the exchange.
+func (e *Exchange) supports(currency string) bool {
+	for _, supported := range e.Currencies {
+		if supported == currency {
+			return true
+		}
+	}
+
+	return false
+}
+
+// fetchRate fetches the SOL exchange rate for the given fiat currency from
+// every provider, aggregating the quotes into their median.
+func (e *Exchange) fetchRate(ctx context.Context, currency string) (_ aggregates.Rate, err error) {
+	ctx, span := tracer.Start(ctx, "Exchange.fetchRate",
+		trace.WithAttributes(attribute.String("currency", currency)))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	quotes := make([]rateQuote, len(e.Providers))
+
+	var wg sync.WaitGroup
+	for i, provider := range e.Providers {
+		breaker := e.breakers[breakerKey(provider.Name(), currency)]
+		if !breaker.Allow() {
+			quotes[i] = rateQuote{source: provider.Name(), err: errCircuitOpen}
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, provider RateProvider) {
+			defer wg.Done()
+
+			ctx, span := tracer.Start(ctx, "RateProvider.FetchRate",
+				trace.WithAttributes(attribute.String("provider", provider.Name())))
+
+			value, err := provider.FetchRate(ctx, currency)
+			tracing.EndSpan(span, err)
+
+			switch {
+			// The refresh was canceled, the provider might be fine.
+			case err != nil && ctx.Err() != nil:
+				breaker.Abandon()
+			case err != nil:
+				breaker.Failure()
+			default:
+				breaker.Success()
+			}
+
+			quotes[i] = rateQuote{source: provider.Name(), value: value, err: err}
+		}(i, provider)
 	}
-	defer resp.Body.Close()
+	wg.Wait()
 
-	var result struct {
-		Error  []string `json:"error"`
-		Result map[string]struct {
-			P []string `json:"p"`
-		} `json:"result"`
+	valid := make([]rateQuote, 0, len(quotes))
+	for _, quote := range quotes {
+		if errors.Is(quote.err, errCircuitOpen) {
+			continue
+		}
+
+		if quote.err != nil {
+			e.logger.WarnContext(ctx, "error fetching rate from provider",
+				"provider", quote.source, "currency", currency, "error", quote.err)
+			metrics.RecordProviderFetchFailure(quote.source, currency)
+			continue
+		}
+
+		valid = append(valid, quote)
 	}
 
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return aggregates.Rate{}, fmt.Errorf("error decoding response: %w", err)
+	if len(valid) == 0 {
+		return aggregates.Rate{}, fmt.Errorf("no provider returned a rate for %s", currency)
 	}
 
-	if len(result.Error) != 0 {
-		return aggregates.Rate{}, fmt.Errorf("exchange rate api returned an error: %v", result.Error)
+	settings := e.currentSettings()
+
+	median := medianRate(valid)
+	maxDeviation := new(big.Rat).SetFloat64(settings.MaxDeviation)
+
+	accepted := make([]rateQuote, 0, len(valid))
+	for _, quote := range valid {
+		deviation := new(big.Rat).Sub(quote.value, median)
+		deviation.Abs(deviation).Quo(deviation, median)
+
+		if deviation.Cmp(maxDeviation) > 0 {
+			e.logger.WarnContext(ctx, "rejecting outlier rate",
+				"provider", quote.source, "currency", currency,
+				"rate", quote.value.FloatString(6), "median", median.FloatString(6))
+			continue
+		}
+
+		accepted = append(accepted, quote)
 	}
 
-	pairData, ok := result.Result[currency]
-	if !ok || len(pairData.P) < 2 {
-		return aggregates.Rate{}, fmt.Errorf("invalid data received from exchange rate api")
+	// The median itself always is within the accepted deviation, so there is
+	// at least one accepted quote unless there is an even number of them and
+	// the median falls between two middle quotes that are both too far from
+	// it. The providers are split then, and the tie resolves to the mean of
+	// the two middle quotes.
+	if len(accepted) == 0 {
+		accepted = middleQuotes(valid)
+
+		e.logger.WarnContext(ctx, "providers split, using the mean of the middle rates",
+			"currency", currency, "median", median.FloatString(6),
+			"providers", []string{accepted[0].source, accepted[1].source})
 	}
 
-	// I've used big.Rat to do the calculations, as it does provide a better
-	// precision than float64, but it's not ideal, as it's not as easy to work
-	// with ,
-	rate := &big.Rat{}
-	rate, ok = rate.SetString(pairData.P[1])
-	if !ok {
-		return aggregates.Rate{}, fmt.Errorf("error parsing exchange rate: %s", pairData.P[1])
+	span.SetAttributes(
+		attribute.Int("exchange.quotes.valid", len(valid)),
+		attribute.Int("exchange.quotes.accepted", len(accepted)),
+	)
+
+	sources := make([]string, len(accepted))
+	for i, quote := range accepted {
+		sources[i] = quote.source
 	}
+	sort.Strings(sources)
 
 	return aggregates.Rate{
 		Currency:  currency,
-		Value:     rate,
-		ExpiredAt: time.Now().Add(exchangeRateExpirationTime),
+		Value:     medianRate(accepted),
+		Sources:   sources,
+		Time:      time.Now(),
+		ExpiredAt: time.Now().Add(settings.RateExpiration),
 	}, nil
 }
+
+// breakerKey returns the key of the circuit breaker of the provider for the
+// currency, such as kraken/EUR. A provider failing for a currency it doesn't
+// quote is still used for the others.
+func breakerKey(provider, currency string) string {
+	return provider + "/" + currency
+}
+
+// errCircuitOpen is the error of the quotes of providers skipped by their
+// circuit breaker.
+var errCircuitOpen = errors.New("circuit open")
+
+// rateQuote is the result of fetching a rate from a provider.
+type rateQuote struct {
+	source string
+	value  *big.Rat
+	err    error
+}
+
+// medianRate returns the median value of the quotes, the mean of the two
+// middle values when there is an even number of them.
+func medianRate(quotes []rateQuote) *big.Rat {
+	middle := middleQuotes(quotes)
+
+	median := new(big.Rat)
+	for _, quote := range middle {
+		median.Add(median, quote.value)
+	}
+
+	return median.Quo(median, big.NewRat(int64(len(middle)), 1))
+}
+
+// middleQuotes returns the middle quote of the quotes sorted by value, or the
+// two middle ones when there is an even number of them.
+func middleQuotes(quotes []rateQuote) []rateQuote {
+	sorted := make([]rateQuote, len(quotes))
+	copy(sorted, quotes)
+
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].value.Cmp(sorted[j].value) < 0
+	})
+
+	middle := len(sorted) / 2
+	if len(sorted)%2 == 1 {
+		return sorted[middle : middle+1]
+	}
+
+	return sorted[middle-1 : middle+1]
+}
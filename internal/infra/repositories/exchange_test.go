@@ -3,6 +3,7 @@ package repositories_test
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"math/big"
 	"net/http"
 	"net/http/httptest"
@@ -10,16 +11,23 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 
 	"github.com/jcleira/coding-challenge/internal/domain/aggregates"
 	"github.com/jcleira/coding-challenge/internal/infra/repositories"
+	"github.com/jcleira/coding-challenge/mocks"
 )
 
+// exchangeSettings are the settings of the exchanges under test.
+var exchangeSettings = repositories.ExchangeSettings{MaxDeviation: 0.05}
+
 func TestNewExchange(t *testing.T) {
 	tests := []struct {
-		name     string
-		response map[string]interface{}
-		wantErr  bool
+		name          string
+		response      map[string]interface{}
+		startDegraded bool
+		wantErr       bool
 	}{
 		{
 			name: "success",
@@ -44,6 +52,16 @@ func TestNewExchange(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "error starting degraded",
+			response: map[string]interface{}{
+				"error": map[string]interface{}{
+					"code":    123,
+					"message": "error message",
+				},
+			},
+			startDegraded: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -57,15 +75,28 @@ func TestNewExchange(t *testing.T) {
 			ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
 			defer cancel()
 
-			exchange, err := repositories.NewExchange(ctx, server.URL)
+			exchange, err := repositories.NewExchange(ctx,
+				[]repositories.RateProvider{repositories.NewKrakenProvider(server.URL)},
+				[]string{"EUR"}, exchangeSettings,
+				repositories.ExchangeOptions{StartDegraded: tt.startDegraded})
 			if tt.wantErr {
 				assert.Error(t, err)
 				assert.Nil(t, exchange)
 				return
 			}
+			require.NoError(t, err)
+			require.NotNil(t, exchange)
+
+			_, err = exchange.GetRate(ctx, "EUR")
+			if tt.startDegraded {
+				assert.ErrorIs(t, err, aggregates.ErrRateExpired)
+				assert.Equal(t, aggregates.RateHealthExpired, exchange.Health().State)
+				assert.NotEmpty(t, exchange.Health().LastError)
+				return
+			}
 
 			assert.NoError(t, err)
-			assert.NotNil(t, exchange)
+			assert.Equal(t, aggregates.RateHealthFresh, exchange.Health().State)
 		})
 	}
 }
@@ -84,33 +115,503 @@ func TestGetRate(t *testing.T) {
 	}))
 	defer server.Close()
 
-	exchange, err := repositories.NewExchange(context.Background(), server.URL)
+	ctx := context.Background()
+
+	exchange, err := repositories.NewExchange(ctx,
+		[]repositories.RateProvider{repositories.NewKrakenProvider(server.URL)},
+		[]string{"EUR"}, exchangeSettings, repositories.ExchangeOptions{})
 	assert.NoError(t, err)
 	assert.NotNil(t, exchange)
 
 	tests := []struct {
-		name    string
-		rate    aggregates.Rate
-		wantErr error
+		name     string
+		currency string
+		rate     aggregates.Rate
+		wantErr  error
 	}{
 		{
-			name:    "success",
-			rate:    aggregates.Rate{Currency: "SOLEUR", Value: big.NewRat(12, 10)},
-			wantErr: nil,
+			name:     "success",
+			currency: "EUR",
+			rate:     aggregates.Rate{Currency: "EUR", Value: big.NewRat(12, 10)},
+			wantErr:  nil,
+		},
+		{
+			name:     "currency not supported",
+			currency: "USD",
+			wantErr:  aggregates.ErrCurrencyNotSupported,
 		},
 		// TODO: Add test about timeouts
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			rate, err := exchange.GetRate()
+			rate, err := exchange.GetRate(ctx, tt.currency)
 			if tt.wantErr != nil {
 				assert.Error(t, err)
 				assert.Equal(t, tt.wantErr, err)
 				return
 			}
 
-			assert.NotNil(t, rate)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.rate.Currency, rate.Currency)
+			assert.Equal(t, tt.rate.Value, rate.Value)
 		})
 	}
 }
+
+func TestExchange_MedianAggregation(t *testing.T) {
+	tests := []struct {
+		name        string
+		quotes      map[string]*big.Rat
+		failing     []string
+		wantRate    *big.Rat
+		wantSources []string
+		wantErr     bool
+	}{
+		{
+			name: "odd number of quotes",
+			quotes: map[string]*big.Rat{
+				"kraken":   big.NewRat(100, 1),
+				"coinbase": big.NewRat(101, 1),
+				"binance":  big.NewRat(99, 1),
+			},
+			wantRate:    big.NewRat(100, 1),
+			wantSources: []string{"binance", "coinbase", "kraken"},
+		},
+		{
+			name: "even number of quotes",
+			quotes: map[string]*big.Rat{
+				"kraken":   big.NewRat(100, 1),
+				"coinbase": big.NewRat(101, 1),
+			},
+			wantRate:    big.NewRat(201, 2),
+			wantSources: []string{"coinbase", "kraken"},
+		},
+		{
+			name: "even number of split quotes",
+			quotes: map[string]*big.Rat{
+				"kraken":   big.NewRat(100, 1),
+				"coinbase": big.NewRat(120, 1),
+			},
+			wantRate:    big.NewRat(110, 1),
+			wantSources: []string{"coinbase", "kraken"},
+		},
+		{
+			name: "even number of quotes split in two groups",
+			quotes: map[string]*big.Rat{
+				"kraken":    big.NewRat(100, 1),
+				"coinbase":  big.NewRat(101, 1),
+				"binance":   big.NewRat(120, 1),
+				"coingecko": big.NewRat(121, 1),
+			},
+			wantRate:    big.NewRat(221, 2),
+			wantSources: []string{"binance", "coinbase"},
+		},
+		{
+			name: "outlier rejected",
+			quotes: map[string]*big.Rat{
+				"kraken":    big.NewRat(100, 1),
+				"coinbase":  big.NewRat(101, 1),
+				"binance":   big.NewRat(102, 1),
+				"coingecko": big.NewRat(150, 1),
+			},
+			wantRate:    big.NewRat(101, 1),
+			wantSources: []string{"binance", "coinbase", "kraken"},
+		},
+		{
+			name: "failing provider ignored",
+			quotes: map[string]*big.Rat{
+				"kraken":   big.NewRat(100, 1),
+				"coinbase": big.NewRat(102, 1),
+			},
+			failing:     []string{"binance"},
+			wantRate:    big.NewRat(101, 1),
+			wantSources: []string{"coinbase", "kraken"},
+		},
+		{
+			name:    "every provider failing",
+			failing: []string{"kraken", "coinbase"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var providers []repositories.RateProvider
+
+			for name, value := range tt.quotes {
+				provider := mocks.NewRateProvider(t)
+				provider.On("Name").Return(name)
+				provider.On("FetchRate", mock.Anything, "EUR").Return(value, nil)
+				providers = append(providers, provider)
+			}
+
+			for _, name := range tt.failing {
+				provider := mocks.NewRateProvider(t)
+				provider.On("Name").Return(name)
+				provider.On("FetchRate", mock.Anything, "EUR").Return(nil, errors.New("provider error"))
+				providers = append(providers, provider)
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			exchange, err := repositories.NewExchange(ctx, providers, []string{"EUR"}, exchangeSettings,
+				repositories.ExchangeOptions{StartDegraded: true})
+			require.NoError(t, err)
+
+			rate, err := exchange.GetRate(ctx, "EUR")
+			if tt.wantErr {
+				assert.ErrorIs(t, err, aggregates.ErrRateExpired)
+				return
+			}
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.wantRate, rate.Value)
+			assert.Equal(t, tt.wantSources, rate.Sources)
+		})
+	}
+}
+
+func TestExchange_CircuitBreaker(t *testing.T) {
+	currencies := []string{"EUR", "JPY"}
+
+	kraken := mocks.NewRateProvider(t)
+	kraken.On("Name").Return("kraken")
+	kraken.On("FetchRate", mock.Anything, mock.Anything).Return(big.NewRat(100, 1), nil)
+
+	// The provider failing for a currency is only called for it until its
+	// circuit breaker opens, and it's still used for the other currencies.
+	binance := mocks.NewRateProvider(t)
+	binance.On("Name").Return("binance")
+	binance.On("FetchRate", mock.Anything, "EUR").Return(big.NewRat(100, 1), nil)
+	binance.On("FetchRate", mock.Anything, "JPY").
+		Return(nil, errors.New("provider error")).Times(3)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	exchange, err := repositories.NewExchange(ctx,
+		[]repositories.RateProvider{kraken, binance}, currencies,
+		repositories.ExchangeSettings{MaxDeviation: 0.05, RefreshInterval: 10 * time.Millisecond},
+		repositories.ExchangeOptions{})
+	require.NoError(t, err)
+
+	assert.Eventually(t, func() bool {
+		return len(exchange.Health().OpenCircuits) != 0
+	}, time.Second, 10*time.Millisecond)
+
+	rate, err := exchange.GetRate(ctx, "EUR")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"binance", "kraken"}, rate.Sources)
+
+	rate, err = exchange.GetRate(ctx, "JPY")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"kraken"}, rate.Sources)
+
+	health := exchange.Health()
+	assert.Equal(t, aggregates.RateHealthFresh, health.State)
+	assert.Equal(t, []string{"binance/JPY"}, health.OpenCircuits)
+	assert.Equal(t, 0, health.ConsecutiveFailures)
+	assert.False(t, health.LastSuccess.IsZero())
+}
+
+func TestExchange_CircuitBreakerIgnoresCancellations(t *testing.T) {
+	// The provider fails twice, one failure short of opening its circuit
+	// breaker, and the third fetch is canceled by the exchange stopping.
+	fetching := make(chan struct{})
+
+	provider := mocks.NewRateProvider(t)
+	provider.On("Name").Return("kraken")
+	provider.On("FetchRate", mock.Anything, "JPY").
+		Return(nil, errors.New("provider error")).Times(2)
+	provider.On("FetchRate", mock.Anything, "JPY").
+		Run(func(args mock.Arguments) {
+			close(fetching)
+			<-args.Get(0).(context.Context).Done()
+		}).
+		Return(nil, context.Canceled).Once()
+
+	exchange, err := repositories.NewExchange(context.Background(),
+		[]repositories.RateProvider{provider}, []string{"JPY"},
+		repositories.ExchangeSettings{MaxDeviation: 0.05, RefreshInterval: 10 * time.Millisecond},
+		repositories.ExchangeOptions{StartDegraded: true})
+	require.NoError(t, err)
+
+	select {
+	case <-fetching:
+	case <-time.After(5 * time.Second):
+		t.Fatal("rate not refreshed after the failures")
+	}
+
+	exchange.Stop()
+
+	assert.Empty(t, exchange.Health().OpenCircuits)
+}
+
+func TestExchange_CurrencyBackoff(t *testing.T) {
+	provider := mocks.NewRateProvider(t)
+	provider.On("Name").Return("kraken")
+	provider.On("FetchRate", mock.Anything, "EUR").Return(big.NewRat(100, 1), nil)
+	provider.On("FetchRate", mock.Anything, "JPY").Return(nil, errors.New("provider error"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	exchange, err := repositories.NewExchange(ctx,
+		[]repositories.RateProvider{provider}, []string{"EUR", "JPY"},
+		repositories.ExchangeSettings{MaxDeviation: 0.05, RefreshInterval: 10 * time.Millisecond},
+		repositories.ExchangeOptions{StartDegraded: true})
+	require.NoError(t, err)
+
+	updates, unsubscribe := exchange.Subscribe()
+	defer unsubscribe()
+
+	// The failing currency backs off on its own, while the other one keeps
+	// refreshing at the regular interval.
+	for i := 0; i < 3; i++ {
+		select {
+		case rate := <-updates:
+			assert.Equal(t, "EUR", rate.Currency)
+		case <-time.After(500 * time.Millisecond):
+			t.Fatal("rates not refreshed while another currency is failing")
+		}
+	}
+
+	_, err = exchange.GetRate(ctx, "JPY")
+	assert.Error(t, err)
+
+	// The initial fetch and the first refresh failed, the next retry is due
+	// after at least a second.
+	health := exchange.Health()
+	assert.Equal(t, aggregates.RateHealthExpired, health.State)
+	assert.Equal(t, 2, health.ConsecutiveFailures)
+	assert.Contains(t, health.LastError, "JPY")
+}
+
+func TestExchange_RateStore(t *testing.T) {
+	tests := []struct {
+		name          string
+		storedExpiry  time.Duration
+		notStored     bool
+		failing       bool
+		startDegraded bool
+		wantRate      *big.Rat
+		wantErr       error
+		wantStartErr  bool
+	}{
+		{
+			name:         "fetched rate replaces the stored one",
+			storedExpiry: time.Hour,
+			wantRate:     big.NewRat(100, 1),
+		},
+		{
+			name:         "valid stored rate used while providers are down",
+			storedExpiry: time.Hour,
+			failing:      true,
+			wantRate:     big.NewRat(95, 1),
+		},
+		{
+			name:         "expired stored rate refuses to start",
+			storedExpiry: -time.Hour,
+			failing:      true,
+			wantStartErr: true,
+		},
+		{
+			name:          "expired stored rate starting degraded",
+			storedExpiry:  -time.Hour,
+			failing:       true,
+			startDegraded: true,
+			wantErr:       aggregates.ErrRateExpired,
+		},
+		{
+			name:          "never fetched rate starting degraded",
+			notStored:     true,
+			failing:       true,
+			startDegraded: true,
+			wantErr:       aggregates.ErrRateExpired,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store, err := repositories.NewFileRateStore(t.TempDir() + "/rates.json")
+			require.NoError(t, err)
+
+			if !tt.notStored {
+				expiredAt := time.Now().Add(tt.storedExpiry)
+				require.NoError(t, store.Save(map[string]aggregates.Rate{
+					"EUR": {
+						Currency:  "EUR",
+						Value:     big.NewRat(95, 1),
+						Sources:   []string{"kraken"},
+						Time:      expiredAt.Add(-time.Minute),
+						ExpiredAt: expiredAt,
+					},
+				}))
+			}
+
+			provider := mocks.NewRateProvider(t)
+			provider.On("Name").Return("kraken")
+			if tt.failing {
+				provider.On("FetchRate", mock.Anything, "EUR").Return(nil, errors.New("provider error"))
+			} else {
+				provider.On("FetchRate", mock.Anything, "EUR").Return(big.NewRat(100, 1), nil)
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			exchange, err := repositories.NewExchange(ctx,
+				[]repositories.RateProvider{provider}, []string{"EUR"}, exchangeSettings,
+				repositories.ExchangeOptions{Store: store, StartDegraded: tt.startDegraded})
+			if tt.wantStartErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			rate, err := exchange.GetRate(ctx, "EUR")
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantRate, rate.Value)
+
+			// The rates are stored, so the next start can use them.
+			rates, err := store.Load()
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantRate, rates["EUR"].Value)
+		})
+	}
+}
+
+func TestFileRateStore_ExactValue(t *testing.T) {
+	store, err := repositories.NewFileRateStore(t.TempDir() + "/rates.json")
+	require.NoError(t, err)
+
+	// The mean of split quotes doesn't always have a finite decimal form.
+	value := big.NewRat(2000, 3)
+
+	require.NoError(t, store.Save(map[string]aggregates.Rate{
+		"EUR": {Currency: "EUR", Value: value, ExpiredAt: time.Now().Add(time.Hour)},
+	}))
+
+	rates, err := store.Load()
+	require.NoError(t, err)
+	assert.Equal(t, value, rates["EUR"].Value)
+}
+
+func TestExchange_Override(t *testing.T) {
+	provider := mocks.NewRateProvider(t)
+	provider.On("Name").Return("kraken")
+	provider.On("FetchRate", mock.Anything, "EUR").Return(big.NewRat(100, 1), nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	exchange, err := repositories.NewExchange(ctx,
+		[]repositories.RateProvider{provider}, []string{"EUR"}, exchangeSettings, repositories.ExchangeOptions{})
+	require.NoError(t, err)
+
+	updates, unsubscribe := exchange.Subscribe()
+	defer unsubscribe()
+
+	_, err = exchange.SetOverride("USD", big.NewRat(90, 1), time.Hour)
+	assert.ErrorIs(t, err, aggregates.ErrCurrencyNotSupported)
+
+	_, err = exchange.SetOverride("EUR", big.NewRat(-90, 1), time.Hour)
+	assert.ErrorIs(t, err, aggregates.ErrInvalidRateOverride)
+
+	_, err = exchange.SetOverride("EUR", big.NewRat(90, 1), 0)
+	assert.ErrorIs(t, err, aggregates.ErrInvalidRateOverride)
+
+	// The override replaces the fetched rate, and is published to the feed.
+	_, err = exchange.SetOverride("EUR", big.NewRat(90, 1), time.Hour)
+	require.NoError(t, err)
+
+	rate, err := exchange.GetRate(ctx, "EUR")
+	require.NoError(t, err)
+	assert.Equal(t, big.NewRat(90, 1), rate.Value)
+	assert.Equal(t, []string{"override"}, rate.Sources)
+	assert.True(t, rate.Overridden)
+	assert.Equal(t, rate, <-updates)
+
+	// Clearing it restores the fetched rate, which is published to the feed.
+	require.NoError(t, exchange.ClearOverride("EUR"))
+
+	rate, err = exchange.GetRate(ctx, "EUR")
+	require.NoError(t, err)
+	assert.Equal(t, big.NewRat(100, 1), rate.Value)
+	assert.False(t, rate.Overridden)
+	assert.Equal(t, rate, <-updates)
+
+	// Expired overrides are ignored.
+	_, err = exchange.SetOverride("EUR", big.NewRat(90, 1), time.Millisecond)
+	require.NoError(t, err)
+
+	time.Sleep(5 * time.Millisecond)
+
+	rate, err = exchange.GetRate(ctx, "EUR")
+	require.NoError(t, err)
+	assert.Equal(t, big.NewRat(100, 1), rate.Value)
+}
+
+func TestExchange_Configure(t *testing.T) {
+	provider := mocks.NewRateProvider(t)
+	provider.On("Name").Return("kraken")
+	provider.On("FetchRate", mock.Anything, "EUR").Return(big.NewRat(100, 1), nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	exchange, err := repositories.NewExchange(ctx,
+		[]repositories.RateProvider{provider}, []string{"EUR"},
+		repositories.ExchangeSettings{MaxDeviation: 0.05, RefreshInterval: time.Hour},
+		repositories.ExchangeOptions{})
+	require.NoError(t, err)
+
+	updates, unsubscribe := exchange.Subscribe()
+	defer unsubscribe()
+
+	// The new refresh interval applies right away, instead of waiting for the
+	// previous one, and the refreshed rates expire with the new expiration.
+	exchange.Configure(repositories.ExchangeSettings{
+		MaxDeviation:    0.05,
+		RefreshInterval: 10 * time.Millisecond,
+		RateExpiration:  time.Hour,
+	})
+
+	select {
+	case rate := <-updates:
+		assert.WithinDuration(t, time.Now().Add(time.Hour), rate.ExpiredAt, time.Minute)
+	case <-time.After(time.Second):
+		t.Fatal("rates not refreshed with the new refresh interval")
+	}
+}
+
+func TestExchange_Stop(t *testing.T) {
+	provider := mocks.NewRateProvider(t)
+	provider.On("Name").Return("kraken")
+	provider.On("FetchRate", mock.Anything, "EUR").Return(big.NewRat(100, 1), nil)
+
+	ctx := context.Background()
+
+	exchange, err := repositories.NewExchange(ctx,
+		[]repositories.RateProvider{provider}, []string{"EUR"}, exchangeSettings, repositories.ExchangeOptions{})
+	require.NoError(t, err)
+
+	updates, unsubscribe := exchange.Subscribe()
+	defer unsubscribe()
+
+	exchange.Stop()
+
+	// The subscribers are done, while the last rates are still served.
+	_, ok := <-updates
+	assert.False(t, ok)
+
+	rate, err := exchange.GetRate(ctx, "EUR")
+	require.NoError(t, err)
+	assert.Equal(t, big.NewRat(100, 1), rate.Value)
+}
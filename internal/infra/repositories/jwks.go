@@ -0,0 +1,281 @@
+package repositories
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+
+	"github.com/jcleira/coding-challenge/internal/domain/aggregates"
+)
+
+const (
+	// jwksRequestTimeout is the maximum time a request to the JWKS URL can
+	// take, including reading the response.
+	jwksRequestTimeout = 5 * time.Second
+
+	// jwksRefreshInterval is how long the fetched keys are used before
+	// fetching them again.
+	jwksRefreshInterval = 15 * time.Minute
+
+	// jwksMinRefreshInterval is the minimum time between two fetches of the
+	// keys triggered by tokens signed with unknown keys, so forged key IDs
+	// can't flood the identity provider.
+	jwksMinRefreshInterval = 30 * time.Second
+
+	// rsaMinKeyBits is the minimum size of the accepted RSA keys.
+	rsaMinKeyBits = 2048
+)
+
+// JWKS is a cache of the JSON Web Key Set published by an identity provider,
+// keyed by key ID.
+//
+// The keys are fetched again every RefreshInterval, and when a token is
+// signed with an unknown key, at most once every MinRefreshInterval, so
+// rotated keys are picked up as soon as they are used. The last known keys
+// are kept when the identity provider can't be reached.
+type JWKS struct {
+	URL                string
+	RefreshInterval    time.Duration
+	MinRefreshInterval time.Duration
+
+	client *http.Client
+	logger *slog.Logger
+
+	mu          sync.Mutex
+	keys        map[string]crypto.PublicKey
+	fetchedAt   time.Time
+	attemptedAt time.Time
+
+	// fetches deduplicates the concurrent fetches of the keys.
+	fetches singleflight.Group
+}
+
+// NewJWKS creates a new JWKS fetching the keys from url.
+func NewJWKS(url string, logger *slog.Logger) *JWKS {
+	return &JWKS{
+		URL:                url,
+		RefreshInterval:    jwksRefreshInterval,
+		MinRefreshInterval: jwksMinRefreshInterval,
+		client:             &http.Client{Timeout: jwksRequestTimeout},
+		logger:             logger,
+	}
+}
+
+// Key returns the public key with the key ID, unknown keys return
+// ErrUnauthenticated.
+//
+// The keys are fetched without holding the lock, so the requests that don't
+// need a fetch aren't blocked by one, and the concurrent requests needing a
+// fetch share a single one. The fetch isn't bound to the request that
+// triggered it, a request done before it finishes doesn't cancel it for the
+// others.
+func (j *JWKS) Key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	key, known, refresh := j.cached(kid)
+	if !refresh {
+		if known {
+			return key, nil
+		}
+
+		return nil, fmt.Errorf("%w: unknown signing key %q", aggregates.ErrUnauthenticated, kid)
+	}
+
+	keys, err := j.refresh(ctx)
+	if err != nil {
+		if known {
+			j.logger.WarnContext(ctx, "error refreshing JWKS, using the last known keys", "error", err)
+			return key, nil
+		}
+
+		return nil, err
+	}
+
+	key, known = keys[kid]
+	if !known {
+		return nil, fmt.Errorf("%w: unknown signing key %q", aggregates.ErrUnauthenticated, kid)
+	}
+
+	return key, nil
+}
+
+// cached returns the cached key with the key ID, if any, and whether the keys
+// need to be fetched, because they are stale or don't have the key, and the
+// last fetch finished at least MinRefreshInterval ago.
+func (j *JWKS) cached(kid string) (crypto.PublicKey, bool, bool) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	now := time.Now()
+
+	key, known := j.keys[kid]
+	stale := j.keys == nil || now.Sub(j.fetchedAt) >= j.RefreshInterval
+
+	if known && !stale {
+		return key, true, false
+	}
+
+	return key, known, now.Sub(j.attemptedAt) >= j.MinRefreshInterval
+}
+
+// refresh fetches the keys, sharing the ongoing fetch if there is one, and
+// caches them. The fetch has its own timeout, detached from the cancellation
+// of ctx, which only stops waiting for it.
+func (j *JWKS) refresh(ctx context.Context) (map[string]crypto.PublicKey, error) {
+	fetched := j.fetches.DoChan("keys", func() (interface{}, error) {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), jwksRequestTimeout)
+		defer cancel()
+
+		keys, err := j.fetch(ctx)
+
+		j.mu.Lock()
+		defer j.mu.Unlock()
+
+		j.attemptedAt = time.Now()
+		if err != nil {
+			return nil, err
+		}
+
+		j.keys = keys
+		j.fetchedAt = j.attemptedAt
+
+		return keys, nil
+	})
+
+	select {
+	case result := <-fetched:
+		if result.Err != nil {
+			return nil, result.Err
+		}
+
+		return result.Val.(map[string]crypto.PublicKey), nil
+
+	case <-ctx.Done():
+		return nil, fmt.Errorf("error waiting for JWKS: %w", ctx.Err())
+	}
+}
+
+// fetch fetches the signing keys of the key set, the keys of unsupported
+// types or meant for encryption are skipped.
+func (j *JWKS) fetch(ctx context.Context) (map[string]crypto.PublicKey, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, j.URL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating request to JWKS: %w", err)
+	}
+
+	resp, err := j.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error making request to JWKS: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("JWKS returned status: %d", resp.StatusCode)
+	}
+
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return nil, fmt.Errorf("error decoding JWKS: %w", err)
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+
+		key, err := jwk.publicKey()
+		if err != nil {
+			j.logger.WarnContext(ctx, "skipping JWKS key", "kid", jwk.Kid, "error", err)
+			continue
+		}
+
+		keys[jwk.Kid] = key
+	}
+
+	return keys, nil
+}
+
+// jsonWebKey is a public key of a JSON Web Key Set, RFC 7517.
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// publicKey returns the RSA or P-256 ECDSA public key of the JWK.
+func (k jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("error decoding RSA modulus: %w", err)
+		}
+
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, fmt.Errorf("error decoding RSA exponent: %w", err)
+		}
+
+		exponent := new(big.Int).SetBytes(e)
+		if !exponent.IsInt64() || exponent.Int64() < 3 || exponent.Int64() > 1<<31-1 {
+			return nil, fmt.Errorf("invalid RSA exponent")
+		}
+
+		key := &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exponent.Int64())}
+		if key.N.BitLen() < rsaMinKeyBits {
+			return nil, fmt.Errorf("RSA key of %d bits is too short", key.N.BitLen())
+		}
+
+		return key, nil
+
+	case "EC":
+		if k.Crv != "P-256" {
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil || len(x) != 32 {
+			return nil, fmt.Errorf("invalid EC x coordinate")
+		}
+
+		y, err := base64.RawURLEncoding.DecodeString(k.Y)
+		if err != nil || len(y) != 32 {
+			return nil, fmt.Errorf("invalid EC y coordinate")
+		}
+
+		// crypto/ecdh checks the point is on the curve.
+		point := append(append([]byte{4}, x...), y...)
+		if _, err := ecdh.P256().NewPublicKey(point); err != nil {
+			return nil, fmt.Errorf("invalid EC point: %w", err)
+		}
+
+		return &ecdsa.PublicKey{
+			Curve: elliptic.P256(),
+			X:     new(big.Int).SetBytes(x),
+			Y:     new(big.Int).SetBytes(y),
+		}, nil
+
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+}
@@ -0,0 +1,200 @@
+package repositories
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/jcleira/coding-challenge/internal/domain/aggregates"
+)
+
+// oidcLeeway is the clock skew tolerated when checking the token expiry and
+// not before times.
+const oidcLeeway = time.Minute
+
+// OIDCVerifier verifies the RS256 and ES256 signed JWTs issued by an OIDC
+// identity provider, with the keys of its JWKS, checking their issuer,
+// audience and expiry.
+type OIDCVerifier struct {
+	Issuer   string
+	Audience string
+
+	keys *JWKS
+}
+
+// NewOIDCVerifier creates a new OIDCVerifier for the tokens issued by issuer
+// to audience, signed with the keys.
+func NewOIDCVerifier(keys *JWKS, issuer, audience string) *OIDCVerifier {
+	return &OIDCVerifier{
+		Issuer:   issuer,
+		Audience: audience,
+		keys:     keys,
+	}
+}
+
+// Verify verifies the token and returns its claims, invalid tokens return
+// ErrUnauthenticated.
+func (v *OIDCVerifier) Verify(ctx context.Context, token string) (aggregates.TokenClaims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("%w: malformed token", aggregates.ErrUnauthenticated)
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+
+	if err := decodeTokenPart(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("%w: malformed token header: %w", aggregates.ErrUnauthenticated, err)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed token signature: %w", aggregates.ErrUnauthenticated, err)
+	}
+
+	// The algorithm is checked before fetching the key, so tokens with the
+	// none or HMAC algorithms never reach the key set.
+	if header.Alg != "RS256" && header.Alg != "ES256" {
+		return nil, fmt.Errorf("%w: unsupported algorithm %q", aggregates.ErrUnauthenticated, header.Alg)
+	}
+
+	key, err := v.keys.Key(ctx, header.Kid)
+	if err != nil {
+		return nil, err
+	}
+
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+
+	if err := verifySignature(header.Alg, key, digest[:], signature); err != nil {
+		return nil, fmt.Errorf("%w: %w", aggregates.ErrUnauthenticated, err)
+	}
+
+	claims := aggregates.TokenClaims{}
+	if err := decodeTokenPart(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("%w: malformed token claims: %w", aggregates.ErrUnauthenticated, err)
+	}
+
+	if err := v.checkClaims(claims, time.Now()); err != nil {
+		return nil, fmt.Errorf("%w: %w", aggregates.ErrUnauthenticated, err)
+	}
+
+	return claims, nil
+}
+
+// checkClaims checks the issuer, audience, expiry and not before claims at
+// the time now.
+func (v *OIDCVerifier) checkClaims(claims aggregates.TokenClaims, now time.Time) error {
+	if issuer, _ := claims["iss"].(string); issuer != v.Issuer {
+		return fmt.Errorf("unexpected issuer %q", issuer)
+	}
+
+	if !containsString(claims.Strings("aud"), v.Audience) {
+		return fmt.Errorf("token not issued for the %q audience", v.Audience)
+	}
+
+	expiry, ok := numericDate(claims["exp"])
+	if !ok {
+		return fmt.Errorf("token without expiry")
+	}
+
+	if now.After(expiry.Add(oidcLeeway)) {
+		return fmt.Errorf("token expired at %s", expiry.Format(time.RFC3339))
+	}
+
+	if notBefore, ok := numericDate(claims["nbf"]); ok && now.Add(oidcLeeway).Before(notBefore) {
+		return fmt.Errorf("token not valid before %s", notBefore.Format(time.RFC3339))
+	}
+
+	return nil
+}
+
+// verifySignature verifies the signature of the digest with the key, which
+// must be of the type of the algorithm.
+func verifySignature(alg string, key crypto.PublicKey, digest, signature []byte) error {
+	switch alg {
+	case "RS256":
+		rsaKey, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return fmt.Errorf("RS256 token signed with a non RSA key")
+		}
+
+		if err := rsa.VerifyPKCS1v15(rsaKey, crypto.SHA256, digest, signature); err != nil {
+			return fmt.Errorf("invalid signature")
+		}
+
+	case "ES256":
+		ecKey, ok := key.(*ecdsa.PublicKey)
+		if !ok {
+			return fmt.Errorf("ES256 token signed with a non EC key")
+		}
+
+		// JWS ECDSA signatures are the concatenation of r and s, RFC 7518.
+		if len(signature) != 64 {
+			return fmt.Errorf("invalid signature")
+		}
+
+		r := new(big.Int).SetBytes(signature[:32])
+		s := new(big.Int).SetBytes(signature[32:])
+
+		if !ecdsa.Verify(ecKey, digest, r, s) {
+			return fmt.Errorf("invalid signature")
+		}
+
+	default:
+		return fmt.Errorf("unsupported algorithm %q", alg)
+	}
+
+	return nil
+}
+
+// decodeTokenPart decodes a base64url encoded JSON part of a token, keeping
+// the numbers as json.Number.
+func decodeTokenPart(part string, value interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(part)
+	if err != nil {
+		return err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	return decoder.Decode(value)
+}
+
+// numericDate returns the time of a JWT NumericDate claim, the seconds since
+// the Unix epoch.
+func numericDate(value interface{}) (time.Time, bool) {
+	number, ok := value.(json.Number)
+	if !ok {
+		return time.Time{}, false
+	}
+
+	seconds, err := number.Float64()
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	return time.Unix(0, 0).Add(time.Duration(seconds * float64(time.Second))), true
+}
+
+// containsString reports whether values contains value.
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
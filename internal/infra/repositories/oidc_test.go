@@ -0,0 +1,351 @@
+package repositories_test
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jcleira/coding-challenge/internal/domain/aggregates"
+	"github.com/jcleira/coding-challenge/internal/infra/repositories"
+)
+
+const (
+	testIssuer   = "https://issuer.example.com"
+	testAudience = "payments"
+)
+
+// jwksServer is a local stand-in of an identity provider JWKS endpoint.
+type jwksServer struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	keys     []map[string]string
+	requests atomic.Int32
+
+	// delay delays the responses, in nanoseconds.
+	delay atomic.Int64
+}
+
+// newJWKSServer starts a JWKS server publishing the keys.
+func newJWKSServer(t *testing.T, keys ...map[string]string) *jwksServer {
+	t.Helper()
+
+	server := &jwksServer{keys: keys}
+	server.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		server.requests.Add(1)
+
+		time.Sleep(time.Duration(server.delay.Load()))
+
+		server.mu.Lock()
+		defer server.mu.Unlock()
+
+		json.NewEncoder(w).Encode(map[string]interface{}{"keys": server.keys})
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+// publish replaces the published keys.
+func (s *jwksServer) publish(keys ...map[string]string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.keys = keys
+}
+
+// rsaJWK returns the JWK of an RSA public key.
+func rsaJWK(kid string, key *rsa.PublicKey) map[string]string {
+	return map[string]string{
+		"kty": "RSA",
+		"kid": kid,
+		"use": "sig",
+		"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}
+}
+
+// ecJWK returns the JWK of a P-256 public key.
+func ecJWK(kid string, key *ecdsa.PublicKey) map[string]string {
+	return map[string]string{
+		"kty": "EC",
+		"kid": kid,
+		"crv": "P-256",
+		"x":   base64.RawURLEncoding.EncodeToString(key.X.FillBytes(make([]byte, 32))),
+		"y":   base64.RawURLEncoding.EncodeToString(key.Y.FillBytes(make([]byte, 32))),
+	}
+}
+
+// signToken signs a JWT with the claims, the algorithm and the key.
+func signToken(t *testing.T, alg, kid string, key crypto.Signer, claims map[string]interface{}) string {
+	t.Helper()
+
+	header, err := json.Marshal(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"})
+	require.NoError(t, err)
+
+	payload, err := json.Marshal(claims)
+	require.NoError(t, err)
+
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." +
+		base64.RawURLEncoding.EncodeToString(payload)
+
+	digest := sha256.Sum256([]byte(signingInput))
+
+	var signature []byte
+	switch key := key.(type) {
+	case *rsa.PrivateKey:
+		signature, err = rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+		require.NoError(t, err)
+
+	case *ecdsa.PrivateKey:
+		r, s, err := ecdsa.Sign(rand.Reader, key, digest[:])
+		require.NoError(t, err)
+
+		signature = append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+	}
+
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+// validClaims returns the claims of a valid token, with the overrides.
+func validClaims(overrides map[string]interface{}) map[string]interface{} {
+	claims := map[string]interface{}{
+		"iss":   testIssuer,
+		"aud":   testAudience,
+		"sub":   "user",
+		"exp":   time.Now().Add(time.Hour).Unix(),
+		"scope": "openid send",
+	}
+
+	for name, value := range overrides {
+		if value == nil {
+			delete(claims, name)
+			continue
+		}
+
+		claims[name] = value
+	}
+
+	return claims
+}
+
+func TestOIDCVerifier_Verify(t *testing.T) {
+	t.Parallel()
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	server := newJWKSServer(t, rsaJWK("rsa", &rsaKey.PublicKey), ecJWK("ec", &ecKey.PublicKey))
+
+	verifier := repositories.NewOIDCVerifier(
+		repositories.NewJWKS(server.URL, slog.Default()), testIssuer, testAudience)
+
+	tests := []struct {
+		name      string
+		token     string
+		wantError bool
+	}{
+		{
+			name:  "RS256",
+			token: signToken(t, "RS256", "rsa", rsaKey, validClaims(nil)),
+		},
+		{
+			name:  "ES256",
+			token: signToken(t, "ES256", "ec", ecKey, validClaims(nil)),
+		},
+		{
+			name: "audience array",
+			token: signToken(t, "RS256", "rsa", rsaKey,
+				validClaims(map[string]interface{}{"aud": []string{"other", testAudience}})),
+		},
+		{
+			name: "expired within the leeway",
+			token: signToken(t, "RS256", "rsa", rsaKey,
+				validClaims(map[string]interface{}{"exp": time.Now().Add(-30 * time.Second).Unix()})),
+		},
+		{
+			name: "expired",
+			token: signToken(t, "RS256", "rsa", rsaKey,
+				validClaims(map[string]interface{}{"exp": time.Now().Add(-time.Hour).Unix()})),
+			wantError: true,
+		},
+		{
+			name: "without expiry",
+			token: signToken(t, "RS256", "rsa", rsaKey,
+				validClaims(map[string]interface{}{"exp": nil})),
+			wantError: true,
+		},
+		{
+			name: "not valid yet",
+			token: signToken(t, "RS256", "rsa", rsaKey,
+				validClaims(map[string]interface{}{"nbf": time.Now().Add(time.Hour).Unix()})),
+			wantError: true,
+		},
+		{
+			name: "other issuer",
+			token: signToken(t, "RS256", "rsa", rsaKey,
+				validClaims(map[string]interface{}{"iss": "https://evil.example.com"})),
+			wantError: true,
+		},
+		{
+			name: "other audience",
+			token: signToken(t, "RS256", "rsa", rsaKey,
+				validClaims(map[string]interface{}{"aud": "other"})),
+			wantError: true,
+		},
+		{
+			name:      "signed with another key",
+			token:     signToken(t, "RS256", "rsa", otherKey, validClaims(nil)),
+			wantError: true,
+		},
+		{
+			name:      "algorithm not matching the key",
+			token:     signToken(t, "ES256", "rsa", ecKey, validClaims(nil)),
+			wantError: true,
+		},
+		{
+			name:      "unknown key",
+			token:     signToken(t, "RS256", "unknown", rsaKey, validClaims(nil)),
+			wantError: true,
+		},
+		{
+			name: "none algorithm",
+			token: strings.Join([]string{
+				base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none","kid":"rsa"}`)),
+				base64.RawURLEncoding.EncodeToString([]byte(`{"iss":"` + testIssuer + `"}`)),
+				"",
+			}, "."),
+			wantError: true,
+		},
+		{
+			name:      "malformed",
+			token:     "token",
+			wantError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			claims, err := verifier.Verify(context.Background(), tt.token)
+			if tt.wantError {
+				assert.True(t, errors.Is(err, aggregates.ErrUnauthenticated), "got %v", err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, "user", claims.Subject())
+		})
+	}
+}
+
+func TestJWKS_Rotation(t *testing.T) {
+	t.Parallel()
+
+	oldKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	newKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	server := newJWKSServer(t, ecJWK("old", &oldKey.PublicKey))
+
+	jwks := repositories.NewJWKS(server.URL, slog.Default())
+	verifier := repositories.NewOIDCVerifier(jwks, testIssuer, testAudience)
+
+	ctx := context.Background()
+
+	_, err = verifier.Verify(ctx, signToken(t, "ES256", "old", oldKey, validClaims(nil)))
+	require.NoError(t, err)
+
+	// The keys are cached.
+	_, err = verifier.Verify(ctx, signToken(t, "ES256", "old", oldKey, validClaims(nil)))
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), server.requests.Load())
+
+	server.publish(ecJWK("new", &newKey.PublicKey))
+
+	// Unknown keys don't refetch the keys more than once every
+	// MinRefreshInterval.
+	_, err = verifier.Verify(ctx, signToken(t, "ES256", "new", newKey, validClaims(nil)))
+	assert.True(t, errors.Is(err, aggregates.ErrUnauthenticated), "got %v", err)
+	assert.Equal(t, int32(1), server.requests.Load())
+
+	// Once it's elapsed, the rotated key is fetched.
+	jwks.MinRefreshInterval = 0
+
+	_, err = verifier.Verify(ctx, signToken(t, "ES256", "new", newKey, validClaims(nil)))
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), server.requests.Load())
+
+	// The retired key is gone.
+	_, err = verifier.Verify(ctx, signToken(t, "ES256", "old", oldKey, validClaims(nil)))
+	assert.True(t, errors.Is(err, aggregates.ErrUnauthenticated), "got %v", err)
+}
+
+func TestJWKS_ConcurrentRefresh(t *testing.T) {
+	t.Parallel()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	server := newJWKSServer(t, ecJWK("key", &key.PublicKey))
+	server.delay.Store(int64(100 * time.Millisecond))
+
+	jwks := repositories.NewJWKS(server.URL, slog.Default())
+
+	// A request done before the fetch finishes doesn't cancel it.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err = jwks.Key(ctx, "key")
+	assert.True(t, errors.Is(err, context.DeadlineExceeded), "got %v", err)
+
+	// The concurrent requests share the ongoing fetch.
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			publicKey, err := jwks.Key(context.Background(), "key")
+			assert.NoError(t, err)
+			assert.Equal(t, &key.PublicKey, publicKey)
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(1), server.requests.Load())
+
+	// The minimum refresh interval counts from the end of the fetch.
+	_, err = jwks.Key(context.Background(), "unknown")
+	assert.True(t, errors.Is(err, aggregates.ErrUnauthenticated), "got %v", err)
+	assert.Equal(t, int32(1), server.requests.Load())
+}
@@ -0,0 +1,249 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// quotaDayLayout is the layout of the day the stored counts belong to.
+const quotaDayLayout = "2006-01-02"
+
+// FileQuotaStore is a quota store keeping the daily request counts in a JSON
+// file, it's meant to live on a volume shared by the replicas, which take an
+// exclusive lock on the file while they update it. Only the counts of the
+// current day are kept.
+//
+// The requests are counted in memory and flushed to the file every flush
+// interval, adding them to the counts of the other replicas, so requests
+// don't rewrite the file each. A replica only sees the requests of the
+// others once it flushes, so the quotas can be exceeded by the requests of a
+// flush interval.
+type FileQuotaStore struct {
+	Path string
+
+	logger *slog.Logger
+
+	mu sync.Mutex
+
+	// day is the day of the counts, counts are the counts of the file as of
+	// the last flush plus the pending ones, and pending are the counts not
+	// flushed yet.
+	day     string
+	counts  map[string]int
+	pending map[string]int
+
+	// cancel stops the flush loop, which closes stopped once it's done.
+	cancel  context.CancelFunc
+	stopped chan struct{}
+}
+
+// NewFileQuotaStore creates a new FileQuotaStore storing the counts in the
+// file at path, and starts flushing them every flushInterval until Stop is
+// called.
+func NewFileQuotaStore(ctx context.Context,
+	path string, flushInterval time.Duration, logger *slog.Logger) (*FileQuotaStore, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("error creating quota store directory: %w", err)
+	}
+
+	s := &FileQuotaStore{
+		Path:    path,
+		logger:  logger,
+		counts:  make(map[string]int),
+		pending: make(map[string]int),
+		stopped: make(chan struct{}),
+	}
+
+	ctx, s.cancel = context.WithCancel(ctx)
+	go s.start(ctx, flushInterval)
+
+	return s, nil
+}
+
+// Stop stops flushing the counts, after flushing the pending ones.
+func (s *FileQuotaStore) Stop() {
+	s.cancel()
+	<-s.stopped
+}
+
+// Increment increments the count of requests with the key on the day,
+// returning the new count.
+func (s *FileQuotaStore) Increment(_ context.Context, key string, day time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// The first request of a day starts from the counts of the other
+	// replicas, if any, and drops the pending counts of the previous day.
+	if s.day != day.Format(quotaDayLayout) {
+		s.day = day.Format(quotaDayLayout)
+		s.counts = make(map[string]int)
+		s.pending = make(map[string]int)
+
+		stored, err := s.load()
+		if err != nil {
+			return 0, err
+		}
+
+		if stored.Day == s.day {
+			s.counts = stored.Counts
+		}
+	}
+
+	s.counts[key]++
+	s.pending[key]++
+
+	return s.counts[key], nil
+}
+
+// Flush adds the pending counts to the file, and takes the counts of the
+// other replicas from it. The pending counts are kept for the next flush
+// when it fails.
+func (s *FileQuotaStore) Flush() error {
+	s.mu.Lock()
+	day, pending := s.day, s.pending
+	s.pending = make(map[string]int)
+	s.mu.Unlock()
+
+	if day == "" {
+		return nil
+	}
+
+	counts, err := s.flush(day, pending)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// The counts of a day that is already over are dropped.
+	if s.day != day {
+		return err
+	}
+
+	if err != nil {
+		for key, count := range pending {
+			s.pending[key] += count
+		}
+
+		return err
+	}
+
+	for key, count := range s.pending {
+		counts[key] += count
+	}
+	s.counts = counts
+
+	return nil
+}
+
+// flush adds the pending counts of the day to the file under the lock shared
+// with the other replicas, returning the stored counts.
+func (s *FileQuotaStore) flush(day string, pending map[string]int) (map[string]int, error) {
+	unlock, err := lockFile(s.Path + ".lock")
+	if err != nil {
+		return nil, fmt.Errorf("error locking quota store: %w", err)
+	}
+	defer unlock()
+
+	stored, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+
+	if stored.Day != day {
+		stored = storedQuotas{Day: day, Counts: make(map[string]int)}
+	}
+
+	if len(pending) == 0 {
+		return stored.Counts, nil
+	}
+
+	for key, count := range pending {
+		stored.Counts[key] += count
+	}
+
+	if err := s.save(stored); err != nil {
+		return nil, err
+	}
+
+	return stored.Counts, nil
+}
+
+// start flushes the counts every flush interval until the context is done,
+// flushing them one last time then.
+func (s *FileQuotaStore) start(ctx context.Context, flushInterval time.Duration) {
+	defer close(s.stopped)
+
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := s.Flush(); err != nil {
+				s.logger.ErrorContext(ctx, "error flushing quotas", "error", err)
+			}
+
+		case <-ctx.Done():
+			if err := s.Flush(); err != nil {
+				s.logger.Error("error flushing quotas", "error", err)
+			}
+
+			return
+		}
+	}
+}
+
+// load loads the stored counts, a missing file means no counts.
+func (s *FileQuotaStore) load() (storedQuotas, error) {
+	counts := storedQuotas{Counts: make(map[string]int)}
+
+	data, err := os.ReadFile(s.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return counts, nil
+	}
+	if err != nil {
+		return storedQuotas{}, fmt.Errorf("error reading quota store: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &counts); err != nil {
+		return storedQuotas{}, fmt.Errorf("error decoding quota store: %w", err)
+	}
+
+	if counts.Counts == nil {
+		counts.Counts = make(map[string]int)
+	}
+
+	return counts, nil
+}
+
+// save replaces the stored counts, it writes to a temporary file first so a
+// crash can't leave a truncated file behind.
+func (s *FileQuotaStore) save(counts storedQuotas) error {
+	data, err := json.Marshal(counts)
+	if err != nil {
+		return fmt.Errorf("error encoding quotas: %w", err)
+	}
+
+	tmpFilename := s.Path + ".tmp"
+	if err := os.WriteFile(tmpFilename, data, 0600); err != nil {
+		return fmt.Errorf("error writing quota store: %w", err)
+	}
+
+	if err := os.Rename(tmpFilename, s.Path); err != nil {
+		return fmt.Errorf("error renaming quota store: %w", err)
+	}
+
+	return nil
+}
+
+// storedQuotas are the request counts of a day, by key.
+type storedQuotas struct {
+	Day    string         `json:"day"`
+	Counts map[string]int `json:"counts"`
+}
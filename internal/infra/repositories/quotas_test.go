@@ -0,0 +1,100 @@
+package repositories_test
+
+import (
+	"context"
+	"log/slog"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jcleira/coding-challenge/internal/infra/repositories"
+)
+
+func TestFileQuotaStore_Increment(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "quotas.json")
+	ctx := context.Background()
+
+	// The counts are only flushed explicitly.
+	store, err := repositories.NewFileQuotaStore(ctx, path, time.Hour, slog.Default())
+	require.NoError(t, err)
+	t.Cleanup(store.Stop)
+
+	day := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	count, err := store.Increment(ctx, "client|read", day)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	count, err = store.Increment(ctx, "client|send", day)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	require.NoError(t, store.Flush())
+
+	// The counts are shared by every store on the file, such as the ones of
+	// other replicas, once they're flushed.
+	other, err := repositories.NewFileQuotaStore(ctx, path, time.Hour, slog.Default())
+	require.NoError(t, err)
+	t.Cleanup(other.Stop)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(store *repositories.FileQuotaStore) {
+			defer wg.Done()
+
+			_, err := store.Increment(ctx, "client|read", day)
+			assert.NoError(t, err)
+		}([]*repositories.FileQuotaStore{store, other}[i%2])
+	}
+	wg.Wait()
+
+	// The other store counts the first request and its own ones until the
+	// stores flush.
+	count, err = other.Increment(ctx, "client|read", day)
+	require.NoError(t, err)
+	assert.Equal(t, 7, count)
+
+	require.NoError(t, store.Flush())
+	require.NoError(t, other.Flush())
+
+	count, err = other.Increment(ctx, "client|read", day)
+	require.NoError(t, err)
+	assert.Equal(t, 13, count)
+
+	// The counts start over every day.
+	count, err = store.Increment(ctx, "client|read", day.Add(24*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+}
+
+func TestFileQuotaStore_Stop(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "quotas.json")
+	ctx := context.Background()
+	day := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	store, err := repositories.NewFileQuotaStore(ctx, path, time.Hour, slog.Default())
+	require.NoError(t, err)
+
+	_, err = store.Increment(ctx, "client|read", day)
+	require.NoError(t, err)
+
+	// Stopping the store flushes the pending counts.
+	store.Stop()
+
+	reopened, err := repositories.NewFileQuotaStore(ctx, path, time.Hour, slog.Default())
+	require.NoError(t, err)
+	t.Cleanup(reopened.Stop)
+
+	count, err := reopened.Increment(ctx, "client|read", day)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+}
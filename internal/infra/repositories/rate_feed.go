@@ -0,0 +1,94 @@
+package repositories
+
+import (
+	"sync"
+
+	"github.com/jcleira/coding-challenge/internal/domain/aggregates"
+)
+
+// rateFeedBuffer is the number of rate updates buffered for every subscriber,
+// a subscriber falling further behind is dropped.
+const rateFeedBuffer = 16
+
+// RateFeed fans out rate updates to many subscribers.
+//
+// Publishing never blocks: a subscriber whose buffer is full is considered a
+// slow consumer and is dropped, closing its channel, so a bad client can't
+// block the exchange refresher.
+type RateFeed struct {
+	subscribers map[chan aggregates.Rate]struct{}
+
+	// closed is set once the feed is closed, later subscribers get a closed
+	// channel.
+	closed bool
+
+	subscribersMutex sync.Mutex
+}
+
+// NewRateFeed creates a new RateFeed without subscribers.
+func NewRateFeed() *RateFeed {
+	return &RateFeed{
+		subscribers: make(map[chan aggregates.Rate]struct{}),
+	}
+}
+
+// Subscribe subscribes to the rate updates, the returned function must be
+// called to unsubscribe once the updates are not needed anymore.
+//
+// The channel is closed when unsubscribing, when the subscriber is dropped
+// for being too slow, or when the feed is closed.
+func (f *RateFeed) Subscribe() (<-chan aggregates.Rate, func()) {
+	updates := make(chan aggregates.Rate, rateFeedBuffer)
+
+	f.subscribersMutex.Lock()
+	defer f.subscribersMutex.Unlock()
+
+	if f.closed {
+		close(updates)
+		return updates, func() {}
+	}
+
+	f.subscribers[updates] = struct{}{}
+
+	return updates, func() { f.remove(updates) }
+}
+
+// Close closes the channels of every subscriber, and of the later ones, so
+// the streams end on shutdown.
+func (f *RateFeed) Close() {
+	f.subscribersMutex.Lock()
+	defer f.subscribersMutex.Unlock()
+
+	for updates := range f.subscribers {
+		delete(f.subscribers, updates)
+		close(updates)
+	}
+
+	f.closed = true
+}
+
+// Publish sends the rate update to every subscriber.
+func (f *RateFeed) Publish(rate aggregates.Rate) {
+	f.subscribersMutex.Lock()
+	defer f.subscribersMutex.Unlock()
+
+	for updates := range f.subscribers {
+		select {
+		case updates <- rate:
+		default:
+			delete(f.subscribers, updates)
+			close(updates)
+		}
+	}
+}
+
+// remove unsubscribes the updates channel, unless it was already dropped.
+func (f *RateFeed) remove(updates chan aggregates.Rate) {
+	f.subscribersMutex.Lock()
+	defer f.subscribersMutex.Unlock()
+
+	if _, ok := f.subscribers[updates]; ok {
+		delete(f.subscribers, updates)
+		close(updates)
+	}
+}
@@ -0,0 +1,62 @@
+package repositories_test
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/jcleira/coding-challenge/internal/domain/aggregates"
+	"github.com/jcleira/coding-challenge/internal/infra/repositories"
+)
+
+func TestRateFeed(t *testing.T) {
+	feed := repositories.NewRateFeed()
+
+	fast, unsubscribeFast := feed.Subscribe()
+	defer unsubscribeFast()
+
+	slow, unsubscribeSlow := feed.Subscribe()
+	defer unsubscribeSlow()
+
+	rate := aggregates.Rate{Currency: "EUR", Value: big.NewRat(85, 1)}
+
+	// The fast subscriber reads every update, while the slow one never does
+	// and gets dropped once its buffer is full, without blocking Publish.
+	for i := 0; i < 100; i++ {
+		feed.Publish(rate)
+		assert.Equal(t, rate, <-fast)
+	}
+
+	received := 0
+	for range slow {
+		received++
+	}
+	assert.Less(t, received, 100)
+
+	// Unsubscribing closes the channel.
+	unsubscribeFast()
+	_, ok := <-fast
+	assert.False(t, ok)
+}
+
+func TestRateFeed_Close(t *testing.T) {
+	feed := repositories.NewRateFeed()
+
+	updates, unsubscribe := feed.Subscribe()
+	defer unsubscribe()
+
+	// Closing closes the channels of the current subscribers and of the
+	// later ones, and publishing to a closed feed is a no-op.
+	feed.Close()
+	feed.Publish(aggregates.Rate{Currency: "EUR", Value: big.NewRat(85, 1)})
+
+	_, ok := <-updates
+	assert.False(t, ok)
+
+	later, unsubscribeLater := feed.Subscribe()
+	defer unsubscribeLater()
+
+	_, ok = <-later
+	assert.False(t, ok)
+}
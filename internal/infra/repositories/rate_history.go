@@ -0,0 +1,414 @@
+package repositories
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/sync/singleflight"
+
+	"github.com/jcleira/coding-challenge/internal/domain/aggregates"
+	"github.com/jcleira/coding-challenge/internal/infra/tracing"
+)
+
+const (
+	// rateHistoryMaxGap is the maximum time between a requested time and the
+	// closest earlier rate for it to be used, it matches the daily OHLC
+	// candles that cover the oldest history.
+	rateHistoryMaxGap = 24 * time.Hour
+
+	// rateHistoryBackfillInterval is the minimum time between two backfills of
+	// the same currency, it prevents a listing with many old transactions
+	// from hammering the OHLC endpoint.
+	rateHistoryBackfillInterval = time.Hour
+
+	// rateHistorySnapshotSource is the source recorded for our own snapshots.
+	rateHistorySnapshotSource = "snapshot"
+
+	// rateHistoryOHLCSource is the source recorded for Kraken OHLC candles.
+	rateHistoryOHLCSource = "kraken-ohlc"
+)
+
+// rateHistoryOHLCIntervals are the Kraken OHLC intervals, in minutes, used for
+// backfilling. Kraken returns the last 720 candles of each interval, so hourly
+// candles cover the last 30 days and daily candles the last two years.
+var rateHistoryOHLCIntervals = []int{60, 1440}
+
+// ExchangeRateSource defines the methods for getting the current exchange rate
+// for a fiat currency.
+type ExchangeRateSource interface {
+	GetRate(ctx context.Context, currency string) (aggregates.Rate, error)
+}
+
+// RateHistory is a store of past exchange rates, used to value transactions
+// at the time they happened.
+//
+// It's filled from two sources, periodic snapshots of our own exchange rates
+// and, for the times not covered by them, the Kraken OHLC endpoint. Rates are
+// stored as JSON lines, one file per currency.
+type RateHistory struct {
+	Path    string
+	OHLCURL string
+
+	// points maps fiat currencies to their known rates, sorted by time.
+	points map[string][]ratePoint
+
+	// backfilledAt maps fiat currencies to the time of their last successful
+	// backfill.
+	backfilledAt map[string]time.Time
+
+	pointsMutex sync.RWMutex
+
+	// backfills deduplicates the concurrent backfills of a currency.
+	backfills singleflight.Group
+
+	logger *slog.Logger
+}
+
+// NewRateHistory creates a new RateHistory storing rates in path, and
+// backfilling them from the Kraken OHLC API at ohlcURL.
+func NewRateHistory(path, ohlcURL string, logger *slog.Logger) (*RateHistory, error) {
+	if err := os.MkdirAll(path, 0755); err != nil {
+		return nil, fmt.Errorf("error creating rate history directory: %w", err)
+	}
+
+	h := &RateHistory{
+		Path:         path,
+		OHLCURL:      ohlcURL,
+		points:       make(map[string][]ratePoint),
+		backfilledAt: make(map[string]time.Time),
+		logger:       logger,
+	}
+
+	files, err := filepath.Glob(filepath.Join(path, "*.jsonl"))
+	if err != nil {
+		return nil, fmt.Errorf("error listing rate history files: %w", err)
+	}
+
+	for _, file := range files {
+		points, err := loadRatePoints(file)
+		if err != nil {
+			return nil, fmt.Errorf("error loading rate history: %w", err)
+		}
+
+		currency := filepath.Base(file[:len(file)-len(".jsonl")])
+		h.points[currency] = mergeRatePoints(nil, points)
+	}
+
+	return h, nil
+}
+
+// GetRateAt gets the exchange rate for the fiat currency at the given time,
+// that is the closest known rate at or before it.
+//
+// When there is no known rate close enough, the history is backfilled from
+// the OHLC endpoint before giving up with aggregates.ErrHistoricalRateNotFound.
+func (h *RateHistory) GetRateAt(
+	ctx context.Context, currency string, at time.Time) (_ aggregates.Rate, err error) {
+	ctx, span := tracer.Start(ctx, "RateHistory.GetRateAt", trace.WithAttributes(
+		attribute.String("currency", currency),
+		attribute.String("rate_history.at", at.Format(time.RFC3339)),
+	))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	if rate, ok := h.lookup(currency, at); ok {
+		return rate, nil
+	}
+
+	span.SetAttributes(attribute.Bool("rate_history.missed", true))
+
+	if err := h.backfill(ctx, currency); err != nil {
+		return aggregates.Rate{}, fmt.Errorf("error backfilling rate history: %w", err)
+	}
+
+	if rate, ok := h.lookup(currency, at); ok {
+		return rate, nil
+	}
+
+	return aggregates.Rate{}, fmt.Errorf("%w: %s at %s",
+		aggregates.ErrHistoricalRateNotFound, currency, at.Format(time.RFC3339))
+}
+
+// Record records a rate in the history.
+func (h *RateHistory) Record(rate aggregates.Rate, source string) error {
+	return h.store(rate.Currency, []ratePoint{
+		{Time: rate.Time, Value: rate.Value.FloatString(8), Source: source},
+	})
+}
+
+// StartSnapshots records the current rate of every currency, every interval,
+// until the context is done. Overridden rates aren't recorded, the history
+// keeps the market rates the transactions are valued at.
+func (h *RateHistory) StartSnapshots(
+	ctx context.Context, exchange ExchangeRateSource, currencies []string, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			for _, currency := range currencies {
+				rate, err := exchange.GetRate(ctx, currency)
+				if err != nil {
+					h.logger.WarnContext(ctx, "error getting rate for snapshot",
+						"currency", currency, "error", err)
+					continue
+				}
+
+				if rate.Overridden {
+					continue
+				}
+
+				if err := h.Record(rate, rateHistorySnapshotSource); err != nil {
+					h.logger.ErrorContext(ctx, "error recording rate snapshot",
+						"currency", currency, "error", err)
+				}
+			}
+
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// lookup finds the closest rate at or before the given time.
+func (h *RateHistory) lookup(currency string, at time.Time) (aggregates.Rate, bool) {
+	h.pointsMutex.RLock()
+	defer h.pointsMutex.RUnlock()
+
+	points := h.points[currency]
+
+	i := sort.Search(len(points), func(i int) bool {
+		return points[i].Time.After(at)
+	})
+	if i == 0 {
+		return aggregates.Rate{}, false
+	}
+
+	point := points[i-1]
+	if at.Sub(point.Time) > rateHistoryMaxGap {
+		return aggregates.Rate{}, false
+	}
+
+	value, ok := new(big.Rat).SetString(point.Value)
+	if !ok {
+		return aggregates.Rate{}, false
+	}
+
+	return aggregates.Rate{
+		Currency: currency,
+		Value:    value,
+		Sources:  []string{point.Source},
+		Time:     point.Time,
+	}, true
+}
+
+// backfill fetches the OHLC candles for the currency, unless it has been
+// backfilled recently. A failed backfill is retried on the next miss.
+func (h *RateHistory) backfill(ctx context.Context, currency string) error {
+	_, err, _ := h.backfills.Do(currency, func() (interface{}, error) {
+		h.pointsMutex.RLock()
+		backfilledAt := h.backfilledAt[currency]
+		h.pointsMutex.RUnlock()
+
+		if time.Since(backfilledAt) < rateHistoryBackfillInterval {
+			return nil, nil
+		}
+
+		var points []ratePoint
+		for _, interval := range rateHistoryOHLCIntervals {
+			candles, err := h.fetchOHLC(ctx, currency, interval)
+			if err != nil {
+				return nil, fmt.Errorf("error fetching OHLC: %w", err)
+			}
+
+			points = append(points, candles...)
+		}
+
+		if err := h.store(currency, points); err != nil {
+			return nil, err
+		}
+
+		h.pointsMutex.Lock()
+		h.backfilledAt[currency] = time.Now()
+		h.pointsMutex.Unlock()
+
+		return nil, nil
+	})
+
+	return err
+}
+
+// fetchOHLC fetches the Kraken OHLC candles for the currency, using the volume
+// weighted average price of each candle as its rate.
+//
+// https://docs.kraken.com/rest/#tag/Market-Data/operation/getOHLCData
+func (h *RateHistory) fetchOHLC(
+	ctx context.Context, currency string, interval int) ([]ratePoint, error) {
+	pair := "SOL" + currency
+
+	var result struct {
+		Error  []string                   `json:"error"`
+		Result map[string]json.RawMessage `json:"result"`
+	}
+
+	err := getJSON(ctx, fmt.Sprintf("%s?pair=%s&interval=%d", h.OHLCURL, pair, interval), &result)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(result.Error) != 0 {
+		return nil, fmt.Errorf("OHLC api returned an error: %v", result.Error)
+	}
+
+	raw, ok := result.Result[pair]
+	if !ok {
+		return nil, fmt.Errorf("invalid data received from OHLC api")
+	}
+
+	// Every candle is [time, open, high, low, close, vwap, volume, count].
+	var candles [][]interface{}
+	if err := json.Unmarshal(raw, &candles); err != nil {
+		return nil, fmt.Errorf("error decoding OHLC candles: %w", err)
+	}
+
+	points := make([]ratePoint, 0, len(candles))
+	for _, candle := range candles {
+		if len(candle) < 6 {
+			return nil, fmt.Errorf("invalid OHLC candle: %v", candle)
+		}
+
+		timestamp, ok := candle[0].(float64)
+		if !ok {
+			return nil, fmt.Errorf("invalid OHLC candle time: %v", candle[0])
+		}
+
+		vwap, ok := candle[5].(string)
+		if !ok {
+			return nil, fmt.Errorf("invalid OHLC candle vwap: %v", candle[5])
+		}
+
+		if _, err := parseRate(vwap); err != nil {
+			return nil, fmt.Errorf("invalid OHLC candle vwap: %w", err)
+		}
+
+		points = append(points, ratePoint{
+			Time:   time.Unix(int64(timestamp), 0).UTC(),
+			Value:  vwap,
+			Source: rateHistoryOHLCSource,
+		})
+	}
+
+	return points, nil
+}
+
+// store merges the points into the currency history and appends the new ones
+// to its file.
+func (h *RateHistory) store(currency string, points []ratePoint) error {
+	h.pointsMutex.Lock()
+	defer h.pointsMutex.Unlock()
+
+	known := make(map[int64]bool, len(h.points[currency]))
+	for _, point := range h.points[currency] {
+		known[point.Time.Unix()] = true
+	}
+
+	var added []ratePoint
+	for _, point := range points {
+		if known[point.Time.Unix()] {
+			continue
+		}
+
+		known[point.Time.Unix()] = true
+		added = append(added, point)
+	}
+
+	if len(added) == 0 {
+		return nil
+	}
+
+	file, err := os.OpenFile(filepath.Join(h.Path, currency+".jsonl"),
+		os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("error opening rate history file: %w", err)
+	}
+	defer file.Close()
+
+	writer := bufio.NewWriter(file)
+	encoder := json.NewEncoder(writer)
+	for _, point := range added {
+		if err := encoder.Encode(point); err != nil {
+			return fmt.Errorf("error writing rate history: %w", err)
+		}
+	}
+
+	if err := writer.Flush(); err != nil {
+		return fmt.Errorf("error writing rate history: %w", err)
+	}
+
+	h.points[currency] = mergeRatePoints(h.points[currency], added)
+
+	return nil
+}
+
+// ratePoint is the storage version of a historical rate.
+type ratePoint struct {
+	Time   time.Time `json:"time"`
+	Value  string    `json:"value"`
+	Source string    `json:"source"`
+}
+
+// loadRatePoints loads the rate points stored in filename.
+func loadRatePoints(filename string) ([]ratePoint, error) {
+	file, err := os.Open(filename)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error opening rate history file: %w", err)
+	}
+	defer file.Close()
+
+	var points []ratePoint
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var point ratePoint
+		if err := json.Unmarshal(scanner.Bytes(), &point); err != nil {
+			return nil, fmt.Errorf("error decoding rate history: %w", err)
+		}
+
+		points = append(points, point)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error scanning rate history file: %w", err)
+	}
+
+	return points, nil
+}
+
+// mergeRatePoints merges the added points into the sorted points, keeping
+// them sorted by time.
+func mergeRatePoints(points, added []ratePoint) []ratePoint {
+	merged := make([]ratePoint, 0, len(points)+len(added))
+	merged = append(merged, points...)
+	merged = append(merged, added...)
+
+	sort.SliceStable(merged, func(i, j int) bool {
+		return merged[i].Time.Before(merged[j].Time)
+	})
+
+	return merged
+}
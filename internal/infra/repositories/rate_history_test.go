@@ -0,0 +1,160 @@
+package repositories_test
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jcleira/coding-challenge/internal/domain/aggregates"
+	"github.com/jcleira/coding-challenge/internal/infra/repositories"
+	"github.com/jcleira/coding-challenge/mocks"
+)
+
+func TestRateHistory_GetRateAt(t *testing.T) {
+	var requests atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+
+		assert.Equal(t, "SOLEUR", r.URL.Query().Get("pair"))
+
+		w.Write([]byte(`{"error":[],"result":{"SOLEUR":[` +
+			`[1693580400,"20.1","20.5","19.9","20.3","20.25","100.0",10],` +
+			`[1693584000,"20.3","20.6","20.2","20.4","20.45","120.0",12]` +
+			`],"last":1693584000}}`))
+	}))
+	defer server.Close()
+
+	path := t.TempDir()
+
+	history, err := repositories.NewRateHistory(path, server.URL, slog.Default())
+	require.NoError(t, err)
+
+	// Recorded snapshots are used without reaching the OHLC endpoint.
+	snapshotTime := time.Date(2023, 10, 1, 12, 0, 0, 0, time.UTC)
+	require.NoError(t, history.Record(aggregates.Rate{
+		Currency: "EUR",
+		Value:    big.NewRat(2150, 100),
+		Time:     snapshotTime,
+	}, "snapshot"))
+
+	rate, err := history.GetRateAt(context.Background(), "EUR", snapshotTime.Add(time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, big.NewRat(2150, 100), rate.Value)
+	assert.Equal(t, []string{"snapshot"}, rate.Sources)
+	assert.Equal(t, int32(0), requests.Load())
+
+	// Older times are backfilled from the OHLC candles.
+	rate, err = history.GetRateAt(context.Background(), "EUR",
+		time.Unix(1693584000, 0).Add(30*time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, big.NewRat(2045, 100), rate.Value)
+	assert.Equal(t, []string{"kraken-ohlc"}, rate.Sources)
+
+	// Times not covered by any rate are not found, without backfilling again.
+	backfills := requests.Load()
+	_, err = history.GetRateAt(context.Background(), "EUR", time.Unix(1693580400, 0).Add(-time.Hour))
+	assert.True(t, errors.Is(err, aggregates.ErrHistoricalRateNotFound))
+	assert.Equal(t, backfills, requests.Load())
+
+	// The history is persisted between restarts.
+	reloaded, err := repositories.NewRateHistory(path, server.URL, slog.Default())
+	require.NoError(t, err)
+
+	rate, err = reloaded.GetRateAt(context.Background(), "EUR", time.Unix(1693580400, 0))
+	require.NoError(t, err)
+	assert.Equal(t, big.NewRat(2025, 100), rate.Value)
+	assert.Equal(t, backfills, requests.Load())
+}
+
+func TestRateHistory_GetRateAtRetriesFailedBackfill(t *testing.T) {
+	var (
+		requests atomic.Int32
+		failing  atomic.Bool
+	)
+	failing.Store(true)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+
+		if failing.Load() {
+			http.Error(w, http.StatusText(http.StatusBadGateway), http.StatusBadGateway)
+			return
+		}
+
+		w.Write([]byte(`{"error":[],"result":{"SOLEUR":[` +
+			`[1693580400,"20.1","20.5","19.9","20.3","20.25","100.0",10]` +
+			`],"last":1693580400}}`))
+	}))
+	defer server.Close()
+
+	history, err := repositories.NewRateHistory(t.TempDir(), server.URL, slog.Default())
+	require.NoError(t, err)
+
+	_, err = history.GetRateAt(context.Background(), "EUR", time.Unix(1693580400, 0))
+	require.Error(t, err)
+	assert.False(t, errors.Is(err, aggregates.ErrHistoricalRateNotFound))
+
+	// The failed backfill doesn't hold back the next one.
+	failing.Store(false)
+	failed := requests.Load()
+
+	rate, err := history.GetRateAt(context.Background(), "EUR", time.Unix(1693580400, 0))
+	require.NoError(t, err)
+	assert.Equal(t, big.NewRat(2025, 100), rate.Value)
+	assert.Greater(t, requests.Load(), failed)
+}
+
+func TestRateHistory_StartSnapshotsSkipsOverrides(t *testing.T) {
+	// The OHLC endpoint has no candles, only the snapshots are found.
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"error":[],"result":{"` + r.URL.Query().Get("pair") + `":[],"last":0}}`))
+	}))
+	defer server.Close()
+
+	history, err := repositories.NewRateHistory(t.TempDir(), server.URL, slog.Default())
+	require.NoError(t, err)
+
+	now := time.Now()
+
+	exchange := mocks.NewExchangeRateSource(t)
+	exchange.On("GetRate", mock.Anything, "EUR").Return(aggregates.Rate{
+		Currency:   "EUR",
+		Value:      big.NewRat(90, 1),
+		Time:       now,
+		Overridden: true,
+	}, nil)
+	exchange.On("GetRate", mock.Anything, "USD").Return(aggregates.Rate{
+		Currency: "USD",
+		Value:    big.NewRat(100, 1),
+		Time:     now,
+	}, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		history.StartSnapshots(ctx, exchange, []string{"EUR", "USD"}, 10*time.Millisecond)
+	}()
+
+	assert.Eventually(t, func() bool {
+		_, err := history.GetRateAt(context.Background(), "USD", now)
+		return err == nil
+	}, time.Second, 10*time.Millisecond)
+
+	cancel()
+	<-done
+
+	_, err = history.GetRateAt(context.Background(), "EUR", now)
+	assert.True(t, errors.Is(err, aggregates.ErrHistoricalRateNotFound), "got %v", err)
+}
@@ -0,0 +1,268 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// rateRequestTimeout is the maximum time a request to a rate provider can
+// take, including reading the response.
+const rateRequestTimeout = 5 * time.Second
+
+// rateHTTPClient is the http client used for every request to the rate
+// providers, unlike http.DefaultClient it doesn't wait forever on a provider
+// that stopped responding.
+var rateHTTPClient = &http.Client{Timeout: rateRequestTimeout}
+
+// RateProvider defines the methods for fetching SOL exchange rates from an
+// external source, such as an exchange or a price aggregator.
+type RateProvider interface {
+	// Name returns the name of the provider, it's published with the rates it
+	// contributed to.
+	Name() string
+
+	// FetchRate fetches the SOL exchange rate for the given fiat currency.
+	FetchRate(ctx context.Context, currency string) (*big.Rat, error)
+}
+
+// KrakenProvider fetches rates from the Kraken ticker API, using the volume
+// weighted average price of the last 24 hours.
+//
+// https://docs.kraken.com/rest/#tag/Market-Data/operation/getTickerInformation
+type KrakenProvider struct {
+	APIURL string
+}
+
+// NewKrakenProvider creates a new KrakenProvider with the given API URL.
+func NewKrakenProvider(apiURL string) *KrakenProvider {
+	return &KrakenProvider{APIURL: apiURL}
+}
+
+// Name returns the name of the provider.
+func (k *KrakenProvider) Name() string {
+	return "kraken"
+}
+
+// FetchRate fetches the SOL exchange rate for the given fiat currency, the
+// Kraken pair is SOL followed by the currency code, such as SOLEUR.
+func (k *KrakenProvider) FetchRate(ctx context.Context, currency string) (*big.Rat, error) {
+	pair := "SOL" + currency
+
+	var result struct {
+		Error  []string `json:"error"`
+		Result map[string]struct {
+			P []string `json:"p"`
+		} `json:"result"`
+	}
+
+	if err := getJSON(ctx, fmt.Sprintf("%s?pair=%s", k.APIURL, pair), &result); err != nil {
+		return nil, err
+	}
+
+	if len(result.Error) != 0 {
+		return nil, fmt.Errorf("exchange rate api returned an error: %v", result.Error)
+	}
+
+	pairData, ok := result.Result[pair]
+	if !ok || len(pairData.P) < 2 {
+		return nil, fmt.Errorf("invalid data received from exchange rate api")
+	}
+
+	return parseRate(pairData.P[1])
+}
+
+// CoinbaseProvider fetches rates from the Coinbase spot price API.
+//
+// https://docs.cloud.coinbase.com/sign-in-with-coinbase/docs/api-prices
+type CoinbaseProvider struct {
+	APIURL string
+}
+
+// NewCoinbaseProvider creates a new CoinbaseProvider with the given API URL,
+// such as https://api.coinbase.com/v2/prices.
+func NewCoinbaseProvider(apiURL string) *CoinbaseProvider {
+	return &CoinbaseProvider{APIURL: apiURL}
+}
+
+// Name returns the name of the provider.
+func (c *CoinbaseProvider) Name() string {
+	return "coinbase"
+}
+
+// FetchRate fetches the SOL exchange rate for the given fiat currency.
+func (c *CoinbaseProvider) FetchRate(ctx context.Context, currency string) (*big.Rat, error) {
+	var result struct {
+		Data struct {
+			Amount   string `json:"amount"`
+			Base     string `json:"base"`
+			Currency string `json:"currency"`
+		} `json:"data"`
+	}
+
+	if err := getJSON(ctx, fmt.Sprintf("%s/SOL-%s/spot", c.APIURL, currency), &result); err != nil {
+		return nil, err
+	}
+
+	if result.Data.Base != "SOL" || result.Data.Currency != currency {
+		return nil, fmt.Errorf("invalid data received from exchange rate api")
+	}
+
+	return parseRate(result.Data.Amount)
+}
+
+// BinanceProvider fetches rates from the Binance ticker price API.
+//
+// https://binance-docs.github.io/apidocs/spot/en/#symbol-price-ticker
+type BinanceProvider struct {
+	APIURL string
+}
+
+// NewBinanceProvider creates a new BinanceProvider with the given API URL,
+// such as https://api.binance.com/api/v3/ticker/price.
+func NewBinanceProvider(apiURL string) *BinanceProvider {
+	return &BinanceProvider{APIURL: apiURL}
+}
+
+// Name returns the name of the provider.
+func (b *BinanceProvider) Name() string {
+	return "binance"
+}
+
+// FetchRate fetches the SOL exchange rate for the given fiat currency, the
+// Binance symbol is SOL followed by the currency code, such as SOLEUR.
+func (b *BinanceProvider) FetchRate(ctx context.Context, currency string) (*big.Rat, error) {
+	symbol := "SOL" + currency
+
+	var result struct {
+		Symbol string `json:"symbol"`
+		Price  string `json:"price"`
+	}
+
+	if err := getJSON(ctx, fmt.Sprintf("%s?symbol=%s", b.APIURL, symbol), &result); err != nil {
+		return nil, err
+	}
+
+	if result.Symbol != symbol {
+		return nil, fmt.Errorf("invalid data received from exchange rate api")
+	}
+
+	return parseRate(result.Price)
+}
+
+// CoinGeckoProvider fetches rates from the CoinGecko simple price API.
+//
+// https://www.coingecko.com/api/documentation
+type CoinGeckoProvider struct {
+	APIURL string
+}
+
+// NewCoinGeckoProvider creates a new CoinGeckoProvider with the given API URL,
+// such as https://api.coingecko.com/api/v3/simple/price.
+func NewCoinGeckoProvider(apiURL string) *CoinGeckoProvider {
+	return &CoinGeckoProvider{APIURL: apiURL}
+}
+
+// Name returns the name of the provider.
+func (c *CoinGeckoProvider) Name() string {
+	return "coingecko"
+}
+
+// FetchRate fetches the SOL exchange rate for the given fiat currency.
+func (c *CoinGeckoProvider) FetchRate(ctx context.Context, currency string) (*big.Rat, error) {
+	vsCurrency := strings.ToLower(currency)
+
+	// Prices are JSON numbers, decoding them as json.Number keeps the exact
+	// decimal representation instead of going through float64.
+	var result map[string]map[string]json.Number
+
+	query := url.Values{
+		"ids":           []string{"solana"},
+		"vs_currencies": []string{vsCurrency},
+	}
+
+	if err := getJSON(ctx, fmt.Sprintf("%s?%s", c.APIURL, query.Encode()), &result); err != nil {
+		return nil, err
+	}
+
+	price, ok := result["solana"][vsCurrency]
+	if !ok {
+		return nil, fmt.Errorf("invalid data received from exchange rate api")
+	}
+
+	return parseRate(price.String())
+}
+
+// StaticProvider returns fixed rates, it's meant for local development and
+// tests, where depending on the real providers is not desirable.
+type StaticProvider struct {
+	Rates map[string]*big.Rat
+}
+
+// NewStaticProvider creates a new StaticProvider returning the given rates,
+// keyed by fiat currency.
+func NewStaticProvider(rates map[string]*big.Rat) *StaticProvider {
+	return &StaticProvider{Rates: rates}
+}
+
+// Name returns the name of the provider.
+func (s *StaticProvider) Name() string {
+	return "static"
+}
+
+// FetchRate returns the static rate for the given fiat currency.
+func (s *StaticProvider) FetchRate(_ context.Context, currency string) (*big.Rat, error) {
+	rate, ok := s.Rates[currency]
+	if !ok {
+		return nil, fmt.Errorf("no static rate for %s", currency)
+	}
+
+	return new(big.Rat).Set(rate), nil
+}
+
+// getJSON performs a GET request to url, decoding the JSON response into
+// result.
+func getJSON(ctx context.Context, url string, result interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return fmt.Errorf("error creating request to exchange rate api: %w", err)
+	}
+
+	resp, err := rateHTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("error making request to exchange rate api: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("exchange rate api returned status: %d", resp.StatusCode)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return fmt.Errorf("error decoding response: %w", err)
+	}
+
+	return nil
+}
+
+// parseRate parses a decimal rate, rejecting non positive values.
+//
+// I've used big.Rat to do the calculations, as it does provide a better
+// precision than float64.
+func parseRate(value string) (*big.Rat, error) {
+	rate, ok := new(big.Rat).SetString(value)
+	if !ok {
+		return nil, fmt.Errorf("error parsing exchange rate: %s", value)
+	}
+
+	if rate.Sign() <= 0 {
+		return nil, fmt.Errorf("invalid exchange rate: %s", value)
+	}
+
+	return rate, nil
+}
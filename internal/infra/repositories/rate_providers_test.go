@@ -0,0 +1,128 @@
+package repositories_test
+
+import (
+	"context"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jcleira/coding-challenge/internal/infra/repositories"
+)
+
+func TestRateProviders_FetchRate(t *testing.T) {
+	tests := []struct {
+		name      string
+		provider  func(url string) repositories.RateProvider
+		wantPath  string
+		wantQuery string
+		status    int
+		response  string
+		wantRate  *big.Rat
+		wantErr   bool
+	}{
+		{
+			name:      "kraken",
+			provider:  func(url string) repositories.RateProvider { return repositories.NewKrakenProvider(url) },
+			wantQuery: "pair=SOLEUR",
+			status:    http.StatusOK,
+			response:  `{"error":[],"result":{"SOLEUR":{"p":["85.1","85.25"]}}}`,
+			wantRate:  big.NewRat(8525, 100),
+		},
+		{
+			name:      "kraken error",
+			provider:  func(url string) repositories.RateProvider { return repositories.NewKrakenProvider(url) },
+			wantQuery: "pair=SOLEUR",
+			status:    http.StatusOK,
+			response:  `{"error":["EQuery:Unknown asset pair"]}`,
+			wantErr:   true,
+		},
+		{
+			name:     "coinbase",
+			provider: func(url string) repositories.RateProvider { return repositories.NewCoinbaseProvider(url) },
+			wantPath: "/SOL-EUR/spot",
+			status:   http.StatusOK,
+			response: `{"data":{"amount":"85.3","base":"SOL","currency":"EUR"}}`,
+			wantRate: big.NewRat(853, 10),
+		},
+		{
+			name:      "binance",
+			provider:  func(url string) repositories.RateProvider { return repositories.NewBinanceProvider(url) },
+			wantQuery: "symbol=SOLEUR",
+			status:    http.StatusOK,
+			response:  `{"symbol":"SOLEUR","price":"85.40000000"}`,
+			wantRate:  big.NewRat(854, 10),
+		},
+		{
+			name:      "binance invalid symbol",
+			provider:  func(url string) repositories.RateProvider { return repositories.NewBinanceProvider(url) },
+			wantQuery: "symbol=SOLEUR",
+			status:    http.StatusBadRequest,
+			response:  `{"code":-1121,"msg":"Invalid symbol."}`,
+			wantErr:   true,
+		},
+		{
+			name:      "coingecko",
+			provider:  func(url string) repositories.RateProvider { return repositories.NewCoinGeckoProvider(url) },
+			wantQuery: "ids=solana&vs_currencies=eur",
+			status:    http.StatusOK,
+			response:  `{"solana":{"eur":85.12}}`,
+			wantRate:  big.NewRat(8512, 100),
+		},
+		{
+			name:      "coingecko missing currency",
+			provider:  func(url string) repositories.RateProvider { return repositories.NewCoinGeckoProvider(url) },
+			wantQuery: "ids=solana&vs_currencies=eur",
+			status:    http.StatusOK,
+			response:  `{"solana":{}}`,
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.wantPath != "" {
+					assert.Equal(t, tt.wantPath, r.URL.Path)
+				}
+				if tt.wantQuery != "" {
+					assert.Equal(t, tt.wantQuery, r.URL.RawQuery)
+				}
+
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.response))
+			}))
+			defer server.Close()
+
+			rate, err := tt.provider(server.URL).FetchRate(context.Background(), "EUR")
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantRate, rate)
+		})
+	}
+}
+
+func TestStaticProvider_FetchRate(t *testing.T) {
+	provider := repositories.NewStaticProvider(map[string]*big.Rat{
+		"EUR": big.NewRat(851, 10),
+		"USD": big.NewRat(92, 1),
+	})
+
+	rate, err := provider.FetchRate(context.Background(), "EUR")
+	require.NoError(t, err)
+	assert.Equal(t, big.NewRat(851, 10), rate)
+
+	rate, err = provider.FetchRate(context.Background(), "USD")
+	require.NoError(t, err)
+	assert.Equal(t, big.NewRat(92, 1), rate)
+
+	_, err = provider.FetchRate(context.Background(), "GBP")
+	assert.Error(t, err)
+}
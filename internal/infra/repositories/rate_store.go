@@ -0,0 +1,110 @@
+package repositories
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/jcleira/coding-challenge/internal/domain/aggregates"
+)
+
+// RateStore defines the methods for persisting the last known exchange rates,
+// so a restart doesn't depend on the rate providers being up.
+type RateStore interface {
+	// Load loads the last saved rates, keyed by fiat currency.
+	Load() (map[string]aggregates.Rate, error)
+
+	// Save replaces the saved rates.
+	Save(rates map[string]aggregates.Rate) error
+}
+
+// FileRateStore is a RateStore keeping the rates in a JSON file.
+type FileRateStore struct {
+	Path string
+}
+
+// NewFileRateStore creates a new FileRateStore storing the rates in the file
+// at path.
+func NewFileRateStore(path string) (*FileRateStore, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("error creating rate store directory: %w", err)
+	}
+
+	return &FileRateStore{Path: path}, nil
+}
+
+// Load loads the last saved rates, a missing file means no rates.
+func (s *FileRateStore) Load() (map[string]aggregates.Rate, error) {
+	rates := make(map[string]aggregates.Rate)
+
+	data, err := os.ReadFile(s.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return rates, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading rate store: %w", err)
+	}
+
+	var entries map[string]storedRate
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("error decoding rate store: %w", err)
+	}
+
+	for currency, entry := range entries {
+		value, err := parseRate(entry.Value)
+		if err != nil {
+			return nil, fmt.Errorf("error decoding %s rate: %w", currency, err)
+		}
+
+		rates[currency] = aggregates.Rate{
+			Currency:  currency,
+			Value:     value,
+			Sources:   entry.Sources,
+			Time:      entry.Time,
+			ExpiredAt: entry.ExpiredAt,
+		}
+	}
+
+	return rates, nil
+}
+
+// Save replaces the saved rates, it writes to a temporary file first so a
+// crash can't leave a truncated file behind.
+func (s *FileRateStore) Save(rates map[string]aggregates.Rate) error {
+	entries := make(map[string]storedRate, len(rates))
+	for currency, rate := range rates {
+		entries[currency] = storedRate{
+			Value:     rate.Value.RatString(),
+			Sources:   rate.Sources,
+			Time:      rate.Time,
+			ExpiredAt: rate.ExpiredAt,
+		}
+	}
+
+	data, err := json.Marshal(entries)
+	if err != nil {
+		return fmt.Errorf("error encoding rates: %w", err)
+	}
+
+	tmpFilename := s.Path + ".tmp"
+	if err := os.WriteFile(tmpFilename, data, 0600); err != nil {
+		return fmt.Errorf("error writing rate store: %w", err)
+	}
+
+	if err := os.Rename(tmpFilename, s.Path); err != nil {
+		return fmt.Errorf("error renaming rate store: %w", err)
+	}
+
+	return nil
+}
+
+// storedRate is the storage version of a domain rate.
+type storedRate struct {
+	Value     string    `json:"value"`
+	Sources   []string  `json:"sources"`
+	Time      time.Time `json:"time"`
+	ExpiredAt time.Time `json:"expired_at"`
+}
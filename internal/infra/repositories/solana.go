@@ -4,62 +4,122 @@ import (
 	"context"
 	"encoding/binary"
 	"fmt"
+	"log/slog"
+	"sync"
 	"time"
 
 	"github.com/gagliardetto/solana-go"
 	"github.com/gagliardetto/solana-go/programs/system"
 	"github.com/gagliardetto/solana-go/rpc"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/jcleira/coding-challenge/internal/domain/aggregates"
+	"github.com/jcleira/coding-challenge/internal/infra/metrics"
+	"github.com/jcleira/coding-challenge/internal/infra/tracing"
 )
 
-const (
-	// confirmationTimeout is the timeout for the transaction confirmation
+// SolanaSettings are the settings of a Solana that can be changed while it
+// runs.
+type SolanaSettings struct {
+	// Commitment is the commitment level of the reads, and the one a sent
+	// transaction must reach to be confirmed.
+	Commitment rpc.CommitmentType
+
+	// ConfirmationTimeout is the timeout for the transaction confirmation
 	//
 	// I'm keeping it low for the sake of providing a quick feedback to the user,
 	// and I'd be returning an especific error for the frontend to handle. This
 	// is a design decision that requires a proper coordination in the frontend,
 	// to inform the user that the transaction has been sent and we're waiting.
-	confirmationTimeout = 3 * time.Second
+	ConfirmationTimeout time.Duration
 
-	// confirmationInterval is the interval to check for the transaction
+	// ConfirmationInterval is the interval to check for the transaction
 	// confirmation.
-	confirmationInterval = 500 * time.Millisecond
-)
+	ConfirmationInterval time.Duration
+
+	// RPCScoreHalfLife is the time it takes the score of an RPC endpoint to
+	// get halfway back to the one of a new endpoint, so an endpoint that
+	// failed or was slow is called again once it had time to recover. The
+	// scores don't decay when it's zero.
+	RPCScoreHalfLife time.Duration
+}
 
 // Solana defines the dependencies for sending transactions to the Solana
 // blockchain.
 type Solana struct {
 	client *rpc.Client
+	logger *slog.Logger
+
+	settings      SolanaSettings
+	settingsMutex sync.RWMutex
 }
 
-// NewSolana creates a new Solana.
-func NewSolana(rpcURL string) *Solana {
-	return &Solana{
-		client: rpc.New(rpcURL),
+// NewSolana creates a new Solana calling the RPC endpoints, failing over
+// between them, and recording the metrics of its RPC calls.
+func NewSolana(endpoints []RPCEndpoint, settings SolanaSettings, logger *slog.Logger) *Solana {
+	s := &Solana{
+		logger:   logger,
+		settings: settings,
 	}
+
+	s.client = newFailoverRPCClient(endpoints, s.rpcScoreHalfLife, logger)
+
+	return s
+}
+
+// Configure replaces the settings, the sends already waiting for their
+// confirmation keep the previous ones.
+func (s *Solana) Configure(settings SolanaSettings) {
+	s.settingsMutex.Lock()
+	defer s.settingsMutex.Unlock()
+
+	s.settings = settings
+}
+
+// currentSettings returns the current settings.
+func (s *Solana) currentSettings() SolanaSettings {
+	s.settingsMutex.RLock()
+	defer s.settingsMutex.RUnlock()
+
+	return s.settings
+}
+
+// rpcScoreHalfLife returns the current half-life of the RPC endpoint scores.
+func (s *Solana) rpcScoreHalfLife() time.Duration {
+	return s.currentSettings().RPCScoreHalfLife
 }
 
 // SendTransaction sends a transaction to the Solana blockchain, returning the
-// transaction signature.
+// transaction signature. A transaction not confirmed in time might still be
+// confirmed, so its signature is returned along with the timeout error.
 //
 // I didn't rely on the solana-go client wait for confirmation, because I was
 // not aware that it did existed, I could be using it even though we migh want
 // to have a custom implementation of the interval and timeout.
 func (s *Solana) SendTransaction(ctx context.Context,
-	transaction aggregates.Transaction, wallet aggregates.Wallet) (string, error) {
+	transaction aggregates.Transaction, wallet aggregates.Wallet) (_ string, err error) {
+	ctx, span := tracer.Start(ctx, "Solana.SendTransaction", trace.WithAttributes(
+		attribute.String("wallet.public_key", wallet.PublicKey),
+		attribute.String("solana.counter_party", transaction.CounterParty),
+		attribute.Int64("solana.amount_lamports", int64(transaction.AmountLAM)),
+	))
+	defer func() { tracing.EndSpan(span, err) }()
+
 	fromPublicKey, err := solana.PublicKeyFromBase58(wallet.PublicKey)
 	if err != nil {
-		return "", fmt.Errorf("error converting string to solana.PublicKey: %w", err)
+		return "", fmt.Errorf("error converting string to solana.PublicKey: %w: %w",
+			aggregates.ErrInvalidPublicKey, err)
 	}
 
 	toPublicKey, err := solana.PublicKeyFromBase58(transaction.CounterParty)
 	if err != nil {
-		return "", fmt.Errorf("error converting string to solana.PublicKey: %w", err)
+		return "", fmt.Errorf("error converting string to solana.PublicKey: %w: %w",
+			aggregates.ErrInvalidPublicKey, err)
 	}
 
 	transferInstruction := system.NewTransferInstruction(
-		transaction.AmountLAM,
+		uint64(transaction.AmountLAM),
 		fromPublicKey,
 		toPublicKey,
 	).Build()
@@ -93,29 +153,49 @@ func (s *Solana) SendTransaction(ctx context.Context,
 		return "", fmt.Errorf("error signing transaction: %w", err)
 	}
 
+	submittedAt := time.Now()
+
 	signature, err := s.client.SendTransaction(ctx, tx)
 	if err != nil {
+		metrics.ObserveSend(metrics.SendFailed, time.Since(submittedAt))
 		return "", fmt.Errorf("error sending transaction: %w", err)
 	}
 
-	ticker := time.NewTicker(confirmationInterval)
+	span.SetAttributes(attribute.String("solana.signature", signature.String()))
+
+	logger := s.logger.With("wallet", wallet.PublicKey, "signature", signature.String())
+
+	settings := s.currentSettings()
+
+	ticker := time.NewTicker(settings.ConfirmationInterval)
 	defer ticker.Stop()
 
+	timeout := time.NewTimer(settings.ConfirmationTimeout)
+	defer timeout.Stop()
+
 	for {
 		select {
-		case <-time.After(confirmationTimeout):
-			return "", aggregates.ErrTransactionConfirmationTimeout
+		case <-timeout.C:
+			metrics.ObserveSend(metrics.SendTimeout, time.Since(submittedAt))
+			logger.WarnContext(ctx, "transaction not confirmed in time, it might still be confirmed",
+				"timeout", settings.ConfirmationTimeout)
+			return signature.String(), aggregates.ErrTransactionConfirmationTimeout
 		case <-ticker.C:
 			status, err := s.client.GetSignatureStatuses(ctx, false, signature)
 			if err != nil {
+				metrics.ObserveSend(metrics.SendFailed, time.Since(submittedAt))
+				logger.ErrorContext(ctx, "error getting signature status", "error", err)
 				return "", fmt.Errorf("error getting signature status: %w", err)
 			}
 
 			if status != nil && len(status.Value) > 0 && status.Value[0] != nil {
 				switch {
 				case status.Value[0].Err != nil:
+					metrics.ObserveSend(metrics.SendFailed, time.Since(submittedAt))
+					logger.ErrorContext(ctx, "transaction failed", "error", status.Value[0].Err)
 					return "", fmt.Errorf("error confirming transaction: %v", status.Value[0].Err)
-				case status.Value[0].ConfirmationStatus == rpc.ConfirmationStatusConfirmed:
+				case reachedCommitment(status.Value[0].ConfirmationStatus, settings.Commitment):
+					metrics.ObserveSend(metrics.SendConfirmed, time.Since(submittedAt))
 					return signature.String(), nil
 				default:
 					continue
@@ -125,9 +205,35 @@ func (s *Solana) SendTransaction(ctx context.Context,
 	}
 }
 
+// commitmentLevels orders the commitment levels, and the confirmation
+// statuses named after them.
+var commitmentLevels = map[string]int{
+	string(rpc.CommitmentProcessed): 1,
+	string(rpc.CommitmentConfirmed): 2,
+	string(rpc.CommitmentFinalized): 3,
+}
+
+// CheckHealth checks an RPC endpoint is reachable and the node behind it is
+// healthy.
+func (s *Solana) CheckHealth(ctx context.Context) error {
+	if _, err := s.client.GetHealth(ctx); err != nil {
+		return fmt.Errorf("error getting RPC health: %w", err)
+	}
+
+	return nil
+}
+
+// reachedCommitment returns whether a transaction with the status reached the
+// commitment, a finalized transaction is also confirmed and processed.
+func reachedCommitment(status rpc.ConfirmationStatusType, commitment rpc.CommitmentType) bool {
+	level := commitmentLevels[string(status)]
+
+	return level > 0 && level >= commitmentLevels[string(commitment)]
+}
+
 // GetRecentBlockhash gets the recent blockhash from the Solana blockchain.
 func (s *Solana) GetRecentBlockhash(ctx context.Context) (solana.Hash, error) {
-	recentBlockHash, err := s.client.GetRecentBlockhash(ctx, rpc.CommitmentFinalized)
+	recentBlockHash, err := s.client.GetRecentBlockhash(ctx, s.currentSettings().Commitment)
 	if err != nil {
 		return solana.Hash{}, fmt.Errorf("error getting recent blockhash: %w", err)
 	}
@@ -139,13 +245,61 @@ func (s *Solana) GetRecentBlockhash(ctx context.Context) (solana.Hash, error) {
 	return recentBlockHash.Value.Blockhash, nil
 }
 
+// GetTransferFee gets the fee the Solana blockchain charges for a transfer from
+// one public key to another.
+func (s *Solana) GetTransferFee(ctx context.Context, from, to string) (_ uint64, err error) {
+	ctx, span := tracer.Start(ctx, "Solana.GetTransferFee")
+	defer func() { tracing.EndSpan(span, err) }()
+
+	fromPublicKey, err := solana.PublicKeyFromBase58(from)
+	if err != nil {
+		return 0, fmt.Errorf("error converting string to solana.PublicKey: %w: %w",
+			aggregates.ErrInvalidPublicKey, err)
+	}
+
+	toPublicKey, err := solana.PublicKeyFromBase58(to)
+	if err != nil {
+		return 0, fmt.Errorf("error converting string to solana.PublicKey: %w: %w",
+			aggregates.ErrInvalidPublicKey, err)
+	}
+
+	recentBlockhash, err := s.GetRecentBlockhash(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("error getting recent blockhash: %w", err)
+	}
+
+	// The amount doesn't change the fee, the fee only depends on the
+	// signatures and the instructions of the message.
+	tx, err := solana.NewTransaction(
+		[]solana.Instruction{
+			system.NewTransferInstruction(0, fromPublicKey, toPublicKey).Build(),
+		},
+		recentBlockhash,
+		solana.TransactionPayer(fromPublicKey),
+	)
+	if err != nil {
+		return 0, fmt.Errorf("error creating transaction: %w", err)
+	}
+
+	fee, err := s.client.GetFeeForMessage(ctx, tx.Message.ToBase64(), s.currentSettings().Commitment)
+	if err != nil {
+		return 0, fmt.Errorf("error getting fee for message: %w", err)
+	}
+
+	if fee.Value == nil {
+		return 0, fmt.Errorf("error getting fee for message result: %w", aggregates.ErrNoFeeValue)
+	}
+
+	return *fee.Value, nil
+}
+
 func (s *Solana) GetBalance(ctx context.Context, publicKey string) (uint64, error) {
 	publicKeySol, err := solana.PublicKeyFromBase58(publicKey)
 	if err != nil {
-		return 0, fmt.Errorf("error decoding public key: %w", err)
+		return 0, fmt.Errorf("error decoding public key: %w: %w", aggregates.ErrInvalidPublicKey, err)
 	}
 
-	balance, err := s.client.GetBalance(ctx, publicKeySol, rpc.CommitmentFinalized)
+	balance, err := s.client.GetBalance(ctx, publicKeySol, s.currentSettings().Commitment)
 	if err != nil {
 		return 0, fmt.Errorf("error getting balance: %w", err)
 	}
@@ -153,18 +307,45 @@ func (s *Solana) GetBalance(ctx context.Context, publicKey string) (uint64, erro
 	return balance.Value, nil
 }
 
-// GetTransactions gets the transactions for a given public key.
-func (s *Solana) GetTransactions(ctx context.Context, publicKey string) ([]aggregates.Transaction, error) {
+// GetTransactions gets a page of the transactions for a given public key,
+// newest first.
+//
+// Every signature costs a GetTransaction call, the span records how many of
+// them the page had.
+func (s *Solana) GetTransactions(ctx context.Context,
+	publicKey string, page aggregates.TransactionsPage) (_ []aggregates.Transaction, err error) {
+	ctx, span := tracer.Start(ctx, "Solana.GetTransactions", trace.WithAttributes(
+		attribute.String("wallet.public_key", publicKey),
+		attribute.Int("solana.page.limit", page.Limit),
+	))
+	defer func() { tracing.EndSpan(span, err) }()
+
 	publicKeySol, err := solana.PublicKeyFromBase58(publicKey)
 	if err != nil {
-		return nil, fmt.Errorf("error decoding public key: %w", err)
+		return nil, fmt.Errorf("error decoding public key: %w: %w", aggregates.ErrInvalidPublicKey, err)
 	}
 
-	signatures, err := s.client.GetSignaturesForAddress(ctx, publicKeySol)
+	opts := &rpc.GetSignaturesForAddressOpts{}
+
+	if page.Limit > 0 {
+		opts.Limit = &page.Limit
+	}
+
+	if page.Before != "" {
+		opts.Before, err = solana.SignatureFromBase58(page.Before)
+		if err != nil {
+			return nil, fmt.Errorf("error decoding before signature: %w: %w",
+				aggregates.ErrInvalidSignature, err)
+		}
+	}
+
+	signatures, err := s.client.GetSignaturesForAddressWithOpts(ctx, publicKeySol, opts)
 	if err != nil {
 		return nil, fmt.Errorf("error getting transactions: %w", err)
 	}
 
+	span.SetAttributes(attribute.Int("solana.signatures.count", len(signatures)))
+
 	transactions := make([]aggregates.Transaction, len(signatures))
 
 	for i := range signatures {
@@ -197,7 +378,7 @@ func (s *Solana) GetTransactions(ctx context.Context, publicKey string) ([]aggre
 			BlockTime:    convertToTime(tx.BlockTime),
 			Signature:    signatures[i].Signature.String(),
 			CounterParty: counterParty,
-			AmountLAM:    amount,
+			AmountLAM:    aggregates.Lamports(amount),
 		}
 	}
 
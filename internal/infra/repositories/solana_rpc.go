@@ -0,0 +1,456 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/gagliardetto/solana-go/rpc/jsonrpc"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/jcleira/coding-challenge/internal/domain/aggregates"
+	"github.com/jcleira/coding-challenge/internal/infra/metrics"
+	"github.com/jcleira/coding-challenge/internal/infra/tracing"
+)
+
+const (
+	// batchRPCMethod is the method label of the batched RPC calls.
+	batchRPCMethod = "batch"
+
+	// sendTransactionRPCMethod is the RPC method broadcasting a transaction,
+	// it's only called on the broadcast endpoints.
+	sendTransactionRPCMethod = "sendTransaction"
+
+	// rpcEndpointTimeout is the timeout of a call to a single endpoint, so a
+	// hung endpoint is failed over instead of holding the call.
+	rpcEndpointTimeout = 10 * time.Second
+
+	// rpcBreakerThreshold is the number of consecutive failures after which
+	// an endpoint is skipped, for rpcBreakerCooldown.
+	rpcBreakerThreshold = 3
+	rpcBreakerCooldown  = 30 * time.Second
+
+	// rpcThrottleBaseTime and rpcThrottleMaxTime bound the time an endpoint
+	// is ranked last after throttling the service, doubling on every
+	// consecutive throttle.
+	rpcThrottleBaseTime = time.Second
+	rpcThrottleMaxTime  = 30 * time.Second
+
+	// rpcScoreSmoothing is the weight of the last call in the moving averages
+	// of the latency and the error rate of an endpoint.
+	rpcScoreSmoothing = 0.2
+
+	// rpcErrorRatePenalty is how much the error rate of an endpoint inflates
+	// its latency in its score, an endpoint failing half of the calls ranks
+	// as six times slower.
+	rpcErrorRatePenalty = 10
+
+	// rpcNodeUnhealthyCode is the JSON-RPC error code of a node that fell
+	// behind the cluster.
+	rpcNodeUnhealthyCode = -32005
+)
+
+// rpcFailure is the reason a call to an endpoint is failed over.
+type rpcFailure string
+
+const (
+	// rpcFailureThrottled is an endpoint rate limiting the service.
+	rpcFailureThrottled rpcFailure = "throttled"
+
+	// rpcFailureDown is an endpoint not reachable, erroring or unhealthy.
+	rpcFailureDown rpcFailure = "down"
+)
+
+// RPCEndpoint is a Solana RPC endpoint.
+type RPCEndpoint struct {
+	// URL is the URL of the endpoint, it might carry an API key so only its
+	// host is logged and labeled.
+	URL string
+
+	// Broadcast is whether the endpoint broadcasts the transactions sent to
+	// it, some providers only serve reads or don't forward the transactions
+	// to the leaders.
+	Broadcast bool
+}
+
+// rpcEndpoint is an endpoint of a failoverRPCClient along with its health.
+type rpcEndpoint struct {
+	url       string
+	host      string
+	broadcast bool
+	client    rpc.JSONRPCClient
+	breaker   *CircuitBreaker
+
+	mutex          sync.Mutex
+	latency        time.Duration
+	errorRate      float64
+	observedAt     time.Time
+	throttles      int
+	throttledUntil time.Time
+}
+
+// health returns whether the endpoint is throttling the service and its
+// score, the lower the better.
+func (e *rpcEndpoint) health(now time.Time, halfLife time.Duration) (bool, float64) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	latency, errorRate, _ := e.decayed(now, halfLife)
+
+	// The millisecond keeps the error rate weighting the endpoints without a
+	// measured latency yet.
+	score := float64(latency+time.Millisecond) * (1 + rpcErrorRatePenalty*errorRate)
+
+	return now.Before(e.throttledUntil), score
+}
+
+// observe records the outcome of a call to the endpoint, the latency is only
+// averaged for the calls it served.
+func (e *rpcEndpoint) observe(now time.Time, latency time.Duration, failed bool, halfLife time.Duration) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	decayedLatency, decayedErrorRate, weight := e.decayed(now, halfLife)
+	e.observedAt = now
+
+	// The last call weighs more the older the previous ones are, a new
+	// endpoint, or one that wasn't called for long, is scored on its last
+	// call alone.
+	smoothing := 1 - (1-rpcScoreSmoothing)*weight
+
+	failure := 0.0
+	if failed {
+		failure = 1
+	}
+	e.errorRate = decayedErrorRate + smoothing*(failure-decayedErrorRate)
+
+	if failed {
+		e.latency = decayedLatency
+		return
+	}
+
+	e.throttles = 0
+
+	if decayedLatency == 0 {
+		e.latency = latency
+		return
+	}
+	e.latency = decayedLatency + time.Duration(smoothing*float64(latency-decayedLatency))
+}
+
+// decayed returns the latency and the error rate of the endpoint decayed
+// toward the ones of a new endpoint, halving every halfLife since its last
+// call, along with the weight left to its observed ones. An endpoint ranked
+// last is no longer called, so its score only recovers this way.
+func (e *rpcEndpoint) decayed(now time.Time, halfLife time.Duration) (time.Duration, float64, float64) {
+	if e.observedAt.IsZero() {
+		return 0, 0, 0
+	}
+
+	weight := 1.0
+	if halfLife > 0 {
+		elapsed := max(now.Sub(e.observedAt), 0)
+		weight = math.Exp2(-elapsed.Seconds() / halfLife.Seconds())
+	}
+
+	return time.Duration(weight * float64(e.latency)), weight * e.errorRate, weight
+}
+
+// throttle ranks the endpoint last for a backoff growing with its
+// consecutive throttles.
+func (e *rpcEndpoint) throttle() {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	e.throttles++
+
+	backoff := Backoff{Base: rpcThrottleBaseTime, Max: rpcThrottleMaxTime}
+	e.throttledUntil = time.Now().Add(backoff.Delay(e.throttles))
+}
+
+// redact replaces the URL of the endpoint with its host in the error
+// message, as the HTTP errors carry the URL along with its API key.
+func (e *rpcEndpoint) redact(err error) string {
+	return strings.ReplaceAll(err.Error(), e.url, e.host)
+}
+
+// failoverRPCClient is a JSON-RPC client spreading the calls over several
+// Solana RPC endpoints.
+//
+// Every call goes to the endpoint with the best score, its average latency
+// inflated by its error rate, and fails over to the next one when the
+// endpoint throttles the service, is unreachable or unhealthy. The errors of
+// the calls themselves, such as a transaction failing its simulation, are
+// returned as they are. Throttled endpoints are ranked last for a while, and
+// every endpoint is guarded by a circuit breaker. The scores decay toward the
+// one of a new endpoint, so the endpoints ranked last are tried again.
+//
+// The transactions are only sent to the broadcast endpoints. Sending a
+// transaction again to the next endpoint is safe, its signature is the same
+// so the cluster processes it at most once.
+type failoverRPCClient struct {
+	endpoints     []*rpcEndpoint
+	scoreHalfLife func() time.Duration
+	logger        *slog.Logger
+}
+
+// newFailoverRPCClient creates a new Solana RPC client calling the endpoints
+// through a failoverRPCClient, their scores decay with the half-life returned
+// by scoreHalfLife.
+func newFailoverRPCClient(endpoints []RPCEndpoint,
+	scoreHalfLife func() time.Duration, logger *slog.Logger) *rpc.Client {
+	client := &failoverRPCClient{scoreHalfLife: scoreHalfLife, logger: logger}
+
+	for _, endpoint := range endpoints {
+		host := endpointHost(endpoint.URL)
+
+		client.endpoints = append(client.endpoints, &rpcEndpoint{
+			url:       endpoint.URL,
+			host:      host,
+			broadcast: endpoint.Broadcast,
+			client: &instrumentedRPCClient{
+				host: host,
+				client: jsonrpc.NewClientWithOpts(endpoint.URL, &jsonrpc.RPCClientOpts{
+					HTTPClient: &http.Client{Timeout: rpcEndpointTimeout},
+				}),
+			},
+			breaker: NewCircuitBreaker(rpcBreakerThreshold, rpcBreakerCooldown),
+		})
+	}
+
+	return rpc.NewWithCustomRPCClient(client)
+}
+
+// CallForInto implements rpc.JSONRPCClient.
+func (c *failoverRPCClient) CallForInto(ctx context.Context,
+	out interface{}, method string, params []interface{}) error {
+	return c.call(ctx, method, func(client rpc.JSONRPCClient) error {
+		return client.CallForInto(ctx, out, method, params)
+	})
+}
+
+// CallWithCallback implements rpc.JSONRPCClient.
+func (c *failoverRPCClient) CallWithCallback(ctx context.Context, method string,
+	params []interface{}, callback func(*http.Request, *http.Response) error) error {
+	return c.call(ctx, method, func(client rpc.JSONRPCClient) error {
+		return client.CallWithCallback(ctx, method, params, callback)
+	})
+}
+
+// CallBatch implements rpc.JSONRPCClient.
+func (c *failoverRPCClient) CallBatch(ctx context.Context,
+	requests jsonrpc.RPCRequests) (jsonrpc.RPCResponses, error) {
+	var responses jsonrpc.RPCResponses
+
+	err := c.call(ctx, batchRPCMethod, func(client rpc.JSONRPCClient) error {
+		var err error
+		responses, err = client.CallBatch(ctx, requests)
+		return err
+	})
+
+	return responses, err
+}
+
+// call calls the method on the endpoints in their ranking order until one of
+// them serves it, returning aggregates.ErrSolanaUnavailable when none did.
+func (c *failoverRPCClient) call(ctx context.Context,
+	method string, call func(rpc.JSONRPCClient) error) error {
+	var errs []error
+
+	halfLife := c.scoreHalfLife()
+
+	for _, endpoint := range c.ranked(method, halfLife) {
+		if !endpoint.breaker.Allow() {
+			errs = append(errs, fmt.Errorf("endpoint %s: %w", endpoint.host, errCircuitOpen))
+			continue
+		}
+
+		start := time.Now()
+		err := call(endpoint.client)
+		latency := time.Since(start)
+
+		// The caller gave up, the endpoint might be fine.
+		if err != nil && ctx.Err() != nil {
+			endpoint.breaker.Abandon()
+			return err
+		}
+
+		failure, ok := classifyRPCError(err)
+		if !ok {
+			endpoint.breaker.Success()
+			endpoint.observe(time.Now(), latency, false, halfLife)
+			return err
+		}
+
+		switch failure {
+		case rpcFailureThrottled:
+			// The endpoint answered, it's only busy.
+			endpoint.breaker.Success()
+			endpoint.throttle()
+		case rpcFailureDown:
+			endpoint.breaker.Failure()
+		}
+		endpoint.observe(time.Now(), latency, true, halfLife)
+
+		metrics.RecordRPCEndpointFailure(endpoint.host, string(failure))
+		c.logger.WarnContext(ctx, "Solana RPC endpoint failed, failing over",
+			"endpoint", endpoint.host, "method", method, "reason", failure,
+			"error", endpoint.redact(err))
+
+		errs = append(errs, fmt.Errorf("endpoint %s %s: %s",
+			endpoint.host, failure, endpoint.redact(err)))
+	}
+
+	return fmt.Errorf("%w: %w", aggregates.ErrSolanaUnavailable, errors.Join(errs...))
+}
+
+// ranked returns the endpoints that can serve the method, the healthy ones
+// first by their score and then the throttled ones, the ties keep the
+// configured order.
+func (c *failoverRPCClient) ranked(method string, halfLife time.Duration) []*rpcEndpoint {
+	type rankedEndpoint struct {
+		endpoint  *rpcEndpoint
+		throttled bool
+		score     float64
+	}
+
+	now := time.Now()
+
+	candidates := make([]rankedEndpoint, 0, len(c.endpoints))
+	for _, endpoint := range c.endpoints {
+		if method == sendTransactionRPCMethod && !endpoint.broadcast {
+			continue
+		}
+
+		throttled, score := endpoint.health(now, halfLife)
+		candidates = append(candidates, rankedEndpoint{endpoint, throttled, score})
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].throttled != candidates[j].throttled {
+			return !candidates[i].throttled
+		}
+
+		return candidates[i].score < candidates[j].score
+	})
+
+	ranked := make([]*rpcEndpoint, len(candidates))
+	for i, candidate := range candidates {
+		ranked[i] = candidate.endpoint
+	}
+
+	return ranked
+}
+
+// classifyRPCError returns why a call failing with err must be failed over,
+// or false when it succeeded or failed on its own, such as a transaction
+// failing its simulation, which the next endpoint would fail too.
+func classifyRPCError(err error) (rpcFailure, bool) {
+	if err == nil {
+		return "", false
+	}
+
+	var rpcErr *jsonrpc.RPCError
+	if errors.As(err, &rpcErr) {
+		switch rpcErr.Code {
+		case http.StatusTooManyRequests:
+			return rpcFailureThrottled, true
+		case rpcNodeUnhealthyCode:
+			return rpcFailureDown, true
+		default:
+			return "", false
+		}
+	}
+
+	var httpErr *jsonrpc.HTTPError
+	if errors.As(err, &httpErr) && httpErr.Code == http.StatusTooManyRequests {
+		return rpcFailureThrottled, true
+	}
+
+	// HTTP errors without a JSON-RPC error, timeouts, unreachable hosts or
+	// unparseable responses.
+	return rpcFailureDown, true
+}
+
+// endpointHost returns the host of the endpoint URL, the part of it that is
+// safe to log.
+func endpointHost(endpointURL string) string {
+	parsed, err := url.Parse(endpointURL)
+	if err != nil || parsed.Host == "" {
+		return "invalid"
+	}
+
+	return parsed.Host
+}
+
+// instrumentedRPCClient is a JSON-RPC client recording the count, errors and
+// latency of the calls per method, along with a client span for each call.
+type instrumentedRPCClient struct {
+	host   string
+	client rpc.JSONRPCClient
+}
+
+// CallForInto implements rpc.JSONRPCClient.
+func (c *instrumentedRPCClient) CallForInto(ctx context.Context,
+	out interface{}, method string, params []interface{}) error {
+	ctx, span := startRPCSpan(ctx, method, c.host)
+	start := time.Now()
+
+	err := c.client.CallForInto(ctx, out, method, params)
+	metrics.ObserveRPCCall(method, time.Since(start), err)
+	tracing.EndSpan(span, err)
+
+	return err
+}
+
+// CallWithCallback implements rpc.JSONRPCClient.
+func (c *instrumentedRPCClient) CallWithCallback(ctx context.Context, method string,
+	params []interface{}, callback func(*http.Request, *http.Response) error) error {
+	ctx, span := startRPCSpan(ctx, method, c.host)
+	start := time.Now()
+
+	err := c.client.CallWithCallback(ctx, method, params, callback)
+	metrics.ObserveRPCCall(method, time.Since(start), err)
+	tracing.EndSpan(span, err)
+
+	return err
+}
+
+// CallBatch implements rpc.JSONRPCClient.
+func (c *instrumentedRPCClient) CallBatch(ctx context.Context,
+	requests jsonrpc.RPCRequests) (jsonrpc.RPCResponses, error) {
+	ctx, span := startRPCSpan(ctx, batchRPCMethod, c.host)
+	span.SetAttributes(attribute.Int("rpc.batch.size", len(requests)))
+	start := time.Now()
+
+	responses, err := c.client.CallBatch(ctx, requests)
+	metrics.ObserveRPCCall(batchRPCMethod, time.Since(start), err)
+	tracing.EndSpan(span, err)
+
+	return responses, err
+}
+
+// startRPCSpan starts the client span of a call to the RPC method on the
+// endpoint host.
+func startRPCSpan(ctx context.Context, method, host string) (context.Context, trace.Span) {
+	return tracer.Start(ctx, method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.RPCSystemKey.String("jsonrpc"),
+			semconv.RPCService("solana"),
+			semconv.RPCMethod(method),
+			semconv.ServerAddress(host),
+		),
+	)
+}
@@ -1,3 +1,364 @@
 package repositories_test
 
-// TODO: add tests
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jcleira/coding-challenge/internal/domain/aggregates"
+	"github.com/jcleira/coding-challenge/internal/infra/repositories"
+)
+
+// rpcResponse is the response of a fake RPC endpoint to a method, either its
+// JSON-RPC result or error member, or an HTTP error status.
+type rpcResponse struct {
+	status int
+	member string
+}
+
+var (
+	rpcServed    = rpcResponse{status: http.StatusOK}
+	rpcThrottled = rpcResponse{status: http.StatusTooManyRequests}
+	rpcDown      = rpcResponse{status: http.StatusBadGateway}
+	rpcUnhealthy = rpcResponse{status: http.StatusOK,
+		member: `"error":{"code":-32005,"message":"Node is behind by 42 slots"}`}
+	rpcInvalidParams = rpcResponse{status: http.StatusOK,
+		member: `"error":{"code":-32602,"message":"Invalid params"}`}
+)
+
+// rpcResults are the results the fake RPC endpoints serve per method.
+var rpcResults = map[string]string{
+	"getBalance": `{"context":{"slot":1},"value":42}`,
+	"getRecentBlockhash": fmt.Sprintf(
+		`{"context":{"slot":1},"value":{"blockhash":%q,"feeCalculator":{"lamportsPerSignature":5000}}}`,
+		solana.Hash{}.String()),
+	"sendTransaction": fmt.Sprintf("%q", solana.Signature{}.String()),
+	"getSignatureStatuses": `{"context":{"slot":1},"value":[` +
+		`{"slot":1,"confirmations":null,"err":null,"confirmationStatus":"confirmed"}]}`,
+}
+
+// rpcServer is a local stand-in of a Solana RPC endpoint, recording the
+// methods called on it.
+type rpcServer struct {
+	*httptest.Server
+
+	mu      sync.Mutex
+	methods []string
+}
+
+// newRPCServer starts an RPC server answering the methods with respond.
+func newRPCServer(t *testing.T, respond func(method string) rpcResponse) *rpcServer {
+	t.Helper()
+
+	server := &rpcServer{}
+	server.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request struct {
+			ID     int    `json:"id"`
+			Method string `json:"method"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		server.mu.Lock()
+		server.methods = append(server.methods, request.Method)
+		server.mu.Unlock()
+
+		response := respond(request.Method)
+		if response.status != http.StatusOK {
+			http.Error(w, http.StatusText(response.status), response.status)
+			return
+		}
+
+		member := response.member
+		if member == "" {
+			member = `"result":` + rpcResults[request.Method]
+		}
+
+		fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,%s}`, request.ID, member)
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+// calls returns how many times the method was called.
+func (s *rpcServer) calls(method string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	calls := 0
+	for _, called := range s.methods {
+		if called == method {
+			calls++
+		}
+	}
+
+	return calls
+}
+
+// respondWith responds every method with the response.
+func respondWith(response rpcResponse) func(string) rpcResponse {
+	return func(string) rpcResponse { return response }
+}
+
+var solanaSettings = repositories.SolanaSettings{
+	Commitment:           rpc.CommitmentConfirmed,
+	ConfirmationTimeout:  time.Second,
+	ConfirmationInterval: 10 * time.Millisecond,
+	RPCScoreHalfLife:     time.Minute,
+}
+
+func TestSolana_GetBalanceFailover(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name            string
+		first           rpcResponse
+		second          rpcResponse
+		want            uint64
+		wantErr         error
+		wantFirstCalls  int
+		wantSecondCalls int
+	}{
+		{
+			name:           "first endpoint serves",
+			first:          rpcServed,
+			second:         rpcServed,
+			want:           42,
+			wantFirstCalls: 1,
+		},
+		{
+			name:            "throttled endpoint fails over",
+			first:           rpcThrottled,
+			second:          rpcServed,
+			want:            42,
+			wantFirstCalls:  1,
+			wantSecondCalls: 1,
+		},
+		{
+			name:            "down endpoint fails over",
+			first:           rpcDown,
+			second:          rpcServed,
+			want:            42,
+			wantFirstCalls:  1,
+			wantSecondCalls: 1,
+		},
+		{
+			name:            "unhealthy node fails over",
+			first:           rpcUnhealthy,
+			second:          rpcServed,
+			want:            42,
+			wantFirstCalls:  1,
+			wantSecondCalls: 1,
+		},
+		{
+			name:           "call error doesn't fail over",
+			first:          rpcInvalidParams,
+			second:         rpcServed,
+			wantErr:        assert.AnError,
+			wantFirstCalls: 1,
+		},
+		{
+			name:            "every endpoint unavailable",
+			first:           rpcThrottled,
+			second:          rpcDown,
+			wantErr:         aggregates.ErrSolanaUnavailable,
+			wantFirstCalls:  1,
+			wantSecondCalls: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			first := newRPCServer(t, respondWith(tt.first))
+			second := newRPCServer(t, respondWith(tt.second))
+
+			client := repositories.NewSolana([]repositories.RPCEndpoint{
+				{URL: first.URL, Broadcast: true},
+				{URL: second.URL, Broadcast: true},
+			}, solanaSettings, slog.Default())
+
+			balance, err := client.GetBalance(context.Background(), solana.SystemProgramID.String())
+
+			switch {
+			case tt.wantErr == assert.AnError:
+				require.Error(t, err)
+				assert.NotErrorIs(t, err, aggregates.ErrSolanaUnavailable)
+			case tt.wantErr != nil:
+				assert.ErrorIs(t, err, tt.wantErr)
+			default:
+				require.NoError(t, err)
+				assert.Equal(t, tt.want, balance)
+			}
+
+			assert.Equal(t, tt.wantFirstCalls, first.calls("getBalance"))
+			assert.Equal(t, tt.wantSecondCalls, second.calls("getBalance"))
+		})
+	}
+}
+
+func TestSolana_ThrottledEndpointRankedLast(t *testing.T) {
+	t.Parallel()
+
+	throttled := newRPCServer(t, respondWith(rpcThrottled))
+	served := newRPCServer(t, respondWith(rpcServed))
+
+	client := repositories.NewSolana([]repositories.RPCEndpoint{
+		{URL: throttled.URL, Broadcast: true},
+		{URL: served.URL, Broadcast: true},
+	}, solanaSettings, slog.Default())
+
+	for i := 0; i < 3; i++ {
+		_, err := client.GetBalance(context.Background(), solana.SystemProgramID.String())
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, 1, throttled.calls("getBalance"))
+	assert.Equal(t, 3, served.calls("getBalance"))
+}
+
+func TestSolana_FailedEndpointRecovers(t *testing.T) {
+	t.Parallel()
+
+	var down atomic.Bool
+	down.Store(true)
+
+	recovering := newRPCServer(t, func(string) rpcResponse {
+		if down.Load() {
+			return rpcDown
+		}
+		return rpcServed
+	})
+	slow := newRPCServer(t, func(string) rpcResponse {
+		time.Sleep(5 * time.Millisecond)
+		return rpcServed
+	})
+
+	settings := solanaSettings
+	settings.RPCScoreHalfLife = 100 * time.Millisecond
+
+	client := repositories.NewSolana([]repositories.RPCEndpoint{
+		{URL: recovering.URL, Broadcast: true},
+		{URL: slow.URL, Broadcast: true},
+	}, settings, slog.Default())
+
+	// The failed endpoint is ranked after the slow one.
+	for i := 0; i < 2; i++ {
+		_, err := client.GetBalance(context.Background(), solana.SystemProgramID.String())
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, 1, recovering.calls("getBalance"))
+	assert.Equal(t, 2, slow.calls("getBalance"))
+
+	// Its score decays until it's tried again, and it ranks first once it
+	// serves the calls.
+	down.Store(false)
+
+	assert.Eventually(t, func() bool {
+		_, err := client.GetBalance(context.Background(), solana.SystemProgramID.String())
+		require.NoError(t, err)
+
+		return recovering.calls("getBalance") > 1
+	}, 5*time.Second, 10*time.Millisecond)
+
+	assert.Eventually(t, func() bool {
+		slowCalls := slow.calls("getBalance")
+
+		_, err := client.GetBalance(context.Background(), solana.SystemProgramID.String())
+		require.NoError(t, err)
+
+		return slow.calls("getBalance") == slowCalls
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestSolana_SendTransactionBroadcastEndpoints(t *testing.T) {
+	t.Parallel()
+
+	reads := newRPCServer(t, respondWith(rpcServed))
+	throttled := newRPCServer(t, func(method string) rpcResponse {
+		if method == "sendTransaction" {
+			return rpcThrottled
+		}
+
+		return rpcServed
+	})
+	broadcast := newRPCServer(t, respondWith(rpcServed))
+
+	client := repositories.NewSolana([]repositories.RPCEndpoint{
+		{URL: reads.URL},
+		{URL: throttled.URL, Broadcast: true},
+		{URL: broadcast.URL, Broadcast: true},
+	}, solanaSettings, slog.Default())
+
+	privateKey, err := solana.NewRandomPrivateKey()
+	require.NoError(t, err)
+
+	signature, err := client.SendTransaction(context.Background(),
+		aggregates.Transaction{
+			CounterParty: solana.SystemProgramID.String(),
+			AmountLAM:    1000,
+		},
+		aggregates.Wallet{
+			PrivateKey: privateKey,
+			PublicKey:  privateKey.PublicKey().String(),
+		})
+	require.NoError(t, err)
+	assert.Equal(t, solana.Signature{}.String(), signature)
+
+	assert.Equal(t, 1, reads.calls("getRecentBlockhash"))
+	assert.Equal(t, 0, reads.calls("sendTransaction"))
+	assert.Equal(t, 1, throttled.calls("sendTransaction"))
+	assert.Equal(t, 1, broadcast.calls("sendTransaction"))
+}
+
+func TestSolana_SendTransactionConfirmationTimeout(t *testing.T) {
+	t.Parallel()
+
+	server := newRPCServer(t, func(method string) rpcResponse {
+		if method == "getSignatureStatuses" {
+			return rpcResponse{status: http.StatusOK,
+				member: `"result":{"context":{"slot":1},"value":[null]}`}
+		}
+
+		return rpcServed
+	})
+
+	settings := solanaSettings
+	settings.ConfirmationTimeout = 50 * time.Millisecond
+
+	client := repositories.NewSolana([]repositories.RPCEndpoint{{URL: server.URL, Broadcast: true}},
+		settings, slog.Default())
+
+	privateKey, err := solana.NewRandomPrivateKey()
+	require.NoError(t, err)
+
+	signature, err := client.SendTransaction(context.Background(),
+		aggregates.Transaction{
+			CounterParty: solana.SystemProgramID.String(),
+			AmountLAM:    1000,
+		},
+		aggregates.Wallet{
+			PrivateKey: privateKey,
+			PublicKey:  privateKey.PublicKey().String(),
+		})
+	assert.ErrorIs(t, err, aggregates.ErrTransactionConfirmationTimeout)
+	assert.Equal(t, solana.Signature{}.String(), signature)
+}
@@ -0,0 +1,7 @@
+package repositories
+
+import "go.opentelemetry.io/otel"
+
+// tracer is the tracer of the repositories spans, they are children of the
+// span carried by the context of each call.
+var tracer = otel.Tracer("github.com/jcleira/coding-challenge/internal/infra/repositories")
@@ -1,13 +1,23 @@
 package repositories
 
 import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"sync"
 
 	"github.com/gagliardetto/solana-go"
 
 	"github.com/jcleira/coding-challenge/internal/domain/aggregates"
+	"github.com/jcleira/coding-challenge/internal/infra/metrics"
 )
 
 // Vault is an abstraction for storing and retrieving wallets.
@@ -15,21 +25,129 @@ import (
 // At the moment, it does has a pretty simple implementation, as it does store
 // the private key in a file, but it could be extended to use a provider, such as
 // AWS Secrets Manager, Hashicorp Vault, or Evervault.
+//
+// Wallets are stored under the namespace of the tenant carried by the context
+// of each call, so a tenant can't reach the wallets of another one. Every
+// tenant has its own data key, which encrypts its private keys and is itself
+// encrypted with the vault master key.
 type Vault struct {
 	Path string
+
+	// masterKey encrypts the data keys of the tenants.
+	masterKey cipher.AEAD
+
+	// tenants caches the tenant namespaces loaded so far, by tenant name.
+	tenants map[string]*tenantVault
+
+	tenantsMutex sync.Mutex
+
+	logger *slog.Logger
+}
+
+// tenantVault is the namespace of a tenant in the vault.
+type tenantVault struct {
+	tenant string
+	path   string
+
+	// dataKey encrypts the private keys of the tenant wallets.
+	dataKey cipher.AEAD
+
+	// rotations maps the public key of retired wallets to the public key of
+	// the wallet that replaced them, it's persisted in the rotationsFile.
+	rotations map[string]string
+
+	rotationsMutex sync.RWMutex
 }
 
-// NewVault creates a new Vault instance.
-func NewVault(path string) (*Vault, error) {
+const (
+	// rotationsFile is the file, within the tenant path, where the rotations
+	// mapping is stored. Public keys are base58 encoded, so they can't clash
+	// with this name.
+	rotationsFile = "rotations.json"
+
+	// dataKeyFile is the file, within the tenant path, where the encrypted
+	// data key of the tenant is stored.
+	dataKeyFile = "key"
+
+	// masterKeyFile is the file, within the vault path, where the master key
+	// is generated when none is given.
+	masterKeyFile = "master.key"
+
+	// masterKeySize is the size of the master and data keys, for AES-256.
+	masterKeySize = 32
+
+	// maxRotations is the maximum number of rotations followed when resolving
+	// a public key, it protects us from cycles on a corrupted rotations file.
+	maxRotations = 64
+)
+
+// NewVault creates a new Vault instance encrypting the tenant data keys with
+// masterKey, 32 bytes long.
+//
+// When masterKey is nil, a master key is generated and stored along with the
+// wallets, which is only meant for local development. The wallets stored
+// before tenants existed are moved to the default tenant.
+func NewVault(path string, masterKey []byte, logger *slog.Logger) (*Vault, error) {
 	err := os.MkdirAll(path, 0755)
 	if err != nil {
 		return nil, fmt.Errorf("error creating vault directory: %w", err)
 	}
-	return &Vault{Path: path}, nil
+
+	if masterKey == nil {
+		masterKey, err = loadOrCreateMasterKey(filepath.Join(path, masterKeyFile))
+		if err != nil {
+			return nil, fmt.Errorf("error loading master key: %w", err)
+		}
+
+		logger.Warn("vault master key stored along with the wallets, set one for production",
+			"path", filepath.Join(path, masterKeyFile))
+	}
+
+	aead, err := newAEAD(masterKey)
+	if err != nil {
+		return nil, fmt.Errorf("error initializing master key: %w", err)
+	}
+
+	v := &Vault{
+		Path:      path,
+		masterKey: aead,
+		tenants:   make(map[string]*tenantVault),
+		logger:    logger,
+	}
+
+	if err := v.migrate(); err != nil {
+		return nil, fmt.Errorf("error migrating wallets to the default tenant: %w", err)
+	}
+
+	return v, nil
+}
+
+// CheckHealth checks the vault directory can be written, so new wallets can be
+// stored.
+func (v *Vault) CheckHealth(_ context.Context) error {
+	file, err := os.CreateTemp(v.Path, ".health-*")
+	if err != nil {
+		return fmt.Errorf("error writing to vault directory: %w", err)
+	}
+
+	file.Close()
+
+	if err := os.Remove(file.Name()); err != nil {
+		return fmt.Errorf("error removing vault health file: %w", err)
+	}
+
+	return nil
 }
 
 // CreateWallet creates a new wallet and stores it in the vault.
-func (v *Vault) CreateWallet() (aggregates.Wallet, error) {
+func (v *Vault) CreateWallet(ctx context.Context) (_ aggregates.Wallet, err error) {
+	defer func() { metrics.RecordVaultOperation("create", err) }()
+
+	tv, err := v.tenant(aggregates.TenantFromContext(ctx), true)
+	if err != nil {
+		return aggregates.Wallet{}, err
+	}
+
 	privateKey, err := solana.NewRandomPrivateKey()
 	if err != nil {
 		return aggregates.Wallet{}, fmt.Errorf("error generating new private key: %w", err)
@@ -42,35 +160,449 @@ func (v *Vault) CreateWallet() (aggregates.Wallet, error) {
 		PublicKey:  publicKey,
 	}
 
-	if err := v.store(wallet); err != nil {
+	if err := tv.store(wallet); err != nil {
 		return aggregates.Wallet{}, fmt.Errorf("error storing wallet: %w", err)
 	}
 
 	return wallet, nil
 }
 
-// Getaggregates.Wallet retrieves a wallet from the vault by its public key.
-func (v *Vault) GetWallet(publicKey string) (aggregates.Wallet, error) {
-	filename := filepath.Join(v.Path, publicKey)
+// GetWallet retrieves a wallet from the vault by its public key.
+//
+// If the wallet has been retired by a rotation, the wallet that replaced it is
+// returned instead, callers can tell by comparing the returned public key.
+func (v *Vault) GetWallet(ctx context.Context, publicKey string) (_ aggregates.Wallet, err error) {
+	defer func() { metrics.RecordVaultOperation("get", err) }()
 
-	privateKeyBytes, err := os.ReadFile(filename)
+	// Public keys are used as file names, so anything else must be rejected.
+	if _, err := solana.PublicKeyFromBase58(publicKey); err != nil {
+		return aggregates.Wallet{}, fmt.Errorf("%w: %w", aggregates.ErrInvalidPublicKey, err)
+	}
+
+	tv, err := v.tenant(aggregates.TenantFromContext(ctx), false)
+	if errors.Is(err, os.ErrNotExist) {
+		return aggregates.Wallet{}, fmt.Errorf("%w: %s", aggregates.ErrWalletNotFound, publicKey)
+	}
 	if err != nil {
-		return aggregates.Wallet{}, fmt.Errorf("error reading private key from file: %w", err)
+		return aggregates.Wallet{}, err
+	}
+
+	publicKey, err = tv.resolve(publicKey)
+	if err != nil {
+		return aggregates.Wallet{}, fmt.Errorf("error resolving public key: %w", err)
+	}
+
+	privateKey, err := tv.load(publicKey)
+	if errors.Is(err, os.ErrNotExist) {
+		return aggregates.Wallet{}, fmt.Errorf("%w: %s", aggregates.ErrWalletNotFound, publicKey)
+	}
+	if err != nil {
+		return aggregates.Wallet{}, err
 	}
 
 	return aggregates.Wallet{
-		PrivateKey: solana.PrivateKey(privateKeyBytes),
+		PrivateKey: privateKey,
 		PublicKey:  publicKey,
 	}, nil
 }
 
-// store stores a wallet in the vault by its public key.
-func (v *Vault) store(wallet aggregates.Wallet) error {
-	filename := filepath.Join(v.Path, wallet.PublicKey)
+// HasWallet reports whether the wallet with the public key, active or
+// retired, belongs to the tenant carried by ctx.
+func (v *Vault) HasWallet(ctx context.Context, publicKey string) (_ bool, err error) {
+	defer func() { metrics.RecordVaultOperation("has", err) }()
+
+	if _, err := solana.PublicKeyFromBase58(publicKey); err != nil {
+		return false, fmt.Errorf("%w: %w", aggregates.ErrInvalidPublicKey, err)
+	}
+
+	tv, err := v.tenant(aggregates.TenantFromContext(ctx), false)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	_, err = os.Stat(filepath.Join(tv.path, publicKey))
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("error checking wallet: %w", err)
+	}
+
+	return true, nil
+}
+
+// RetireWallet marks the wallet identified by oldPublicKey as retired, lookups
+// by its public key will be redirected to the wallet identified by
+// newPublicKey.
+//
+// The retired private key is kept in the vault, as it might still be needed
+// to recover funds sent to the old address after the rotation.
+func (v *Vault) RetireWallet(ctx context.Context, oldPublicKey, newPublicKey string) (err error) {
+	defer func() { metrics.RecordVaultOperation("retire", err) }()
+
+	tv, err := v.tenant(aggregates.TenantFromContext(ctx), false)
+	if err != nil {
+		return err
+	}
+
+	tv.rotationsMutex.Lock()
+	defer tv.rotationsMutex.Unlock()
+
+	if _, ok := tv.rotations[oldPublicKey]; ok {
+		return aggregates.ErrWalletRetired
+	}
+
+	if _, err := os.Stat(filepath.Join(tv.path, newPublicKey)); err != nil {
+		return fmt.Errorf("error checking new wallet: %w", err)
+	}
+
+	rotations := make(map[string]string, len(tv.rotations)+1)
+	for oldKey, newKey := range tv.rotations {
+		rotations[oldKey] = newKey
+	}
+	rotations[oldPublicKey] = newPublicKey
+
+	if err := storeRotations(filepath.Join(tv.path, rotationsFile), rotations); err != nil {
+		return fmt.Errorf("error storing rotations: %w", err)
+	}
+
+	tv.rotations = rotations
+
+	return nil
+}
+
+// tenant returns the namespace of the tenant, creating it along with its data
+// key when create is set, a missing namespace returns os.ErrNotExist
+// otherwise.
+func (v *Vault) tenant(tenant string, create bool) (*tenantVault, error) {
+	// Tenants are used as directory names, so anything else must be rejected.
+	if err := aggregates.ValidateTenant(tenant); err != nil {
+		return nil, err
+	}
+
+	v.tenantsMutex.Lock()
+	defer v.tenantsMutex.Unlock()
+
+	if tv, ok := v.tenants[tenant]; ok {
+		return tv, nil
+	}
+
+	path := filepath.Join(v.Path, tenant)
+	keyFilename := filepath.Join(path, dataKeyFile)
+
+	encryptedKey, err := os.ReadFile(keyFilename)
+	if errors.Is(err, os.ErrNotExist) && create {
+		encryptedKey, err = v.createDataKey(tenant, path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading data key of tenant %s: %w", tenant, err)
+	}
+
+	dataKey, err := open(v.masterKey, encryptedKey, []byte(tenant))
+	if err != nil {
+		return nil, fmt.Errorf("error decrypting data key of tenant %s: %w", tenant, err)
+	}
+
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return nil, fmt.Errorf("error initializing data key of tenant %s: %w", tenant, err)
+	}
+
+	rotations, err := loadRotations(filepath.Join(path, rotationsFile))
+	if err != nil {
+		return nil, fmt.Errorf("error loading rotations of tenant %s: %w", tenant, err)
+	}
+
+	tv := &tenantVault{
+		tenant:    tenant,
+		path:      path,
+		dataKey:   aead,
+		rotations: rotations,
+	}
+
+	v.tenants[tenant] = tv
+
+	return tv, nil
+}
+
+// createDataKey creates the namespace of the tenant with a new random data
+// key, returning the data key encrypted with the master key.
+func (v *Vault) createDataKey(tenant, path string) ([]byte, error) {
+	if err := os.MkdirAll(path, 0700); err != nil {
+		return nil, fmt.Errorf("error creating tenant directory: %w", err)
+	}
+
+	dataKey := make([]byte, masterKeySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return nil, fmt.Errorf("error generating data key: %w", err)
+	}
+
+	encryptedKey, err := seal(v.masterKey, dataKey, []byte(tenant))
+	if err != nil {
+		return nil, fmt.Errorf("error encrypting data key: %w", err)
+	}
+
+	if err := writeFileAtomically(filepath.Join(path, dataKeyFile), encryptedKey); err != nil {
+		return nil, err
+	}
+
+	return encryptedKey, nil
+}
+
+// migrate moves the plain text wallets and the rotations stored in the vault
+// root, before tenants existed, to the default tenant.
+func (v *Vault) migrate() error {
+	entries, err := os.ReadDir(v.Path)
+	if err != nil {
+		return fmt.Errorf("error reading vault directory: %w", err)
+	}
+
+	var tv *tenantVault
+
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		name := entry.Name()
+		if name != rotationsFile {
+			if _, err := solana.PublicKeyFromBase58(name); err != nil {
+				continue
+			}
+		}
 
-	if err := os.WriteFile(filename, wallet.PrivateKey, 0644); err != nil {
+		if tv == nil {
+			tv, err = v.tenant(aggregates.DefaultTenant, true)
+			if err != nil {
+				return err
+			}
+		}
+
+		filename := filepath.Join(v.Path, name)
+
+		if name == rotationsFile {
+			if err := v.migrateRotations(tv, filename); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		privateKey, err := os.ReadFile(filename)
+		if err != nil {
+			return fmt.Errorf("error reading private key from file: %w", err)
+		}
+
+		if err := tv.store(aggregates.Wallet{PrivateKey: privateKey, PublicKey: name}); err != nil {
+			return fmt.Errorf("error storing wallet %s: %w", name, err)
+		}
+
+		if err := os.Remove(filename); err != nil {
+			return fmt.Errorf("error removing plain text wallet: %w", err)
+		}
+
+		v.logger.Info("wallet moved to the default tenant", "wallet", name)
+	}
+
+	return nil
+}
+
+// migrateRotations merges the rotations in filename into the ones of the
+// tenant, removing the file afterwards.
+func (v *Vault) migrateRotations(tv *tenantVault, filename string) error {
+	legacy, err := loadRotations(filename)
+	if err != nil {
+		return fmt.Errorf("error loading rotations: %w", err)
+	}
+
+	tv.rotationsMutex.Lock()
+	defer tv.rotationsMutex.Unlock()
+
+	for oldKey, newKey := range tv.rotations {
+		legacy[oldKey] = newKey
+	}
+
+	if err := storeRotations(filepath.Join(tv.path, rotationsFile), legacy); err != nil {
+		return fmt.Errorf("error storing rotations: %w", err)
+	}
+
+	tv.rotations = legacy
+
+	if err := os.Remove(filename); err != nil {
+		return fmt.Errorf("error removing rotations file: %w", err)
+	}
+
+	return nil
+}
+
+// store stores a wallet in the tenant namespace by its public key, encrypted
+// with the tenant data key.
+func (tv *tenantVault) store(wallet aggregates.Wallet) error {
+	encrypted, err := seal(tv.dataKey, wallet.PrivateKey, tv.additionalData(wallet.PublicKey))
+	if err != nil {
+		return fmt.Errorf("error encrypting private key: %w", err)
+	}
+
+	if err := writeFileAtomically(filepath.Join(tv.path, wallet.PublicKey), encrypted); err != nil {
 		return fmt.Errorf("error writing private key to file: %w", err)
 	}
 
 	return nil
 }
+
+// load loads the private key of the wallet with the public key.
+func (tv *tenantVault) load(publicKey string) (solana.PrivateKey, error) {
+	encrypted, err := os.ReadFile(filepath.Join(tv.path, publicKey))
+	if err != nil {
+		return nil, fmt.Errorf("error reading private key from file: %w", err)
+	}
+
+	privateKey, err := open(tv.dataKey, encrypted, tv.additionalData(publicKey))
+	if err != nil {
+		return nil, fmt.Errorf("error decrypting private key: %w", err)
+	}
+
+	return solana.PrivateKey(privateKey), nil
+}
+
+// additionalData binds an encrypted private key to its tenant and its public
+// key, so a file copied to another tenant or wallet doesn't decrypt.
+func (tv *tenantVault) additionalData(publicKey string) []byte {
+	return []byte(tv.tenant + "/" + publicKey)
+}
+
+// resolve follows the rotations mapping from publicKey to the public key of
+// the active wallet.
+func (tv *tenantVault) resolve(publicKey string) (string, error) {
+	tv.rotationsMutex.RLock()
+	defer tv.rotationsMutex.RUnlock()
+
+	for i := 0; i < maxRotations; i++ {
+		newPublicKey, ok := tv.rotations[publicKey]
+		if !ok {
+			return publicKey, nil
+		}
+
+		publicKey = newPublicKey
+	}
+
+	return "", fmt.Errorf("too many rotations for public key: %s", publicKey)
+}
+
+// loadOrCreateMasterKey loads the master key in filename, generating it on
+// first use.
+func loadOrCreateMasterKey(filename string) ([]byte, error) {
+	key, err := os.ReadFile(filename)
+	if err == nil {
+		return key, nil
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("error reading master key: %w", err)
+	}
+
+	key = make([]byte, masterKeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("error generating master key: %w", err)
+	}
+
+	if err := writeFileAtomically(filename, key); err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
+
+// newAEAD returns the AES-256-GCM cipher of the key.
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	if len(key) != masterKeySize {
+		return nil, fmt.Errorf("key must be %d bytes long, got %d", masterKeySize, len(key))
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// seal encrypts plaintext with a random nonce, which is prepended to the
+// ciphertext.
+func seal(aead cipher.AEAD, plaintext, additionalData []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, fmt.Errorf("error generating nonce: %w", err)
+	}
+
+	return aead.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+// open decrypts the data sealed by seal.
+func open(aead cipher.AEAD, data, additionalData []byte) ([]byte, error) {
+	if len(data) < aead.NonceSize() {
+		return nil, errors.New("encrypted data too short")
+	}
+
+	nonce, ciphertext := data[:aead.NonceSize()], data[aead.NonceSize():]
+
+	return aead.Open(nil, nonce, ciphertext, additionalData)
+}
+
+// writeFileAtomically writes data to filename, readable by the owner only,
+// it writes to a temporary file first so a crash can't leave a truncated file
+// behind.
+func writeFileAtomically(filename string, data []byte) error {
+	tmpFilename := filename + ".tmp"
+	if err := os.WriteFile(tmpFilename, data, 0600); err != nil {
+		return fmt.Errorf("error writing %s: %w", filepath.Base(filename), err)
+	}
+
+	if err := os.Rename(tmpFilename, filename); err != nil {
+		return fmt.Errorf("error renaming %s: %w", filepath.Base(filename), err)
+	}
+
+	return nil
+}
+
+// loadRotations loads the rotations mapping from filename, a missing file
+// means that no wallet has been rotated yet.
+func loadRotations(filename string) (map[string]string, error) {
+	rotations := make(map[string]string)
+
+	data, err := os.ReadFile(filename)
+	if errors.Is(err, os.ErrNotExist) {
+		return rotations, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading rotations file: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &rotations); err != nil {
+		return nil, fmt.Errorf("error decoding rotations file: %w", err)
+	}
+
+	return rotations, nil
+}
+
+// storeRotations stores the rotations mapping in filename, it writes to a
+// temporary file first so a crash can't leave a truncated mapping behind.
+func storeRotations(filename string, rotations map[string]string) error {
+	data, err := json.Marshal(rotations)
+	if err != nil {
+		return fmt.Errorf("error encoding rotations: %w", err)
+	}
+
+	tmpFilename := filename + ".tmp"
+	if err := os.WriteFile(tmpFilename, data, 0600); err != nil {
+		return fmt.Errorf("error writing rotations file: %w", err)
+	}
+
+	if err := os.Rename(tmpFilename, filename); err != nil {
+		return fmt.Errorf("error renaming rotations file: %w", err)
+	}
+
+	return nil
+}
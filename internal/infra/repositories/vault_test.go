@@ -1,8 +1,12 @@
 package repositories_test
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
 	"errors"
 	"io/ioutil"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"testing"
@@ -10,6 +14,9 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/gagliardetto/solana-go"
+
+	"github.com/jcleira/coding-challenge/internal/domain/aggregates"
 	"github.com/jcleira/coding-challenge/internal/infra/repositories"
 )
 
@@ -18,64 +25,80 @@ func TestNewVault(t *testing.T) {
 	require.NoError(t, err)
 	defer os.RemoveAll(tmpDir)
 
-	vault, err := repositories.NewVault(tmpDir)
+	vault, err := repositories.NewVault(tmpDir, nil, slog.Default())
 	assert.NoError(t, err)
 	assert.NotNil(t, vault)
 	assert.DirExists(t, tmpDir)
 }
 
 func TestCreateWallet(t *testing.T) {
+	ctx := context.Background()
+
 	tmpDir, err := ioutil.TempDir("", "vault_test")
 	require.NoError(t, err)
 	defer os.RemoveAll(tmpDir)
 
-	vault, err := repositories.NewVault(tmpDir)
+	vault, err := repositories.NewVault(tmpDir, nil, slog.Default())
 	require.NoError(t, err)
 
-	wallet, err := vault.CreateWallet()
+	wallet, err := vault.CreateWallet(ctx)
 	require.NoError(t, err)
 	assert.NotEmpty(t, wallet.PrivateKey)
 	assert.NotEmpty(t, wallet.PublicKey)
 
-	filename := filepath.Join(tmpDir, wallet.PublicKey)
-	_, err = os.Stat(filename)
-	assert.NoError(t, err)
+	filename := filepath.Join(tmpDir, aggregates.DefaultTenant, wallet.PublicKey)
+	data, err := os.ReadFile(filename)
+	require.NoError(t, err)
+
+	// The private key is encrypted at rest.
+	assert.False(t, bytes.Contains(data, wallet.PrivateKey))
 }
 
 func TestGetWallet(t *testing.T) {
+	ctx := context.Background()
+
 	tmpDir, err := ioutil.TempDir("", "vault_test")
 	require.NoError(t, err)
 	defer os.RemoveAll(tmpDir)
 
-	vault, err := repositories.NewVault(tmpDir)
+	vault, err := repositories.NewVault(tmpDir, nil, slog.Default())
 	require.NoError(t, err)
 
-	createdWallet, err := vault.CreateWallet()
+	createdWallet, err := vault.CreateWallet(ctx)
 	require.NoError(t, err)
 
 	tests := []struct {
-		name string
-		key  string
-		// TODO I would change this bool to an error type.
-		wantErr bool
+		name    string
+		key     string
+		wantErr error
 	}{
 		{
 			name:    "wallet exists",
 			key:     createdWallet.PublicKey,
-			wantErr: false,
+			wantErr: nil,
 		},
 		{
 			name:    "wallet does not exist",
+			key:     "4zdNGgAtFsW1cQgHqkiWyRsxaAgxrSRRynnuunxzjxu1",
+			wantErr: aggregates.ErrWalletNotFound,
+		},
+		{
+			name:    "invalid public key",
 			key:     "non-existent-key",
-			wantErr: true,
+			wantErr: aggregates.ErrInvalidPublicKey,
+		},
+		{
+			name:    "path outside the vault",
+			key:     "../" + filepath.Base(tmpDir) + "/" + createdWallet.PublicKey,
+			wantErr: aggregates.ErrInvalidPublicKey,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			wallet, err := vault.GetWallet(tt.key)
-			if tt.wantErr {
-				assert.True(t, errors.Is(err, os.ErrNotExist))
+			wallet, err := vault.GetWallet(ctx, tt.key)
+			if tt.wantErr != nil {
+				assert.True(t, errors.Is(err, tt.wantErr))
 				return
 			}
 			assert.NoError(t, err)
@@ -85,3 +108,134 @@ func TestGetWallet(t *testing.T) {
 		})
 	}
 }
+
+func TestRetireWallet(t *testing.T) {
+	ctx := context.Background()
+
+	tmpDir, err := ioutil.TempDir("", "vault_test")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	vault, err := repositories.NewVault(tmpDir, nil, slog.Default())
+	require.NoError(t, err)
+
+	oldWallet, err := vault.CreateWallet(ctx)
+	require.NoError(t, err)
+
+	newWallet, err := vault.CreateWallet(ctx)
+	require.NoError(t, err)
+
+	require.NoError(t, vault.RetireWallet(ctx, oldWallet.PublicKey, newWallet.PublicKey))
+
+	wallet, err := vault.GetWallet(ctx, oldWallet.PublicKey)
+	require.NoError(t, err)
+	assert.Equal(t, newWallet.PublicKey, wallet.PublicKey)
+	assert.Equal(t, newWallet.PrivateKey, wallet.PrivateKey)
+
+	err = vault.RetireWallet(ctx, oldWallet.PublicKey, newWallet.PublicKey)
+	assert.ErrorIs(t, err, aggregates.ErrWalletRetired)
+
+	// The rotations must survive a restart.
+	reopened, err := repositories.NewVault(tmpDir, nil, slog.Default())
+	require.NoError(t, err)
+
+	wallet, err = reopened.GetWallet(ctx, oldWallet.PublicKey)
+	require.NoError(t, err)
+	assert.Equal(t, newWallet.PublicKey, wallet.PublicKey)
+}
+
+func TestVault_Tenants(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "vault_test")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	masterKey := bytes.Repeat([]byte{1}, 32)
+
+	vault, err := repositories.NewVault(tmpDir, masterKey, slog.Default())
+	require.NoError(t, err)
+
+	acme := aggregates.ContextWithTenant(context.Background(), "acme")
+	globex := aggregates.ContextWithTenant(context.Background(), "globex")
+
+	wallet, err := vault.CreateWallet(acme)
+	require.NoError(t, err)
+
+	owned, err := vault.HasWallet(acme, wallet.PublicKey)
+	require.NoError(t, err)
+	assert.True(t, owned)
+
+	// Another tenant can't find the wallet, even knowing its public key.
+	owned, err = vault.HasWallet(globex, wallet.PublicKey)
+	require.NoError(t, err)
+	assert.False(t, owned)
+
+	_, err = vault.GetWallet(globex, wallet.PublicKey)
+	assert.ErrorIs(t, err, aggregates.ErrWalletNotFound)
+
+	// Nor use the wallet file copied to its namespace, it's bound to its
+	// tenant.
+	_, err = vault.CreateWallet(globex)
+	require.NoError(t, err)
+
+	data, err := os.ReadFile(filepath.Join(tmpDir, "acme", wallet.PublicKey))
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "globex", wallet.PublicKey), data, 0600))
+
+	_, err = vault.GetWallet(globex, wallet.PublicKey)
+	assert.Error(t, err)
+
+	_, err = vault.GetWallet(
+		aggregates.ContextWithTenant(context.Background(), "../acme"), wallet.PublicKey)
+	assert.ErrorIs(t, err, aggregates.ErrInvalidTenant)
+
+	// The wallets only decrypt with the master key they were stored with.
+	reopened, err := repositories.NewVault(tmpDir, masterKey, slog.Default())
+	require.NoError(t, err)
+
+	got, err := reopened.GetWallet(acme, wallet.PublicKey)
+	require.NoError(t, err)
+	assert.Equal(t, wallet.PrivateKey, got.PrivateKey)
+
+	other, err := repositories.NewVault(tmpDir, bytes.Repeat([]byte{2}, 32), slog.Default())
+	require.NoError(t, err)
+
+	_, err = other.GetWallet(acme, wallet.PublicKey)
+	assert.Error(t, err)
+
+	_, err = repositories.NewVault(tmpDir, []byte("short"), slog.Default())
+	assert.Error(t, err)
+}
+
+func TestNewVault_MigratesPlainTextWallets(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "vault_test")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	oldKey, err := solana.NewRandomPrivateKey()
+	require.NoError(t, err)
+
+	newKey, err := solana.NewRandomPrivateKey()
+	require.NoError(t, err)
+
+	oldPublicKey, newPublicKey := oldKey.PublicKey().String(), newKey.PublicKey().String()
+
+	// The layout of the vault before tenants existed.
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, oldPublicKey), oldKey, 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, newPublicKey), newKey, 0644))
+
+	rotations, err := json.Marshal(map[string]string{oldPublicKey: newPublicKey})
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "rotations.json"), rotations, 0600))
+
+	vault, err := repositories.NewVault(tmpDir, nil, slog.Default())
+	require.NoError(t, err)
+
+	assert.NoFileExists(t, filepath.Join(tmpDir, oldPublicKey))
+	assert.NoFileExists(t, filepath.Join(tmpDir, newPublicKey))
+	assert.NoFileExists(t, filepath.Join(tmpDir, "rotations.json"))
+
+	wallet, err := vault.GetWallet(context.Background(), oldPublicKey)
+	require.NoError(t, err)
+	assert.Equal(t, newPublicKey, wallet.PublicKey)
+	assert.Equal(t, []byte(newKey), []byte(wallet.PrivateKey))
+}
@@ -2,90 +2,637 @@ package main
 
 import (
 	"context"
+	"encoding/base64"
+	"errors"
+	"flag"
+	"fmt"
 	"log/slog"
+	"math/big"
 	"net/http"
 	"os"
-
-	_ "net/http/pprof"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
 
 	"golang.org/x/sync/errgroup"
 
+	"github.com/jcleira/coding-challenge/internal/domain/aggregates"
 	"github.com/jcleira/coding-challenge/internal/domain/services"
+	"github.com/jcleira/coding-challenge/internal/infra/config"
 	"github.com/jcleira/coding-challenge/internal/infra/handlers"
+	"github.com/jcleira/coding-challenge/internal/infra/logging"
+	"github.com/jcleira/coding-challenge/internal/infra/metrics"
 	"github.com/jcleira/coding-challenge/internal/infra/repositories"
+	"github.com/jcleira/coding-challenge/internal/infra/tracing"
 )
 
-// I will be using constants for the configuration, but it could be easily
-// extended to use a configuration file, or environment variables.
+// The settings of the deployments are loaded with the config package, these
+// are the ones that don't change between them.
 const (
-	// solanaRPCURL is the URL of the Solana RPC endpoint
-	solanaRPCURL = "https://api.devnet.solana.com"
-
-	// valutPath is the path where the wallets will be stored
-	vaultPath = "./tmp/wallets"
+	// exchangeConfigActor is the actor recorded for the rate overrides set on
+	// startup
+	exchangeConfigActor = "config"
 
-	// exchangeURL is the URL of the exchange API
-	exchangeURL = "https://api.kraken.com/0/public/Ticker"
+	// cliActor is the actor recorded for the operations of the maintenance
+	// commands
+	cliActor = "cli"
 )
 
+// openAPIInfo is the metadata of the OpenAPI document served on /openapi.json.
+var openAPIInfo = handlers.OpenAPIInfo{
+	Title:   "Solana payments",
+	Version: "1.0.0",
+}
+
 func main() {
-	vault, err := repositories.NewVault(vaultPath)
+	// The maintenance commands parse their own flags, so they're only
+	// configured with the configuration file and the environment.
+	var command string
+	var commandArgs, configArgs []string
+	if len(os.Args) > 1 && !strings.HasPrefix(os.Args[1], "-") {
+		command, commandArgs = os.Args[1], os.Args[2:]
+	} else {
+		configArgs = os.Args[1:]
+	}
+
+	cfg, err := config.Load(configArgs)
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(0)
+	}
 	if err != nil {
-		slog.Error("error initializing vault: ", err)
+		fmt.Fprintln(os.Stderr, "error loading configuration:", err)
+		os.Exit(2)
+	}
+
+	// logLevel is changed when the configuration is reloaded.
+	var logLevel slog.LevelVar
+
+	logger, err := newLogger(cfg.Log, &logLevel)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error configuring logger:", err)
+		os.Exit(1)
+	}
+
+	// The handlers and the maintenance commands log with the default logger.
+	slog.SetDefault(logger)
+
+	if command != "" {
+		runCommand(cfg.Storage, command, commandArgs)
+		return
+	}
+
+	if err := run(cfg, configArgs, logger, &logLevel); err != nil {
+		slog.Error("error running server", "error", err)
 		os.Exit(1)
 	}
+}
+
+// run runs the server with the configuration until it's stopped with SIGINT
+// or SIGTERM, the configArgs are used to reload the configuration on SIGHUP.
+func run(cfg config.Config,
+	configArgs []string, logger *slog.Logger, logLevel *slog.LevelVar) error {
+	masterKey, err := vaultMasterKey()
+	if err != nil {
+		return fmt.Errorf("error reading vault master key: %w", err)
+	}
+
+	vault, err := repositories.NewVault(cfg.Storage.VaultPath, masterKey, logger)
+	if err != nil {
+		return fmt.Errorf("error initializing vault: %w", err)
+	}
+
+	auditLog, err := repositories.NewAuditLog(cfg.Storage.AuditLogPath)
+	if err != nil {
+		return fmt.Errorf("error initializing audit log: %w", err)
+	}
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	exchange, err := repositories.NewExchange(ctx, exchangeURL)
+	shutdownTracing, err := tracing.Setup(ctx)
 	if err != nil {
-		slog.Error("error initializing exchange: ", err)
-		os.Exit(1)
+		return fmt.Errorf("error setting up tracing: %w", err)
+	}
+	defer func() {
+		if err := shutdownTracing(context.Background()); err != nil {
+			slog.Error("error shutting down tracing", "error", err)
+		}
+	}()
+
+	rateStore, err := repositories.NewFileRateStore(cfg.Storage.ExchangeRatesPath)
+	if err != nil {
+		return fmt.Errorf("error initializing rate store: %w", err)
+	}
+
+	exchange, err := repositories.NewExchange(ctx,
+		exchangeRateProviders(cfg.Exchange),
+		cfg.Exchange.Currencies,
+		exchangeSettings(cfg.Exchange),
+		repositories.ExchangeOptions{
+			Store:         rateStore,
+			StartDegraded: cfg.Exchange.StartDegraded,
+			Logger:        logger,
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("error initializing exchange: %w", err)
+	}
+
+	rateHistory, err := repositories.NewRateHistory(
+		cfg.Storage.RateHistoryPath, cfg.Exchange.KrakenOHLCURL, logger)
+	if err != nil {
+		return fmt.Errorf("error initializing rate history: %w", err)
 	}
 
-	solana := repositories.NewSolana(solanaRPCURL)
+	go rateHistory.StartSnapshots(ctx, exchange, cfg.Exchange.Currencies,
+		time.Duration(cfg.Exchange.SnapshotInterval))
+
+	solana := repositories.NewSolana(rpcEndpoints(cfg.Solana), solanaSettings(cfg.Solana), logger)
+
+	go reloadConfig(ctx, configArgs, cfg, func(cfg config.Config) error {
+		level, err := logging.ParseLevel(cfg.Log.Level)
+		if err != nil {
+			return err
+		}
+
+		logLevel.Set(level)
+		solana.Configure(solanaSettings(cfg.Solana))
+		exchange.Configure(exchangeSettings(cfg.Exchange))
+
+		return nil
+	})
+
+	converter := services.NewConverter(cfg.Conversion.RoundingMode)
 
 	transactionsGetterHandler := handlers.NewTransactionsGetterHandler(
-		services.NewTransactionsGetter(solana, exchange),
+		services.NewTransactionsGetter(solana, exchange, rateHistory, converter, logger),
 	)
 
 	transactionsSenderHandler := handlers.NewTransactionsSenderHandler(
-		services.NewTransactionsSender(vault, solana, exchange),
+		services.NewTransactionsSender(vault, solana, exchange, auditLog, converter, logger),
+		cfg.Exchange.Currencies,
 	)
 
 	walletInitializerHandler := handlers.NewWalletInitializerHandler(
-		services.NewWalletInitializer(vault),
+		services.NewWalletInitializer(vault, auditLog),
 	)
 
 	walletBalanceGetterHandler := handlers.NewWalletBalanceGetterHandler(
-		services.NewWalletBalanceGetter(solana, exchange),
+		services.NewWalletBalanceGetter(solana, exchange, converter),
+	)
+
+	walletRotatorHandler := handlers.NewWalletRotatorHandler(
+		services.NewWalletRotator(vault, solana, auditLog, logger),
+	)
+
+	auditLogGetterHandler := handlers.NewAuditLogGetterHandler(
+		services.NewAuditLogGetter(auditLog),
 	)
 
 	exchangeRateGetterHandler := handlers.NewExchangeRateGetterHandler(
 		services.NewExchangeRateGetter(exchange),
 	)
 
-	http.HandleFunc("/init", walletInitializerHandler.Handler())
-	http.HandleFunc("/balance", walletBalanceGetterHandler.Handler())
-	http.HandleFunc("/exchange_rate", exchangeRateGetterHandler.Handler())
-	http.HandleFunc("/send", transactionsSenderHandler.Handler())
-	http.HandleFunc("/transactions", transactionsGetterHandler.Handler())
+	exchangeRateOverrider := services.NewExchangeRateOverrider(exchange, auditLog)
+
+	if err := setExchangeRateOverrides(ctx,
+		exchangeRateOverrider, cfg.Exchange.RateOverrides, time.Duration(cfg.Exchange.RateOverridesTTL)); err != nil {
+		return fmt.Errorf("error setting exchange rate overrides: %w", err)
+	}
+
+	exchangeRateOverrideHandler := handlers.NewExchangeRateOverrideHandler(
+		exchangeRateOverrider,
+	)
+
+	exchangeHealthGetterHandler := handlers.NewExchangeHealthGetterHandler(
+		services.NewExchangeHealthGetter(exchange),
+	)
+
+	exchangeRateStreamHandler := handlers.NewExchangeRateStreamHandler(
+		services.NewExchangeRateStreamer(exchange),
+	)
+
+	apiKeyStore, err := repositories.NewFileAPIKeyStore(cfg.Storage.APIKeysPath)
+	if err != nil {
+		return fmt.Errorf("error initializing API key store: %w", err)
+	}
+
+	apiKeyManager := services.NewAPIKeyManager(apiKeyStore, auditLog, logger)
+
+	apiKeysHandler := handlers.NewAPIKeysHandler(apiKeyManager)
+
+	router := handlers.NewRouter()
+
+	if cfg.Auth.Disabled {
+		slog.Warn("API key authentication disabled")
+	} else {
+		router.RequireAPIKeys(apiKeyManager)
+		router.RequireTenantWallets(vault)
+
+		if cfg.Auth.OIDC.Enabled() {
+			router.RequireBearerTokens(oidcTokenAuthenticator(cfg.Auth.OIDC, logger))
+		}
+	}
+
+	rateLimiter, quotaStore, err := newRateLimiter(ctx, cfg.RateLimit, cfg.Storage, logger)
+	if err != nil {
+		return fmt.Errorf("error initializing rate limiter: %w", err)
+	}
+
+	// The quota store flushes the pending counts once the server is stopped.
+	if quotaStore != nil {
+		defer quotaStore.Stop()
+	}
+
+	router.LimitRequests(rateLimiter)
+
+	// The endpoints of the original specification are kept as they are for its
+	// automated tests, the /v1 ones are registered along with them.
+	router.Register(walletInitializerHandler.Routes()...)
+	router.Register(walletRotatorHandler.Routes()...)
+	router.Register(walletBalanceGetterHandler.Routes()...)
+	router.Register(exchangeRateGetterHandler.Routes()...)
+	router.Register(exchangeRateStreamHandler.Routes()...)
+	router.Register(exchangeHealthGetterHandler.Routes()...)
+	router.Register(transactionsSenderHandler.Routes()...)
+	router.Register(transactionsGetterHandler.Routes()...)
+	router.Register(auditLogGetterHandler.Routes()...)
+	router.Register(exchangeRateOverrideHandler.Routes()...)
+	router.Register(apiKeysHandler.Routes()...)
+	router.Register(handlers.ProfilingRoutes()...)
 
-	g, ctx := errgroup.WithContext(ctx)
+	router.HandleFunc(http.MethodGet, "/openapi.json", handlers.OpenAPIHandler(
+		handlers.NewOpenAPIDocument(openAPIInfo, router.Routes())))
+	router.Handle(http.MethodGet, "/metrics", metrics.Handler())
+
+	healthHandler := handlers.NewHealthHandler(
+		services.NewReadinessChecker(solana, exchange, vault),
+	)
+
+	// The probes are neither rate limited nor documented, as /metrics.
+	router.HandleFunc(http.MethodGet, "/healthz", healthHandler.Liveness())
+	router.HandleFunc(http.MethodGet, "/readyz", healthHandler.Readiness())
+
+	server := &http.Server{
+		Addr:              cfg.Server.Addr,
+		Handler:           handlers.RequestContext(handlers.Trace(handlers.Recoverer(router))),
+		ReadHeaderTimeout: time.Duration(cfg.Server.ReadTimeout),
+		ReadTimeout:       time.Duration(cfg.Server.ReadTimeout),
+		IdleTimeout:       time.Duration(cfg.Server.IdleTimeout),
+		// There is no write timeout, the rate streams are long lived and set
+		// their own write deadlines, and the sends are bounded by their
+		// confirmation timeout.
+	}
+
+	signalCtx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	g, gctx := errgroup.WithContext(signalCtx)
 	g.Go(func() error {
-		if err := http.ListenAndServe(":8888", nil); err != nil {
-			slog.Error("error starting server: ", err)
-			return err
+		slog.Info("server started", "addr", cfg.Server.Addr)
+
+		if err := server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+			return fmt.Errorf("error serving: %w", err)
 		}
 		return nil
 	})
 
-	if err := g.Wait(); err != nil {
-		slog.Error("error running server: ", err)
-		cancel()
-		os.Exit(1)
+	g.Go(func() error {
+		<-gctx.Done()
+
+		// A second signal kills the process right away.
+		stop()
+
+		return shutdown(server, exchange, healthHandler,
+			time.Duration(cfg.Server.ShutdownDelay), time.Duration(cfg.Server.ShutdownTimeout))
+	})
+
+	return g.Wait()
+}
+
+// shutdown shuts the server down gracefully within the timeout: the readiness
+// probe fails from then on, and the server keeps serving new requests for the
+// delay, until the load balancers notice it. Then the exchange stops
+// refreshing, which ends the rate streams, and the in-flight requests, such
+// as the sends waiting for their confirmation, are drained before the server
+// stops.
+func shutdown(server *http.Server, exchange *repositories.Exchange,
+	health *handlers.HealthHandler, delay, timeout time.Duration) error {
+	slog.Info("shutting down, failing readiness", "delay", delay)
+
+	health.Drain()
+	time.Sleep(delay)
+
+	slog.Info("draining requests", "timeout", timeout)
+
+	exchange.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if err := server.Shutdown(ctx); err != nil {
+		server.Close()
+		return fmt.Errorf("error draining requests: %w", err)
+	}
+
+	slog.Info("server stopped")
+
+	return nil
+}
+
+// exchangeRateProviders returns the rate providers for the exchange, the
+// static rates of the configuration replace the real providers when they're
+// set, for local development and tests.
+func exchangeRateProviders(cfg config.Exchange) []repositories.RateProvider {
+	if len(cfg.StaticRates) > 0 {
+		slog.Warn("using static exchange rates", "rates", cfg.StaticRates)
+
+		return []repositories.RateProvider{
+			repositories.NewStaticProvider(map[string]*big.Rat(cfg.StaticRates)),
+		}
+	}
+
+	return []repositories.RateProvider{
+		repositories.NewKrakenProvider(cfg.KrakenURL),
+		repositories.NewCoinbaseProvider(cfg.CoinbaseURL),
+		repositories.NewBinanceProvider(cfg.BinanceURL),
+		repositories.NewCoinGeckoProvider(cfg.CoinGeckoURL),
+	}
+}
+
+// exchangeSettings returns the settings of the exchange from its
+// configuration.
+func exchangeSettings(cfg config.Exchange) repositories.ExchangeSettings {
+	return repositories.ExchangeSettings{
+		MaxDeviation:    cfg.MaxDeviation,
+		RefreshInterval: time.Duration(cfg.RefreshInterval),
+		RateExpiration:  time.Duration(cfg.RateExpiration),
+	}
+}
+
+// solanaSettings returns the settings of the Solana client from its
+// configuration.
+func solanaSettings(cfg config.Solana) repositories.SolanaSettings {
+	return repositories.SolanaSettings{
+		Commitment:           cfg.Commitment,
+		ConfirmationTimeout:  time.Duration(cfg.ConfirmationTimeout),
+		ConfirmationInterval: time.Duration(cfg.ConfirmationInterval),
+		RPCScoreHalfLife:     time.Duration(cfg.RPCScoreHalfLife),
+	}
+}
+
+// rpcEndpoints returns the Solana RPC endpoints from its configuration.
+func rpcEndpoints(cfg config.Solana) []repositories.RPCEndpoint {
+	urls := cfg.RPCEndpoints()
+
+	endpoints := make([]repositories.RPCEndpoint, len(urls))
+	for i, url := range urls {
+		endpoints[i] = repositories.RPCEndpoint{URL: url, Broadcast: cfg.Broadcasts(url)}
+	}
+
+	return endpoints
+}
+
+// reloadConfig loads the configuration again with the args on every SIGHUP,
+// until the context is done, applying its reloadable fields with apply. The
+// changes of the other fields are ignored with a warning, they require a
+// restart, and an invalid configuration leaves the current one in place.
+func reloadConfig(ctx context.Context,
+	args []string, current config.Config, apply func(config.Config) error) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+	defer signal.Stop(signals)
+
+	for {
+		select {
+		case <-signals:
+			loaded, err := config.Load(args)
+			if err != nil {
+				slog.ErrorContext(ctx, "error reloading configuration, keeping the current one",
+					"error", err)
+				continue
+			}
+
+			reloaded, ignored, err := config.Reload(current, loaded)
+			if err != nil {
+				slog.ErrorContext(ctx, "error reloading configuration, keeping the current one",
+					"error", err)
+				continue
+			}
+
+			if len(ignored) > 0 {
+				slog.WarnContext(ctx, "configuration changes ignored until restart",
+					"fields", ignored)
+			}
+
+			if err := apply(reloaded); err != nil {
+				slog.ErrorContext(ctx, "error applying configuration", "error", err)
+				continue
+			}
+
+			current = reloaded
+
+			slog.InfoContext(ctx, "configuration reloaded")
+
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// newLogger returns the logger of the service, logging in the configured
+// format from the configured level, which is set on level so it can be
+// changed later on.
+func newLogger(cfg config.Log, level *slog.LevelVar) (*slog.Logger, error) {
+	parsed, err := logging.ParseLevel(cfg.Level)
+	if err != nil {
+		return nil, err
+	}
+
+	level.Set(parsed)
+
+	return logging.New(os.Stderr, cfg.Format, level)
+}
+
+// newRateLimiter returns the rate limiter of the API with the configured
+// limits, the daily quotas are counted in the quota store of the storage,
+// which is only returned when there are quotas.
+func newRateLimiter(ctx context.Context, cfg config.RateLimit, storage config.Storage,
+	logger *slog.Logger) (*services.RateLimiter, *repositories.FileQuotaStore, error) {
+	if len(cfg.DailyQuotas) == 0 {
+		return services.NewRateLimiter(cfg.Limits(), nil, logger), nil, nil
+	}
+
+	store, err := repositories.NewFileQuotaStore(ctx,
+		storage.QuotasPath, time.Duration(storage.QuotasFlushInterval), logger)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return services.NewRateLimiter(cfg.Limits(), store, logger), store, nil
+}
+
+// vaultMasterKey returns the master key of the vault, base64 encoded in the
+// VAULT_MASTER_KEY environment variable. Without it, the vault generates its
+// own, for local development.
+func vaultMasterKey() ([]byte, error) {
+	encoded := os.Getenv("VAULT_MASTER_KEY")
+	if encoded == "" {
+		return nil, nil
+	}
+
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding VAULT_MASTER_KEY: %w", err)
+	}
+
+	return key, nil
+}
+
+// oidcTokenAuthenticator returns the authenticator of the OIDC bearer tokens
+// of the configuration.
+func oidcTokenAuthenticator(cfg config.OIDC, logger *slog.Logger) *services.TokenAuthenticator {
+	verifier := repositories.NewOIDCVerifier(
+		repositories.NewJWKS(cfg.JWKSURL, logger), cfg.Issuer, cfg.Audience)
+
+	return services.NewTokenAuthenticator(verifier,
+		cfg.WalletsClaim, cfg.TenantClaim, cfg.DefaultTenant)
+}
+
+// setExchangeRateOverrides sets the rate overrides of the configuration, they
+// last for ttl and are audited as set by the config.
+func setExchangeRateOverrides(ctx context.Context,
+	overrider *services.ExchangeRateOverrider, overrides config.Rates, ttl time.Duration) error {
+	ctx = aggregates.ContextWithActor(ctx, exchangeConfigActor)
+
+	for currency, value := range overrides {
+		if _, err := overrider.SetOverride(ctx, currency, value, ttl); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// runCommand runs the maintenance command name with its args instead of the
+// server, on the configured storage.
+func runCommand(storage config.Storage, name string, args []string) {
+	switch name {
+	case "audit-verify":
+		auditLog, err := repositories.NewAuditLog(storage.AuditLogPath)
+		if err != nil {
+			slog.Error("error initializing audit log", "error", err)
+			os.Exit(1)
+		}
+
+		if err := auditLog.Verify(); err != nil {
+			slog.Error("audit log verification failed", "error", err)
+			os.Exit(1)
+		}
+
+		slog.Info("audit log verified", "path", storage.AuditLogPath)
+	case "api-keys":
+		if err := runAPIKeysCommand(storage, args); err != nil {
+			slog.Error("error managing API keys", "error", err)
+			os.Exit(1)
+		}
+	default:
+		slog.Error("unknown command", "command", name)
+		os.Exit(2)
+	}
+}
+
+// runAPIKeysCommand manages the API keys of a tenant, the default one unless
+// -tenant names another, the first admin key of each tenant has to be issued
+// with it:
+//
+//	api-keys issue -tenant acme -name ops -scopes admin
+//	api-keys issue -name shop -scopes read-balance,send -wallets <pubkey>,...
+//	api-keys list -tenant acme
+//	api-keys revoke -tenant acme <id>
+func runAPIKeysCommand(storage config.Storage, args []string) error {
+	if len(args) == 0 {
+		return errors.New("missing subcommand, issue, list or revoke")
+	}
+
+	auditLog, err := repositories.NewAuditLog(storage.AuditLogPath)
+	if err != nil {
+		return err
+	}
+
+	store, err := repositories.NewFileAPIKeyStore(storage.APIKeysPath)
+	if err != nil {
+		return err
+	}
+
+	manager := services.NewAPIKeyManager(store, auditLog, slog.Default())
+
+	flags := flag.NewFlagSet("api-keys "+args[0], flag.ContinueOnError)
+	tenant := flags.String("tenant", aggregates.DefaultTenant, "tenant of the keys")
+	name := flags.String("name", "", "name of the key owner")
+	scopes := flags.String("scopes", "", "comma separated scopes: read-balance, read-transactions, send, init, admin")
+	wallets := flags.String("wallets", "", "comma separated public keys of the wallets")
+	if err := flags.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	if err := aggregates.ValidateTenant(*tenant); err != nil {
+		return err
+	}
+
+	ctx := aggregates.ContextWithActor(context.Background(), cliActor)
+	ctx = aggregates.ContextWithTenant(ctx, *tenant)
+
+	switch args[0] {
+	case "issue":
+		parsedScopes, err := aggregates.ParseScopes(*scopes)
+		if err != nil {
+			return err
+		}
+
+		var walletList []string
+		if *wallets != "" {
+			walletList = strings.Split(*wallets, ",")
+		}
+
+		key, token, err := manager.Issue(ctx, *name, parsedScopes, walletList)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("id: %s\ntoken: %s\n", key.ID, token)
+	case "list":
+		keys, err := manager.List(ctx)
+		if err != nil {
+			return err
+		}
+
+		for _, key := range keys {
+			status := "active"
+			if key.Revoked() {
+				status = "revoked"
+			}
+
+			lastUsed := "never"
+			if !key.LastUsedAt.IsZero() {
+				lastUsed = key.LastUsedAt.Format(time.RFC3339)
+			}
+
+			fmt.Printf("%s\t%s\t%s\t%v\t%v\tlast used %s\n",
+				key.ID, key.Name, status, key.Scopes, key.Wallets, lastUsed)
+		}
+	case "revoke":
+		if flags.NArg() != 1 {
+			return errors.New("usage: api-keys revoke [-tenant <tenant>] <id>")
+		}
+
+		if err := manager.Revoke(ctx, flags.Arg(0)); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unknown subcommand %s", args[0])
 	}
 
-	slog.Info("Server started")
+	return nil
 }
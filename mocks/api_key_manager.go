@@ -0,0 +1,113 @@
+// Code generated by mockery. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	aggregates "github.com/jcleira/coding-challenge/internal/domain/aggregates"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// APIKeyManager is an autogenerated mock type for the APIKeyManager type
+type APIKeyManager struct {
+	mock.Mock
+}
+
+// Issue provides a mock function with given fields: ctx, name, scopes, wallets
+func (_m *APIKeyManager) Issue(ctx context.Context, name string, scopes []aggregates.Scope, wallets []string) (aggregates.APIKey, string, error) {
+	ret := _m.Called(ctx, name, scopes, wallets)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Issue")
+	}
+
+	var r0 aggregates.APIKey
+	var r1 string
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, []aggregates.Scope, []string) (aggregates.APIKey, string, error)); ok {
+		return rf(ctx, name, scopes, wallets)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, []aggregates.Scope, []string) aggregates.APIKey); ok {
+		r0 = rf(ctx, name, scopes, wallets)
+	} else {
+		r0 = ret.Get(0).(aggregates.APIKey)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, []aggregates.Scope, []string) string); ok {
+		r1 = rf(ctx, name, scopes, wallets)
+	} else {
+		r1 = ret.Get(1).(string)
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, string, []aggregates.Scope, []string) error); ok {
+		r2 = rf(ctx, name, scopes, wallets)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// List provides a mock function with given fields: ctx
+func (_m *APIKeyManager) List(ctx context.Context) ([]aggregates.APIKey, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for List")
+	}
+
+	var r0 []aggregates.APIKey
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]aggregates.APIKey, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []aggregates.APIKey); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]aggregates.APIKey)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Revoke provides a mock function with given fields: ctx, id
+func (_m *APIKeyManager) Revoke(ctx context.Context, id string) error {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Revoke")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewAPIKeyManager creates a new instance of APIKeyManager. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewAPIKeyManager(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *APIKeyManager {
+	mock := &APIKeyManager{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
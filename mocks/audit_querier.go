@@ -0,0 +1,60 @@
+// Code generated by mockery. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	aggregates "github.com/jcleira/coding-challenge/internal/domain/aggregates"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// AuditQuerier is an autogenerated mock type for the AuditQuerier type
+type AuditQuerier struct {
+	mock.Mock
+}
+
+// Query provides a mock function with given fields: ctx, filter
+func (_m *AuditQuerier) Query(ctx context.Context, filter aggregates.AuditFilter) ([]aggregates.AuditEvent, error) {
+	ret := _m.Called(ctx, filter)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Query")
+	}
+
+	var r0 []aggregates.AuditEvent
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, aggregates.AuditFilter) ([]aggregates.AuditEvent, error)); ok {
+		return rf(ctx, filter)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, aggregates.AuditFilter) []aggregates.AuditEvent); ok {
+		r0 = rf(ctx, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]aggregates.AuditEvent)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, aggregates.AuditFilter) error); ok {
+		r1 = rf(ctx, filter)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewAuditQuerier creates a new instance of AuditQuerier. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewAuditQuerier(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *AuditQuerier {
+	mock := &AuditQuerier{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
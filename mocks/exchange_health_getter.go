@@ -0,0 +1,46 @@
+// Code generated by mockery. DO NOT EDIT.
+
+package mocks
+
+import (
+	aggregates "github.com/jcleira/coding-challenge/internal/domain/aggregates"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// ExchangeHealthGetter is an autogenerated mock type for the ExchangeHealthGetter type
+type ExchangeHealthGetter struct {
+	mock.Mock
+}
+
+// GetHealth provides a mock function with no fields
+func (_m *ExchangeHealthGetter) GetHealth() aggregates.RateHealth {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetHealth")
+	}
+
+	var r0 aggregates.RateHealth
+	if rf, ok := ret.Get(0).(func() aggregates.RateHealth); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(aggregates.RateHealth)
+	}
+
+	return r0
+}
+
+// NewExchangeHealthGetter creates a new instance of ExchangeHealthGetter. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewExchangeHealthGetter(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *ExchangeHealthGetter {
+	mock := &ExchangeHealthGetter{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
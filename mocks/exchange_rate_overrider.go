@@ -0,0 +1,80 @@
+// Code generated by mockery. DO NOT EDIT.
+
+package mocks
+
+import (
+	big "math/big"
+
+	aggregates "github.com/jcleira/coding-challenge/internal/domain/aggregates"
+
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+
+	time "time"
+)
+
+// ExchangeRateOverrider is an autogenerated mock type for the ExchangeRateOverrider type
+type ExchangeRateOverrider struct {
+	mock.Mock
+}
+
+// ClearOverride provides a mock function with given fields: ctx, currency
+func (_m *ExchangeRateOverrider) ClearOverride(ctx context.Context, currency string) error {
+	ret := _m.Called(ctx, currency)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ClearOverride")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, currency)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetOverride provides a mock function with given fields: ctx, currency, value, ttl
+func (_m *ExchangeRateOverrider) SetOverride(ctx context.Context, currency string, value *big.Rat, ttl time.Duration) (aggregates.Rate, error) {
+	ret := _m.Called(ctx, currency, value, ttl)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetOverride")
+	}
+
+	var r0 aggregates.Rate
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, *big.Rat, time.Duration) (aggregates.Rate, error)); ok {
+		return rf(ctx, currency, value, ttl)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, *big.Rat, time.Duration) aggregates.Rate); ok {
+		r0 = rf(ctx, currency, value, ttl)
+	} else {
+		r0 = ret.Get(0).(aggregates.Rate)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, *big.Rat, time.Duration) error); ok {
+		r1 = rf(ctx, currency, value, ttl)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewExchangeRateOverrider creates a new instance of ExchangeRateOverrider. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewExchangeRateOverrider(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *ExchangeRateOverrider {
+	mock := &ExchangeRateOverrider{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
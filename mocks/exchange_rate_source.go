@@ -0,0 +1,58 @@
+// Code generated by mockery. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	aggregates "github.com/jcleira/coding-challenge/internal/domain/aggregates"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// ExchangeRateSource is an autogenerated mock type for the ExchangeRateSource type
+type ExchangeRateSource struct {
+	mock.Mock
+}
+
+// GetRate provides a mock function with given fields: ctx, currency
+func (_m *ExchangeRateSource) GetRate(ctx context.Context, currency string) (aggregates.Rate, error) {
+	ret := _m.Called(ctx, currency)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetRate")
+	}
+
+	var r0 aggregates.Rate
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (aggregates.Rate, error)); ok {
+		return rf(ctx, currency)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) aggregates.Rate); ok {
+		r0 = rf(ctx, currency)
+	} else {
+		r0 = ret.Get(0).(aggregates.Rate)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, currency)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewExchangeRateSource creates a new instance of ExchangeRateSource. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewExchangeRateSource(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *ExchangeRateSource {
+	mock := &ExchangeRateSource{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
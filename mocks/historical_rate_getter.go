@@ -0,0 +1,60 @@
+// Code generated by mockery. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	aggregates "github.com/jcleira/coding-challenge/internal/domain/aggregates"
+
+	mock "github.com/stretchr/testify/mock"
+
+	time "time"
+)
+
+// HistoricalRateGetter is an autogenerated mock type for the HistoricalRateGetter type
+type HistoricalRateGetter struct {
+	mock.Mock
+}
+
+// GetRateAt provides a mock function with given fields: ctx, currency, at
+func (_m *HistoricalRateGetter) GetRateAt(ctx context.Context, currency string, at time.Time) (aggregates.Rate, error) {
+	ret := _m.Called(ctx, currency, at)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetRateAt")
+	}
+
+	var r0 aggregates.Rate
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, time.Time) (aggregates.Rate, error)); ok {
+		return rf(ctx, currency, at)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, time.Time) aggregates.Rate); ok {
+		r0 = rf(ctx, currency, at)
+	} else {
+		r0 = ret.Get(0).(aggregates.Rate)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, time.Time) error); ok {
+		r1 = rf(ctx, currency, at)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewHistoricalRateGetter creates a new instance of HistoricalRateGetter. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewHistoricalRateGetter(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *HistoricalRateGetter {
+	mock := &HistoricalRateGetter{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,58 @@
+// Code generated by mockery. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+
+	time "time"
+)
+
+// QuotaStore is an autogenerated mock type for the QuotaStore type
+type QuotaStore struct {
+	mock.Mock
+}
+
+// Increment provides a mock function with given fields: ctx, key, day
+func (_m *QuotaStore) Increment(ctx context.Context, key string, day time.Time) (int, error) {
+	ret := _m.Called(ctx, key, day)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Increment")
+	}
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, time.Time) (int, error)); ok {
+		return rf(ctx, key, day)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, time.Time) int); ok {
+		r0 = rf(ctx, key, day)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, time.Time) error); ok {
+		r1 = rf(ctx, key, day)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewQuotaStore creates a new instance of QuotaStore. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewQuotaStore(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *QuotaStore {
+	mock := &QuotaStore{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -3,7 +3,10 @@
 package mocks
 
 import (
+	context "context"
+
 	aggregates "github.com/jcleira/coding-challenge/internal/domain/aggregates"
+
 	mock "github.com/stretchr/testify/mock"
 )
 
@@ -12,9 +15,9 @@ type RateGetter struct {
 	mock.Mock
 }
 
-// GetRate provides a mock function with given fields:
-func (_m *RateGetter) GetRate() (aggregates.Rate, error) {
-	ret := _m.Called()
+// GetRate provides a mock function with given fields: ctx, currency
+func (_m *RateGetter) GetRate(ctx context.Context, currency string) (aggregates.Rate, error) {
+	ret := _m.Called(ctx, currency)
 
 	if len(ret) == 0 {
 		panic("no return value specified for GetRate")
@@ -22,17 +25,17 @@ func (_m *RateGetter) GetRate() (aggregates.Rate, error) {
 
 	var r0 aggregates.Rate
 	var r1 error
-	if rf, ok := ret.Get(0).(func() (aggregates.Rate, error)); ok {
-		return rf()
+	if rf, ok := ret.Get(0).(func(context.Context, string) (aggregates.Rate, error)); ok {
+		return rf(ctx, currency)
 	}
-	if rf, ok := ret.Get(0).(func() aggregates.Rate); ok {
-		r0 = rf()
+	if rf, ok := ret.Get(0).(func(context.Context, string) aggregates.Rate); ok {
+		r0 = rf(ctx, currency)
 	} else {
 		r0 = ret.Get(0).(aggregates.Rate)
 	}
 
-	if rf, ok := ret.Get(1).(func() error); ok {
-		r1 = rf()
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, currency)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -0,0 +1,45 @@
+// Code generated by mockery. DO NOT EDIT.
+
+package mocks
+
+import (
+	aggregates "github.com/jcleira/coding-challenge/internal/domain/aggregates"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// RateHealthGetter is an autogenerated mock type for the RateHealthGetter type
+type RateHealthGetter struct {
+	mock.Mock
+}
+
+// Health provides a mock function with no fields
+func (_m *RateHealthGetter) Health() aggregates.RateHealth {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for Health")
+	}
+
+	var r0 aggregates.RateHealth
+	if rf, ok := ret.Get(0).(func() aggregates.RateHealth); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(aggregates.RateHealth)
+	}
+
+	return r0
+}
+
+// NewRateHealthGetter creates a new instance of RateHealthGetter. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewRateHealthGetter(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *RateHealthGetter {
+	mock := &RateHealthGetter{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
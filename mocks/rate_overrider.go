@@ -0,0 +1,78 @@
+// Code generated by mockery. DO NOT EDIT.
+
+package mocks
+
+import (
+	big "math/big"
+
+	aggregates "github.com/jcleira/coding-challenge/internal/domain/aggregates"
+
+	mock "github.com/stretchr/testify/mock"
+
+	time "time"
+)
+
+// RateOverrider is an autogenerated mock type for the RateOverrider type
+type RateOverrider struct {
+	mock.Mock
+}
+
+// ClearOverride provides a mock function with given fields: currency
+func (_m *RateOverrider) ClearOverride(currency string) error {
+	ret := _m.Called(currency)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ClearOverride")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string) error); ok {
+		r0 = rf(currency)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetOverride provides a mock function with given fields: currency, value, ttl
+func (_m *RateOverrider) SetOverride(currency string, value *big.Rat, ttl time.Duration) (aggregates.Rate, error) {
+	ret := _m.Called(currency, value, ttl)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetOverride")
+	}
+
+	var r0 aggregates.Rate
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, *big.Rat, time.Duration) (aggregates.Rate, error)); ok {
+		return rf(currency, value, ttl)
+	}
+	if rf, ok := ret.Get(0).(func(string, *big.Rat, time.Duration) aggregates.Rate); ok {
+		r0 = rf(currency, value, ttl)
+	} else {
+		r0 = ret.Get(0).(aggregates.Rate)
+	}
+
+	if rf, ok := ret.Get(1).(func(string, *big.Rat, time.Duration) error); ok {
+		r1 = rf(currency, value, ttl)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewRateOverrider creates a new instance of RateOverrider. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewRateOverrider(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *RateOverrider {
+	mock := &RateOverrider{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
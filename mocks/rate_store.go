@@ -0,0 +1,75 @@
+// Code generated by mockery. DO NOT EDIT.
+
+package mocks
+
+import (
+	aggregates "github.com/jcleira/coding-challenge/internal/domain/aggregates"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// RateStore is an autogenerated mock type for the RateStore type
+type RateStore struct {
+	mock.Mock
+}
+
+// Load provides a mock function with no fields
+func (_m *RateStore) Load() (map[string]aggregates.Rate, error) {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for Load")
+	}
+
+	var r0 map[string]aggregates.Rate
+	var r1 error
+	if rf, ok := ret.Get(0).(func() (map[string]aggregates.Rate, error)); ok {
+		return rf()
+	}
+	if rf, ok := ret.Get(0).(func() map[string]aggregates.Rate); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(map[string]aggregates.Rate)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Save provides a mock function with given fields: rates
+func (_m *RateStore) Save(rates map[string]aggregates.Rate) error {
+	ret := _m.Called(rates)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Save")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(map[string]aggregates.Rate) error); ok {
+		r0 = rf(rates)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewRateStore creates a new instance of RateStore. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewRateStore(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *RateStore {
+	mock := &RateStore{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,59 @@
+// Code generated by mockery. DO NOT EDIT.
+
+package mocks
+
+import (
+	aggregates "github.com/jcleira/coding-challenge/internal/domain/aggregates"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// RateSubscriber is an autogenerated mock type for the RateSubscriber type
+type RateSubscriber struct {
+	mock.Mock
+}
+
+// Subscribe provides a mock function with no fields
+func (_m *RateSubscriber) Subscribe() (<-chan aggregates.Rate, func()) {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for Subscribe")
+	}
+
+	var r0 <-chan aggregates.Rate
+	var r1 func()
+	if rf, ok := ret.Get(0).(func() (<-chan aggregates.Rate, func())); ok {
+		return rf()
+	}
+	if rf, ok := ret.Get(0).(func() <-chan aggregates.Rate); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(<-chan aggregates.Rate)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func() func()); ok {
+		r1 = rf()
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(func())
+		}
+	}
+
+	return r0, r1
+}
+
+// NewRateSubscriber creates a new instance of RateSubscriber. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewRateSubscriber(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *RateSubscriber {
+	mock := &RateSubscriber{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
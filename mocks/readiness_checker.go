@@ -0,0 +1,48 @@
+// Code generated by mockery. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	aggregates "github.com/jcleira/coding-challenge/internal/domain/aggregates"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// ReadinessChecker is an autogenerated mock type for the ReadinessChecker type
+type ReadinessChecker struct {
+	mock.Mock
+}
+
+// CheckReadiness provides a mock function with given fields: ctx
+func (_m *ReadinessChecker) CheckReadiness(ctx context.Context) aggregates.Readiness {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CheckReadiness")
+	}
+
+	var r0 aggregates.Readiness
+	if rf, ok := ret.Get(0).(func(context.Context) aggregates.Readiness); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Get(0).(aggregates.Readiness)
+	}
+
+	return r0
+}
+
+// NewReadinessChecker creates a new instance of ReadinessChecker. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewReadinessChecker(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *ReadinessChecker {
+	mock := &ReadinessChecker{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,58 @@
+// Code generated by mockery. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	aggregates "github.com/jcleira/coding-challenge/internal/domain/aggregates"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// RequestLimiter is an autogenerated mock type for the RequestLimiter type
+type RequestLimiter struct {
+	mock.Mock
+}
+
+// Allow provides a mock function with given fields: ctx, client, class
+func (_m *RequestLimiter) Allow(ctx context.Context, client string, class aggregates.RateClass) (aggregates.RateLimitDecision, error) {
+	ret := _m.Called(ctx, client, class)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Allow")
+	}
+
+	var r0 aggregates.RateLimitDecision
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, aggregates.RateClass) (aggregates.RateLimitDecision, error)); ok {
+		return rf(ctx, client, class)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, aggregates.RateClass) aggregates.RateLimitDecision); ok {
+		r0 = rf(ctx, client, class)
+	} else {
+		r0 = ret.Get(0).(aggregates.RateLimitDecision)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, aggregates.RateClass) error); ok {
+		r1 = rf(ctx, client, class)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewRequestLimiter creates a new instance of RequestLimiter. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewRequestLimiter(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *RequestLimiter {
+	mock := &RequestLimiter{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,56 @@
+// Code generated by mockery. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// SolanaFeeGetter is an autogenerated mock type for the SolanaFeeGetter type
+type SolanaFeeGetter struct {
+	mock.Mock
+}
+
+// GetTransferFee provides a mock function with given fields: ctx, from, to
+func (_m *SolanaFeeGetter) GetTransferFee(ctx context.Context, from string, to string) (uint64, error) {
+	ret := _m.Called(ctx, from, to)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetTransferFee")
+	}
+
+	var r0 uint64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) (uint64, error)); ok {
+		return rf(ctx, from, to)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) uint64); ok {
+		r0 = rf(ctx, from, to)
+	} else {
+		r0 = ret.Get(0).(uint64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, from, to)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewSolanaFeeGetter creates a new instance of SolanaFeeGetter. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewSolanaFeeGetter(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *SolanaFeeGetter {
+	mock := &SolanaFeeGetter{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -15,9 +15,9 @@ type SolanaGetter struct {
 	mock.Mock
 }
 
-// GetTransactions provides a mock function with given fields: ctx, publicKey
-func (_m *SolanaGetter) GetTransactions(ctx context.Context, publicKey string) ([]aggregates.Transaction, error) {
-	ret := _m.Called(ctx, publicKey)
+// GetTransactions provides a mock function with given fields: ctx, publicKey, page
+func (_m *SolanaGetter) GetTransactions(ctx context.Context, publicKey string, page aggregates.TransactionsPage) ([]aggregates.Transaction, error) {
+	ret := _m.Called(ctx, publicKey, page)
 
 	if len(ret) == 0 {
 		panic("no return value specified for GetTransactions")
@@ -25,19 +25,19 @@ func (_m *SolanaGetter) GetTransactions(ctx context.Context, publicKey string) (
 
 	var r0 []aggregates.Transaction
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, string) ([]aggregates.Transaction, error)); ok {
-		return rf(ctx, publicKey)
+	if rf, ok := ret.Get(0).(func(context.Context, string, aggregates.TransactionsPage) ([]aggregates.Transaction, error)); ok {
+		return rf(ctx, publicKey, page)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, string) []aggregates.Transaction); ok {
-		r0 = rf(ctx, publicKey)
+	if rf, ok := ret.Get(0).(func(context.Context, string, aggregates.TransactionsPage) []aggregates.Transaction); ok {
+		r0 = rf(ctx, publicKey, page)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]aggregates.Transaction)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
-		r1 = rf(ctx, publicKey)
+	if rf, ok := ret.Get(1).(func(context.Context, string, aggregates.TransactionsPage) error); ok {
+		r1 = rf(ctx, publicKey, page)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -15,9 +15,9 @@ type TransactionsGetter struct {
 	mock.Mock
 }
 
-// GetTransactions provides a mock function with given fields: ctx, publicKey
-func (_m *TransactionsGetter) GetTransactions(ctx context.Context, publicKey string) ([]aggregates.Transaction, error) {
-	ret := _m.Called(ctx, publicKey)
+// GetTransactions provides a mock function with given fields: ctx, query
+func (_m *TransactionsGetter) GetTransactions(ctx context.Context, query aggregates.TransactionsQuery) ([]aggregates.Transaction, error) {
+	ret := _m.Called(ctx, query)
 
 	if len(ret) == 0 {
 		panic("no return value specified for GetTransactions")
@@ -25,19 +25,19 @@ func (_m *TransactionsGetter) GetTransactions(ctx context.Context, publicKey str
 
 	var r0 []aggregates.Transaction
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, string) ([]aggregates.Transaction, error)); ok {
-		return rf(ctx, publicKey)
+	if rf, ok := ret.Get(0).(func(context.Context, aggregates.TransactionsQuery) ([]aggregates.Transaction, error)); ok {
+		return rf(ctx, query)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, string) []aggregates.Transaction); ok {
-		r0 = rf(ctx, publicKey)
+	if rf, ok := ret.Get(0).(func(context.Context, aggregates.TransactionsQuery) []aggregates.Transaction); ok {
+		r0 = rf(ctx, query)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]aggregates.Transaction)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
-		r1 = rf(ctx, publicKey)
+	if rf, ok := ret.Get(1).(func(context.Context, aggregates.TransactionsQuery) error); ok {
+		r1 = rf(ctx, query)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -13,9 +13,9 @@ type WalletBalanceGetter struct {
 	mock.Mock
 }
 
-// GetBalance provides a mock function with given fields: ctx, pubKey
-func (_m *WalletBalanceGetter) GetBalance(ctx context.Context, pubKey string) (string, error) {
-	ret := _m.Called(ctx, pubKey)
+// GetBalance provides a mock function with given fields: ctx, pubKey, currency
+func (_m *WalletBalanceGetter) GetBalance(ctx context.Context, pubKey string, currency string) (string, error) {
+	ret := _m.Called(ctx, pubKey, currency)
 
 	if len(ret) == 0 {
 		panic("no return value specified for GetBalance")
@@ -23,17 +23,17 @@ func (_m *WalletBalanceGetter) GetBalance(ctx context.Context, pubKey string) (s
 
 	var r0 string
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, string) (string, error)); ok {
-		return rf(ctx, pubKey)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) (string, error)); ok {
+		return rf(ctx, pubKey, currency)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, string) string); ok {
-		r0 = rf(ctx, pubKey)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) string); ok {
+		r0 = rf(ctx, pubKey, currency)
 	} else {
 		r0 = ret.Get(0).(string)
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
-		r1 = rf(ctx, pubKey)
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, pubKey, currency)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -3,7 +3,10 @@
 package mocks
 
 import (
+	context "context"
+
 	aggregates "github.com/jcleira/coding-challenge/internal/domain/aggregates"
+
 	mock "github.com/stretchr/testify/mock"
 )
 
@@ -12,9 +15,9 @@ type WalletCreator struct {
 	mock.Mock
 }
 
-// CreateWallet provides a mock function with given fields:
-func (_m *WalletCreator) CreateWallet() (aggregates.Wallet, error) {
-	ret := _m.Called()
+// CreateWallet provides a mock function with given fields: ctx
+func (_m *WalletCreator) CreateWallet(ctx context.Context) (aggregates.Wallet, error) {
+	ret := _m.Called(ctx)
 
 	if len(ret) == 0 {
 		panic("no return value specified for CreateWallet")
@@ -22,17 +25,17 @@ func (_m *WalletCreator) CreateWallet() (aggregates.Wallet, error) {
 
 	var r0 aggregates.Wallet
 	var r1 error
-	if rf, ok := ret.Get(0).(func() (aggregates.Wallet, error)); ok {
-		return rf()
+	if rf, ok := ret.Get(0).(func(context.Context) (aggregates.Wallet, error)); ok {
+		return rf(ctx)
 	}
-	if rf, ok := ret.Get(0).(func() aggregates.Wallet); ok {
-		r0 = rf()
+	if rf, ok := ret.Get(0).(func(context.Context) aggregates.Wallet); ok {
+		r0 = rf(ctx)
 	} else {
 		r0 = ret.Get(0).(aggregates.Wallet)
 	}
 
-	if rf, ok := ret.Get(1).(func() error); ok {
-		r1 = rf()
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
 	} else {
 		r1 = ret.Error(1)
 	}
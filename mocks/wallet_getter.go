@@ -3,7 +3,10 @@
 package mocks
 
 import (
+	context "context"
+
 	aggregates "github.com/jcleira/coding-challenge/internal/domain/aggregates"
+
 	mock "github.com/stretchr/testify/mock"
 )
 
@@ -12,9 +15,9 @@ type WalletGetter struct {
 	mock.Mock
 }
 
-// GetWallet provides a mock function with given fields: publicKey
-func (_m *WalletGetter) GetWallet(publicKey string) (aggregates.Wallet, error) {
-	ret := _m.Called(publicKey)
+// GetWallet provides a mock function with given fields: ctx, publicKey
+func (_m *WalletGetter) GetWallet(ctx context.Context, publicKey string) (aggregates.Wallet, error) {
+	ret := _m.Called(ctx, publicKey)
 
 	if len(ret) == 0 {
 		panic("no return value specified for GetWallet")
@@ -22,17 +25,17 @@ func (_m *WalletGetter) GetWallet(publicKey string) (aggregates.Wallet, error) {
 
 	var r0 aggregates.Wallet
 	var r1 error
-	if rf, ok := ret.Get(0).(func(string) (aggregates.Wallet, error)); ok {
-		return rf(publicKey)
+	if rf, ok := ret.Get(0).(func(context.Context, string) (aggregates.Wallet, error)); ok {
+		return rf(ctx, publicKey)
 	}
-	if rf, ok := ret.Get(0).(func(string) aggregates.Wallet); ok {
-		r0 = rf(publicKey)
+	if rf, ok := ret.Get(0).(func(context.Context, string) aggregates.Wallet); ok {
+		r0 = rf(ctx, publicKey)
 	} else {
 		r0 = ret.Get(0).(aggregates.Wallet)
 	}
 
-	if rf, ok := ret.Get(1).(func(string) error); ok {
-		r1 = rf(publicKey)
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, publicKey)
 	} else {
 		r1 = ret.Error(1)
 	}
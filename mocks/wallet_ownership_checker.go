@@ -0,0 +1,56 @@
+// Code generated by mockery. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// WalletOwnershipChecker is an autogenerated mock type for the WalletOwnershipChecker type
+type WalletOwnershipChecker struct {
+	mock.Mock
+}
+
+// HasWallet provides a mock function with given fields: ctx, publicKey
+func (_m *WalletOwnershipChecker) HasWallet(ctx context.Context, publicKey string) (bool, error) {
+	ret := _m.Called(ctx, publicKey)
+
+	if len(ret) == 0 {
+		panic("no return value specified for HasWallet")
+	}
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (bool, error)); ok {
+		return rf(ctx, publicKey)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) bool); ok {
+		r0 = rf(ctx, publicKey)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, publicKey)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewWalletOwnershipChecker creates a new instance of WalletOwnershipChecker. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewWalletOwnershipChecker(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *WalletOwnershipChecker {
+	mock := &WalletOwnershipChecker{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,104 @@
+// Code generated by mockery. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	aggregates "github.com/jcleira/coding-challenge/internal/domain/aggregates"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// WalletRotatorVault is an autogenerated mock type for the WalletRotatorVault type
+type WalletRotatorVault struct {
+	mock.Mock
+}
+
+// CreateWallet provides a mock function with given fields: ctx
+func (_m *WalletRotatorVault) CreateWallet(ctx context.Context) (aggregates.Wallet, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateWallet")
+	}
+
+	var r0 aggregates.Wallet
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) (aggregates.Wallet, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) aggregates.Wallet); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Get(0).(aggregates.Wallet)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetWallet provides a mock function with given fields: ctx, publicKey
+func (_m *WalletRotatorVault) GetWallet(ctx context.Context, publicKey string) (aggregates.Wallet, error) {
+	ret := _m.Called(ctx, publicKey)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetWallet")
+	}
+
+	var r0 aggregates.Wallet
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (aggregates.Wallet, error)); ok {
+		return rf(ctx, publicKey)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) aggregates.Wallet); ok {
+		r0 = rf(ctx, publicKey)
+	} else {
+		r0 = ret.Get(0).(aggregates.Wallet)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, publicKey)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RetireWallet provides a mock function with given fields: ctx, oldPublicKey, newPublicKey
+func (_m *WalletRotatorVault) RetireWallet(ctx context.Context, oldPublicKey string, newPublicKey string) error {
+	ret := _m.Called(ctx, oldPublicKey, newPublicKey)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RetireWallet")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, oldPublicKey, newPublicKey)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewWalletRotatorVault creates a new instance of WalletRotatorVault. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewWalletRotatorVault(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *WalletRotatorVault {
+	mock := &WalletRotatorVault{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}